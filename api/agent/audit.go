@@ -0,0 +1,261 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/spiffe/tornjak/pkg/agent/authentication/user"
+	tornjakTypes "github.com/spiffe/tornjak/pkg/agent/types"
+)
+
+const (
+	// defaultAuditRetention is how long audit records are kept when
+	// 'config > server > audit > retention' is not set.
+	defaultAuditRetention = 90 * 24 * time.Hour
+
+	// auditPruneInterval is how often audit records past retention are removed
+	auditPruneInterval = time.Hour
+
+	// defaultAuditListLimit is the number of records returned when the limit
+	// query parameter is not given
+	defaultAuditListLimit = 100
+)
+
+// auditEnabled reports whether API requests are recorded in the audit log
+func (s *Server) auditEnabled() bool {
+	if s.Db == nil {
+		return false
+	}
+	if s.TornjakConfig != nil && s.TornjakConfig.Server != nil && s.TornjakConfig.Server.AuditConfig != nil {
+		return !s.TornjakConfig.Server.AuditConfig.Disabled
+	}
+	return true
+}
+
+// auditRetention returns the configured audit retention, or the default if unset.
+func (s *Server) auditRetention() time.Duration {
+	if s.TornjakConfig != nil && s.TornjakConfig.Server != nil && s.TornjakConfig.Server.AuditConfig != nil {
+		// validated in VerifyConfiguration
+		if d, err := time.ParseDuration(s.TornjakConfig.Server.AuditConfig.Retention); err == nil {
+			return d
+		}
+	}
+	return defaultAuditRetention
+}
+
+// startAudit removes audit records past retention now and then periodically
+func (s *Server) startAudit() {
+	prune := func() {
+		n, err := s.Db.DeleteAuditRecordsBefore(time.Now().Add(-s.auditRetention()))
+		if err != nil {
+			log.Printf("Failed to remove expired audit records: %v", err)
+		} else if n > 0 {
+			log.Printf("Removed %d expired audit records", n)
+		}
+	}
+	prune()
+	go func() {
+		ticker := time.NewTicker(auditPruneInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			prune()
+		}
+	}()
+}
+
+// auditTrail collects the IDs of resources a request acted on
+type auditTrail struct {
+	mu        sync.Mutex
+	resources []string
+}
+
+type auditTrailKey struct{}
+
+func withAuditTrail(ctx context.Context, trail *auditTrail) context.Context {
+	return context.WithValue(ctx, auditTrailKey{}, trail)
+}
+
+// addAuditResource records that the request of ctx acted on the resource id
+func addAuditResource(ctx context.Context, id string) {
+	trail, ok := ctx.Value(auditTrailKey{}).(*auditTrail)
+	if !ok || id == "" {
+		return
+	}
+	trail.mu.Lock()
+	defer trail.mu.Unlock()
+	for _, r := range trail.resources {
+		if r == id {
+			return
+		}
+	}
+	trail.resources = append(trail.resources, id)
+}
+
+func (t *auditTrail) list() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string{}, t.resources...)
+}
+
+// auditRecorder passes a response through while keeping its status
+type auditRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (rec *auditRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *auditRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	return rec.ResponseWriter.Write(b)
+}
+
+// Flush sends the response written so far, as streaming handlers do
+func (rec *auditRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (rec *auditRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// newAuditRecord describes the request r by the caller userInfo. The route
+// is the path template of the matched API route.
+func newAuditRecord(r *http.Request, userInfo *user.UserInfo) tornjakTypes.AuditRecord {
+	record := tornjakTypes.AuditRecord{
+		Time:       time.Now(),
+		RemoteAddr: newActor(r, userInfo).RemoteAddr,
+		Method:     r.Method,
+		Route:      r.URL.Path,
+		Path:       r.URL.Path,
+	}
+	if userInfo != nil {
+		record.User = userInfo.Name
+		record.Roles = userInfo.Roles
+	}
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			record.Route = template
+		}
+	}
+
+	// path variables name the resources acted on, e.g. {id} in /api/v2/entries/{id}
+	vars := mux.Vars(r)
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, err := url.PathUnescape(vars[name])
+		if err != nil {
+			value = vars[name]
+		}
+		record.Resources = append(record.Resources, value)
+	}
+	return record
+}
+
+// audit stores record with its outcome, logging any failure to store it
+func (s *Server) audit(record tornjakTypes.AuditRecord, status int) {
+	if !s.auditEnabled() {
+		return
+	}
+	record.Status = status
+	record.Outcome = tornjakTypes.AuditSuccess
+	if record.Decision == tornjakTypes.AuditDeny || status == 0 || status >= http.StatusBadRequest {
+		record.Outcome = tornjakTypes.AuditFailure
+	}
+	if _, err := s.Db.AppendAuditRecord(record); err != nil {
+		log.Printf("Failed to record audit record for %s %s: %v", record.Method, record.Path, err)
+	}
+}
+
+// auditList returns audit records, most recent first, filtered by the query
+// parameters since and until (RFC 3339), user, action ("<METHOD> <route>"),
+// resource, decision and limit
+func (s *Server) auditList(w http.ResponseWriter, r *http.Request) {
+	if s.Db == nil {
+		retError(w, "Error: audit log requires a DataStore plugin", http.StatusNotFound)
+		return
+	}
+	query := r.URL.Query()
+	filter := tornjakTypes.AuditFilter{
+		User:     query.Get("user"),
+		Action:   query.Get("action"),
+		Resource: query.Get("resource"),
+		Decision: query.Get("decision"),
+		Limit:    defaultAuditListLimit,
+	}
+	for name, t := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := query.Get(name); v != "" {
+			parsed, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				retError(w, fmt.Sprintf("Error: invalid %s %q: expected an RFC 3339 time", name, v), http.StatusBadRequest)
+				return
+			}
+			*t = parsed
+		}
+	}
+	switch filter.Decision {
+	case "", tornjakTypes.AuditAllow, tornjakTypes.AuditDeny:
+	default:
+		retError(w, fmt.Sprintf("Error: invalid decision %q: expected allow or deny", filter.Decision), http.StatusBadRequest)
+		return
+	}
+	if l := query.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			retError(w, fmt.Sprintf("Error: invalid limit %q: expected a positive number", l), http.StatusBadRequest)
+			return
+		}
+		filter.Limit = n
+	}
+
+	ret, err := s.Db.GetAuditRecords(filter)
+	if err != nil {
+		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
+		return
+	}
+	if err := writeResponseJSON(w, r, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// auditVerify checks the hash chain of the audit log
+func (s *Server) auditVerify(w http.ResponseWriter, r *http.Request) {
+	if s.Db == nil {
+		retError(w, "Error: audit log requires a DataStore plugin", http.StatusNotFound)
+		return
+	}
+	ret, err := s.Db.VerifyAuditRecords()
+	if err != nil {
+		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
+		return
+	}
+	if err := writeResponseJSON(w, r, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
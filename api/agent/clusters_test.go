@@ -0,0 +1,190 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/gorilla/mux"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	tornjakTypes "github.com/spiffe/tornjak/pkg/agent/types"
+	tornjakv1 "github.com/spiffe/tornjak/pkg/proto/tornjak/api/v1"
+)
+
+func TestClusterEditLabelsDetails(t *testing.T) {
+	v1Edit := func(s *Server, body string) *httptest.ResponseRecorder {
+		body = strings.Replace(body, `{"name":"cluster1",`, `{"cluster":{"name":"cluster1","editedName":"cluster1",`, 1) + "}"
+		w := httptest.NewRecorder()
+		s.clusterEdit(w, httptest.NewRequest(http.MethodPatch, "/api/v1/tornjak/clusters", strings.NewReader(body)))
+		return w
+	}
+	v2Update := func(s *Server, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPut, "/api/v2/clusters/cluster1", strings.NewReader(body))
+		s.clusterUpdateV2(w, mux.SetURLVars(r, map[string]string{"name": "cluster1"}))
+		return w
+	}
+
+	tests := []struct {
+		name        string
+		body        string
+		wantLabels  tornjakTypes.Labels
+		wantDetails string
+		// v2Cleared is set when a v2 PUT, replacing the cluster, clears the
+		// labels and details a v1 PATCH keeps
+		v2Cleared bool
+	}{
+		{
+			name:        "omitted",
+			body:        `{"name":"cluster1","platformType":"Kubernetes"}`,
+			wantLabels:  tornjakTypes.Labels{"env": "prod"},
+			wantDetails: `{"ownerTeam":"payments"}`,
+			v2Cleared:   true,
+		},
+		{
+			name:        "replaced",
+			body:        `{"name":"cluster1","platformType":"Kubernetes","labels":{"env":"dev"},"details":{"ownerTeam":"search"}}`,
+			wantLabels:  tornjakTypes.Labels{"env": "dev"},
+			wantDetails: `{"ownerTeam":"search"}`,
+		},
+		{
+			name: "cleared",
+			body: `{"name":"cluster1","platformType":"Kubernetes","labels":{},"details":{}}`,
+		},
+	}
+	for _, api := range []struct {
+		name string
+		edit func(*Server, string) *httptest.ResponseRecorder
+	}{{"v1", v1Edit}, {"v2", v2Update}} {
+		for _, tt := range tests {
+			t.Run(api.name+" "+tt.name, func(t *testing.T) {
+				s := newTestServer(t)
+				err := s.DefineCluster(RegisterClusterRequest{ClusterInstance: tornjakTypes.ClusterInfo{
+					Name:         "cluster1",
+					PlatformType: "Kubernetes",
+					Labels:       tornjakTypes.Labels{"env": "prod"},
+					Details:      json.RawMessage(`{"ownerTeam":"payments"}`),
+				}})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if w := api.edit(s, tt.body); w.Code != http.StatusOK {
+					t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+				}
+				cluster, err := s.GetCluster(GetClusterRequest{Name: "cluster1"})
+				if err != nil {
+					t.Fatal(err)
+				}
+				wantLabels, wantDetails := tt.wantLabels, tt.wantDetails
+				if api.name == "v2" && tt.v2Cleared {
+					wantLabels, wantDetails = nil, ""
+				}
+				if !reflect.DeepEqual(cluster.Labels, wantLabels) {
+					t.Fatalf("Expected labels %v, got %v", wantLabels, cluster.Labels)
+				}
+				if string(cluster.Details) != wantDetails {
+					t.Fatalf("Expected details %s, got %s", wantDetails, cluster.Details)
+				}
+			})
+		}
+	}
+}
+
+func TestConnectClusterLabelsDetails(t *testing.T) {
+	s := newTestServer(t)
+	c := &clusterService{s: s}
+	ctx := context.Background()
+
+	details, err := structpb.NewStruct(map[string]interface{}{"ownerTeam": "payments", "costCenter": 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, err := c.CreateCluster(ctx, connect.NewRequest(&tornjakv1.CreateClusterRequest{Cluster: &tornjakv1.Cluster{
+		Name:         "cluster1",
+		PlatformType: "Kubernetes",
+		Labels:       map[string]string{"env": "prod"},
+		Details:      details,
+	}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(created.Msg.GetLabels(), map[string]string{"env": "prod"}) || !proto.Equal(created.Msg.GetDetails(), details) {
+		t.Fatalf("Unexpected created cluster %v", created.Msg)
+	}
+	stored, err := s.GetCluster(GetClusterRequest{Name: "cluster1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(stored.Details) != `{"costCenter":42,"ownerTeam":"payments"}` {
+		t.Fatalf("Unexpected stored details %s", stored.Details)
+	}
+	_, err = c.CreateCluster(ctx, connect.NewRequest(&tornjakv1.CreateClusterRequest{Cluster: &tornjakv1.Cluster{
+		Name:         "cluster2",
+		PlatformType: "Kubernetes",
+		Labels:       map[string]string{"env": "dev"},
+	}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the label selector is passed through
+	list, err := c.ListClusters(ctx, connect.NewRequest(&tornjakv1.ListClustersRequest{LabelSelector: "env=prod"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Msg.GetClusters()) != 1 || list.Msg.GetClusters()[0].GetName() != "cluster1" {
+		t.Fatalf("Expected only cluster1, got %v", list.Msg.GetClusters())
+	}
+	_, err = c.ListClusters(ctx, connect.NewRequest(&tornjakv1.ListClustersRequest{LabelSelector: "env in prod"}))
+	if connect.CodeOf(err) != connect.CodeInvalidArgument {
+		t.Fatalf("Expected invalid argument for a bad selector, got %v", err)
+	}
+
+	tests := []struct {
+		name        string
+		cluster     *tornjakv1.Cluster
+		wantLabels  tornjakTypes.Labels
+		wantDetails string
+	}{
+		{
+			name: "replaced",
+			cluster: &tornjakv1.Cluster{PlatformType: "Kubernetes", Labels: map[string]string{"env": "staging"},
+				Details: &structpb.Struct{Fields: map[string]*structpb.Value{"ownerTeam": structpb.NewStringValue("search")}}},
+			wantLabels:  tornjakTypes.Labels{"env": "staging"},
+			wantDetails: `{"ownerTeam":"search"}`,
+		},
+		{
+			name:    "cleared when omitted",
+			cluster: &tornjakv1.Cluster{PlatformType: "Kubernetes"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := c.UpdateCluster(ctx, connect.NewRequest(&tornjakv1.UpdateClusterRequest{Name: "cluster1", Cluster: tt.cluster}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tornjakTypes.Labels(updated.Msg.GetLabels()), tt.wantLabels) {
+				t.Fatalf("Expected labels %v, got %v", tt.wantLabels, updated.Msg.GetLabels())
+			}
+			cluster, err := s.GetCluster(GetClusterRequest{Name: "cluster1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(cluster.Labels, tt.wantLabels) {
+				t.Fatalf("Expected stored labels %v, got %v", tt.wantLabels, cluster.Labels)
+			}
+			if string(cluster.Details) != tt.wantDetails {
+				t.Fatalf("Expected stored details %s, got %s", tt.wantDetails, cluster.Details)
+			}
+		})
+	}
+}
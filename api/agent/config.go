@@ -2,6 +2,8 @@ package api
 
 import (
 	"fmt"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -14,6 +16,7 @@ import (
 	"github.com/spiffe/tornjak/pkg/agent/authentication/authenticator"
 	"github.com/spiffe/tornjak/pkg/agent/authorization"
 	agentdb "github.com/spiffe/tornjak/pkg/agent/db"
+	"github.com/spiffe/tornjak/pkg/agent/notifier"
 	"github.com/spiffe/tornjak/pkg/agent/spirecrd"
 )
 
@@ -57,40 +60,177 @@ func getPluginConfig(plugin *ast.ObjectItem) (string, ast.Node, error) {
 
 // NewAgentsDB returns a new agents DB, given a DB connection string
 func NewAgentsDB(dbPlugin *ast.ObjectItem) (agentdb.AgentDB, error) {
+	return newAgentsDB(dbPlugin, false)
+}
+
+// newAgentsDB returns a new agents DB; in dry run, it only checks the
+// config and returns nil
+func newAgentsDB(dbPlugin *ast.ObjectItem, dryRun bool) (agentdb.AgentDB, error) {
+	drivername, dataSource, err := dataStoreSource(dbPlugin)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return nil, nil
+	}
+
+	// TODO can probably add this to config
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = time.Second
+
+	db, err := agentdb.NewAgentDB(drivername, dataSource, expBackoff)
+	if err != nil {
+		// the connection string is not logged, it may hold a password
+		if drivername == "sqlite3" {
+			return nil, errors.Errorf("Could not start DB driver %s, filename: %s: %v", drivername, dataSource, err)
+		}
+		return nil, errors.Errorf("Could not start DB driver %s: %v", drivername, err)
+	}
+	return db, nil
+}
+
+// dataStoreSource returns the driver and data source of the DataStore
+// plugin: the database file of sqlite3, the connection string of postgres
+// and mysql
+func dataStoreSource(dbPlugin *ast.ObjectItem) (string, string, error) {
 	key, data, err := getPluginConfig(dbPlugin)
 	if err != nil { // db is required config
+		return "", "", errors.New("Required DataStore plugin not configured")
+	}
+	if key != "sql" {
+		return "", "", errors.Errorf("Couldn't create datastore")
+	}
+
+	// check if data is defined
+	if data == nil {
+		return "", "", errors.New("SQL DataStore plugin ('config > plugins > DataStore sql > plugin_data') not populated")
+	}
+	fmt.Printf("SQL DATASTORE DATA: %+v\n", data)
+
+	// decode config to struct
+	var config pluginDataStoreSQL
+	if err := hcl.DecodeObject(&config, data); err != nil {
+		return "", "", errors.Errorf("Couldn't parse DB config: %v", err)
+	}
+
+	// sqlite3 keeps its database in a file, postgres and mysql connect to a
+	// server
+	drivername := config.Drivername
+	if drivername == "" {
+		return "", "", errors.New("SQL DataStore plugin ('config > plugins > DataStore sql > plugin_data > drivername') not defined")
+	}
+	switch drivername {
+	case "sqlite3":
+		if config.Filename == "" {
+			return "", "", errors.New("SQL DataStore plugin ('config > plugins > DataStore sql > plugin_data > filename') not defined")
+		}
+		return drivername, config.Filename, nil
+	case "postgres", "mysql":
+		if config.ConnectionString == "" {
+			return "", "", errors.Errorf("SQL DataStore plugin ('config > plugins > DataStore sql > plugin_data > connection_string') not defined for driver %s", drivername)
+		}
+		return drivername, config.ConnectionString, nil
+	default:
+		return "", "", errors.Errorf("SQL DataStore plugin ('config > plugins > DataStore sql > plugin_data > drivername') %q not supported; use sqlite3, postgres or mysql", drivername)
+	}
+}
+
+// NewDataStoreMigrator returns the schema migrator of the database of the
+// DataStore plugin in config
+func NewDataStoreMigrator(config *TornjakConfig) (*agentdb.Migrator, error) {
+	if config.Plugins == nil {
 		return nil, errors.New("Required DataStore plugin not configured")
 	}
+	pluginList, ok := (*config.Plugins).(*ast.ObjectList)
+	if !ok {
+		return nil, fmt.Errorf("expected plugins node type %T but got %T", pluginList, *config.Plugins)
+	}
+	for _, pluginObject := range pluginList.Items {
+		pluginType, err := stringFromToken(pluginObject.Keys[0].Token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid plugin type key %q: %w", pluginObject.Keys[0].Token.Text, err)
+		}
+		if pluginType != "DataStore" {
+			continue
+		}
+		if len(pluginObject.Keys) != 2 {
+			return nil, fmt.Errorf("plugin DataStore expected to have two keys (type then name)")
+		}
+		drivername, dataSource, err := dataStoreSource(pluginObject)
+		if err != nil {
+			return nil, err
+		}
+		migrator, err := agentdb.NewMigrator(drivername, dataSource)
+		if err != nil {
+			return nil, errors.Errorf("Could not open DB driver %s: %v", drivername, err)
+		}
+		return migrator, nil
+	}
+	return nil, errors.New("Required DataStore plugin not configured")
+}
+
+// NewNotifier returns a new Notifier recording its deliveries in db, owned
+// by the Tornjak instance owner
+func NewNotifier(notifierPlugin *ast.ObjectItem, db agentdb.AgentDB, owner string) (notifier.Notifier, error) {
+	return newNotifier(notifierPlugin, db, owner, false)
+}
+
+// newNotifier returns a new Notifier; in dry run, it only checks the config
+// and returns nil
+func newNotifier(notifierPlugin *ast.ObjectItem, db agentdb.AgentDB, owner string, dryRun bool) (notifier.Notifier, error) {
+	key, data, err := getPluginConfig(notifierPlugin)
+	if err != nil {
+		return nil, err
+	}
+	name, err := stringFromToken(notifierPlugin.Keys[2].Token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Notifier name %q: %w", notifierPlugin.Keys[2].Token.Text, err)
+	}
 
 	switch key {
-	case "sql":
+	case "webhook":
 		// check if data is defined
 		if data == nil {
-			return nil, errors.New("SQL DataStore plugin ('config > plugins > DataStore sql > plugin_data') not populated")
+			return nil, errors.New("webhook Notifier plugin ('config > plugins > Notifier webhook > plugin_data') not populated")
 		}
-		fmt.Printf("SQL DATASTORE DATA: %+v\n", data)
-
-		// TODO can probably add this to config
-		expBackoff := backoff.NewExponentialBackOff()
-		expBackoff.MaxElapsedTime = time.Second
 
 		// decode config to struct
-		var config pluginDataStoreSQL
+		var config pluginNotifierWebhook
 		if err := hcl.DecodeObject(&config, data); err != nil {
-			return nil, errors.Errorf("Couldn't parse DB config: %v", err)
+			return nil, errors.Errorf("Couldn't parse Notifier config: %v", err)
+		}
+		var timeout time.Duration
+		if config.Timeout != "" {
+			timeout, err = time.ParseDuration(config.Timeout)
+			if err != nil {
+				return nil, errors.Errorf("Couldn't parse Notifier timeout: %v", err)
+			}
 		}
 
-		// create db
-		drivername := config.Drivername
-		dbfile := config.Filename
-
-		db, err := agentdb.NewLocalSqliteDB(drivername, dbfile, expBackoff)
+		webhookConfig := notifier.WebhookConfig{
+			Name:        name,
+			URL:         config.URL,
+			Secret:      config.Secret,
+			Events:      config.Events,
+			MaxAttempts: config.MaxAttempts,
+			Timeout:     timeout,
+			QueueSize:   config.QueueSize,
+			Workers:     config.Workers,
+			Owner:       owner,
+		}
+		if dryRun {
+			if err := webhookConfig.Validate(); err != nil {
+				return nil, errors.Errorf("Couldn't configure Notifier %s: %v", name, err)
+			}
+			return nil, nil
+		}
+		webhook, err := notifier.NewWebhookNotifier(webhookConfig, db)
 		if err != nil {
-			return nil, errors.Errorf("Could not start DB driver %s, filename: %s: %v", drivername, dbfile, err)
+			return nil, errors.Errorf("Couldn't configure Notifier %s: %v", name, err)
 		}
-		return db, nil
+		return webhook, nil
 	default:
-		return nil, errors.Errorf("Couldn't create datastore")
+		return nil, errors.Errorf("Invalid option for Notifier named %s", key)
 	}
 }
 
@@ -120,6 +260,12 @@ func NewCRDManager(crdPlugin *ast.ObjectItem) (spirecrd.CRDManager, error) {
 
 // NewAuthenticator returns a new Authenticator
 func NewAuthenticator(authenticatorPlugin *ast.ObjectItem) (authenticator.Authenticator, error) {
+	return newAuthenticator(authenticatorPlugin, false)
+}
+
+// newAuthenticator returns a new Authenticator; in dry run, it returns nil
+// for authenticators that would make network calls, after checking their config
+func newAuthenticator(authenticatorPlugin *ast.ObjectItem, dryRun bool) (authenticator.Authenticator, error) {
 	key, data, _ := getPluginConfig(authenticatorPlugin)
 
 	switch key {
@@ -140,20 +286,120 @@ func NewAuthenticator(authenticatorPlugin *ast.ObjectItem) (authenticator.Authen
 			fmt.Println("WARNING: Auth plugin has no expected audience configured - `aud` claim will not be checked (please populate 'config > plugins > UserManagement KeycloakAuth > plugin_data > audience')")
 		}
 
+		if dryRun {
+			// OIDC discovery is not performed in dry run
+			u, err := url.Parse(config.IssuerURL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return nil, errors.Errorf("Couldn't configure Authenticator: invalid issuer %q: expected http(s)://<host>/<path>", config.IssuerURL)
+			}
+			return nil, nil
+		}
+
 		// create authenticator TODO make json an option?
 		authenticator, err := authenticator.NewKeycloakAuthenticator(true, config.IssuerURL, config.Audience)
 		if err != nil {
 			return nil, errors.Errorf("Couldn't configure Authenticator: %v", err)
 		}
 		return authenticator, nil
+	case "UnixPeer":
+		// check if data is defined
+		if data == nil {
+			return nil, errors.New("UnixPeer Authenticator plugin ('config > plugins > Authenticator UnixPeer > plugin_data') not populated")
+		}
+		fmt.Printf("Authenticator UnixPeer Plugin Data: %+v\n", data)
+		// decode config to struct
+		var config pluginAuthenticatorUnixPeer
+		if err := hcl.DecodeObject(&config, data); err != nil {
+			return nil, errors.Errorf("Couldn't parse Authenticator config: %v", err)
+		}
+		uidRoles, err := peerRoleMap("uid", config.UIDRoleMappings)
+		if err != nil {
+			return nil, errors.Errorf("Couldn't parse Authenticator config: %v", err)
+		}
+		gidRoles, err := peerRoleMap("gid", config.GIDRoleMappings)
+		if err != nil {
+			return nil, errors.Errorf("Couldn't parse Authenticator config: %v", err)
+		}
+
+		authenticator, err := authenticator.NewUnixPeerAuthenticator(uidRoles, gidRoles)
+		if err != nil {
+			return nil, errors.Errorf("Couldn't configure Authenticator: %v", err)
+		}
+		return authenticator, nil
+	case "ClientCert":
+		// check if data is defined
+		if data == nil {
+			return nil, errors.New("ClientCert Authenticator plugin ('config > plugins > Authenticator ClientCert > plugin_data') not populated")
+		}
+		fmt.Printf("Authenticator ClientCert Plugin Data: %+v\n", data)
+		// decode config to struct
+		var config pluginAuthenticatorClientCert
+		if err := hcl.DecodeObject(&config, data); err != nil {
+			return nil, errors.Errorf("Couldn't parse Authenticator config: %v", err)
+		}
+		spiffeIDRoles, err := certRoleMap("spiffe_id", config.SPIFFEIDRoleMappings)
+		if err != nil {
+			return nil, errors.Errorf("Couldn't parse Authenticator config: %v", err)
+		}
+		commonNameRoles, err := certRoleMap("common_name", config.CommonNameRoleMappings)
+		if err != nil {
+			return nil, errors.Errorf("Couldn't parse Authenticator config: %v", err)
+		}
+
+		authenticator, err := authenticator.NewClientCertAuthenticator(spiffeIDRoles, commonNameRoles)
+		if err != nil {
+			return nil, errors.Errorf("Couldn't configure Authenticator: %v", err)
+		}
+		return authenticator, nil
 	default:
 		return nil, errors.Errorf("Invalid option for Authenticator named %s", key)
 	}
 }
 
+// peerRoleMap converts uid or gid blocks into a map from numeric ID to roles
+func peerRoleMap(kind string, mappings []PeerRoleMapping) (map[uint32][]string, error) {
+	roles := make(map[uint32][]string)
+	for _, mapping := range mappings {
+		id, err := strconv.ParseUint(mapping.ID, 10, 32)
+		if err != nil {
+			return nil, errors.Errorf("invalid %s %q: expected a number", kind, mapping.ID)
+		}
+		roles[uint32(id)] = append(roles[uint32(id)], mapping.Roles...)
+	}
+	return roles, nil
+}
+
+// certRoleMap converts spiffe_id or common_name blocks into a map from
+// certificate identity to roles
+func certRoleMap(kind string, mappings []PeerRoleMapping) (map[string][]string, error) {
+	roles := make(map[string][]string)
+	for _, mapping := range mappings {
+		if mapping.ID == "" {
+			return nil, errors.Errorf("empty %s", kind)
+		}
+		if kind == "spiffe_id" && !strings.HasPrefix(mapping.ID, "spiffe://") {
+			return nil, errors.Errorf("invalid %s %q: expected spiffe://<trust domain>/<path>", kind, mapping.ID)
+		}
+		roles[mapping.ID] = append(roles[mapping.ID], mapping.Roles...)
+	}
+	return roles, nil
+}
+
+// splitAPIRoleMappingName splits an RBAC API block name "METHOD /path" into method and path
+func splitAPIRoleMappingName(name string) (string, string, error) {
+	arr := strings.Fields(name)
+	if len(arr) != 2 {
+		return "", "", errors.Errorf("expected \"METHOD /path\", got %q", name)
+	}
+	return arr[0], arr[1], nil
+}
+
 // NewAuthorizer returns a new Authorizer
 func NewAuthorizer(authorizerPlugin *ast.ObjectItem) (authorization.Authorizer, error) {
-	key, data, _ := getPluginConfig(authorizerPlugin)
+	key, data, err := getPluginConfig(authorizerPlugin)
+	if err != nil {
+		return nil, err
+	}
 
 	switch key {
 	case "RBAC":
@@ -180,9 +426,10 @@ func NewAuthorizer(authorizerPlugin *ast.ObjectItem) (authorization.Authorizer,
 			}
 		}
 		for _, apiV1 := range config.APIv1RoleMappings {
-			arr := strings.Split(apiV1.Name, " ")
-			apiV1.Method = arr[0]
-			apiV1.Path = arr[1]
+			apiV1.Method, apiV1.Path, err = splitAPIRoleMappingName(apiV1.Name)
+			if err != nil {
+				return nil, errors.Errorf("Invalid APIv1 block: %v", err)
+			}
 			fmt.Printf("API V1 method: %s, API V1 path: %s, API V1 allowed roles: %s \n", apiV1.Method, apiV1.Path, apiV1.AllowedRoles)
 			if _, ok := apiV1Mapping[apiV1.Path]; ok {
 				apiV1Mapping[apiV1.Path][apiV1.Method] = apiV1.AllowedRoles
@@ -191,8 +438,22 @@ func NewAuthorizer(authorizerPlugin *ast.ObjectItem) (authorization.Authorizer,
 			}
 		}
 		fmt.Printf("API V1 Mapping: %+v\n", apiV1Mapping)
+		apiV2Mapping := make(map[string]map[string][]string)
+		for _, apiV2 := range config.APIv2RoleMappings {
+			apiV2.Method, apiV2.Path, err = splitAPIRoleMappingName(apiV2.Name)
+			if err != nil {
+				return nil, errors.Errorf("Invalid APIv2 block: %v", err)
+			}
+			fmt.Printf("API V2 method: %s, API V2 path: %s, API V2 allowed roles: %s \n", apiV2.Method, apiV2.Path, apiV2.AllowedRoles)
+			if _, ok := apiV2Mapping[apiV2.Path]; ok {
+				apiV2Mapping[apiV2.Path][apiV2.Method] = apiV2.AllowedRoles
+			} else {
+				apiV2Mapping[apiV2.Path] = map[string][]string{apiV2.Method: apiV2.AllowedRoles}
+			}
+		}
+		fmt.Printf("API V2 Mapping: %+v\n", apiV2Mapping)
 
-		authorizer, err := authorization.NewRBACAuthorizer(config.Name, roleList, apiV1Mapping)
+		authorizer, err := authorization.NewRBACAuthorizer(config.Name, roleList, apiV1Mapping, apiV2Mapping)
 		if err != nil {
 			return nil, errors.Errorf("Couldn't configure Authorizer: %v", err)
 		}
@@ -202,30 +463,177 @@ func NewAuthorizer(authorizerPlugin *ast.ObjectItem) (authorization.Authorizer,
 	}
 }
 
+// hasAuthenticator reports whether an Authenticator plugin of the given kind
+// is configured
+func (s *Server) hasAuthenticator(kind string) bool {
+	if s.TornjakConfig == nil || s.TornjakConfig.Plugins == nil {
+		return false
+	}
+	pluginList, ok := (*s.TornjakConfig.Plugins).(*ast.ObjectList)
+	if !ok {
+		return false
+	}
+	for _, pluginObject := range pluginList.Filter("Authenticator").Items {
+		if len(pluginObject.Keys) == 1 {
+			if name, err := stringFromToken(pluginObject.Keys[0].Token); err == nil && name == kind {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newAccessPlugins returns the Authenticator and Authorizer configured in
+// pluginList, or the null ones for plugins not given. Authenticator plugins
+// are chained in the order they are given.
+func newAccessPlugins(pluginList *ast.ObjectList) (authenticator.Authenticator, authorization.Authorizer, error) {
+	var authn authenticator.Authenticator = authenticator.NewNullAuthenticator()
+	var authz authorization.Authorizer = authorization.NewNullAuthorizer()
+	var chain []authenticator.NamedAuthenticator
+	for _, pluginObject := range pluginList.Items {
+		pluginType, err := stringFromToken(pluginObject.Keys[0].Token)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid plugin type key %q: %w", pluginObject.Keys[0].Token.Text, err)
+		}
+
+		switch pluginType {
+		// configure Authenticator
+		case "Authenticator":
+			if len(pluginObject.Keys) != 2 {
+				return nil, nil, fmt.Errorf("plugin Authenticator expected to have two keys (type then name)")
+			}
+			name, err := stringFromToken(pluginObject.Keys[1].Token)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid plugin type name %q: %w", pluginObject.Keys[1].Token.Text, err)
+			}
+			for _, a := range chain {
+				if a.Name == name {
+					return nil, nil, errors.Errorf("Cannot configure Authenticator plugin: %s configured more than once", name)
+				}
+			}
+			a, err := NewAuthenticator(pluginObject)
+			if err != nil {
+				return nil, nil, errors.Errorf("Cannot configure Authenticator plugin: %v", err)
+			}
+			chain = append(chain, authenticator.NamedAuthenticator{Name: name, Authenticator: a})
+		// configure Authorizer
+		case "Authorizer":
+			if len(pluginObject.Keys) != 2 {
+				return nil, nil, fmt.Errorf("plugin Authorizer expected to have two keys (type then name)")
+			}
+			authz, err = NewAuthorizer(pluginObject)
+			if err != nil {
+				return nil, nil, errors.Errorf("Cannot configure Authorizer plugin: %v", err)
+			}
+		}
+	}
+	if len(chain) > 0 {
+		var err error
+		authn, err = authenticator.NewChainAuthenticator(chain)
+		if err != nil {
+			return nil, nil, errors.Errorf("Cannot configure Authenticator plugin: %v", err)
+		}
+	}
+	return authn, authz, nil
+}
+
 func (s *Server) VerifyConfiguration() error {
+	if errs := s.verifyConfiguration(); len(errs) > 0 {
+		return errs[0].err
+	}
+	return nil
+}
+
+// fieldError is an error in the config field at keys, e.g. server > socket
+type fieldError struct {
+	keys []string
+	err  error
+}
+
+// verifyConfiguration returns all errors found in the config
+func (s *Server) verifyConfiguration() []fieldError {
 	if s.TornjakConfig == nil {
-		return errors.New("config not given")
+		return []fieldError{{err: errors.New("config not given")}}
 	}
 
 	/*  Verify server  */
-	if s.TornjakConfig.Server == nil { // must be defined
-		return errors.New("'config > server' field not defined")
+	serverConfig := s.TornjakConfig.Server
+	if serverConfig == nil { // must be defined
+		return []fieldError{{err: errors.New("'config > server' field not defined")}}
+	}
+	var errs []fieldError
+	fail := func(err error, keys ...string) {
+		errs = append(errs, fieldError{keys: append([]string{"server"}, keys...), err: err})
 	}
-	if s.TornjakConfig.Server.SPIRESocket == "" {
-		return errors.New("'config > server > spire_socket_path' field not defined")
+	if serverConfig.SPIRESocket == "" {
+		fail(errors.New("'config > server > spire_socket_path' field not defined"))
+	}
+	if serverConfig.MaxRequestBytes < 0 {
+		fail(errors.New("'config > server > max_request_bytes' must not be negative"), "max_request_bytes")
+	}
+	if window := serverConfig.IdempotencyWindow; window != "" {
+		d, err := time.ParseDuration(window)
+		if err != nil {
+			fail(errors.Errorf("'config > server > idempotency_window' invalid: %v", err), "idempotency_window")
+		} else if d <= 0 {
+			fail(errors.New("'config > server > idempotency_window' must be positive"), "idempotency_window")
+		}
+	}
+	if socket := serverConfig.SocketConfig; socket != nil {
+		if socket.Path == "" {
+			fail(errors.New("'config > server > socket > path' field not defined"), "socket")
+		}
+		if _, err := socket.fileMode(); err != nil {
+			fail(errors.Errorf("'config > server > socket > mode' %v", err), "socket", "mode")
+		}
+	}
+	if audit := serverConfig.AuditConfig; audit != nil && audit.Retention != "" {
+		d, err := time.ParseDuration(audit.Retention)
+		if err != nil {
+			fail(errors.Errorf("'config > server > audit > retention' invalid: %v", err), "audit", "retention")
+		} else if d <= 0 {
+			fail(errors.New("'config > server > audit > retention' must be positive"), "audit", "retention")
+		}
+	}
+	if reconcile := serverConfig.ReconcileConfig; reconcile != nil {
+		if reconcile.Interval != "" {
+			d, err := time.ParseDuration(reconcile.Interval)
+			if err != nil {
+				fail(errors.Errorf("'config > server > reconcile > interval' invalid: %v", err), "reconcile", "interval")
+			} else if d <= 0 {
+				fail(errors.New("'config > server > reconcile > interval' must be positive"), "reconcile", "interval")
+			}
+		}
+		switch reconcile.Orphans {
+		case "", reconcileOrphansFlag, reconcileOrphansPrune:
+		default:
+			fail(errors.Errorf("'config > server > reconcile > orphans' %q invalid: expected %s or %s", reconcile.Orphans,
+				reconcileOrphansFlag, reconcileOrphansPrune), "reconcile", "orphans")
+		}
+	}
+	switch serverConfig.AgentCleanup {
+	case "", agentCleanupDelete, agentCleanupTombstone:
+	default:
+		fail(errors.Errorf("'config > server > agent_cleanup' %q invalid: expected %s or %s", serverConfig.AgentCleanup,
+			agentCleanupDelete, agentCleanupTombstone), "agent_cleanup")
+	}
+	if serverConfig.JobWorkers < 0 {
+		fail(errors.New("'config > server > job_workers' must not be negative"), "job_workers")
+	}
+	if serverConfig.JobQueueSize < 0 {
+		fail(errors.New("'config > server > job_queue_size' must not be negative"), "job_queue_size")
 	}
 
 	/*  Verify Plugins  */
 	if s.TornjakConfig.Plugins == nil {
-		return errors.New("'config > plugins' field not defined")
+		errs = append(errs, fieldError{err: errors.New("'config > plugins' field not defined")})
 	}
-	return nil
+	return errs
 }
 
 func (s *Server) ConfigureDefaults() error {
 	// no authorization is a default
-	s.Authenticator = authenticator.NewNullAuthenticator()
-	s.Authorizer = authorization.NewNullAuthorizer()
+	s.setAccessPlugins(authenticator.NewNullAuthenticator(), authorization.NewNullAuthorizer())
 	return nil
 }
 
@@ -255,8 +663,16 @@ func (s *Server) Configure() error {
 		return fmt.Errorf("expected plugins node type %T but got %T", pluginList, pluginConfigs)
 	}
 
-	// iterate over plugin list
+	// Authenticator and Authorizer are configured together, as they are
+	// swapped together on reload
+	authn, authz, err := newAccessPlugins(pluginList)
+	if err != nil {
+		return err
+	}
+	s.setAccessPlugins(authn, authz)
 
+	// iterate over plugin list
+	var notifierPlugins []*ast.ObjectItem
 	for _, pluginObject := range pluginList.Items {
 		pluginType, err := stringFromToken(pluginObject.Keys[0].Token)
 		if err != nil {
@@ -284,27 +700,67 @@ func (s *Server) Configure() error {
 			if err != nil {
 				return errors.Errorf("Cannot configure CRD management plugin: %v", err)
 			}
-		// configure Authenticator
-		case "Authenticator":
-			if len(pluginObject.Keys) != 2 {
-				return fmt.Errorf("plugin Authenticator expected to have two keys (type then name)")
-			}
-			s.Authenticator, err = NewAuthenticator(pluginObject)
-			if err != nil {
-				return errors.Errorf("Cannot configure Authenticator plugin: %v", err)
-			}
-		// configure Authorizer
-		case "Authorizer":
-			if len(pluginObject.Keys) != 2 {
-				return fmt.Errorf("plugin Authorizer expected to have two keys (type then name)")
-			}
-			s.Authorizer, err = NewAuthorizer(pluginObject)
-			if err != nil {
-				return errors.Errorf("Cannot configure Authorizer plugin: %v", err)
+		// configure Notifiers once the datastore is known
+		case "Notifier":
+			if len(pluginObject.Keys) != 3 {
+				return fmt.Errorf("plugin Notifier expected to have three keys (type, kind then name)")
 			}
+			notifierPlugins = append(notifierPlugins, pluginObject)
 		}
 		// TODO Handle when multiple plugins configured
 	}
 
+	/*  Register this instance  */
+	if s.Db != nil {
+		if err := s.registerInstance(); err != nil {
+			return errors.Errorf("Cannot register Tornjak instance: %v", err)
+		}
+	}
+
+	/*  Configure Notifiers  */
+	if len(notifierPlugins) > 0 && s.Db == nil {
+		return errors.New("plugin Notifier requires a DataStore plugin")
+	}
+	names := map[string]struct{}{}
+	for _, pluginObject := range notifierPlugins {
+		n, err := NewNotifier(pluginObject, s.Db, s.instance)
+		if err != nil {
+			return errors.Errorf("Cannot configure Notifier plugin: %v", err)
+		}
+		if _, ok := names[n.Info().Name]; ok {
+			return errors.Errorf("Cannot configure Notifier plugin: duplicate name %s", n.Info().Name)
+		}
+		names[n.Info().Name] = struct{}{}
+		s.Notifiers = append(s.Notifiers, n)
+	}
+	if len(s.Notifiers) > 0 {
+		// deliveries queued in memory were lost on restart
+		if err := s.resumeWebhookDeliveries(); err != nil {
+			return errors.Errorf("Cannot configure Notifier plugin: %v", err)
+		}
+	}
+
+	/*  Start job workers  */
+	if s.Db != nil {
+		if err := s.startJobs(); err != nil {
+			return errors.Errorf("Cannot start job workers: %v", err)
+		}
+	}
+
+	/*  Start instance heartbeat  */
+	if s.Db != nil {
+		s.startInstanceHeartbeat()
+	}
+
+	/*  Start audit log retention  */
+	if s.auditEnabled() {
+		s.startAudit()
+	}
+
+	/*  Start agent reconciliation  */
+	if s.reconcileConfig() != nil {
+		s.startReconcile()
+	}
+
 	return nil
 }
@@ -0,0 +1,628 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/printer"
+	"github.com/hashicorp/hcl/hcl/token"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// configEnvPrefix prefixes environment variables overriding config fields,
+// e.g. TORNJAK_SERVER_HTTP_PORT overrides 'config > server > http > port'
+const configEnvPrefix = "TORNJAK"
+
+// envNameInvalid matches characters not allowed in environment variable names
+var envNameInvalid = regexp.MustCompile(`[^A-Z0-9_]`)
+
+// identifier matches keys that need no quotes in HCL
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// redactedValue replaces secrets in the printed config
+const redactedValue = "REDACTED"
+
+// DefaultRedactKeys mark config keys holding secrets, which are redacted
+// when a config is shown: a key is redacted if its name contains one of them,
+// ignoring case. 'config > server > redact_keys' adds to them.
+var DefaultRedactKeys = []string{"secret", "password", "passwd", "token", "credential", "connection_string", "access_key", "private_key"}
+
+// ParseConfig parses the Tornjak config read from the file name, in the
+// format given by its extension: .json for JSON, .yaml or .yml for YAML, and
+// HCL otherwise. JSON and YAML configs have the structure of the HCL config,
+// with plugins nested by type and name:
+//
+//	plugins:
+//	  Authenticator:
+//	    Keycloak:
+//	      plugin_data: {...}
+//
+// A plugin type configured more than once takes a list of such maps.
+func ParseConfig(name string, data string) (*ast.File, error) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json", ".yaml", ".yml":
+		// JSON is read as YAML, which keeps its line numbers
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(data), &doc); err != nil {
+			return nil, err
+		}
+		if len(doc.Content) == 0 {
+			return &ast.File{Node: &ast.ObjectList{}}, nil
+		}
+		list, err := yamlConfigToHCL(doc.Content[0])
+		if err != nil {
+			return nil, err
+		}
+		return &ast.File{Node: list}, nil
+	default:
+		return hcl.Parse(data)
+	}
+}
+
+// DecodeConfig decodes the Tornjak config parsed into root
+func DecodeConfig(root *ast.File) (*TornjakConfig, error) {
+	config := &TornjakConfig{}
+	if err := hcl.DecodeObject(config, root); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// pluginKeyCount returns the number of keys of plugins of pluginType
+func pluginKeyCount(pluginType string) int {
+	switch pluginType {
+	case "SPIRECRDManager":
+		return 1
+	case "Notifier":
+		return 3
+	default:
+		return 2
+	}
+}
+
+// yamlConfigToHCL converts the top level mapping of a YAML or JSON config
+func yamlConfigToHCL(node *yaml.Node) (*ast.ObjectList, error) {
+	if node.Kind != yaml.MappingNode {
+		return nil, errors.Errorf("line %d: expected a mapping at the top of the config", node.Line)
+	}
+	list := &ast.ObjectList{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Value != "plugins" {
+			items, err := yamlItems([]*ast.ObjectKey{yamlKey(key)}, value)
+			if err != nil {
+				return nil, err
+			}
+			list.Items = append(list.Items, items...)
+			continue
+		}
+
+		// plugin blocks carry their type and names as keys, like in HCL
+		if value.Kind != yaml.MappingNode {
+			return nil, errors.Errorf("line %d: expected a mapping of plugin types", value.Line)
+		}
+		plugins := &ast.ObjectList{}
+		for j := 0; j+1 < len(value.Content); j += 2 {
+			typeKey := value.Content[j]
+			items, err := yamlPluginItems([]*ast.ObjectKey{yamlKey(typeKey)}, pluginKeyCount(typeKey.Value)-1, value.Content[j+1])
+			if err != nil {
+				return nil, err
+			}
+			plugins.Items = append(plugins.Items, items...)
+		}
+		list.Items = append(list.Items, &ast.ObjectItem{
+			Keys: []*ast.ObjectKey{yamlKey(key)},
+			Val:  &ast.ObjectType{List: plugins, Lbrace: token.Pos{Line: value.Line, Column: value.Column}},
+		})
+	}
+	return list, nil
+}
+
+// yamlPluginItems returns the plugin blocks under keys, of which depth name
+// keys are still to be read from node
+func yamlPluginItems(keys []*ast.ObjectKey, depth int, node *yaml.Node) ([]*ast.ObjectItem, error) {
+	if node.Kind == yaml.SequenceNode {
+		var items []*ast.ObjectItem
+		for _, elem := range node.Content {
+			elemItems, err := yamlPluginItems(keys, depth, elem)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, elemItems...)
+		}
+		return items, nil
+	}
+	if node.Kind != yaml.MappingNode {
+		return nil, errors.Errorf("line %d: expected a mapping for plugin %s", node.Line, keys[0].Token.Value())
+	}
+	if depth == 0 {
+		val, err := yamlValue(node)
+		if err != nil {
+			return nil, err
+		}
+		return []*ast.ObjectItem{{Keys: keys, Val: val}}, nil
+	}
+	var items []*ast.ObjectItem
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		nameKeys := append(append([]*ast.ObjectKey{}, keys...), yamlKey(node.Content[i]))
+		nameItems, err := yamlPluginItems(nameKeys, depth-1, node.Content[i+1])
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, nameItems...)
+	}
+	return items, nil
+}
+
+// yamlItems returns the items for the value node under keys: a list of
+// mappings becomes repeated blocks
+func yamlItems(keys []*ast.ObjectKey, node *yaml.Node) ([]*ast.ObjectItem, error) {
+	if node.Kind == yaml.SequenceNode && len(node.Content) > 0 && node.Content[0].Kind == yaml.MappingNode {
+		var items []*ast.ObjectItem
+		for _, elem := range node.Content {
+			val, err := yamlValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, &ast.ObjectItem{Keys: keys, Val: val})
+		}
+		return items, nil
+	}
+	val, err := yamlValue(node)
+	if err != nil {
+		return nil, err
+	}
+	if val == nil {
+		// null values are left unset
+		return nil, nil
+	}
+	return []*ast.ObjectItem{newItem(keys, val)}, nil
+}
+
+// yamlValue converts node to an HCL value
+func yamlValue(node *yaml.Node) (ast.Node, error) {
+	pos := token.Pos{Line: node.Line, Column: node.Column}
+	switch node.Kind {
+	case yaml.AliasNode:
+		return yamlValue(node.Alias)
+	case yaml.MappingNode:
+		list := &ast.ObjectList{}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			items, err := yamlItems([]*ast.ObjectKey{yamlKey(node.Content[i])}, node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			list.Items = append(list.Items, items...)
+		}
+		return &ast.ObjectType{List: list, Lbrace: pos}, nil
+	case yaml.SequenceNode:
+		list := &ast.ListType{Lbrack: pos}
+		for _, elem := range node.Content {
+			val, err := yamlValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := val.(*ast.LiteralType); !ok {
+				return nil, errors.Errorf("line %d: lists may only hold values, not mappings or lists", elem.Line)
+			}
+			list.List = append(list.List, val)
+		}
+		return list, nil
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!null":
+			return nil, nil
+		case "!!int":
+			return literal(token.NUMBER, node.Value, pos), nil
+		case "!!float":
+			return literal(token.FLOAT, node.Value, pos), nil
+		case "!!bool":
+			b, err := strconv.ParseBool(node.Value)
+			if err != nil {
+				return nil, errors.Errorf("line %d: invalid bool %q", node.Line, node.Value)
+			}
+			return literal(token.BOOL, strconv.FormatBool(b), pos), nil
+		default:
+			return literal(token.STRING, strconv.Quote(node.Value), pos), nil
+		}
+	default:
+		return nil, errors.Errorf("line %d: unexpected YAML node", node.Line)
+	}
+}
+
+func yamlKey(node *yaml.Node) *ast.ObjectKey {
+	key := newKey(node.Value)
+	key.Token.Pos = token.Pos{Line: node.Line, Column: node.Column}
+	return key
+}
+
+// newItem returns an item setting keys to val; fields are printed with "="
+func newItem(keys []*ast.ObjectKey, val ast.Node) *ast.ObjectItem {
+	item := &ast.ObjectItem{Keys: keys, Val: val}
+	if _, isObject := val.(*ast.ObjectType); !isObject {
+		item.Assign = keys[len(keys)-1].Pos()
+		if !item.Assign.IsValid() {
+			item.Assign = token.Pos{Line: 1, Column: 1}
+		}
+	}
+	return item
+}
+
+func literal(typ token.Type, text string, pos token.Pos) *ast.LiteralType {
+	return &ast.LiteralType{Token: token.Token{Type: typ, Pos: pos, Text: text}}
+}
+
+// ApplyEnvOverrides sets the fields of the config in root given in environ,
+// as returned by os.Environ. A field's variable is TORNJAK followed by the
+// keys to it, upper case and joined by underscores, e.g.
+// TORNJAK_SERVER_HTTP_PORT for 'config > server > http > port'; lists are
+// given comma-separated. Fields of the server config and of the plugin_data
+// of configured plugins can be set; the keys to a plugin_data field are the
+// plugin type and names, without plugin_data, e.g.
+// TORNJAK_PLUGINS_DATASTORE_SQL_CONNECTION_STRING. It returns the names of
+// the variables applied.
+func ApplyEnvOverrides(root *ast.File, environ []string) ([]string, error) {
+	list, ok := root.Node.(*ast.ObjectList)
+	if !ok {
+		return nil, errors.Errorf("unexpected config root %T", root.Node)
+	}
+	env := map[string]string{}
+	for _, kv := range environ {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			env[name] = value
+		}
+	}
+
+	var applied []string
+	err := forEachConfigField(reflect.TypeOf(serverConfig{}), []string{"server"}, func(keys []string, kind reflect.Type) error {
+		name := configEnvName(keys)
+		value, ok := env[name]
+		if !ok {
+			return nil
+		}
+		val, err := envValue(kind, value)
+		if err != nil {
+			return errors.Errorf("invalid %s: %v", name, err)
+		}
+		setConfigValue(list, keys, val)
+		applied = append(applied, name)
+		return nil
+	})
+	if err != nil {
+		return applied, err
+	}
+	pluginApplied, err := applyPluginEnvOverrides(list, env)
+	return append(applied, pluginApplied...), err
+}
+
+// applyPluginEnvOverrides sets the plugin_data fields of the plugins
+// configured in list that are given in env. A field keeps the type of its
+// value in the config, and is a string if not in the config.
+func applyPluginEnvOverrides(list *ast.ObjectList, env map[string]string) ([]string, error) {
+	// plugin blocks by the prefix of the variables of their fields
+	plugins := map[string]*ast.ObjectList{}
+	for _, item := range list.Items {
+		obj, ok := item.Val.(*ast.ObjectType)
+		if !ok || len(item.Keys) != 1 || !strings.EqualFold(keyName(item.Keys[0]), "plugins") {
+			continue
+		}
+		for _, plugin := range obj.List.Items {
+			body, ok := plugin.Val.(*ast.ObjectType)
+			if !ok {
+				continue
+			}
+			keys := []string{"plugins"}
+			for _, key := range plugin.Keys {
+				keys = append(keys, keyName(key))
+			}
+			plugins[configEnvName(keys)+"_"] = body.List
+		}
+	}
+	if len(plugins) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(env))
+	for name := range env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var applied []string
+	for _, name := range names {
+		// the longest prefix, as plugin names may contain underscores
+		prefix := ""
+		for p := range plugins {
+			if strings.HasPrefix(name, p) && len(p) > len(prefix) {
+				prefix = p
+			}
+		}
+		field := strings.ToLower(strings.TrimPrefix(name, prefix))
+		if prefix == "" || field == "" {
+			continue
+		}
+		body := plugins[prefix]
+
+		kind := reflect.TypeOf("")
+		if current := pluginDataField(body, field); current != nil {
+			field = keyName(current.Keys[0])
+			var err error
+			if kind, err = configValueKind(current.Val); err != nil {
+				return applied, errors.Errorf("invalid %s: %v", name, err)
+			}
+		}
+		val, err := envValue(kind, env[name])
+		if err != nil {
+			return applied, errors.Errorf("invalid %s: %v", name, err)
+		}
+		setConfigValue(body, []string{"plugin_data", field}, val)
+		applied = append(applied, name)
+	}
+	return applied, nil
+}
+
+// pluginDataField returns the item of the plugin_data field of the plugin
+// body, ignoring case, or nil if not set
+func pluginDataField(body *ast.ObjectList, field string) *ast.ObjectItem {
+	for _, item := range body.Items {
+		data, ok := item.Val.(*ast.ObjectType)
+		if !ok || len(item.Keys) != 1 || keyName(item.Keys[0]) != "plugin_data" {
+			continue
+		}
+		for _, dataItem := range data.List.Items {
+			if strings.EqualFold(keyName(dataItem.Keys[0]), field) {
+				return dataItem
+			}
+		}
+	}
+	return nil
+}
+
+// configValueKind returns the type of a config value that can be overridden
+func configValueKind(val ast.Node) (reflect.Type, error) {
+	switch v := val.(type) {
+	case *ast.LiteralType:
+		switch v.Token.Type {
+		case token.NUMBER:
+			return reflect.TypeOf(int64(0)), nil
+		case token.FLOAT:
+			return reflect.TypeOf(float64(0)), nil
+		case token.BOOL:
+			return reflect.TypeOf(false), nil
+		}
+		return reflect.TypeOf(""), nil
+	case *ast.ListType:
+		return reflect.TypeOf([]string{}), nil
+	default:
+		return nil, errors.New("blocks cannot be overridden")
+	}
+}
+
+// configEnvName returns the environment variable overriding the field at
+// keys; characters not allowed in variable names become underscores
+func configEnvName(keys []string) string {
+	return configEnvPrefix + "_" + envNameInvalid.ReplaceAllString(strings.ToUpper(strings.Join(keys, "_")), "_")
+}
+
+// forEachConfigField calls fn with the keys and type of every value field
+// of the config struct typ found at keys
+func forEachConfigField(typ reflect.Type, keys []string, fn func([]string, reflect.Type) error) error {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		name := strings.Split(field.Tag.Get("hcl"), ",")[0]
+		if name == "" {
+			continue
+		}
+		fieldKeys := append(append([]string{}, keys...), name)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() == reflect.Struct {
+			if err := forEachConfigField(fieldType, fieldKeys, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := fn(fieldKeys, fieldType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// envValue converts the environment variable value to an HCL value of kind
+func envValue(kind reflect.Type, value string) (ast.Node, error) {
+	switch kind.Kind() {
+	case reflect.String:
+		return literal(token.STRING, strconv.Quote(value), token.Pos{}), nil
+	case reflect.Int, reflect.Int64:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, errors.Errorf("expected a number, got %q", value)
+		}
+		return literal(token.NUMBER, value, token.Pos{}), nil
+	case reflect.Float64:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, errors.Errorf("expected a number, got %q", value)
+		}
+		return literal(token.FLOAT, value, token.Pos{}), nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.Errorf("expected true or false, got %q", value)
+		}
+		return literal(token.BOOL, strconv.FormatBool(b), token.Pos{}), nil
+	case reflect.Slice:
+		list := &ast.ListType{}
+		for _, elem := range strings.Split(value, ",") {
+			if elem = strings.TrimSpace(elem); elem != "" {
+				list.List = append(list.List, literal(token.STRING, strconv.Quote(elem), token.Pos{}))
+			}
+		}
+		return list, nil
+	default:
+		return nil, errors.Errorf("fields of type %s cannot be overridden", kind)
+	}
+}
+
+// setConfigValue sets the field at keys in list to val, creating the blocks
+// along keys as needed
+func setConfigValue(list *ast.ObjectList, keys []string, val ast.Node) {
+	for _, key := range keys[:len(keys)-1] {
+		var next *ast.ObjectList
+		for _, item := range list.Items {
+			if obj, ok := item.Val.(*ast.ObjectType); ok && len(item.Keys) == 1 && keyName(item.Keys[0]) == key {
+				next = obj.List
+			}
+		}
+		if next == nil {
+			next = &ast.ObjectList{}
+			list.Add(&ast.ObjectItem{
+				Keys: []*ast.ObjectKey{newKey(key)},
+				Val:  &ast.ObjectType{List: next},
+			})
+		}
+		list = next
+	}
+
+	field := keys[len(keys)-1]
+	set := false
+	for _, item := range list.Items {
+		if len(item.Keys) == 1 && keyName(item.Keys[0]) == field {
+			item.Val = val
+			set = true
+		}
+	}
+	if !set {
+		list.Add(newItem([]*ast.ObjectKey{newKey(field)}, val))
+	}
+}
+
+func keyName(key *ast.ObjectKey) string {
+	name, err := stringFromToken(key.Token)
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+// newKey returns a key for name, quoted unless it is an identifier
+func newKey(name string) *ast.ObjectKey {
+	if identifier.MatchString(name) {
+		return &ast.ObjectKey{Token: token.Token{Type: token.IDENT, Text: name}}
+	}
+	return &ast.ObjectKey{Token: token.Token{Type: token.STRING, Text: strconv.Quote(name)}}
+}
+
+// RedactKeys returns DefaultRedactKeys with the redact_keys of config added
+func RedactKeys(config *TornjakConfig) []string {
+	keys := append([]string{}, DefaultRedactKeys...)
+	if config != nil && config.Server != nil {
+		keys = append(keys, config.Server.RedactKeys...)
+	}
+	return keys
+}
+
+// RedactConfig replaces the values of keys holding secrets in node, as
+// marked by redactKeys, with REDACTED. It modifies node.
+func RedactConfig(node ast.Node, redactKeys []string) {
+	ast.Walk(node, func(n ast.Node) (ast.Node, bool) {
+		item, ok := n.(*ast.ObjectItem)
+		if !ok || len(item.Keys) == 0 {
+			return n, true
+		}
+		if _, isObject := item.Val.(*ast.ObjectType); isObject || !isSecretKey(keyName(item.Keys[len(item.Keys)-1]), redactKeys) {
+			return n, true
+		}
+		item.Val = literal(token.STRING, strconv.Quote(redactedValue), item.Val.Pos())
+		return n, false
+	})
+}
+
+func isSecretKey(name string, redactKeys []string) bool {
+	name = strings.ToLower(name)
+	for _, part := range redactKeys {
+		if part != "" && strings.Contains(name, strings.ToLower(part)) {
+			return true
+		}
+	}
+	return false
+}
+
+// ConfigValue converts an HCL value to plain values for JSON: objects become
+// maps, with labelled blocks such as role "admin" { ... } nested by label,
+// and keys given more than once become lists.
+func ConfigValue(node ast.Node) interface{} {
+	switch n := node.(type) {
+	case *ast.File:
+		return ConfigValue(n.Node)
+	case *ast.ObjectType:
+		return ConfigValue(n.List)
+	case *ast.ObjectList:
+		m := map[string]interface{}{}
+		for _, item := range n.Items {
+			target := m
+			for _, key := range item.Keys[:len(item.Keys)-1] {
+				name := keyName(key)
+				next, ok := target[name].(map[string]interface{})
+				if !ok {
+					next = map[string]interface{}{}
+					target[name] = next
+				}
+				target = next
+			}
+			addConfigValue(target, keyName(item.Keys[len(item.Keys)-1]), ConfigValue(item.Val))
+		}
+		return m
+	case *ast.ListType:
+		list := make([]interface{}, 0, len(n.List))
+		for _, elem := range n.List {
+			list = append(list, ConfigValue(elem))
+		}
+		return list
+	case *ast.LiteralType:
+		return n.Token.Value()
+	default:
+		return nil
+	}
+}
+
+// addConfigValue sets name to value in m, merging objects and collecting
+// other values given more than once in a list
+func addConfigValue(m map[string]interface{}, name string, value interface{}) {
+	existing, ok := m[name]
+	if !ok {
+		m[name] = value
+		return
+	}
+	existingMap, ok1 := existing.(map[string]interface{})
+	valueMap, ok2 := value.(map[string]interface{})
+	if ok1 && ok2 {
+		for k, v := range valueMap {
+			addConfigValue(existingMap, k, v)
+		}
+		return
+	}
+	if list, ok := existing.([]interface{}); ok {
+		m[name] = append(list, value)
+		return
+	}
+	m[name] = []interface{}{existing, value}
+}
+
+// PrintConfig writes the config in root as HCL
+func PrintConfig(w io.Writer, root *ast.File) error {
+	if err := printer.Fprint(w, root); err != nil {
+		return fmt.Errorf("unable to print config: %w", err)
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
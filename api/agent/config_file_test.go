@@ -0,0 +1,257 @@
+package api
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/hcl/ast"
+)
+
+const testConfigHCL = `
+server {
+  spire_socket_path = "unix:///tmp/spire-server/private/api.sock"
+  redact_keys = ["pin"]
+  http {
+    port = 10000
+  }
+}
+
+plugins {
+  DataStore "sql" {
+    plugin_data {
+      drivername = "sqlite3"
+      filename = "/run/tornjak.sqlite3"
+      connection_string = "file.db"
+      max_open_conns = 10
+    }
+  }
+  Authenticator "Keycloak" {
+    plugin_data {
+      issuer = "http://localhost:8080/realms/tornjak"
+    }
+  }
+  Authenticator "ClientCert" {
+    plugin_data {
+      spiffe_id "spiffe://example.org/ci" { roles = ["admin"] }
+    }
+  }
+}
+`
+
+const testConfigYAML = `
+server:
+  spire_socket_path: unix:///tmp/spire-server/private/api.sock
+  redact_keys: [pin]
+  http:
+    port: 10000
+plugins:
+  DataStore:
+    sql:
+      plugin_data:
+        drivername: sqlite3
+        filename: /run/tornjak.sqlite3
+        connection_string: file.db
+        max_open_conns: 10
+  Authenticator:
+    - Keycloak:
+        plugin_data:
+          issuer: http://localhost:8080/realms/tornjak
+    - ClientCert:
+        plugin_data:
+          spiffe_id:
+            spiffe://example.org/ci:
+              roles: [admin]
+`
+
+const testConfigJSON = `{
+  "server": {
+    "spire_socket_path": "unix:///tmp/spire-server/private/api.sock",
+    "redact_keys": ["pin"],
+    "http": {"port": 10000}
+  },
+  "plugins": {
+    "DataStore": {
+      "sql": {
+        "plugin_data": {
+          "drivername": "sqlite3",
+          "filename": "/run/tornjak.sqlite3",
+          "connection_string": "file.db",
+          "max_open_conns": 10
+        }
+      }
+    },
+    "Authenticator": [
+      {"Keycloak": {"plugin_data": {"issuer": "http://localhost:8080/realms/tornjak"}}},
+      {"ClientCert": {"plugin_data": {"spiffe_id": {"spiffe://example.org/ci": {"roles": ["admin"]}}}}}
+    ]
+  }
+}`
+
+// parseTestConfig parses and decodes the config data read from the file name
+func parseTestConfig(t *testing.T, name string, data string) (*ast.File, *TornjakConfig) {
+	root, err := ParseConfig(name, data)
+	if err != nil {
+		t.Fatalf("Failed parsing %s: %v", name, err)
+	}
+	config, err := DecodeConfig(root)
+	if err != nil {
+		t.Fatalf("Failed decoding %s: %v", name, err)
+	}
+	return root, config
+}
+
+func TestParseConfig(t *testing.T) {
+	_, want := parseTestConfig(t, "tornjak.conf", testConfigHCL)
+	if want.Server.HTTPConfig.ListenPort != 10000 || want.Server.SPIRESocket != "unix:///tmp/spire-server/private/api.sock" {
+		t.Fatalf("Unexpected server config %+v", want.Server)
+	}
+	wantPlugins := ConfigValue(*want.Plugins)
+
+	for _, tt := range []struct {
+		name string
+		data string
+	}{
+		{name: "tornjak.yaml", data: testConfigYAML},
+		{name: "tornjak.yml", data: testConfigYAML},
+		{name: "tornjak.json", data: testConfigJSON},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, config := parseTestConfig(t, tt.name, tt.data)
+			if !reflect.DeepEqual(config.Server, want.Server) {
+				t.Fatalf("Expected server config %+v, got %+v", want.Server, config.Server)
+			}
+			if plugins := ConfigValue(*config.Plugins); !reflect.DeepEqual(plugins, wantPlugins) {
+				t.Fatalf("Expected plugins %v, got %v", wantPlugins, plugins)
+			}
+		})
+	}
+}
+
+func TestParseConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{name: "tornjak.yaml", data: "- server", wantErr: "expected a mapping at the top of the config"},
+		{name: "tornjak.yaml", data: "plugins: [DataStore]", wantErr: "expected a mapping of plugin types"},
+		{name: "tornjak.yaml", data: "plugins:\n  DataStore:\n    sql: true", wantErr: "line 3: expected a mapping for plugin DataStore"},
+		{name: "tornjak.json", data: `{"server": {"redact_keys": [["pin"]]}}`, wantErr: "lists may only hold values"},
+		{name: "tornjak.json", data: `{"server": `, wantErr: ""},
+	}
+	for _, tt := range tests {
+		_, err := ParseConfig(tt.name, tt.data)
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Fatalf("Expected error containing %q parsing %q, got %v", tt.wantErr, tt.data, err)
+		}
+	}
+}
+
+func TestApplyEnvOverrides(t *testing.T) {
+	environ := []string{
+		"TORNJAK_SERVER_HTTP_PORT=11000",
+		"TORNJAK_SERVER_REDACT_KEYS=pin, otp",
+		"TORNJAK_SERVER_INSTANCE_ID=tornjak-0",
+		"TORNJAK_PLUGINS_DATASTORE_SQL_CONNECTION_STRING=postgres://user:pass@db/tornjak",
+		"TORNJAK_PLUGINS_DATASTORE_SQL_MAX_OPEN_CONNS=20",
+		"TORNJAK_PLUGINS_AUTHENTICATOR_KEYCLOAK_AUDIENCE=tornjak-backend",
+		"TORNJAK_PLUGINS_AUTHORIZER_RBAC_NAME=unconfigured plugin",
+		"OTHER=ignored",
+	}
+	for _, tt := range []struct {
+		name string
+		data string
+	}{
+		{name: "tornjak.conf", data: testConfigHCL},
+		{name: "tornjak.yaml", data: testConfigYAML},
+		{name: "tornjak.json", data: testConfigJSON},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := ParseConfig(tt.name, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			applied, err := ApplyEnvOverrides(root, environ)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantApplied := []string{
+				"TORNJAK_SERVER_REDACT_KEYS",
+				"TORNJAK_SERVER_INSTANCE_ID",
+				"TORNJAK_SERVER_HTTP_PORT",
+				"TORNJAK_PLUGINS_AUTHENTICATOR_KEYCLOAK_AUDIENCE",
+				"TORNJAK_PLUGINS_DATASTORE_SQL_CONNECTION_STRING",
+				"TORNJAK_PLUGINS_DATASTORE_SQL_MAX_OPEN_CONNS",
+			}
+			if !reflect.DeepEqual(applied, wantApplied) {
+				t.Fatalf("Expected applied %v, got %v", wantApplied, applied)
+			}
+
+			config, err := DecodeConfig(root)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config.Server.HTTPConfig.ListenPort != 11000 {
+				t.Fatalf("Expected port 11000, got %d", config.Server.HTTPConfig.ListenPort)
+			}
+			if !reflect.DeepEqual(config.Server.RedactKeys, []string{"pin", "otp"}) {
+				t.Fatalf("Expected redact keys [pin otp], got %v", config.Server.RedactKeys)
+			}
+			if config.Server.InstanceID != "tornjak-0" {
+				t.Fatalf("Expected instance ID tornjak-0, got %q", config.Server.InstanceID)
+			}
+
+			plugins := ConfigValue(*config.Plugins).(map[string]interface{})
+			sql := plugins["DataStore"].(map[string]interface{})["sql"].(map[string]interface{})["plugin_data"].(map[string]interface{})
+			if sql["connection_string"] != "postgres://user:pass@db/tornjak" {
+				t.Fatalf("Expected overridden connection string, got %v", sql["connection_string"])
+			}
+			if sql["max_open_conns"] != int64(20) {
+				t.Fatalf("Expected max_open_conns 20 as a number, got %#v", sql["max_open_conns"])
+			}
+			if sql["drivername"] != "sqlite3" {
+				t.Fatalf("Expected drivername kept, got %v", sql["drivername"])
+			}
+			keycloak := plugins["Authenticator"].(map[string]interface{})["Keycloak"].(map[string]interface{})["plugin_data"].(map[string]interface{})
+			if keycloak["audience"] != "tornjak-backend" {
+				t.Fatalf("Expected audience added, got %v", keycloak["audience"])
+			}
+			if _, ok := plugins["Authorizer"]; ok {
+				t.Fatal("Expected unconfigured plugin not to be added")
+			}
+
+			// the overridden secret is redacted when printed
+			RedactConfig(root, RedactKeys(config))
+			var out bytes.Buffer
+			if err := PrintConfig(&out, root); err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(out.String(), "user:pass") || !strings.Contains(out.String(), redactedValue) {
+				t.Fatalf("Expected connection string redacted, got %s", out.String())
+			}
+		})
+	}
+}
+
+func TestApplyEnvOverridesErrors(t *testing.T) {
+	tests := []struct {
+		env     string
+		wantErr string
+	}{
+		{env: "TORNJAK_SERVER_HTTP_PORT=http", wantErr: "invalid TORNJAK_SERVER_HTTP_PORT: expected a number"},
+		{env: "TORNJAK_PLUGINS_DATASTORE_SQL_MAX_OPEN_CONNS=many", wantErr: "invalid TORNJAK_PLUGINS_DATASTORE_SQL_MAX_OPEN_CONNS: expected a number"},
+		{env: "TORNJAK_PLUGINS_AUTHENTICATOR_CLIENTCERT_SPIFFE_ID=x", wantErr: "blocks cannot be overridden"},
+	}
+	for _, tt := range tests {
+		root, err := ParseConfig("tornjak.conf", testConfigHCL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ApplyEnvOverrides(root, []string{tt.env})
+		if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Fatalf("Expected error containing %q for %s, got %v", tt.wantErr, tt.env, err)
+		}
+	}
+}
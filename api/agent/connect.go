@@ -0,0 +1,508 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"connectrpc.com/connect"
+	agent "github.com/spiffe/spire-api-sdk/proto/spire/api/server/agent/v1"
+	bundle "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
+	debugServer "github.com/spiffe/spire-api-sdk/proto/spire/api/server/debug/v1"
+	entry "github.com/spiffe/spire-api-sdk/proto/spire/api/server/entry/v1"
+	trustdomain "github.com/spiffe/spire-api-sdk/proto/spire/api/server/trustdomain/v1"
+	types "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	agentdb "github.com/spiffe/tornjak/pkg/agent/db"
+	tornjakTypes "github.com/spiffe/tornjak/pkg/agent/types"
+	tornjakv1 "github.com/spiffe/tornjak/pkg/proto/tornjak/api/v1"
+	"github.com/spiffe/tornjak/pkg/proto/tornjak/api/v1/tornjakv1connect"
+)
+
+/*
+
+Connect / gRPC API
+
+The services defined in proto/tornjak/api/v1 are served on the same listeners
+as the HTTP API using the Connect, gRPC and gRPC-Web protocols. Every procedure
+is authorized as the API v1 route it is equivalent to, so existing
+authorization policies apply to both.
+
+*/
+
+type apiV1Route struct {
+	method string
+	path   string
+}
+
+// connectAPIV1Routes maps each procedure to its equivalent API v1 route.
+var connectAPIV1Routes = map[string]apiV1Route{
+	tornjakv1connect.ClusterServiceListClustersProcedure:  {http.MethodGet, "/api/v1/tornjak/clusters"},
+	tornjakv1connect.ClusterServiceGetClusterProcedure:    {http.MethodGet, "/api/v1/tornjak/clusters"},
+	tornjakv1connect.ClusterServiceCreateClusterProcedure: {http.MethodPost, "/api/v1/tornjak/clusters"},
+	tornjakv1connect.ClusterServiceUpdateClusterProcedure: {http.MethodPatch, "/api/v1/tornjak/clusters"},
+	tornjakv1connect.ClusterServiceDeleteClusterProcedure: {http.MethodDelete, "/api/v1/tornjak/clusters"},
+
+	tornjakv1connect.SelectorServiceListSelectorsProcedure:     {http.MethodGet, "/api/v1/tornjak/selectors"},
+	tornjakv1connect.SelectorServiceDefineSelectorProcedure:    {http.MethodPost, "/api/v1/tornjak/selectors"},
+	tornjakv1connect.SelectorServiceListAgentMetadataProcedure: {http.MethodGet, "/api/v1/tornjak/agents"},
+
+	tornjakv1connect.SPIREServiceGetInfoProcedure:                           {http.MethodGet, "/api/v1/spire/serverinfo"},
+	tornjakv1connect.SPIREServiceListAgentsProcedure:                        {http.MethodGet, "/api/v1/spire/agents"},
+	tornjakv1connect.SPIREServiceGetAgentProcedure:                          {http.MethodGet, "/api/v1/spire/agents"},
+	tornjakv1connect.SPIREServiceDeleteAgentProcedure:                       {http.MethodDelete, "/api/v1/spire/agents"},
+	tornjakv1connect.SPIREServiceBanAgentProcedure:                          {http.MethodPost, "/api/v1/spire/agents/ban"},
+	tornjakv1connect.SPIREServiceCreateJoinTokenProcedure:                   {http.MethodPost, "/api/v1/spire/agents/jointoken"},
+	tornjakv1connect.SPIREServiceListEntriesProcedure:                       {http.MethodGet, "/api/v1/spire/entries"},
+	tornjakv1connect.SPIREServiceGetEntryProcedure:                          {http.MethodGet, "/api/v1/spire/entries"},
+	tornjakv1connect.SPIREServiceBatchCreateEntryProcedure:                  {http.MethodPost, "/api/v1/spire/entries"},
+	tornjakv1connect.SPIREServiceBatchDeleteEntryProcedure:                  {http.MethodDelete, "/api/v1/spire/entries"},
+	tornjakv1connect.SPIREServiceGetBundleProcedure:                         {http.MethodGet, "/api/v1/spire/bundle"},
+	tornjakv1connect.SPIREServiceListFederatedBundlesProcedure:              {http.MethodGet, "/api/v1/spire/federations/bundles"},
+	tornjakv1connect.SPIREServiceBatchCreateFederatedBundleProcedure:        {http.MethodPost, "/api/v1/spire/federations/bundles"},
+	tornjakv1connect.SPIREServiceBatchUpdateFederatedBundleProcedure:        {http.MethodPatch, "/api/v1/spire/federations/bundles"},
+	tornjakv1connect.SPIREServiceBatchDeleteFederatedBundleProcedure:        {http.MethodDelete, "/api/v1/spire/federations/bundles"},
+	tornjakv1connect.SPIREServiceListFederationRelationshipsProcedure:       {http.MethodGet, "/api/v1/spire/federations"},
+	tornjakv1connect.SPIREServiceBatchCreateFederationRelationshipProcedure: {http.MethodPost, "/api/v1/spire/federations"},
+	tornjakv1connect.SPIREServiceBatchUpdateFederationRelationshipProcedure: {http.MethodPatch, "/api/v1/spire/federations"},
+	tornjakv1connect.SPIREServiceBatchDeleteFederationRelationshipProcedure: {http.MethodDelete, "/api/v1/spire/federations"},
+}
+
+// connectAuthInterceptor authenticates and authorizes calls with the
+// configured authenticator and authorizer.
+func (s *Server) connectAuthInterceptor() connect.UnaryInterceptorFunc {
+	return func(next connect.UnaryFunc) connect.UnaryFunc {
+		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+			route, ok := connectAPIV1Routes[req.Spec().Procedure]
+			if !ok {
+				return nil, connect.NewError(connect.CodePermissionDenied, fmt.Errorf("procedure %s has no authorization mapping", req.Spec().Procedure))
+			}
+			r, err := http.NewRequestWithContext(ctx, route.method, route.path, nil)
+			if err != nil {
+				return nil, connect.NewError(connect.CodeInternal, err)
+			}
+			r.Header = req.Header().Clone()
+			r.RemoteAddr = req.Peer().Addr
+			r.TLS = tlsStateFromContext(ctx)
+
+			authn, authz := s.accessPlugins()
+			userInfo := authn.AuthenticateRequest(r)
+			// Connect calls are audited by procedure
+			record := newAuditRecord(r, userInfo)
+			record.Method = http.MethodPost
+			record.Route = req.Spec().Procedure
+			record.Path = req.Spec().Procedure
+			if err := authz.AuthorizeRequest(r, userInfo); err != nil {
+				code := connect.CodePermissionDenied
+				if userInfo != nil && userInfo.AuthenticationError != nil {
+					code = connect.CodeUnauthenticated
+				}
+				record.Decision = tornjakTypes.AuditDeny
+				record.Reason = err.Error()
+				s.audit(record, 0)
+				return nil, connect.NewError(code, fmt.Errorf("Error authorizing request: %v", err.Error()))
+			}
+
+			trail := &auditTrail{}
+			res, err := next(withAuditTrail(withActor(ctx, newActor(r, userInfo)), trail), req)
+			record.Decision = tornjakTypes.AuditAllow
+			record.Resources = trail.list()
+			status := http.StatusOK
+			if err != nil {
+				record.Reason = err.Error()
+				status = 0
+			}
+			s.audit(record, status)
+			return res, err
+		}
+	}
+}
+
+// connectHandlers returns the Connect service handlers with the paths to mount them on.
+func (s *Server) connectHandlers() map[string]http.Handler {
+	opts := []connect.HandlerOption{
+		connect.WithInterceptors(s.connectAuthInterceptor()),
+		connect.WithReadMaxBytes(int(s.maxRequestBytes())),
+	}
+	handlers := map[string]http.Handler{}
+	path, handler := tornjakv1connect.NewClusterServiceHandler(&clusterService{s}, opts...)
+	handlers[path] = withTLSState(handler)
+	path, handler = tornjakv1connect.NewSelectorServiceHandler(&selectorService{s}, opts...)
+	handlers[path] = withTLSState(handler)
+	path, handler = tornjakv1connect.NewSPIREServiceHandler(&spireService{s}, opts...)
+	handlers[path] = withTLSState(handler)
+	return handlers
+}
+
+type tlsStateKey struct{}
+
+// withTLSState passes the TLS connection state of requests, holding the
+// client certificate, to the interceptors of next in their context
+func withTLSState(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			r = r.WithContext(context.WithValue(r.Context(), tlsStateKey{}, r.TLS))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// tlsStateFromContext returns the TLS connection state stored by
+// withTLSState, nil if the call did not come over TLS
+func tlsStateFromContext(ctx context.Context) *tls.ConnectionState {
+	state, _ := ctx.Value(tlsStateKey{}).(*tls.ConnectionState)
+	return state
+}
+
+// connectTornjakError converts an error returned by a Tornjak API call,
+// following the same classification as retTornjakError.
+func connectTornjakError(err error) error {
+	var (
+		getErr  agentdb.GetError
+		postErr agentdb.PostFailure
+		sqlErr  agentdb.SQLError
+	)
+	code := connect.CodeInvalidArgument
+	switch {
+	case errors.As(err, &getErr):
+		code = connect.CodeNotFound
+	case errors.As(err, &postErr):
+		code = connect.CodeAlreadyExists
+	case errors.As(err, &sqlErr):
+		code = connect.CodeInternal
+	}
+	return connect.NewError(code, err)
+}
+
+// connectSPIREError converts an error returned by a SPIRE API call, keeping
+// the status code SPIRE returned.
+func connectSPIREError(err error) error {
+	st := status.Convert(err)
+	return connect.NewError(connect.Code(st.Code()), errors.New(st.Message()))
+}
+
+func clusterToProto(cinfo tornjakTypes.ClusterInfo) (*tornjakv1.Cluster, error) {
+	cluster := &tornjakv1.Cluster{
+		Name:         cinfo.Name,
+		CreationTime: cinfo.CreationTime,
+		DomainName:   cinfo.DomainName,
+		ManagedBy:    cinfo.ManagedBy,
+		PlatformType: cinfo.PlatformType,
+		Agents:       cinfo.AgentsList,
+		Labels:       cinfo.Labels,
+	}
+	if len(cinfo.Details) > 0 {
+		var details map[string]interface{}
+		if err := json.Unmarshal(cinfo.Details, &details); err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("invalid details of cluster %s: %v", cinfo.Name, err))
+		}
+		var err error
+		if cluster.Details, err = structpb.NewStruct(details); err != nil {
+			return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("invalid details of cluster %s: %v", cinfo.Name, err))
+		}
+	}
+	return cluster, nil
+}
+
+func clusterFromProto(cluster *tornjakv1.Cluster) (tornjakTypes.ClusterInfo, error) {
+	cinfo := tornjakTypes.ClusterInfo{
+		Name:         cluster.GetName(),
+		DomainName:   cluster.GetDomainName(),
+		ManagedBy:    cluster.GetManagedBy(),
+		PlatformType: cluster.GetPlatformType(),
+		AgentsList:   cluster.GetAgents(),
+		Labels:       tornjakTypes.Labels(cluster.GetLabels()),
+	}
+	if cluster.GetDetails() != nil {
+		details, err := json.Marshal(cluster.GetDetails().AsMap())
+		if err != nil {
+			return tornjakTypes.ClusterInfo{}, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("invalid details: %v", err))
+		}
+		cinfo.Details = details
+	}
+	return cinfo, nil
+}
+
+func agentInfoListToProto(agents []tornjakTypes.AgentInfo) []*tornjakv1.AgentInfo {
+	resp := make([]*tornjakv1.AgentInfo, 0, len(agents))
+	for _, ainfo := range agents {
+		resp = append(resp, &tornjakv1.AgentInfo{
+			SpiffeId: ainfo.Spiffeid,
+			Plugin:   ainfo.Plugin,
+			Cluster:  ainfo.Cluster,
+		})
+	}
+	return resp
+}
+
+// clusterService implements tornjakv1connect.ClusterServiceHandler.
+type clusterService struct {
+	s *Server
+}
+
+func (c *clusterService) ListClusters(ctx context.Context, req *connect.Request[tornjakv1.ListClustersRequest]) (*connect.Response[tornjakv1.ListClustersResponse], error) {
+	ret, err := c.s.ListClusters(ListClustersRequest{LabelSelector: req.Msg.GetLabelSelector()})
+	if err != nil {
+		return nil, connectTornjakError(err)
+	}
+	resp := &tornjakv1.ListClustersResponse{}
+	for _, cinfo := range ret.Clusters {
+		cluster, err := clusterToProto(cinfo)
+		if err != nil {
+			return nil, err
+		}
+		resp.Clusters = append(resp.Clusters, cluster)
+	}
+	return connect.NewResponse(resp), nil
+}
+
+func (c *clusterService) GetCluster(ctx context.Context, req *connect.Request[tornjakv1.GetClusterRequest]) (*connect.Response[tornjakv1.Cluster], error) {
+	ret, err := c.s.GetCluster(GetClusterRequest{Name: req.Msg.GetName()})
+	if err != nil {
+		return nil, connectTornjakError(err)
+	}
+	cluster, err := clusterToProto(tornjakTypes.ClusterInfo(*ret))
+	if err != nil {
+		return nil, err
+	}
+	return connect.NewResponse(cluster), nil
+}
+
+func (c *clusterService) CreateCluster(ctx context.Context, req *connect.Request[tornjakv1.CreateClusterRequest]) (*connect.Response[tornjakv1.Cluster], error) {
+	cinfo, err := clusterFromProto(req.Msg.GetCluster())
+	if err != nil {
+		return nil, err
+	}
+	if err := c.s.defineCluster(ctx, RegisterClusterRequest{ClusterInstance: cinfo}); err != nil {
+		return nil, connectTornjakError(err)
+	}
+	return c.GetCluster(ctx, connect.NewRequest(&tornjakv1.GetClusterRequest{Name: cinfo.Name}))
+}
+
+func (c *clusterService) UpdateCluster(ctx context.Context, req *connect.Request[tornjakv1.UpdateClusterRequest]) (*connect.Response[tornjakv1.Cluster], error) {
+	// an empty name in the new definition keeps the current name
+	if _, err := c.s.GetCluster(GetClusterRequest{Name: req.Msg.GetName()}); err != nil {
+		return nil, connectTornjakError(err)
+	}
+	cinfo, err := clusterFromProto(req.Msg.GetCluster())
+	if err != nil {
+		return nil, err
+	}
+	// the cluster is replaced, so labels and details not given are cleared
+	if cinfo.Labels == nil {
+		cinfo.Labels = tornjakTypes.Labels{}
+	}
+	if cinfo.Details == nil {
+		cinfo.Details = json.RawMessage(`{}`)
+	}
+	cinfo.EditedName = cinfo.Name
+	if len(cinfo.EditedName) == 0 {
+		cinfo.EditedName = req.Msg.GetName()
+	}
+	cinfo.Name = req.Msg.GetName()
+	if err := c.s.editCluster(ctx, EditClusterRequest{ClusterInstance: cinfo}); err != nil {
+		return nil, connectTornjakError(err)
+	}
+	return c.GetCluster(ctx, connect.NewRequest(&tornjakv1.GetClusterRequest{Name: cinfo.EditedName}))
+}
+
+func (c *clusterService) DeleteCluster(ctx context.Context, req *connect.Request[tornjakv1.DeleteClusterRequest]) (*connect.Response[emptypb.Empty], error) {
+	cinfo := tornjakTypes.ClusterInfo{Name: req.Msg.GetName()}
+	if err := c.s.deleteCluster(ctx, DeleteClusterRequest{ClusterInstance: cinfo}); err != nil {
+		return nil, connectTornjakError(err)
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+// selectorService implements tornjakv1connect.SelectorServiceHandler.
+type selectorService struct {
+	s *Server
+}
+
+func (c *selectorService) ListSelectors(ctx context.Context, req *connect.Request[tornjakv1.ListSelectorsRequest]) (*connect.Response[tornjakv1.ListSelectorsResponse], error) {
+	ret, err := c.s.ListSelectors(ListSelectorsRequest{})
+	if err != nil {
+		return nil, connectTornjakError(err)
+	}
+	return connect.NewResponse(&tornjakv1.ListSelectorsResponse{Agents: agentInfoListToProto(ret.Agents)}), nil
+}
+
+func (c *selectorService) DefineSelector(ctx context.Context, req *connect.Request[tornjakv1.DefineSelectorRequest]) (*connect.Response[emptypb.Empty], error) {
+	sinfo := RegisterSelectorRequest{
+		Spiffeid: req.Msg.GetSpiffeId(),
+		Plugin:   req.Msg.GetPlugin(),
+	}
+	if err := c.s.DefineSelectors(sinfo); err != nil {
+		return nil, connectTornjakError(err)
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (c *selectorService) ListAgentMetadata(ctx context.Context, req *connect.Request[tornjakv1.ListAgentMetadataRequest]) (*connect.Response[tornjakv1.ListAgentMetadataResponse], error) {
+	ret, err := c.s.ListAgentMetadata(ListAgentMetadataRequest{Agents: req.Msg.GetSpiffeIds()})
+	if err != nil {
+		return nil, connectTornjakError(err)
+	}
+	return connect.NewResponse(&tornjakv1.ListAgentMetadataResponse{Agents: agentInfoListToProto(ret.Agents)}), nil
+}
+
+// spireService implements tornjakv1connect.SPIREServiceHandler by proxying to the SPIRE server.
+type spireService struct {
+	s *Server
+}
+
+func (c *spireService) GetInfo(ctx context.Context, req *connect.Request[debugServer.GetInfoRequest]) (*connect.Response[debugServer.GetInfoResponse], error) {
+	ret, err := c.s.DebugServer((*DebugServerRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*debugServer.GetInfoResponse)(ret)), nil
+}
+
+func (c *spireService) ListAgents(ctx context.Context, req *connect.Request[agent.ListAgentsRequest]) (*connect.Response[agent.ListAgentsResponse], error) {
+	ret, err := c.s.ListAgents((*ListAgentsRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*agent.ListAgentsResponse)(ret)), nil
+}
+
+func (c *spireService) GetAgent(ctx context.Context, req *connect.Request[agent.GetAgentRequest]) (*connect.Response[types.Agent], error) {
+	ret, err := c.s.GetAgent((*GetAgentRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*types.Agent)(ret)), nil
+}
+
+func (c *spireService) DeleteAgent(ctx context.Context, req *connect.Request[agent.DeleteAgentRequest]) (*connect.Response[emptypb.Empty], error) {
+	if err := c.s.deleteAgent(ctx, (*DeleteAgentRequest)(req.Msg)); err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (c *spireService) BanAgent(ctx context.Context, req *connect.Request[agent.BanAgentRequest]) (*connect.Response[emptypb.Empty], error) {
+	if err := c.s.banAgent(ctx, (*BanAgentRequest)(req.Msg)); err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse(&emptypb.Empty{}), nil
+}
+
+func (c *spireService) CreateJoinToken(ctx context.Context, req *connect.Request[agent.CreateJoinTokenRequest]) (*connect.Response[types.JoinToken], error) {
+	ret, err := c.s.CreateJoinToken((*CreateJoinTokenRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*types.JoinToken)(ret)), nil
+}
+
+func (c *spireService) ListEntries(ctx context.Context, req *connect.Request[entry.ListEntriesRequest]) (*connect.Response[entry.ListEntriesResponse], error) {
+	ret, err := c.s.ListEntries((*ListEntriesRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*entry.ListEntriesResponse)(ret)), nil
+}
+
+func (c *spireService) GetEntry(ctx context.Context, req *connect.Request[entry.GetEntryRequest]) (*connect.Response[types.Entry], error) {
+	ret, err := c.s.GetEntry((*GetEntryRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*types.Entry)(ret)), nil
+}
+
+func (c *spireService) BatchCreateEntry(ctx context.Context, req *connect.Request[entry.BatchCreateEntryRequest]) (*connect.Response[entry.BatchCreateEntryResponse], error) {
+	ret, err := c.s.createEntries(ctx, (*BatchCreateEntryRequest)(req.Msg), nil)
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*entry.BatchCreateEntryResponse)(ret)), nil
+}
+
+func (c *spireService) BatchDeleteEntry(ctx context.Context, req *connect.Request[entry.BatchDeleteEntryRequest]) (*connect.Response[entry.BatchDeleteEntryResponse], error) {
+	ret, err := c.s.deleteEntries(ctx, (*BatchDeleteEntryRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*entry.BatchDeleteEntryResponse)(ret)), nil
+}
+
+func (c *spireService) GetBundle(ctx context.Context, req *connect.Request[bundle.GetBundleRequest]) (*connect.Response[types.Bundle], error) {
+	ret, err := c.s.GetBundle((*GetBundleRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*types.Bundle)(ret)), nil
+}
+
+func (c *spireService) ListFederatedBundles(ctx context.Context, req *connect.Request[bundle.ListFederatedBundlesRequest]) (*connect.Response[bundle.ListFederatedBundlesResponse], error) {
+	ret, err := c.s.ListFederatedBundles((*ListFederatedBundlesRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*bundle.ListFederatedBundlesResponse)(ret)), nil
+}
+
+func (c *spireService) BatchCreateFederatedBundle(ctx context.Context, req *connect.Request[bundle.BatchCreateFederatedBundleRequest]) (*connect.Response[bundle.BatchCreateFederatedBundleResponse], error) {
+	ret, err := c.s.CreateFederatedBundle((*CreateFederatedBundleRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*bundle.BatchCreateFederatedBundleResponse)(ret)), nil
+}
+
+func (c *spireService) BatchUpdateFederatedBundle(ctx context.Context, req *connect.Request[bundle.BatchUpdateFederatedBundleRequest]) (*connect.Response[bundle.BatchUpdateFederatedBundleResponse], error) {
+	ret, err := c.s.UpdateFederatedBundle((*UpdateFederatedBundleRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*bundle.BatchUpdateFederatedBundleResponse)(ret)), nil
+}
+
+func (c *spireService) BatchDeleteFederatedBundle(ctx context.Context, req *connect.Request[bundle.BatchDeleteFederatedBundleRequest]) (*connect.Response[bundle.BatchDeleteFederatedBundleResponse], error) {
+	ret, err := c.s.DeleteFederatedBundle((*DeleteFederatedBundleRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*bundle.BatchDeleteFederatedBundleResponse)(ret)), nil
+}
+
+func (c *spireService) ListFederationRelationships(ctx context.Context, req *connect.Request[trustdomain.ListFederationRelationshipsRequest]) (*connect.Response[trustdomain.ListFederationRelationshipsResponse], error) {
+	ret, err := c.s.ListFederationRelationships((*ListFederationRelationshipsRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*trustdomain.ListFederationRelationshipsResponse)(ret)), nil
+}
+
+func (c *spireService) BatchCreateFederationRelationship(ctx context.Context, req *connect.Request[trustdomain.BatchCreateFederationRelationshipRequest]) (*connect.Response[trustdomain.BatchCreateFederationRelationshipResponse], error) {
+	ret, err := c.s.CreateFederationRelationship((*CreateFederationRelationshipRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*trustdomain.BatchCreateFederationRelationshipResponse)(ret)), nil
+}
+
+func (c *spireService) BatchUpdateFederationRelationship(ctx context.Context, req *connect.Request[trustdomain.BatchUpdateFederationRelationshipRequest]) (*connect.Response[trustdomain.BatchUpdateFederationRelationshipResponse], error) {
+	ret, err := c.s.UpdateFederationRelationship((*UpdateFederationRelationshipRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*trustdomain.BatchUpdateFederationRelationshipResponse)(ret)), nil
+}
+
+func (c *spireService) BatchDeleteFederationRelationship(ctx context.Context, req *connect.Request[trustdomain.BatchDeleteFederationRelationshipRequest]) (*connect.Response[trustdomain.BatchDeleteFederationRelationshipResponse], error) {
+	ret, err := c.s.DeleteFederationRelationship((*DeleteFederationRelationshipRequest)(req.Msg))
+	if err != nil {
+		return nil, connectSPIREError(err)
+	}
+	return connect.NewResponse((*trustdomain.BatchDeleteFederationRelationshipResponse)(ret)), nil
+}
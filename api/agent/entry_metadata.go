@@ -0,0 +1,185 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	entry "github.com/spiffe/spire-api-sdk/proto/spire/api/server/entry/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	tornjakTypes "github.com/spiffe/tornjak/pkg/agent/types"
+)
+
+// entryMetadataKey is the member of entry create requests holding the
+// Tornjak metadata of the entries, which is not part of the SPIRE request
+const entryMetadataKey = "metadata"
+
+const (
+	maxEntryMetadataLength = 1024
+	maxEntryTags           = 64
+)
+
+// EntryMetadataInput is the metadata given on entry creation; the creator
+// and creation time are recorded by Tornjak
+type EntryMetadataInput struct {
+	Owner       string   `json:"owner,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Ticket      string   `json:"ticket,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+func (m EntryMetadataInput) validate() error {
+	for name, value := range map[string]string{"owner": m.Owner, "description": m.Description, "ticket": m.Ticket} {
+		if len(value) > maxEntryMetadataLength {
+			return fmt.Errorf("invalid entry metadata: %s exceeds %d characters", name, maxEntryMetadataLength)
+		}
+	}
+	if len(m.Tags) > maxEntryTags {
+		return fmt.Errorf("invalid entry metadata: more than %d tags", maxEntryTags)
+	}
+	for _, tag := range m.Tags {
+		if tag == "" || len(tag) > maxEntryMetadataLength {
+			return fmt.Errorf("invalid entry metadata: invalid tag %q", tag)
+		}
+	}
+	return nil
+}
+
+// ListEntriesWithMetadataResponse is a SPIRE entry list with the Tornjak
+// metadata of the listed entries, by entry ID
+type ListEntriesWithMetadataResponse struct {
+	*ListEntriesResponse
+	Metadata map[string]tornjakTypes.EntryMetadata `json:"metadata,omitempty"`
+}
+
+// readEntryRequestProtoJSON reads a request body as readRequestProtoJSON does,
+// after removing its top-level "metadata" member, which is decoded into
+// metadata if present
+func readEntryRequestProtoJSON(r *http.Request, input proto.Message, metadata interface{}) (int64, error) {
+	data, err := readRequestBody(r)
+	if err != nil {
+		return 0, err
+	}
+
+	n := int64(len(data))
+	if n == 0 {
+		return n, nil
+	}
+
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return n, fmt.Errorf("error unmarshaling JSON: %v", err)
+	}
+	if raw, ok := members[entryMetadataKey]; ok {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(metadata); err != nil {
+			return n, fmt.Errorf("error unmarshaling entry metadata: %v", err)
+		}
+		delete(members, entryMetadataKey)
+		if data, err = json.Marshal(members); err != nil {
+			return n, fmt.Errorf("error unmarshaling JSON: %v", err)
+		}
+	}
+
+	if err := protojson.Unmarshal(data, input); err != nil {
+		return n, fmt.Errorf("error unmarshaling proto JSON: %v", err)
+	}
+	return n, nil
+}
+
+// readEntryCreateRequest reads a v1 entry create request, whose metadata is
+// a list aligned with its entries
+func readEntryCreateRequest(r *http.Request, input *BatchCreateEntryRequest) ([]EntryMetadataInput, error) {
+	var metadata []EntryMetadataInput
+	if _, err := readEntryRequestProtoJSON(r, (*entry.BatchCreateEntryRequest)(input), &metadata); err != nil {
+		return nil, err
+	}
+	if len(metadata) > 0 && len(metadata) != len(input.Entries) {
+		return nil, fmt.Errorf("invalid entry metadata: got %d items for %d entries", len(metadata), len(input.Entries))
+	}
+	for _, m := range metadata {
+		if err := m.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return metadata, nil
+}
+
+// recordEntriesMetadata stores the metadata of the entries created in ret,
+// where metadata[i], if any, is that of the i-th entry requested. Failures
+// are logged: the entries exist in SPIRE whether or not Tornjak keeps their
+// metadata.
+func (s *Server) recordEntriesMetadata(ctx context.Context, ret *BatchCreateEntryResponse, metadata []EntryMetadataInput) {
+	if s.Db == nil {
+		return
+	}
+	createdBy := actorFromContext(ctx).User
+	createdAt := time.Now().UTC()
+	for i, result := range ret.Results {
+		if codes.Code(result.GetStatus().GetCode()) != codes.OK || result.Entry == nil {
+			continue
+		}
+		meta := tornjakTypes.EntryMetadata{EntryID: result.Entry.Id, CreatedBy: createdBy, CreatedAt: createdAt}
+		if i < len(metadata) {
+			meta.Owner, meta.Description = metadata[i].Owner, metadata[i].Description
+			meta.Ticket, meta.Tags = metadata[i].Ticket, metadata[i].Tags
+		}
+		if err := s.Db.CreateEntryMetadata(meta); err != nil {
+			log.Printf("Could not record metadata of entry %s: %v", meta.EntryID, err)
+		}
+	}
+}
+
+// removeEntriesMetadata removes the metadata of the entries deleted in ret,
+// or no longer in SPIRE
+func (s *Server) removeEntriesMetadata(ret *BatchDeleteEntryResponse) {
+	if s.Db == nil {
+		return
+	}
+	ids := []string{}
+	for _, result := range ret.Results {
+		switch codes.Code(result.GetStatus().GetCode()) {
+		case codes.OK, codes.NotFound:
+			ids = append(ids, result.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	if err := s.Db.DeleteEntriesMetadata(ids); err != nil {
+		log.Printf("Could not remove metadata of deleted entries: %v", err)
+	}
+}
+
+// withEntriesMetadata joins the Tornjak metadata of the listed entries to ret.
+// Entries are listed without metadata if it cannot be read.
+func (s *Server) withEntriesMetadata(ret *ListEntriesResponse) *ListEntriesWithMetadataResponse {
+	resp := &ListEntriesWithMetadataResponse{ListEntriesResponse: ret}
+	if s.Db == nil || len(ret.Entries) == 0 {
+		return resp
+	}
+	ids := make([]string, 0, len(ret.Entries))
+	for _, e := range ret.Entries {
+		ids = append(ids, e.GetId())
+	}
+	metadata, err := s.Db.GetEntriesMetadata(ids)
+	if err != nil {
+		log.Printf("Could not read metadata of entries: %v", err)
+		return resp
+	}
+	if len(metadata.Metadata) > 0 {
+		resp.Metadata = make(map[string]tornjakTypes.EntryMetadata, len(metadata.Metadata))
+		for _, meta := range metadata.Metadata {
+			resp.Metadata[meta.EntryID] = meta
+		}
+	}
+	return resp
+}
@@ -0,0 +1,480 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	types "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"gopkg.in/yaml.v3"
+)
+
+/*
+
+Entry import and export
+
+Entry files use the shape of docs/newEntry-json-format.md, as JSON or YAML:
+
+{"entries": [{"spiffe_id": {...}, "parent_id": {...}, "selectors": [...], ...}]}
+
+Server-assigned fields (id, revision_number, created_at) are left out on
+export and ignored on import. An imported entry duplicates an existing one
+when they have the same SPIFFE ID, parent ID and selectors.
+
+*/
+
+const (
+	entryFormatJSON = "json"
+	entryFormatYAML = "yaml"
+
+	entryDuplicateSkip   = "skip"
+	entryDuplicateUpdate = "update"
+
+	entryImportCreate    = "create"
+	entryImportUpdate    = "update"
+	entryImportSkip      = "skip"
+	entryImportUnchanged = "unchanged"
+
+	// entryExportPageSize is the number of entries fetched from SPIRE per export page
+	entryExportPageSize int32 = 500
+	// entryImportBatchSize is the number of entries sent to SPIRE per batch call
+	entryImportBatchSize = 50
+)
+
+var entryFileMarshaler = protojson.MarshalOptions{UseProtoNames: true}
+
+type ImportEntriesRequest struct {
+	Entries     []*types.Entry
+	OnDuplicate string
+	DryRun      bool
+}
+
+// EntryImportResult reports what import did, or would do on a dry run, with one entry of the file
+type EntryImportResult struct {
+	Index    int    `json:"index"`
+	SpiffeID string `json:"spiffe_id"`
+	ParentID string `json:"parent_id"`
+	Action   string `json:"action"`
+	EntryID  string `json:"entry_id,omitempty"`
+	Message  string `json:"message,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// EntryImportSummary counts import results by action; failed results are only counted as Failed
+type EntryImportSummary struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Skipped   int `json:"skipped"`
+	Unchanged int `json:"unchanged"`
+	Failed    int `json:"failed"`
+}
+
+type ImportEntriesResponse struct {
+	DryRun  bool                `json:"dry_run"`
+	Summary EntryImportSummary  `json:"summary"`
+	Results []EntryImportResult `json:"results"`
+}
+
+// ImportEntries creates the given entries in SPIRE, in batches. Entries that
+// duplicate an existing entry are skipped or, with OnDuplicate "update",
+// updated in place. Entries are not modified on a dry run.
+func (s *Server) ImportEntries(ctx context.Context, inp *ImportEntriesRequest) (*ImportEntriesResponse, error) {
+	onDuplicate := inp.OnDuplicate
+	if onDuplicate == "" {
+		onDuplicate = entryDuplicateSkip
+	} else if onDuplicate != entryDuplicateSkip && onDuplicate != entryDuplicateUpdate {
+		return nil, fmt.Errorf("invalid on_duplicate %q: expected %s or %s", onDuplicate, entryDuplicateSkip, entryDuplicateUpdate)
+	}
+
+	existing := map[string]*types.Entry{}
+	err := s.forEachEntry(func(e *types.Entry) error {
+		existing[entryKey(e)] = e
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &ImportEntriesResponse{
+		DryRun:  inp.DryRun,
+		Results: make([]EntryImportResult, len(inp.Entries)),
+	}
+	var toCreate, toUpdate []int
+	seen := map[string]int{}
+	for i, e := range inp.Entries {
+		res := &resp.Results[i]
+		res.Index = i
+		res.SpiffeID = spiffeIDToString(e.GetSpiffeId())
+		res.ParentID = spiffeIDToString(e.GetParentId())
+
+		key := entryKey(e)
+		if j, ok := seen[key]; ok {
+			res.Action = entryImportSkip
+			res.Message = fmt.Sprintf("duplicate of entry %d in the import", j)
+			continue
+		}
+		seen[key] = i
+
+		cur, ok := existing[key]
+		switch {
+		case !ok:
+			res.Action = entryImportCreate
+			toCreate = append(toCreate, i)
+		case entriesEqual(cur, e):
+			res.Action = entryImportUnchanged
+			res.EntryID = cur.Id
+		case onDuplicate == entryDuplicateUpdate:
+			res.Action = entryImportUpdate
+			res.EntryID = cur.Id
+			toUpdate = append(toUpdate, i)
+		default:
+			res.Action = entryImportSkip
+			res.EntryID = cur.Id
+			res.Message = "entry already exists with different fields"
+		}
+	}
+
+	if !inp.DryRun {
+		s.importCreateEntries(ctx, inp.Entries, toCreate, resp.Results)
+		s.importUpdateEntries(ctx, inp.Entries, toUpdate, resp.Results)
+	}
+
+	for _, res := range resp.Results {
+		if res.Error != "" {
+			resp.Summary.Failed++
+			continue
+		}
+		switch res.Action {
+		case entryImportCreate:
+			resp.Summary.Created++
+		case entryImportUpdate:
+			resp.Summary.Updated++
+		case entryImportSkip:
+			resp.Summary.Skipped++
+		case entryImportUnchanged:
+			resp.Summary.Unchanged++
+		}
+	}
+	return resp, nil
+}
+
+// importCreateEntries creates entries[i] for each i in indices and records the outcome in results[i]
+func (s *Server) importCreateEntries(ctx context.Context, entries []*types.Entry, indices []int, results []EntryImportResult) {
+	for start := 0; start < len(indices); start += entryImportBatchSize {
+		chunk := indices[start:min(start+entryImportBatchSize, len(indices))]
+		batch := make([]*types.Entry, 0, len(chunk))
+		for _, i := range chunk {
+			batch = append(batch, entries[i])
+		}
+		ret, err := s.createEntries(ctx, &BatchCreateEntryRequest{Entries: batch}, nil)
+		for k, i := range chunk {
+			switch {
+			case err != nil:
+				results[i].Error = err.Error()
+			case k >= len(ret.Results):
+				results[i].Error = "no result returned by SPIRE"
+			case ret.Results[k].GetStatus().GetCode() != 0:
+				results[i].Error = ret.Results[k].GetStatus().GetMessage()
+			default:
+				results[i].EntryID = ret.Results[k].GetEntry().GetId()
+			}
+		}
+	}
+}
+
+// importUpdateEntries updates the existing entry results[i].EntryID with entries[i]
+// for each i in indices and records the outcome in results[i]
+func (s *Server) importUpdateEntries(ctx context.Context, entries []*types.Entry, indices []int, results []EntryImportResult) {
+	for start := 0; start < len(indices); start += entryImportBatchSize {
+		chunk := indices[start:min(start+entryImportBatchSize, len(indices))]
+		batch := make([]*types.Entry, 0, len(chunk))
+		for _, i := range chunk {
+			e := proto.Clone(entries[i]).(*types.Entry)
+			e.Id = results[i].EntryID
+			batch = append(batch, e)
+		}
+		ret, err := s.updateEntries(ctx, &BatchUpdateEntryRequest{Entries: batch})
+		for k, i := range chunk {
+			switch {
+			case err != nil:
+				results[i].Error = err.Error()
+			case k >= len(ret.Results):
+				results[i].Error = "no result returned by SPIRE"
+			case ret.Results[k].GetStatus().GetCode() != 0:
+				results[i].Error = ret.Results[k].GetStatus().GetMessage()
+			}
+		}
+	}
+}
+
+// forEachEntry calls fn with every entry in SPIRE, one page at a time
+func (s *Server) forEachEntry(fn func(*types.Entry) error) error {
+	pageToken := ""
+	for {
+		ret, err := s.ListEntries(&ListEntriesRequest{PageSize: entryExportPageSize, PageToken: pageToken})
+		if err != nil {
+			return err
+		}
+		for _, e := range ret.Entries {
+			if err := fn(e); err != nil {
+				return err
+			}
+		}
+		if ret.NextPageToken == "" {
+			return nil
+		}
+		pageToken = ret.NextPageToken
+	}
+}
+
+// entryKey identifies an entry by SPIFFE ID, parent ID and selectors
+func entryKey(e *types.Entry) string {
+	selectors := make([]string, 0, len(e.GetSelectors()))
+	for _, sel := range e.GetSelectors() {
+		selectors = append(selectors, sel.GetType()+":"+sel.GetValue())
+	}
+	sort.Strings(selectors)
+	return spiffeIDToString(e.GetSpiffeId()) + "|" + spiffeIDToString(e.GetParentId()) + "|" + strings.Join(selectors, "|")
+}
+
+// entriesEqual reports whether two entries are equal, ignoring server-assigned fields and selector order
+func entriesEqual(a, b *types.Entry) bool {
+	return proto.Equal(normalizedEntry(a), normalizedEntry(b))
+}
+
+func normalizedEntry(e *types.Entry) *types.Entry {
+	n := exportableEntry(e)
+	sort.Slice(n.Selectors, func(i, j int) bool {
+		if n.Selectors[i].Type != n.Selectors[j].Type {
+			return n.Selectors[i].Type < n.Selectors[j].Type
+		}
+		return n.Selectors[i].Value < n.Selectors[j].Value
+	})
+	return n
+}
+
+// exportableEntry returns a copy of the entry without server-assigned fields
+func exportableEntry(e *types.Entry) *types.Entry {
+	n := proto.Clone(e).(*types.Entry)
+	n.Id = ""
+	n.RevisionNumber = 0
+	n.CreatedAt = 0
+	return n
+}
+
+func spiffeIDToString(id *types.SPIFFEID) string {
+	if id == nil {
+		return ""
+	}
+	return "spiffe://" + id.TrustDomain + id.Path
+}
+
+// entryFileFormat returns the entry file format from the format query
+// parameter, or else from the content type. Defaults to JSON.
+func entryFileFormat(query url.Values, contentType string) (string, error) {
+	switch format := query.Get("format"); format {
+	case entryFormatJSON, entryFormatYAML:
+		return format, nil
+	case "":
+		if strings.Contains(contentType, "yaml") {
+			return entryFormatYAML, nil
+		}
+		return entryFormatJSON, nil
+	default:
+		return "", fmt.Errorf("invalid format %q: expected %s or %s", format, entryFormatJSON, entryFormatYAML)
+	}
+}
+
+// parseEntryFile parses an entry file in the given format
+func parseEntryFile(data []byte, format string) ([]*types.Entry, error) {
+	if format == entryFormatYAML {
+		var doc interface{}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("error parsing YAML: %v", err)
+		}
+		var err error
+		if data, err = json.Marshal(doc); err != nil {
+			return nil, fmt.Errorf("error parsing YAML: %v", err)
+		}
+	}
+
+	var file struct {
+		Entries []json.RawMessage `json:"entries"`
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&file); err != nil {
+		return nil, fmt.Errorf("error parsing entry file: %v", err)
+	}
+
+	entries := make([]*types.Entry, 0, len(file.Entries))
+	for i, raw := range file.Entries {
+		e := &types.Entry{}
+		if err := protojson.Unmarshal(raw, e); err != nil {
+			return nil, fmt.Errorf("error parsing entry %d: %v", i, err)
+		}
+		entries = append(entries, exportableEntry(e))
+	}
+	return entries, nil
+}
+
+// entryFileWriter writes entries to an entry file one at a time
+type entryFileWriter struct {
+	w      http.ResponseWriter
+	format string
+	count  int
+}
+
+func (f *entryFileWriter) write(e *types.Entry) error {
+	data, err := entryFileMarshaler.Marshal(exportableEntry(e))
+	if err != nil {
+		return err
+	}
+	switch f.format {
+	case entryFormatYAML:
+		var doc interface{}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return err
+		}
+		if data, err = yaml.Marshal([]interface{}{doc}); err != nil {
+			return err
+		}
+		if f.count == 0 {
+			data = append([]byte("entries:\n"), data...)
+		}
+	default:
+		// protojson output is deliberately unstable in whitespace
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, data); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+		if f.count == 0 {
+			data = append([]byte("{\"entries\":[\n"), data...)
+		} else {
+			data = append([]byte(",\n"), data...)
+		}
+	}
+	f.count++
+	_, err = f.w.Write(data)
+	return err
+}
+
+func (f *entryFileWriter) close() error {
+	var data string
+	switch {
+	case f.format == entryFormatYAML && f.count == 0:
+		data = "entries: []\n"
+	case f.format == entryFormatYAML:
+		return nil
+	case f.count == 0:
+		data = "{\"entries\":[]}\n"
+	default:
+		data = "\n]}\n"
+	}
+	_, err := f.w.Write([]byte(data))
+	return err
+}
+
+// entryExport streams all entries as an entry file
+func (s *Server) entryExport(w http.ResponseWriter, r *http.Request) {
+	format, err := entryFileFormat(r.URL.Query(), "")
+	if err != nil {
+		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	// fetch the first page before writing the response so that a SPIRE
+	// failure can still be reported with an error status
+	first, err := s.ListEntries(&ListEntriesRequest{PageSize: entryExportPageSize})
+	if err != nil {
+		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	corsHeaders(w)
+	if format == entryFormatYAML {
+		w.Header().Set("Content-Type", "application/yaml")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"entries.%s\"", format))
+	w.WriteHeader(http.StatusOK)
+
+	writer := &entryFileWriter{w: w, format: format}
+	ret := first
+	for {
+		for _, e := range ret.Entries {
+			if err := writer.write(e); err != nil {
+				log.Printf("Entry export aborted: %v", err)
+				return
+			}
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if ret.NextPageToken == "" {
+			break
+		}
+		ret, err = s.ListEntries(&ListEntriesRequest{PageSize: entryExportPageSize, PageToken: ret.NextPageToken})
+		if err != nil {
+			// the response is already under way; the truncated file will fail to parse
+			log.Printf("Entry export aborted: %v", err)
+			return
+		}
+	}
+	if err := writer.close(); err != nil {
+		log.Printf("Entry export aborted: %v", err)
+	}
+}
+
+// entryImport creates entries from an entry file and reports the result per entry
+func (s *Server) entryImport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	format, err := entryFileFormat(query, r.Header.Get("Content-Type"))
+	if err != nil {
+		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusBadRequest)
+		return
+	}
+	dryRun, err := boolParam(query, "dry_run")
+	if err != nil {
+		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusBadRequest)
+		return
+	}
+	onDuplicate := query.Get("on_duplicate")
+	if onDuplicate != "" && onDuplicate != entryDuplicateSkip && onDuplicate != entryDuplicateUpdate {
+		retError(w, fmt.Sprintf("Error: invalid on_duplicate %q: expected %s or %s", onDuplicate, entryDuplicateSkip, entryDuplicateUpdate), http.StatusBadRequest)
+		return
+	}
+
+	data, err := readRequestBody(r)
+	if err != nil {
+		retRequestError(w, err)
+		return
+	}
+	entries, err := parseEntryFile(data, format)
+	if err != nil {
+		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusBadRequest)
+		return
+	}
+
+	ret, err := s.ImportEntries(r.Context(), &ImportEntriesRequest{
+		Entries:     entries,
+		OnDuplicate: onDuplicate,
+		DryRun:      dryRun.GetValue(),
+	})
+	if err != nil {
+		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
+		return
+	}
+
+	if err := writeResponseJSON(w, r, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,365 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	entry "github.com/spiffe/spire-api-sdk/proto/spire/api/server/entry/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeEntryServer is a SPIRE entry API holding entries in memory. Entries
+// whose SPIFFE ID path is in fail are rejected by BatchCreateEntry.
+type fakeEntryServer struct {
+	entry.UnimplementedEntryServer
+
+	mu      sync.Mutex
+	entries []*types.Entry
+	nextID  int
+	fail    map[string]bool
+	batches []int
+}
+
+// serveFakeSpire serves the SPIRE APIs registered by register and points s at them
+func serveFakeSpire(t *testing.T, s *Server, register func(*grpc.Server)) {
+	// unix socket paths are short, so t.TempDir can be too long
+	dir, err := os.MkdirTemp("", "spire")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "api.sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := grpc.NewServer()
+	register(server)
+	go server.Serve(ln)
+	t.Cleanup(server.Stop)
+
+	s.SpireServerAddr = "unix://" + path
+}
+
+// startFakeEntryServer serves the fake entry API holding entries and points s at it
+func startFakeEntryServer(t *testing.T, s *Server, entries ...*types.Entry) *fakeEntryServer {
+	fake := &fakeEntryServer{fail: map[string]bool{}}
+	for _, e := range entries {
+		fake.add(proto.Clone(e).(*types.Entry))
+	}
+	serveFakeSpire(t, s, func(server *grpc.Server) {
+		entry.RegisterEntryServer(server, fake)
+	})
+	return fake
+}
+
+func (f *fakeEntryServer) add(e *types.Entry) *types.Entry {
+	f.nextID++
+	e.Id = "entry-" + strconv.Itoa(f.nextID)
+	f.entries = append(f.entries, e)
+	return e
+}
+
+// snapshot returns copies of the entries, in creation order
+func (f *fakeEntryServer) snapshot() []*types.Entry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entries := make([]*types.Entry, 0, len(f.entries))
+	for _, e := range f.entries {
+		entries = append(entries, proto.Clone(e).(*types.Entry))
+	}
+	return entries
+}
+
+func (f *fakeEntryServer) ListEntries(ctx context.Context, req *entry.ListEntriesRequest) (*entry.ListEntriesResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	start := 0
+	if req.PageToken != "" {
+		var err error
+		if start, err = strconv.Atoi(req.PageToken); err != nil {
+			return nil, err
+		}
+	}
+	end := len(f.entries)
+	if req.PageSize > 0 {
+		end = min(start+int(req.PageSize), end)
+	}
+	resp := &entry.ListEntriesResponse{}
+	for _, e := range f.entries[start:end] {
+		resp.Entries = append(resp.Entries, proto.Clone(e).(*types.Entry))
+	}
+	if end < len(f.entries) {
+		resp.NextPageToken = strconv.Itoa(end)
+	}
+	return resp, nil
+}
+
+func (f *fakeEntryServer) BatchCreateEntry(ctx context.Context, req *entry.BatchCreateEntryRequest) (*entry.BatchCreateEntryResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, len(req.Entries))
+	resp := &entry.BatchCreateEntryResponse{}
+	for _, e := range req.Entries {
+		if f.fail[e.GetSpiffeId().GetPath()] {
+			resp.Results = append(resp.Results, &entry.BatchCreateEntryResponse_Result{
+				Status: &types.Status{Code: int32(codes.InvalidArgument), Message: "failed to create entry: invalid"},
+			})
+			continue
+		}
+		created := f.add(proto.Clone(e).(*types.Entry))
+		resp.Results = append(resp.Results, &entry.BatchCreateEntryResponse_Result{
+			Status: &types.Status{}, Entry: proto.Clone(created).(*types.Entry),
+		})
+	}
+	return resp, nil
+}
+
+func (f *fakeEntryServer) BatchUpdateEntry(ctx context.Context, req *entry.BatchUpdateEntryRequest) (*entry.BatchUpdateEntryResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.batches = append(f.batches, len(req.Entries))
+	resp := &entry.BatchUpdateEntryResponse{}
+	for _, e := range req.Entries {
+		result := &entry.BatchUpdateEntryResponse_Result{Status: &types.Status{Code: int32(codes.NotFound), Message: "entry not found"}}
+		for i, cur := range f.entries {
+			if cur.Id == e.Id {
+				f.entries[i] = proto.Clone(e).(*types.Entry)
+				result = &entry.BatchUpdateEntryResponse_Result{Status: &types.Status{}, Entry: proto.Clone(e).(*types.Entry)}
+			}
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+func testEntry(path string, ttl int32) *types.Entry {
+	return &types.Entry{
+		SpiffeId:    &types.SPIFFEID{TrustDomain: "example.org", Path: path},
+		ParentId:    &types.SPIFFEID{TrustDomain: "example.org", Path: "/agent"},
+		Selectors:   []*types.Selector{{Type: "k8s", Value: "ns:default"}, {Type: "k8s", Value: "sa:" + strings.TrimPrefix(path, "/")}},
+		X509SvidTtl: ttl,
+	}
+}
+
+func TestEntryFileRoundTrip(t *testing.T) {
+	source := []*types.Entry{
+		testEntry("/web", 3600),
+		{
+			SpiffeId:      &types.SPIFFEID{TrustDomain: "example.org", Path: "/db"},
+			ParentId:      &types.SPIFFEID{TrustDomain: "example.org", Path: "/agent"},
+			Selectors:     []*types.Selector{{Type: "unix", Value: "uid:1000"}},
+			FederatesWith: []string{"spiffe://other.org"},
+			DnsNames:      []string{"db.example.org"},
+			Admin:         true,
+			Hint:          "db",
+		},
+	}
+	for _, format := range []string{entryFormatJSON, entryFormatYAML} {
+		t.Run(format, func(t *testing.T) {
+			exporter := newTestServer(t)
+			startFakeEntryServer(t, exporter, source...)
+			w := httptest.NewRecorder()
+			exporter.entryExport(w, httptest.NewRequest(http.MethodGet, "/api/v1/spire/entries/export?format="+format, nil))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected export %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			if wantType := "application/yaml"; format == entryFormatYAML && w.Header().Get("Content-Type") != wantType {
+				t.Fatalf("Expected content type %s, got %s", wantType, w.Header().Get("Content-Type"))
+			}
+			file := w.Body.String()
+			if strings.Contains(file, "entry-1") || strings.Contains(file, "revision_number") {
+				t.Fatalf("Expected server-assigned fields left out, got %s", file)
+			}
+
+			importer := newTestServer(t)
+			fake := startFakeEntryServer(t, importer)
+			w = httptest.NewRecorder()
+			importer.entryImport(w, httptest.NewRequest(http.MethodPost, "/api/v1/spire/entries/import?format="+format, strings.NewReader(file)))
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected import %d, got %d: %s", http.StatusOK, w.Code, w.Body.String())
+			}
+			var resp ImportEntriesResponse
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Summary != (EntryImportSummary{Created: len(source)}) {
+				t.Fatalf("Expected %d entries created, got %+v", len(source), resp.Summary)
+			}
+			imported := fake.snapshot()
+			if len(imported) != len(source) {
+				t.Fatalf("Expected %d entries imported, got %d", len(source), len(imported))
+			}
+			for i := range source {
+				if !entriesEqual(imported[i], source[i]) {
+					t.Fatalf("Expected entry %d to be %v, got %v", i, source[i], imported[i])
+				}
+			}
+		})
+	}
+}
+
+func TestImportEntries(t *testing.T) {
+	unchanged := testEntry("/unchanged", 3600)
+	changed := testEntry("/changed", 3600)
+	changedImport := testEntry("/changed", 600)
+	created := testEntry("/new", 3600)
+	imports := []*types.Entry{unchanged, changedImport, created, testEntry("/new", 60)}
+
+	tests := []struct {
+		name        string
+		onDuplicate string
+		dryRun      bool
+		wantActions []string
+		wantSummary EntryImportSummary
+		wantEntries []*types.Entry
+	}{
+		{
+			name:        "skip",
+			wantActions: []string{entryImportUnchanged, entryImportSkip, entryImportCreate, entryImportSkip},
+			wantSummary: EntryImportSummary{Created: 1, Skipped: 2, Unchanged: 1},
+			wantEntries: []*types.Entry{unchanged, changed, created},
+		},
+		{
+			name:        "update",
+			onDuplicate: entryDuplicateUpdate,
+			wantActions: []string{entryImportUnchanged, entryImportUpdate, entryImportCreate, entryImportSkip},
+			wantSummary: EntryImportSummary{Created: 1, Updated: 1, Skipped: 1, Unchanged: 1},
+			wantEntries: []*types.Entry{unchanged, changedImport, created},
+		},
+		{
+			name:        "dry run",
+			onDuplicate: entryDuplicateUpdate,
+			dryRun:      true,
+			wantActions: []string{entryImportUnchanged, entryImportUpdate, entryImportCreate, entryImportSkip},
+			wantSummary: EntryImportSummary{Created: 1, Updated: 1, Skipped: 1, Unchanged: 1},
+			wantEntries: []*types.Entry{unchanged, changed},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			fake := startFakeEntryServer(t, s, unchanged, changed)
+
+			resp, err := s.ImportEntries(context.Background(), &ImportEntriesRequest{Entries: imports, OnDuplicate: tt.onDuplicate, DryRun: tt.dryRun})
+			if err != nil {
+				t.Fatal(err)
+			}
+			actions := []string{}
+			for _, res := range resp.Results {
+				actions = append(actions, res.Action)
+			}
+			if !reflect.DeepEqual(actions, tt.wantActions) || resp.Summary != tt.wantSummary || resp.DryRun != tt.dryRun {
+				t.Fatalf("Expected actions %v with %+v, got %v with %+v", tt.wantActions, tt.wantSummary, actions, resp.Summary)
+			}
+			if msg := resp.Results[3].Message; msg != "duplicate of entry 2 in the import" {
+				t.Fatalf("Expected the repeated entry reported as a duplicate, got %q", msg)
+			}
+
+			entries := fake.snapshot()
+			if len(entries) != len(tt.wantEntries) {
+				t.Fatalf("Expected %d entries, got %d", len(tt.wantEntries), len(entries))
+			}
+			for i := range entries {
+				if !entriesEqual(entries[i], tt.wantEntries[i]) {
+					t.Fatalf("Expected entry %d to be %v, got %v", i, tt.wantEntries[i], entries[i])
+				}
+			}
+			// updates keep the ID of the existing entry
+			if entries[1].Id != resp.Results[1].EntryID {
+				t.Fatalf("Expected entry ID %s, got %s", entries[1].Id, resp.Results[1].EntryID)
+			}
+			if tt.dryRun && len(fake.batches) != 0 {
+				t.Fatalf("Expected no batch calls on a dry run, got %v", fake.batches)
+			}
+		})
+	}
+}
+
+func TestImportEntriesPartialFailure(t *testing.T) {
+	s := newTestServer(t)
+	fake := startFakeEntryServer(t, s)
+	imports := []*types.Entry{}
+	for i := 0; i < 120; i++ {
+		path := fmt.Sprintf("/workload%d", i)
+		imports = append(imports, testEntry(path, 3600))
+		// fail some entries of the first and last batch
+		if i == 3 || i == 49 || i == 110 {
+			fake.fail[path] = true
+		}
+	}
+
+	resp, err := s.ImportEntries(context.Background(), &ImportEntriesRequest{Entries: imports})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (EntryImportSummary{Created: 117, Failed: 3}); resp.Summary != want {
+		t.Fatalf("Expected %+v, got %+v", want, resp.Summary)
+	}
+	if want := []int{50, 50, 20}; !reflect.DeepEqual(fake.batches, want) {
+		t.Fatalf("Expected batches of %v, got %v", want, fake.batches)
+	}
+
+	ids := map[string]bool{}
+	for i, res := range resp.Results {
+		if res.Index != i || res.SpiffeID != fmt.Sprintf("spiffe://example.org/workload%d", i) || res.Action != entryImportCreate {
+			t.Fatalf("Expected result %d to create workload%d, got %+v", i, i, res)
+		}
+		if fake.fail[fmt.Sprintf("/workload%d", i)] {
+			if res.Error != "failed to create entry: invalid" || res.EntryID != "" {
+				t.Fatalf("Expected result %d to fail, got %+v", i, res)
+			}
+			continue
+		}
+		if res.Error != "" || res.EntryID == "" || ids[res.EntryID] {
+			t.Fatalf("Expected result %d created with its own ID, got %+v", i, res)
+		}
+		ids[res.EntryID] = true
+	}
+
+	created := []string{}
+	for _, e := range fake.snapshot() {
+		created = append(created, e.Id)
+	}
+	got := make([]string, 0, len(ids))
+	for id := range ids {
+		got = append(got, id)
+	}
+	sort.Strings(created)
+	sort.Strings(got)
+	if !reflect.DeepEqual(got, created) {
+		t.Fatalf("Expected reported IDs %v, got %v", created, got)
+	}
+}
+
+func TestEntryImportSizeLimit(t *testing.T) {
+	s := newTestServer(t)
+	s.TornjakConfig = &TornjakConfig{Server: &serverConfig{MaxRequestBytes: 256}}
+	fake := startFakeEntryServer(t, s)
+	file := `{"entries":[` + strings.Repeat(`{"spiffe_id":{"trust_domain":"example.org","path":"/web"}},`, 10) + `{}]}`
+
+	w := httptest.NewRecorder()
+	s.requestSizeMiddleware(http.HandlerFunc(s.entryImport)).ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/spire/entries/import", strings.NewReader(file)))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected %d, got %d: %s", http.StatusRequestEntityTooLarge, w.Code, w.Body.String())
+	}
+	if len(fake.snapshot()) != 0 || len(fake.batches) != 0 {
+		t.Fatalf("Expected nothing imported, got batches %v", fake.batches)
+	}
+}
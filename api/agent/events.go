@@ -0,0 +1,429 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/spiffe/tornjak/pkg/agent/authentication/user"
+	"github.com/spiffe/tornjak/pkg/agent/notifier"
+	tornjakTypes "github.com/spiffe/tornjak/pkg/agent/types"
+)
+
+/*
+
+Events
+
+Mutations made through Tornjak, whichever API they come from, go through the
+methods below, which emit an event to the configured notifiers once the
+mutation has succeeded. The actor of an event is taken from the request
+context, where verificationMiddleware and the Connect interceptor put it.
+
+*/
+
+// Event actions
+const (
+	eventEntryCreate   = "entry.create"
+	eventEntryUpdate   = "entry.update"
+	eventEntryDelete   = "entry.delete"
+	eventAgentBan      = "agent.ban"
+	eventAgentDelete   = "agent.delete"
+	eventAgentLabels   = "agent.labels"
+	eventClusterCreate = "cluster.create"
+	eventClusterEdit   = "cluster.edit"
+	eventClusterDelete = "cluster.delete"
+)
+
+type actorKey struct{}
+
+func withActor(ctx context.Context, actor notifier.Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func actorFromContext(ctx context.Context) notifier.Actor {
+	actor, _ := ctx.Value(actorKey{}).(notifier.Actor)
+	return actor
+}
+
+// newActor describes the authenticated caller of r
+func newActor(r *http.Request, userInfo *user.UserInfo) notifier.Actor {
+	actor := notifier.Actor{RemoteAddr: r.RemoteAddr}
+	if userInfo != nil {
+		actor.User = userInfo.Name
+		actor.Roles = userInfo.Roles
+	}
+	if creds := user.PeerCredentialsFromContext(r.Context()); creds != nil {
+		actor.UID, actor.GID = &creds.UID, &creds.GID
+		actor.RemoteAddr = "unix:pid=" + strconv.Itoa(int(creds.PID))
+	}
+	return actor
+}
+
+// eventData encodes the before or after state of a resource, using the
+// protobuf JSON mapping for SPIRE types
+func eventData(v interface{}) json.RawMessage {
+	var (
+		data []byte
+		err  error
+	)
+	switch v := v.(type) {
+	case nil:
+		return nil
+	case proto.Message:
+		data, err = protojson.Marshal(v)
+	default:
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		log.Printf("Could not encode event data: %v", err)
+		return nil
+	}
+	return data
+}
+
+// notify sends an event to all notifiers, and adds the resource to the audit
+// record of the request
+func (s *Server) notify(ctx context.Context, action string, resource notifier.Resource, before interface{}, after interface{}) {
+	addAuditResource(ctx, resource.ID)
+	if len(s.Notifiers) == 0 {
+		return
+	}
+	id, err := newRandomID()
+	if err != nil {
+		log.Printf("Could not create event ID: %v", err)
+		return
+	}
+	event := notifier.Event{
+		ID:       id,
+		Time:     time.Now().UTC(),
+		Actor:    actorFromContext(ctx),
+		Action:   action,
+		Resource: resource,
+		Before:   eventData(before),
+		After:    eventData(after),
+	}
+	for _, n := range s.Notifiers {
+		n.Notify(event)
+	}
+}
+
+// entriesBefore fetches the current state of entries about to be changed,
+// if anyone is notified of the change
+func (s *Server) entriesBefore(ids []string) map[string]*types.Entry {
+	before := map[string]*types.Entry{}
+	if len(s.Notifiers) == 0 {
+		return before
+	}
+	for _, id := range ids {
+		if e, err := s.GetEntry(&GetEntryRequest{Id: id}); err == nil {
+			before[id] = (*types.Entry)(e)
+		}
+	}
+	return before
+}
+
+// createEntries creates entries, records their metadata, given by index of
+// entry if any, and notifies of each entry created
+func (s *Server) createEntries(ctx context.Context, inp *BatchCreateEntryRequest, metadata []EntryMetadataInput) (*BatchCreateEntryResponse, error) {
+	ret, err := s.BatchCreateEntry(inp)
+	if err != nil {
+		return nil, err
+	}
+	s.recordEntriesMetadata(ctx, ret, metadata)
+	for _, result := range ret.Results {
+		if codes.Code(result.GetStatus().GetCode()) == codes.OK && result.Entry != nil {
+			s.notify(ctx, eventEntryCreate, notifier.Resource{Type: "entry", ID: result.Entry.Id}, nil, result.Entry)
+		}
+	}
+	return ret, nil
+}
+
+// updateEntries updates entries and notifies of each entry updated
+func (s *Server) updateEntries(ctx context.Context, inp *BatchUpdateEntryRequest) (*BatchUpdateEntryResponse, error) {
+	ids := make([]string, 0, len(inp.Entries))
+	for _, e := range inp.Entries {
+		ids = append(ids, e.GetId())
+	}
+	before := s.entriesBefore(ids)
+	ret, err := s.BatchUpdateEntry(inp)
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range ret.Results {
+		if codes.Code(result.GetStatus().GetCode()) == codes.OK && result.Entry != nil {
+			s.notify(ctx, eventEntryUpdate, notifier.Resource{Type: "entry", ID: result.Entry.Id}, before[result.Entry.Id], result.Entry)
+		}
+	}
+	return ret, nil
+}
+
+// deleteEntries deletes entries, removes their metadata and notifies of
+// each entry deleted
+func (s *Server) deleteEntries(ctx context.Context, inp *BatchDeleteEntryRequest) (*BatchDeleteEntryResponse, error) {
+	before := s.entriesBefore(inp.Ids)
+	ret, err := s.BatchDeleteEntry(inp)
+	if err != nil {
+		return nil, err
+	}
+	s.removeEntriesMetadata(ret)
+	for _, result := range ret.Results {
+		if codes.Code(result.GetStatus().GetCode()) == codes.OK {
+			var b interface{}
+			if e, ok := before[result.Id]; ok {
+				b = e
+			}
+			s.notify(ctx, eventEntryDelete, notifier.Resource{Type: "entry", ID: result.Id}, b, nil)
+		}
+	}
+	return ret, nil
+}
+
+// agentBefore fetches the current state of an agent about to be changed,
+// if anyone is notified of the change
+func (s *Server) agentBefore(id *types.SPIFFEID) interface{} {
+	if len(s.Notifiers) == 0 {
+		return nil
+	}
+	a, err := s.GetAgent(&GetAgentRequest{Id: id})
+	if err != nil {
+		return nil
+	}
+	return (*types.Agent)(a)
+}
+
+// Policies of 'config > server > agent_cleanup', applied to the Tornjak
+// metadata of agents deleted or banned in SPIRE
+const (
+	agentCleanupDelete    = "delete"
+	agentCleanupTombstone = "tombstone"
+)
+
+// agentCleanup returns the configured agent cleanup policy, or delete if unset
+func (s *Server) agentCleanup() string {
+	if s.TornjakConfig != nil && s.TornjakConfig.Server != nil && s.TornjakConfig.Server.AgentCleanup != "" {
+		return s.TornjakConfig.Server.AgentCleanup
+	}
+	return agentCleanupDelete
+}
+
+// cleanupAgent deletes the Tornjak metadata of an agent gone from SPIRE, or
+// keeps it as a tombstone, as configured
+func (s *Server) cleanupAgent(spiffeid string, reason string) error {
+	if s.Db == nil {
+		return nil
+	}
+	var err error
+	if s.agentCleanup() == agentCleanupTombstone {
+		err = s.Db.TombstoneAgentEntry(spiffeid, reason)
+	} else {
+		err = s.Db.DeleteAgentEntry(spiffeid)
+	}
+	if err != nil {
+		return fmt.Errorf("agent %s removed from SPIRE, but not from the Tornjak datastore: %w", spiffeid, err)
+	}
+	return nil
+}
+
+// banAgent bans an agent, cleans up its metadata and notifies of the ban
+func (s *Server) banAgent(ctx context.Context, inp *BanAgentRequest) error {
+	before := s.agentBefore(inp.Id)
+	if err := s.BanAgent(inp); err != nil {
+		return err
+	}
+	id := spiffeIDToString(inp.Id)
+	s.notify(ctx, eventAgentBan, notifier.Resource{Type: "agent", ID: id}, before, nil)
+	return s.cleanupAgent(id, tornjakTypes.AgentTombstoneBanned)
+}
+
+// deleteAgent deletes an agent, cleans up its metadata and notifies of the
+// deletion
+func (s *Server) deleteAgent(ctx context.Context, inp *DeleteAgentRequest) error {
+	before := s.agentBefore(inp.Id)
+	if err := s.DeleteAgent(inp); err != nil {
+		return err
+	}
+	id := spiffeIDToString(inp.Id)
+	s.notify(ctx, eventAgentDelete, notifier.Resource{Type: "agent", ID: id}, before, nil)
+	return s.cleanupAgent(id, tornjakTypes.AgentTombstoneDeleted)
+}
+
+// clusterState fetches the state of a cluster before or after a change,
+// if anyone is notified of the change
+func (s *Server) clusterState(name string) interface{} {
+	if len(s.Notifiers) == 0 {
+		return nil
+	}
+	cluster, err := s.GetCluster(GetClusterRequest{Name: name})
+	if err != nil {
+		return nil
+	}
+	return cluster
+}
+
+// setAgentLabels replaces the labels of an agent and notifies of the change
+func (s *Server) setAgentLabels(ctx context.Context, inp SetAgentLabelsRequest) error {
+	before := s.agentLabelsState(inp.Spiffeid)
+	if err := s.SetAgentLabels(inp); err != nil {
+		return err
+	}
+	s.notify(ctx, eventAgentLabels, notifier.Resource{Type: "agent", ID: inp.Spiffeid}, before, s.agentLabelsState(inp.Spiffeid))
+	return nil
+}
+
+// agentLabelsState returns the labels of the agent for an event, nil if
+// there are no notifiers or it cannot be read
+func (s *Server) agentLabelsState(spiffeid string) interface{} {
+	if len(s.Notifiers) == 0 {
+		return nil
+	}
+	alabels, err := s.GetAgentLabels(GetAgentLabelsRequest{Spiffeid: spiffeid})
+	if err != nil {
+		return nil
+	}
+	return alabels
+}
+
+// defineCluster registers a cluster and notifies of the creation
+func (s *Server) defineCluster(ctx context.Context, inp RegisterClusterRequest) error {
+	if err := s.DefineCluster(inp); err != nil {
+		return err
+	}
+	s.notify(ctx, eventClusterCreate, notifier.Resource{Type: "cluster", ID: inp.ClusterInstance.Name}, nil, s.clusterState(inp.ClusterInstance.Name))
+	return nil
+}
+
+// editCluster edits a cluster and notifies of the edit
+func (s *Server) editCluster(ctx context.Context, inp EditClusterRequest) error {
+	before := s.clusterState(inp.ClusterInstance.Name)
+	if err := s.EditCluster(inp); err != nil {
+		return err
+	}
+	s.notify(ctx, eventClusterEdit, notifier.Resource{Type: "cluster", ID: inp.ClusterInstance.Name}, before, s.clusterState(inp.ClusterInstance.EditedName))
+	return nil
+}
+
+// deleteCluster deletes a cluster and notifies of the deletion
+func (s *Server) deleteCluster(ctx context.Context, inp DeleteClusterRequest) error {
+	before := s.clusterState(inp.ClusterInstance.Name)
+	if err := s.DeleteCluster(inp); err != nil {
+		return err
+	}
+	s.notify(ctx, eventClusterDelete, notifier.Resource{Type: "cluster", ID: inp.ClusterInstance.Name}, before, nil)
+	return nil
+}
+
+// resumeWebhookDeliveries queues the deliveries left pending by the previous
+// run of this instance again, and dead-letters those to webhooks no longer
+// configured and those of instances no longer alive
+func (s *Server) resumeWebhookDeliveries() error {
+	pending, err := s.Db.GetPendingWebhookDeliveries(s.instance)
+	if err != nil {
+		return err
+	}
+	webhooks := map[string]*notifier.WebhookNotifier{}
+	for _, n := range s.Notifiers {
+		if webhook, ok := n.(*notifier.WebhookNotifier); ok {
+			webhooks[webhook.Info().Name] = webhook
+		}
+	}
+	resumed := 0
+	for _, delivery := range pending {
+		if webhook, ok := webhooks[delivery.Webhook]; ok {
+			webhook.Resume(delivery)
+			resumed++
+			continue
+		}
+		delivery.LastError = "webhook no longer configured"
+		delivery.UpdatedAt = time.Now()
+		if err := s.Db.DeadLetterWebhookDelivery(delivery); err != nil {
+			return err
+		}
+	}
+	if resumed > 0 {
+		fmt.Printf("Resumed %d interrupted webhook deliveries\n", resumed)
+	}
+	if dead := len(pending) - resumed; dead > 0 {
+		fmt.Printf("Moved %d interrupted deliveries of webhooks no longer configured to dead letters\n", dead)
+	}
+
+	// no owner: only the deliveries of instances no longer alive
+	n, err := s.Db.DeadLetterPendingWebhookDeliveries("", "delivery interrupted by Tornjak instance stop")
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		fmt.Printf("Moved %d orphaned webhook deliveries to dead letters\n", n)
+	}
+	return nil
+}
+
+// WebhooksResponse reports the configured notifiers and recent deliveries
+type WebhooksResponse struct {
+	Webhooks    []notifier.Info                  `json:"webhooks"`
+	Deliveries  []tornjakTypes.WebhookDelivery   `json:"deliveries"`
+	DeadLetters []tornjakTypes.WebhookDeadLetter `json:"deadLetters"`
+}
+
+// defaultWebhookListLimit is the number of deliveries returned when the
+// limit query parameter is not given
+const defaultWebhookListLimit = 100
+
+// webhookList reports the configured notifiers with their recent deliveries
+// and dead letters, optionally filtered by webhook name and delivery status
+func (s *Server) webhookList(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	webhook := query.Get("webhook")
+	status := query.Get("status")
+	switch status {
+	case "", tornjakTypes.WebhookPending, tornjakTypes.WebhookDelivered, tornjakTypes.WebhookDead:
+	default:
+		retError(w, fmt.Sprintf("Error: invalid status %q: expected pending, delivered or dead", status), http.StatusBadRequest)
+		return
+	}
+	limit := defaultWebhookListLimit
+	if l := query.Get("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 {
+			retError(w, fmt.Sprintf("Error: invalid limit %q: expected a positive number", l), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	ret := WebhooksResponse{
+		Webhooks:    []notifier.Info{},
+		Deliveries:  []tornjakTypes.WebhookDelivery{},
+		DeadLetters: []tornjakTypes.WebhookDeadLetter{},
+	}
+	for _, n := range s.Notifiers {
+		if info := n.Info(); webhook == "" || info.Name == webhook {
+			ret.Webhooks = append(ret.Webhooks, info)
+		}
+	}
+	if s.Db != nil {
+		deliveries, err := s.Db.GetWebhookDeliveries(webhook, status, limit)
+		if err != nil {
+			retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
+			return
+		}
+		deadLetters, err := s.Db.GetWebhookDeadLetters(webhook, limit)
+		if err != nil {
+			retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
+			return
+		}
+		ret.Deliveries = append(ret.Deliveries, deliveries.Deliveries...)
+		ret.DeadLetters = append(ret.DeadLetters, deadLetters.DeadLetters...)
+	}
+	if err := writeResponseJSON(w, r, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
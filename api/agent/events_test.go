@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spiffe/tornjak/pkg/agent/notifier"
+	tornjakTypes "github.com/spiffe/tornjak/pkg/agent/types"
+)
+
+func TestResumeWebhookDeliveries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	s := newTestServer(t)
+	s.instance = "tornjak-0"
+	for _, instance := range []string{"tornjak-0", "tornjak-1"} {
+		if err := s.Db.HeartbeatInstance(instance, time.Now().Add(-instanceTTL)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	webhook, err := notifier.NewWebhookNotifier(notifier.WebhookConfig{Name: "hook", URL: server.URL, Secret: "whsec_test", Owner: s.instance}, s.Db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Notifiers = []notifier.Notifier{webhook}
+
+	now := time.Now()
+	for _, delivery := range []tornjakTypes.WebhookDelivery{
+		{ID: "resumed", Webhook: "hook", Owner: "tornjak-0"},
+		{ID: "removed", Webhook: "removed-hook", Owner: "tornjak-0"},
+		{ID: "other", Webhook: "hook", Owner: "tornjak-1"},
+		{ID: "orphaned", Webhook: "hook", Owner: "stopped"},
+	} {
+		delivery.EventID, delivery.Action, delivery.Status = "e1", "entry.create", tornjakTypes.WebhookPending
+		delivery.Payload = []byte(`{"id":"e1"}`)
+		delivery.CreatedAt, delivery.UpdatedAt = now, now
+		if err := s.Db.CreateWebhookDelivery(delivery); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.resumeWebhookDeliveries(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"resumed":  tornjakTypes.WebhookDelivered,
+		"removed":  tornjakTypes.WebhookDead,
+		"other":    tornjakTypes.WebhookPending,
+		"orphaned": tornjakTypes.WebhookDead,
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		deliveries, err := s.Db.GetWebhookDeliveries("", "", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]string{}
+		for _, delivery := range deliveries.Deliveries {
+			got[delivery.ID] = delivery.Status
+		}
+		if len(got) == len(want) && got["resumed"] == want["resumed"] {
+			for id, status := range want {
+				if got[id] != status {
+					t.Fatalf("Expected delivery %s %s, got %s", id, status, got[id])
+				}
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected deliveries %v, got %v", want, got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
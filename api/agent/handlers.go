@@ -1,57 +1,91 @@
 package api
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
+	"strconv"
 
+	agent "github.com/spiffe/spire-api-sdk/proto/spire/api/server/agent/v1"
+	bundle "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
+	entry "github.com/spiffe/spire-api-sdk/proto/spire/api/server/entry/v1"
 	trustdomain "github.com/spiffe/spire-api-sdk/proto/spire/api/server/trustdomain/v1"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 )
 
+// readRequestBody reads the whole request body. The body is capped by
+// requestSizeMiddleware, so an oversized request surfaces here as an
+// *http.MaxBytesError.
+func readRequestBody(r *http.Request) ([]byte, error) {
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading request body: %w", err)
+	}
+	return data, nil
+}
+
 // readRequestJSON reads and unmarshals JSON input from the request body into the provided input struct.
+// Fields that do not exist on the input struct are rejected.
 // It returns the number of bytes read and any error encountered.
 func readRequestJSON(r *http.Request, input interface{}) (int64, error) {
-	buf := new(strings.Builder)
-	n, err := io.Copy(buf, r.Body)
+	data, err := readRequestBody(r)
 	if err != nil {
-		return n, fmt.Errorf("error reading request body: %v", err)
+		return 0, err
 	}
 
-	data := buf.String()
+	n := int64(len(data))
 	if n == 0 {
 		return n, nil // Indicates no data provided
 	}
 
-	if err := json.Unmarshal([]byte(data), input); err != nil {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(input); err != nil {
 		return n, fmt.Errorf("error unmarshaling JSON: %v", err)
 	}
+	if dec.More() {
+		return n, errors.New("error unmarshaling JSON: unexpected data after top-level value")
+	}
 
 	return n, nil
 }
 
 // readRequestProtoJSON reads and unmarshals JSON input using protojson (for protobuf messages).
-func readRequestProtoJSON(r *http.Request, input protojson.Message) (int64, error) {
-	buf := new(strings.Builder)
-	n, err := io.Copy(buf, r.Body)
+// Fields that do not exist on the message are rejected.
+func readRequestProtoJSON(r *http.Request, input proto.Message) (int64, error) {
+	data, err := readRequestBody(r)
 	if err != nil {
-		return n, fmt.Errorf("error reading request body: %v", err)
+		return 0, err
 	}
 
-	data := buf.String()
+	n := int64(len(data))
 	if n == 0 {
 		return n, nil
 	}
 
-	if err := protojson.Unmarshal([]byte(data), input); err != nil {
+	if err := protojson.Unmarshal(data, input); err != nil {
 		return n, fmt.Errorf("error unmarshaling proto JSON: %v", err)
 	}
 
 	return n, nil
 }
 
+// retRequestError writes an error returned while reading a request body.
+// Bodies over the configured size limit get 413, everything else 400.
+func retRequestError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		retError(w, fmt.Sprintf("request body exceeds limit of %d bytes", maxBytesErr.Limit), http.StatusRequestEntityTooLarge)
+		return
+	}
+	retError(w, err.Error(), http.StatusBadRequest)
+}
+
 // writeResponseJSON writes the given data structure as JSON to the response writer.
 func writeResponseJSON(w http.ResponseWriter, r *http.Request, v interface{}) error {
 	cors(w, r)
@@ -75,18 +109,12 @@ func writeSuccessResponse(w http.ResponseWriter, r *http.Request) error {
 // healthcheck handles health check requests.
 func (s *Server) healthcheck(w http.ResponseWriter, r *http.Request) {
 	var input HealthcheckRequest
-	n, err := readRequestJSON(r, &input)
-	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+	if _, err := readRequestProtoJSON(r, (*grpc_health_v1.HealthCheckRequest)(&input)); err != nil {
+		retRequestError(w, err)
 		return
 	}
 
-	// If no data, input stays empty (default)
-	if n == 0 {
-		input = HealthcheckRequest{}
-	}
-
-	ret, err := s.SPIREHealthcheck(input)
+	ret, err := s.SPIREHealthcheck(&input)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
@@ -101,7 +129,7 @@ func (s *Server) healthcheck(w http.ResponseWriter, r *http.Request) {
 func (s *Server) debugServer(w http.ResponseWriter, r *http.Request) {
 	input := DebugServerRequest{} // no fields to parse
 
-	ret, err := s.DebugServer(input)
+	ret, err := s.DebugServer(&input)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
@@ -115,17 +143,12 @@ func (s *Server) debugServer(w http.ResponseWriter, r *http.Request) {
 // agentList lists agents.
 func (s *Server) agentList(w http.ResponseWriter, r *http.Request) {
 	var input ListAgentsRequest
-	n, err := readRequestJSON(r, &input)
-	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+	if _, err := readRequestProtoJSON(r, (*agent.ListAgentsRequest)(&input)); err != nil {
+		retRequestError(w, err)
 		return
 	}
 
-	if n == 0 {
-		input = ListAgentsRequest{}
-	}
-
-	ret, err := s.ListAgents(input)
+	ret, err := s.ListAgents(&input)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
@@ -139,9 +162,9 @@ func (s *Server) agentList(w http.ResponseWriter, r *http.Request) {
 // agentBan bans an agent.
 func (s *Server) agentBan(w http.ResponseWriter, r *http.Request) {
 	var input BanAgentRequest
-	n, err := readRequestJSON(r, &input)
+	n, err := readRequestProtoJSON(r, (*agent.BanAgentRequest)(&input))
 	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+		retRequestError(w, err)
 		return
 	}
 
@@ -150,7 +173,7 @@ func (s *Server) agentBan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.BanAgent(input); err != nil {
+	if err := s.banAgent(r.Context(), &input); err != nil {
 		retError(w, fmt.Sprintf("Error listing agents: %v", err.Error()), http.StatusInternalServerError)
 		return
 	}
@@ -160,12 +183,13 @@ func (s *Server) agentBan(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// agentDelete deletes an agent (and potentially its metadata).
+// agentDelete deletes an agent and cleans up its Tornjak metadata, as set by
+// 'config > server > agent_cleanup'.
 func (s *Server) agentDelete(w http.ResponseWriter, r *http.Request) {
 	var input DeleteAgentRequest
-	n, err := readRequestJSON(r, &input)
+	n, err := readRequestProtoJSON(r, (*agent.DeleteAgentRequest)(&input))
 	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+		retRequestError(w, err)
 		return
 	}
 
@@ -174,7 +198,7 @@ func (s *Server) agentDelete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.DeleteAgent(input); err != nil {
+	if err := s.deleteAgent(r.Context(), &input); err != nil {
 		retError(w, fmt.Sprintf("Error listing agents: %v", err.Error()), http.StatusInternalServerError)
 		return
 	}
@@ -187,17 +211,12 @@ func (s *Server) agentDelete(w http.ResponseWriter, r *http.Request) {
 // agentCreateJoinToken creates a join token for an agent.
 func (s *Server) agentCreateJoinToken(w http.ResponseWriter, r *http.Request) {
 	var input CreateJoinTokenRequest
-	n, err := readRequestJSON(r, &input)
-	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+	if _, err := readRequestProtoJSON(r, (*agent.CreateJoinTokenRequest)(&input)); err != nil {
+		retRequestError(w, err)
 		return
 	}
 
-	if n == 0 {
-		input = CreateJoinTokenRequest{}
-	}
-
-	ret, err := s.CreateJoinToken(input)
+	ret, err := s.CreateJoinToken(&input)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
@@ -211,23 +230,18 @@ func (s *Server) agentCreateJoinToken(w http.ResponseWriter, r *http.Request) {
 // entryList lists entries.
 func (s *Server) entryList(w http.ResponseWriter, r *http.Request) {
 	var input ListEntriesRequest
-	n, err := readRequestJSON(r, &input)
-	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+	if _, err := readRequestProtoJSON(r, (*entry.ListEntriesRequest)(&input)); err != nil {
+		retRequestError(w, err)
 		return
 	}
 
-	if n == 0 {
-		input = ListEntriesRequest{}
-	}
-
-	ret, err := s.ListEntries(input)
+	ret, err := s.ListEntries(&input)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
 	}
 
-	if err := writeResponseJSON(w, r, ret); err != nil {
+	if err := writeResponseJSON(w, r, s.withEntriesMetadata(ret)); err != nil {
 		retError(w, err.Error(), http.StatusBadRequest)
 	}
 }
@@ -235,17 +249,13 @@ func (s *Server) entryList(w http.ResponseWriter, r *http.Request) {
 // entryCreate creates one or more entries.
 func (s *Server) entryCreate(w http.ResponseWriter, r *http.Request) {
 	var input BatchCreateEntryRequest
-	n, err := readRequestJSON(r, &input)
+	metadata, err := readEntryCreateRequest(r, &input)
 	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+		retRequestError(w, err)
 		return
 	}
 
-	if n == 0 {
-		input = BatchCreateEntryRequest{}
-	}
-
-	ret, err := s.BatchCreateEntry(input)
+	ret, err := s.createEntries(r.Context(), &input, metadata)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
@@ -259,17 +269,12 @@ func (s *Server) entryCreate(w http.ResponseWriter, r *http.Request) {
 // entryDelete deletes entries.
 func (s *Server) entryDelete(w http.ResponseWriter, r *http.Request) {
 	var input BatchDeleteEntryRequest
-	n, err := readRequestJSON(r, &input)
-	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+	if _, err := readRequestProtoJSON(r, (*entry.BatchDeleteEntryRequest)(&input)); err != nil {
+		retRequestError(w, err)
 		return
 	}
 
-	if n == 0 {
-		input = BatchDeleteEntryRequest{}
-	}
-
-	ret, err := s.BatchDeleteEntry(input)
+	ret, err := s.deleteEntries(r.Context(), &input)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
@@ -283,17 +288,12 @@ func (s *Server) entryDelete(w http.ResponseWriter, r *http.Request) {
 // bundleGet retrieves a bundle.
 func (s *Server) bundleGet(w http.ResponseWriter, r *http.Request) {
 	var input GetBundleRequest
-	n, err := readRequestJSON(r, &input)
-	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+	if _, err := readRequestProtoJSON(r, (*bundle.GetBundleRequest)(&input)); err != nil {
+		retRequestError(w, err)
 		return
 	}
 
-	if n == 0 {
-		input = GetBundleRequest{}
-	}
-
-	ret, err := s.GetBundle(input)
+	ret, err := s.GetBundle(&input)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
@@ -307,17 +307,12 @@ func (s *Server) bundleGet(w http.ResponseWriter, r *http.Request) {
 // federatedBundleList lists federated bundles.
 func (s *Server) federatedBundleList(w http.ResponseWriter, r *http.Request) {
 	var input ListFederatedBundlesRequest
-	n, err := readRequestJSON(r, &input)
-	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+	if _, err := readRequestProtoJSON(r, (*bundle.ListFederatedBundlesRequest)(&input)); err != nil {
+		retRequestError(w, err)
 		return
 	}
 
-	if n == 0 {
-		input = ListFederatedBundlesRequest{}
-	}
-
-	ret, err := s.ListFederatedBundles(input)
+	ret, err := s.ListFederatedBundles(&input)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
@@ -331,17 +326,12 @@ func (s *Server) federatedBundleList(w http.ResponseWriter, r *http.Request) {
 // federatedBundleCreate creates a federated bundle.
 func (s *Server) federatedBundleCreate(w http.ResponseWriter, r *http.Request) {
 	var input CreateFederatedBundleRequest
-	n, err := readRequestJSON(r, &input)
-	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+	if _, err := readRequestProtoJSON(r, (*bundle.BatchCreateFederatedBundleRequest)(&input)); err != nil {
+		retRequestError(w, err)
 		return
 	}
 
-	if n == 0 {
-		input = CreateFederatedBundleRequest{}
-	}
-
-	ret, err := s.CreateFederatedBundle(input)
+	ret, err := s.CreateFederatedBundle(&input)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
@@ -355,17 +345,12 @@ func (s *Server) federatedBundleCreate(w http.ResponseWriter, r *http.Request) {
 // federatedBundleUpdate updates a federated bundle.
 func (s *Server) federatedBundleUpdate(w http.ResponseWriter, r *http.Request) {
 	var input UpdateFederatedBundleRequest
-	n, err := readRequestJSON(r, &input)
-	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+	if _, err := readRequestProtoJSON(r, (*bundle.BatchUpdateFederatedBundleRequest)(&input)); err != nil {
+		retRequestError(w, err)
 		return
 	}
 
-	if n == 0 {
-		input = UpdateFederatedBundleRequest{}
-	}
-
-	ret, err := s.UpdateFederatedBundle(input)
+	ret, err := s.UpdateFederatedBundle(&input)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
@@ -379,17 +364,12 @@ func (s *Server) federatedBundleUpdate(w http.ResponseWriter, r *http.Request) {
 // federatedBundleDelete deletes a federated bundle.
 func (s *Server) federatedBundleDelete(w http.ResponseWriter, r *http.Request) {
 	var input DeleteFederatedBundleRequest
-	n, err := readRequestJSON(r, &input)
-	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+	if _, err := readRequestProtoJSON(r, (*bundle.BatchDeleteFederatedBundleRequest)(&input)); err != nil {
+		retRequestError(w, err)
 		return
 	}
 
-	if n == 0 {
-		input = DeleteFederatedBundleRequest{}
-	}
-
-	ret, err := s.DeleteFederatedBundle(input)
+	ret, err := s.DeleteFederatedBundle(&input)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
@@ -403,17 +383,12 @@ func (s *Server) federatedBundleDelete(w http.ResponseWriter, r *http.Request) {
 // federationList lists federation relationships.
 func (s *Server) federationList(w http.ResponseWriter, r *http.Request) {
 	var input ListFederationRelationshipsRequest
-	n, err := readRequestJSON(r, &input)
-	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+	if _, err := readRequestProtoJSON(r, (*trustdomain.ListFederationRelationshipsRequest)(&input)); err != nil {
+		retRequestError(w, err)
 		return
 	}
 
-	if n == 0 {
-		input = ListFederationRelationshipsRequest{}
-	}
-
-	ret, err := s.ListFederationRelationships(input)
+	ret, err := s.ListFederationRelationships(&input)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
@@ -426,19 +401,13 @@ func (s *Server) federationList(w http.ResponseWriter, r *http.Request) {
 
 // federationCreate creates a federation relationship.
 func (s *Server) federationCreate(w http.ResponseWriter, r *http.Request) {
-	var rawInput trustdomain.BatchCreateFederationRelationshipRequest
-	n, err := readRequestProtoJSON(r, &rawInput)
-	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+	var input CreateFederationRelationshipRequest
+	if _, err := readRequestProtoJSON(r, (*trustdomain.BatchCreateFederationRelationshipRequest)(&input)); err != nil {
+		retRequestError(w, err)
 		return
 	}
 
-	input := CreateFederationRelationshipRequest(rawInput)
-	if n == 0 {
-		input = CreateFederationRelationshipRequest{}
-	}
-
-	ret, err := s.CreateFederationRelationship(input)
+	ret, err := s.CreateFederationRelationship(&input)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
@@ -451,19 +420,13 @@ func (s *Server) federationCreate(w http.ResponseWriter, r *http.Request) {
 
 // federationUpdate updates a federation relationship.
 func (s *Server) federationUpdate(w http.ResponseWriter, r *http.Request) {
-	var rawInput trustdomain.BatchUpdateFederationRelationshipRequest
-	n, err := readRequestProtoJSON(r, &rawInput)
-	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+	var input UpdateFederationRelationshipRequest
+	if _, err := readRequestProtoJSON(r, (*trustdomain.BatchUpdateFederationRelationshipRequest)(&input)); err != nil {
+		retRequestError(w, err)
 		return
 	}
 
-	input := UpdateFederationRelationshipRequest(rawInput)
-	if n == 0 {
-		input = UpdateFederationRelationshipRequest{}
-	}
-
-	ret, err := s.UpdateFederationRelationship(input)
+	ret, err := s.UpdateFederationRelationship(&input)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
@@ -477,17 +440,12 @@ func (s *Server) federationUpdate(w http.ResponseWriter, r *http.Request) {
 // federationDelete deletes a federation relationship.
 func (s *Server) federationDelete(w http.ResponseWriter, r *http.Request) {
 	var input DeleteFederationRelationshipRequest
-	n, err := readRequestJSON(r, &input)
-	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+	if _, err := readRequestProtoJSON(r, (*trustdomain.BatchDeleteFederationRelationshipRequest)(&input)); err != nil {
+		retRequestError(w, err)
 		return
 	}
 
-	if n == 0 {
-		input = DeleteFederationRelationshipRequest{}
-	}
-
-	ret, err := s.DeleteFederationRelationship(input)
+	ret, err := s.DeleteFederationRelationship(&input)
 	if err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
 		return
@@ -520,7 +478,7 @@ func (s *Server) tornjakSelectorsList(w http.ResponseWriter, r *http.Request) {
 	var input ListSelectorsRequest
 	n, err := readRequestJSON(r, &input)
 	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+		retRequestError(w, err)
 		return
 	}
 	if n == 0 {
@@ -543,7 +501,7 @@ func (s *Server) tornjakPluginDefine(w http.ResponseWriter, r *http.Request) {
 	var input RegisterSelectorRequest
 	n, err := readRequestJSON(r, &input)
 	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+		retRequestError(w, err)
 		return
 	}
 	if n == 0 {
@@ -560,17 +518,29 @@ func (s *Server) tornjakPluginDefine(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// tornjakAgentsList lists agent metadata.
+// tornjakAgentsList lists agent metadata, filtered by the labelSelector
+// query parameter.
 func (s *Server) tornjakAgentsList(w http.ResponseWriter, r *http.Request) {
 	var input ListAgentMetadataRequest
 	n, err := readRequestJSON(r, &input)
 	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+		retRequestError(w, err)
 		return
 	}
 	if n == 0 {
 		input = ListAgentMetadataRequest{}
 	}
+	if selector := r.URL.Query().Get("labelSelector"); selector != "" {
+		input.LabelSelector = selector
+	}
+	if v := r.URL.Query().Get("includeTombstones"); v != "" {
+		include, err := strconv.ParseBool(v)
+		if err != nil {
+			retError(w, fmt.Sprintf("Error: invalid includeTombstones %q: expected true or false", v), http.StatusBadRequest)
+			return
+		}
+		input.IncludeTombstones = include
+	}
 
 	ret, err := s.ListAgentMetadata(input)
 	if err != nil {
@@ -583,18 +553,79 @@ func (s *Server) tornjakAgentsList(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// clusterList lists clusters.
+// tornjakAgentLabelsGet returns the labels and annotations of an agent.
+func (s *Server) tornjakAgentLabelsGet(w http.ResponseWriter, r *http.Request) {
+	spiffeid, err := pathVar(r, "spiffeid")
+	if err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ret, err := s.GetAgentLabels(GetAgentLabelsRequest{Spiffeid: spiffeid})
+	if err != nil {
+		retTornjakError(w, err)
+		return
+	}
+
+	if err := writeResponseJSON(w, r, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// tornjakAgentLabelsSet replaces the labels and annotations of an agent.
+func (s *Server) tornjakAgentLabelsSet(w http.ResponseWriter, r *http.Request) {
+	spiffeid, err := pathVar(r, "spiffeid")
+	if err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var input SetAgentLabelsRequest
+	n, err := readRequestJSON(r, &input)
+	if err != nil {
+		retRequestError(w, err)
+		return
+	}
+	if n == 0 {
+		retError(w, "Error: no data provided", http.StatusBadRequest)
+		return
+	}
+	if len(input.Spiffeid) > 0 && input.Spiffeid != spiffeid {
+		retError(w, "Error: spiffeid in body does not match the path", http.StatusBadRequest)
+		return
+	}
+	input.Spiffeid = spiffeid
+
+	if err := s.setAgentLabels(r.Context(), input); err != nil {
+		retTornjakError(w, err)
+		return
+	}
+
+	ret, err := s.GetAgentLabels(GetAgentLabelsRequest{Spiffeid: spiffeid})
+	if err != nil {
+		retTornjakError(w, err)
+		return
+	}
+	if err := writeResponseJSON(w, r, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// clusterList lists clusters, filtered by the labelSelector query parameter.
 func (s *Server) clusterList(w http.ResponseWriter, r *http.Request) {
 	var input ListClustersRequest
 	n, err := readRequestJSON(r, &input)
 	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+		retRequestError(w, err)
 		return
 	}
 
 	if n == 0 {
 		input = ListClustersRequest{}
 	}
+	if selector := r.URL.Query().Get("labelSelector"); selector != "" {
+		input.LabelSelector = selector
+	}
 
 	ret, err := s.ListClusters(input)
 	if err != nil {
@@ -612,7 +643,7 @@ func (s *Server) clusterCreate(w http.ResponseWriter, r *http.Request) {
 	var input RegisterClusterRequest
 	n, err := readRequestJSON(r, &input)
 	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+		retRequestError(w, err)
 		return
 	}
 
@@ -620,7 +651,7 @@ func (s *Server) clusterCreate(w http.ResponseWriter, r *http.Request) {
 		input = RegisterClusterRequest{}
 	}
 
-	if err := s.DefineCluster(input); err != nil {
+	if err := s.defineCluster(r.Context(), input); err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusBadRequest)
 		return
 	}
@@ -635,7 +666,7 @@ func (s *Server) clusterEdit(w http.ResponseWriter, r *http.Request) {
 	var input EditClusterRequest
 	n, err := readRequestJSON(r, &input)
 	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+		retRequestError(w, err)
 		return
 	}
 
@@ -643,7 +674,7 @@ func (s *Server) clusterEdit(w http.ResponseWriter, r *http.Request) {
 		input = EditClusterRequest{}
 	}
 
-	if err := s.EditCluster(input); err != nil {
+	if err := s.editCluster(r.Context(), input); err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusBadRequest)
 		return
 	}
@@ -658,7 +689,7 @@ func (s *Server) clusterDelete(w http.ResponseWriter, r *http.Request) {
 	var input DeleteClusterRequest
 	n, err := readRequestJSON(r, &input)
 	if err != nil {
-		retError(w, err.Error(), http.StatusBadRequest)
+		retRequestError(w, err)
 		return
 	}
 
@@ -666,7 +697,7 @@ func (s *Server) clusterDelete(w http.ResponseWriter, r *http.Request) {
 		input = DeleteClusterRequest{}
 	}
 
-	if err := s.DeleteCluster(input); err != nil {
+	if err := s.deleteCluster(r.Context(), input); err != nil {
 		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusBadRequest)
 		return
 	}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	entry "github.com/spiffe/spire-api-sdk/proto/spire/api/server/entry/v1"
+)
+
+func TestReadRequestJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		limit   int64
+		wantErr string
+	}{
+		{name: "valid", body: `{"spiffeid":"spiffe://example.org/agent","plugin":"k8s_psat"}`},
+		{name: "empty body", body: ``},
+		{name: "unknown field", body: `{"spiffeid":"spiffe://example.org/agent","parentId":"x"}`, wantErr: `unknown field "parentId"`},
+		{name: "trailing data", body: `{"plugin":"k8s_psat"}{}`, wantErr: "unexpected data after top-level value"},
+		{name: "over limit", body: `{"plugin":"k8s_psat"}`, limit: 8, wantErr: "request body too large"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/tornjak/selectors/register", strings.NewReader(tt.body))
+			if tt.limit > 0 {
+				r.Body = http.MaxBytesReader(httptest.NewRecorder(), r.Body, tt.limit)
+			}
+			var input RegisterSelectorRequest
+			n, err := readRequestJSON(r, &input)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			// a body cut off at the limit reports nothing read
+			wantN := int64(len(tt.body))
+			if tt.limit > 0 {
+				wantN = 0
+			}
+			if n != wantN {
+				t.Fatalf("Expected %d bytes read, got %d", wantN, n)
+			}
+		})
+	}
+}
+
+func TestReadRequestProtoJSON(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantPageSize int32
+		wantErr      string
+	}{
+		{name: "proto field name", body: `{"page_size":10}`, wantPageSize: 10},
+		{name: "JSON field name", body: `{"pageSize":10,"filter":{"byParentId":{"trustDomain":"example.org","path":"/agent"}}}`, wantPageSize: 10},
+		{name: "empty body", body: ``},
+		{name: "unknown field", body: `{"parentId":"x"}`, wantErr: `unknown field "parentId"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/spire/entries", strings.NewReader(tt.body))
+			var input entry.ListEntriesRequest
+			_, err := readRequestProtoJSON(r, &input)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if input.PageSize != tt.wantPageSize {
+				t.Fatalf("Expected page size %d, got %d", tt.wantPageSize, input.PageSize)
+			}
+		})
+	}
+}
+
+func TestRequestDecodingErrors(t *testing.T) {
+	s := newTestServer(t)
+	s.TornjakConfig = &TornjakConfig{Server: &serverConfig{MaxRequestBytes: 64}}
+	handler := s.requestSizeMiddleware(http.HandlerFunc(s.tornjakSelectorsList))
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{name: "empty body", body: ``, wantCode: http.StatusOK},
+		{name: "empty object", body: `{}`, wantCode: http.StatusOK},
+		{name: "unknown field", body: `{"parentId":"x"}`, wantCode: http.StatusBadRequest, wantBody: `unknown field "parentId"`},
+		{name: "over limit", body: `{"filter":"` + strings.Repeat("x", 64) + `"}`, wantCode: http.StatusRequestEntityTooLarge, wantBody: "request body exceeds limit of 64 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/tornjak/selectors/list", strings.NewReader(tt.body)))
+			if w.Code != tt.wantCode {
+				t.Fatalf("Expected %d, got %d: %s", tt.wantCode, w.Code, w.Body.String())
+			}
+			if !strings.Contains(w.Body.String(), tt.wantBody) {
+				t.Fatalf("Expected body containing %q, got %q", tt.wantBody, w.Body.String())
+			}
+		})
+	}
+}
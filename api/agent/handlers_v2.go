@@ -0,0 +1,598 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	agent "github.com/spiffe/spire-api-sdk/proto/spire/api/server/agent/v1"
+	entry "github.com/spiffe/spire-api-sdk/proto/spire/api/server/entry/v1"
+	types "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	agentdb "github.com/spiffe/tornjak/pkg/agent/db"
+	tornjakTypes "github.com/spiffe/tornjak/pkg/agent/types"
+)
+
+/*
+
+API v2
+
+Resources are addressed by path, reads take their parameters from the query
+string and identifiers are never read from a request body. SPIFFE IDs used as
+path segments must be URL-escaped, e.g.
+
+GET /api/v2/agents/spiffe%3A%2F%2Fexample.org%2Fspire%2Fagent%2Fjoin_token%2Fabc
+
+*/
+
+const apiV2Prefix = "/api/v2"
+
+// writeResponseJSONStatus writes the given data structure as JSON with the given status code.
+func writeResponseJSONStatus(w http.ResponseWriter, status int, v interface{}) error {
+	corsHeaders(w)
+	w.WriteHeader(status)
+	je := json.NewEncoder(w)
+	if err := je.Encode(v); err != nil {
+		return fmt.Errorf("error encoding response JSON: %v", err)
+	}
+	return nil
+}
+
+// writeCreatedJSON writes a 201 Created response pointing at the new resource.
+func writeCreatedJSON(w http.ResponseWriter, location string, v interface{}) error {
+	w.Header().Set("Location", location)
+	return writeResponseJSONStatus(w, http.StatusCreated, v)
+}
+
+// writeNoContent writes an empty 204 No Content response.
+func writeNoContent(w http.ResponseWriter) {
+	corsHeaders(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// httpStatusFromCode maps a gRPC status code returned by SPIRE to an HTTP status code.
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.InvalidArgument, codes.OutOfRange, codes.FailedPrecondition:
+		return http.StatusBadRequest
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// retSPIREError writes an error returned by a SPIRE API call.
+func retSPIREError(w http.ResponseWriter, err error) {
+	retError(w, fmt.Sprintf("Error: %v", err.Error()), httpStatusFromCode(status.Code(err)))
+}
+
+// retTornjakError writes an error returned by a Tornjak API call. Datastore
+// lookups that found nothing are 404, rejected datastore writes are 409,
+// other datastore failures are 500 and anything else is invalid input.
+func retTornjakError(w http.ResponseWriter, err error) {
+	var (
+		getErr  agentdb.GetError
+		postErr agentdb.PostFailure
+		sqlErr  agentdb.SQLError
+	)
+	code := http.StatusBadRequest
+	switch {
+	case errors.As(err, &getErr):
+		code = http.StatusNotFound
+	case errors.As(err, &postErr):
+		code = http.StatusConflict
+	case errors.As(err, &sqlErr):
+		code = http.StatusInternalServerError
+	}
+	retError(w, fmt.Sprintf("Error: %v", err.Error()), code)
+}
+
+// pathVar returns the unescaped route variable with the given name.
+func pathVar(r *http.Request, name string) (string, error) {
+	value, err := url.PathUnescape(mux.Vars(r)[name])
+	if err != nil {
+		return "", fmt.Errorf("invalid path parameter %s: %v", name, err)
+	}
+	if value == "" {
+		return "", fmt.Errorf("missing path parameter %s", name)
+	}
+	return value, nil
+}
+
+// parseSPIFFEID converts a SPIFFE ID string into its API representation.
+func parseSPIFFEID(id string) (*types.SPIFFEID, error) {
+	u, err := url.Parse(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SPIFFE ID %q: %v", id, err)
+	}
+	if u.Scheme != "spiffe" || u.Host == "" {
+		return nil, fmt.Errorf("invalid SPIFFE ID %q: expected spiffe://<trust domain>/<path>", id)
+	}
+	return &types.SPIFFEID{TrustDomain: u.Host, Path: u.Path}, nil
+}
+
+// pageParams reads the page_size and page_token query parameters.
+func pageParams(query url.Values) (int32, string, error) {
+	var pageSize int32
+	if v := query.Get("page_size"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 32)
+		if err != nil || n < 0 {
+			return 0, "", fmt.Errorf("invalid page_size %q", v)
+		}
+		pageSize = int32(n)
+	}
+	return pageSize, query.Get("page_token"), nil
+}
+
+// boolParam reads an optional boolean query parameter.
+func boolParam(query url.Values, name string) (*wrapperspb.BoolValue, error) {
+	v := query.Get(name)
+	if v == "" {
+		return nil, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", name, v)
+	}
+	return wrapperspb.Bool(b), nil
+}
+
+// selectorMatchParam reads repeated selector=type:value query parameters and the
+// optional selector_match behavior (exact, subset, superset or any).
+func selectorMatchParam(query url.Values) (*types.SelectorMatch, error) {
+	values := query["selector"]
+	if len(values) == 0 {
+		return nil, nil
+	}
+	match := &types.SelectorMatch{}
+	for _, v := range values {
+		selectorType, selectorValue, ok := strings.Cut(v, ":")
+		if !ok || selectorType == "" || selectorValue == "" {
+			return nil, fmt.Errorf("invalid selector %q: expected type:value", v)
+		}
+		match.Selectors = append(match.Selectors, &types.Selector{Type: selectorType, Value: selectorValue})
+	}
+	if v := query.Get("selector_match"); v != "" {
+		behavior, ok := types.SelectorMatch_MatchBehavior_value["MATCH_"+strings.ToUpper(v)]
+		if !ok {
+			return nil, fmt.Errorf("invalid selector_match %q", v)
+		}
+		match.Match = types.SelectorMatch_MatchBehavior(behavior)
+	}
+	return match, nil
+}
+
+// federatesWithMatchParam reads repeated federates_with query parameters and the
+// optional federates_with_match behavior (exact, subset, superset or any).
+func federatesWithMatchParam(query url.Values) (*types.FederatesWithMatch, error) {
+	values := query["federates_with"]
+	if len(values) == 0 {
+		return nil, nil
+	}
+	match := &types.FederatesWithMatch{TrustDomains: values}
+	if v := query.Get("federates_with_match"); v != "" {
+		behavior, ok := types.FederatesWithMatch_MatchBehavior_value["MATCH_"+strings.ToUpper(v)]
+		if !ok {
+			return nil, fmt.Errorf("invalid federates_with_match %q", v)
+		}
+		match.Match = types.FederatesWithMatch_MatchBehavior(behavior)
+	}
+	return match, nil
+}
+
+// agentListV2 lists agents, filtered by query parameters.
+func (s *Server) agentListV2(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var input ListAgentsRequest
+	var err error
+	input.PageSize, input.PageToken, err = pageParams(query)
+	if err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := &agent.ListAgentsRequest_Filter{ByAttestationType: query.Get("attestation_type")}
+	if filter.ByBanned, err = boolParam(query, "banned"); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.ByCanReattest, err = boolParam(query, "can_reattest"); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.BySelectorMatch, err = selectorMatchParam(query); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	input.Filter = filter
+
+	ret, err := s.ListAgents(&input)
+	if err != nil {
+		retSPIREError(w, err)
+		return
+	}
+
+	if err := writeResponseJSON(w, r, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// agentGetV2 returns a single agent.
+func (s *Server) agentGetV2(w http.ResponseWriter, r *http.Request) {
+	id, err := agentIDFromPath(r)
+	if err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ret, err := s.GetAgent(&GetAgentRequest{Id: id})
+	if err != nil {
+		retSPIREError(w, err)
+		return
+	}
+
+	if err := writeResponseJSON(w, r, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// agentBanV2 bans a single agent.
+func (s *Server) agentBanV2(w http.ResponseWriter, r *http.Request) {
+	id, err := agentIDFromPath(r)
+	if err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.banAgent(r.Context(), &BanAgentRequest{Id: id}); err != nil {
+		retSPIREError(w, err)
+		return
+	}
+
+	writeNoContent(w)
+}
+
+// agentDeleteV2 deletes a single agent.
+func (s *Server) agentDeleteV2(w http.ResponseWriter, r *http.Request) {
+	id, err := agentIDFromPath(r)
+	if err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.deleteAgent(r.Context(), &DeleteAgentRequest{Id: id}); err != nil {
+		retSPIREError(w, err)
+		return
+	}
+
+	writeNoContent(w)
+}
+
+// agentIDFromPath parses the {id} route variable as an agent SPIFFE ID.
+func agentIDFromPath(r *http.Request) (*types.SPIFFEID, error) {
+	id, err := pathVar(r, "id")
+	if err != nil {
+		return nil, err
+	}
+	return parseSPIFFEID(id)
+}
+
+// joinTokenCreateV2 creates a join token.
+func (s *Server) joinTokenCreateV2(w http.ResponseWriter, r *http.Request) {
+	var input CreateJoinTokenRequest
+	if _, err := readRequestProtoJSON(r, (*agent.CreateJoinTokenRequest)(&input)); err != nil {
+		retRequestError(w, err)
+		return
+	}
+
+	ret, err := s.CreateJoinToken(&input)
+	if err != nil {
+		retSPIREError(w, err)
+		return
+	}
+
+	if err := writeResponseJSONStatus(w, http.StatusCreated, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// entryListV2 lists entries, filtered by query parameters.
+func (s *Server) entryListV2(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	var input ListEntriesRequest
+	var err error
+	input.PageSize, input.PageToken, err = pageParams(query)
+	if err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	filter := &entry.ListEntriesRequest_Filter{}
+	if v := query.Get("spiffe_id"); v != "" {
+		if filter.BySpiffeId, err = parseSPIFFEID(v); err != nil {
+			retError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if v := query.Get("parent_id"); v != "" {
+		if filter.ByParentId, err = parseSPIFFEID(v); err != nil {
+			retError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if filter.BySelectors, err = selectorMatchParam(query); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if filter.ByFederatesWith, err = federatesWithMatchParam(query); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.Has("hint") {
+		filter.ByHint = wrapperspb.String(query.Get("hint"))
+	}
+	input.Filter = filter
+
+	ret, err := s.ListEntries(&input)
+	if err != nil {
+		retSPIREError(w, err)
+		return
+	}
+
+	if err := writeResponseJSON(w, r, s.withEntriesMetadata(ret)); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// entryGetV2 returns a single entry.
+func (s *Server) entryGetV2(w http.ResponseWriter, r *http.Request) {
+	id, err := pathVar(r, "id")
+	if err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ret, err := s.GetEntry(&GetEntryRequest{Id: id})
+	if err != nil {
+		retSPIREError(w, err)
+		return
+	}
+
+	if err := writeResponseJSON(w, r, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// entryCreateV2 creates a single entry given as the request body.
+func (s *Server) entryCreateV2(w http.ResponseWriter, r *http.Request) {
+	var newEntry types.Entry
+	var metadata *EntryMetadataInput
+	n, err := readEntryRequestProtoJSON(r, &newEntry, &metadata)
+	if err != nil {
+		retRequestError(w, err)
+		return
+	}
+	if n == 0 {
+		retError(w, "Error: no data provided", http.StatusBadRequest)
+		return
+	}
+	var entryMetadata []EntryMetadataInput
+	if metadata != nil {
+		if err := metadata.validate(); err != nil {
+			retError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		entryMetadata = []EntryMetadataInput{*metadata}
+	}
+
+	ret, err := s.createEntries(r.Context(), &BatchCreateEntryRequest{Entries: []*types.Entry{&newEntry}}, entryMetadata)
+	if err != nil {
+		retSPIREError(w, err)
+		return
+	}
+	if len(ret.Results) != 1 {
+		retError(w, fmt.Sprintf("Error: expected 1 result from SPIRE, got %d", len(ret.Results)), http.StatusInternalServerError)
+		return
+	}
+	result := ret.Results[0]
+	if code := codes.Code(result.Status.GetCode()); code != codes.OK {
+		retError(w, fmt.Sprintf("Error: %v", result.Status.GetMessage()), httpStatusFromCode(code))
+		return
+	}
+
+	location := apiV2Prefix + "/entries/" + url.PathEscape(result.Entry.GetId())
+	if err := writeCreatedJSON(w, location, result.Entry); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// entryDeleteV2 deletes a single entry.
+func (s *Server) entryDeleteV2(w http.ResponseWriter, r *http.Request) {
+	id, err := pathVar(r, "id")
+	if err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ret, err := s.deleteEntries(r.Context(), &BatchDeleteEntryRequest{Ids: []string{id}})
+	if err != nil {
+		retSPIREError(w, err)
+		return
+	}
+	for _, result := range ret.Results {
+		if code := codes.Code(result.Status.GetCode()); code != codes.OK {
+			retError(w, fmt.Sprintf("Error: %v", result.Status.GetMessage()), httpStatusFromCode(code))
+			return
+		}
+	}
+
+	writeNoContent(w)
+}
+
+// clusterListV2 lists clusters, filtered by the labelSelector query parameter.
+func (s *Server) clusterListV2(w http.ResponseWriter, r *http.Request) {
+	ret, err := s.ListClusters(ListClustersRequest{LabelSelector: r.URL.Query().Get("labelSelector")})
+	if err != nil {
+		retTornjakError(w, err)
+		return
+	}
+
+	if err := writeResponseJSON(w, r, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// clusterGetV2 returns a single cluster.
+func (s *Server) clusterGetV2(w http.ResponseWriter, r *http.Request) {
+	name, err := pathVar(r, "name")
+	if err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ret, err := s.GetCluster(GetClusterRequest{Name: name})
+	if err != nil {
+		retTornjakError(w, err)
+		return
+	}
+
+	if err := writeResponseJSON(w, r, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// clusterCreateV2 creates a cluster given as the request body.
+func (s *Server) clusterCreateV2(w http.ResponseWriter, r *http.Request) {
+	var cinfo tornjakTypes.ClusterInfo
+	n, err := readRequestJSON(r, &cinfo)
+	if err != nil {
+		retRequestError(w, err)
+		return
+	}
+	if n == 0 {
+		retError(w, "Error: no data provided", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.defineCluster(r.Context(), RegisterClusterRequest{ClusterInstance: cinfo}); err != nil {
+		retTornjakError(w, err)
+		return
+	}
+
+	ret, err := s.GetCluster(GetClusterRequest{Name: cinfo.Name})
+	if err != nil {
+		retTornjakError(w, err)
+		return
+	}
+
+	location := apiV2Prefix + "/clusters/" + url.PathEscape(cinfo.Name)
+	if err := writeCreatedJSON(w, location, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// clusterUpdateV2 replaces a cluster with the request body. A name in the
+// body that differs from the path renames the cluster.
+func (s *Server) clusterUpdateV2(w http.ResponseWriter, r *http.Request) {
+	name, err := pathVar(r, "name")
+	if err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// look up the cluster first so a missing cluster is reported as 404
+	if _, err := s.GetCluster(GetClusterRequest{Name: name}); err != nil {
+		retTornjakError(w, err)
+		return
+	}
+
+	var cinfo tornjakTypes.ClusterInfo
+	n, err := readRequestJSON(r, &cinfo)
+	if err != nil {
+		retRequestError(w, err)
+		return
+	}
+	if n == 0 {
+		retError(w, "Error: no data provided", http.StatusBadRequest)
+		return
+	}
+
+	if len(cinfo.EditedName) > 0 {
+		retError(w, "Error: editedName is not supported, set name to rename the cluster", http.StatusBadRequest)
+		return
+	}
+	cinfo.EditedName = cinfo.Name
+	if len(cinfo.EditedName) == 0 {
+		cinfo.EditedName = name
+	}
+	cinfo.Name = name
+	// PUT replaces the cluster, so omitted labels and details are cleared
+	// rather than kept as on PATCH
+	if cinfo.Labels == nil {
+		cinfo.Labels = tornjakTypes.Labels{}
+	}
+	if cinfo.Details == nil {
+		cinfo.Details = json.RawMessage(`{}`)
+	}
+
+	if err := s.editCluster(r.Context(), EditClusterRequest{ClusterInstance: cinfo}); err != nil {
+		retTornjakError(w, err)
+		return
+	}
+
+	ret, err := s.GetCluster(GetClusterRequest{Name: cinfo.EditedName})
+	if err != nil {
+		retTornjakError(w, err)
+		return
+	}
+
+	if cinfo.EditedName != name {
+		w.Header().Set("Location", apiV2Prefix+"/clusters/"+url.PathEscape(cinfo.EditedName))
+	}
+	if err := writeResponseJSON(w, r, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// clusterDeleteV2 deletes a cluster.
+func (s *Server) clusterDeleteV2(w http.ResponseWriter, r *http.Request) {
+	name, err := pathVar(r, "name")
+	if err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// look up the cluster first so a missing cluster is reported as 404
+	if _, err := s.GetCluster(GetClusterRequest{Name: name}); err != nil {
+		retTornjakError(w, err)
+		return
+	}
+
+	if err := s.deleteCluster(r.Context(), DeleteClusterRequest{ClusterInstance: tornjakTypes.ClusterInfo{Name: name}}); err != nil {
+		retTornjakError(w, err)
+		return
+	}
+
+	writeNoContent(w)
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/spiffe/tornjak/pkg/agent/authentication/user"
+	agentdb "github.com/spiffe/tornjak/pkg/agent/db"
+	tornjakTypes "github.com/spiffe/tornjak/pkg/agent/types"
+)
+
+const (
+	idempotencyKeyHeader      = "Idempotency-Key"
+	idempotencyReplayedHeader = "Idempotency-Replayed"
+	maxIdempotencyKeyLength   = 255
+	// maxIdempotencyPrincipalLength fits the principal in the unique key of
+	// the datastore; longer principals are stored as a hash
+	maxIdempotencyPrincipalLength = 255
+
+	// idempotencyInProgressTimeout is how long a key stays reserved for a
+	// request that never completed, for example because the server crashed
+	idempotencyInProgressTimeout = 5 * time.Minute
+
+	// defaultIdempotencyWindow is how long responses are kept when
+	// 'config > server > idempotency_window' is not set.
+	defaultIdempotencyWindow = 24 * time.Hour
+)
+
+// idempotencyWindow returns the configured idempotency window, or the default if unset.
+func (s *Server) idempotencyWindow() time.Duration {
+	if s.TornjakConfig != nil && s.TornjakConfig.Server != nil && s.TornjakConfig.Server.IdempotencyWindow != "" {
+		// validated in VerifyConfiguration
+		if d, err := time.ParseDuration(s.TornjakConfig.Server.IdempotencyWindow); err == nil {
+			return d
+		}
+	}
+	return defaultIdempotencyWindow
+}
+
+type userInfoKey struct{}
+
+// withUserInfo returns a copy of ctx carrying the authenticated caller
+func withUserInfo(ctx context.Context, userInfo *user.UserInfo) context.Context {
+	return context.WithValue(ctx, userInfoKey{}, userInfo)
+}
+
+// idempotencyPrincipal identifies the authenticated caller of r by its
+// authenticator and user name, "" if there is no authentication
+func idempotencyPrincipal(r *http.Request) string {
+	userInfo, _ := r.Context().Value(userInfoKey{}).(*user.UserInfo)
+	if userInfo == nil {
+		return ""
+	}
+	principal := userInfo.Authenticator + ":" + userInfo.Name
+	if len(principal) > maxIdempotencyPrincipalLength {
+		sum := sha256.Sum256([]byte(userInfo.Name))
+		principal = userInfo.Authenticator + ":sha256:" + hex.EncodeToString(sum[:])
+	}
+	return principal
+}
+
+// idempotencyRecorder passes a response through while keeping a copy of it.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *idempotencyRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
+
+// Flush sends the response written so far, as streaming handlers do
+func (rec *idempotencyRecorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (rec *idempotencyRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// idempotent makes a create handler honour the Idempotency-Key header. Keys
+// are scoped to the caller. The first request with a key runs the handler
+// and stores its response for the idempotency window; repeats with the same
+// body replay that response, and repeats with a different body are
+// rejected. Responses with a 5xx status are not stored, so the request can
+// be retried.
+func (s *Server) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return s.idempotentHandler(next, true)
+}
+
+// idempotentSecret is idempotent for handlers whose response holds a
+// secret, such as a join token, which is not kept in the datastore. Repeats
+// of a completed request are rejected rather than replayed.
+func (s *Server) idempotentSecret(next http.HandlerFunc) http.HandlerFunc {
+	return s.idempotentHandler(next, false)
+}
+
+func (s *Server) idempotentHandler(next http.HandlerFunc, keepResponse bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" || s.Db == nil {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			retError(w, "Error: Idempotency-Key must be at most 255 characters", http.StatusBadRequest)
+			return
+		}
+
+		body, err := readRequestBody(r)
+		if err != nil {
+			retRequestError(w, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		sum := sha256.Sum256(body)
+		now := time.Now()
+
+		inProgressTimeout := idempotencyInProgressTimeout
+		if window := s.idempotencyWindow(); window < inProgressTimeout {
+			inProgressTimeout = window
+		}
+		err = s.Db.DeleteIdempotencyRecordsBefore(now.Add(-s.idempotencyWindow()).Unix(), now.Add(-inProgressTimeout).Unix())
+		if err != nil {
+			log.Printf("Failed to remove expired idempotency keys: %v", err)
+		}
+
+		rec := tornjakTypes.IdempotencyRecord{
+			Key:         key,
+			Path:        r.URL.Path,
+			RequestHash: hex.EncodeToString(sum[:]),
+			Principal:   idempotencyPrincipal(r),
+			CreatedAt:   now.Unix(),
+		}
+		err = s.Db.CreateIdempotencyRecord(rec)
+		var postErr agentdb.PostFailure
+		if errors.As(err, &postErr) {
+			s.replayIdempotentResponse(w, rec, keepResponse)
+			return
+		} else if err != nil {
+			retError(w, "Error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		recorder := &idempotencyRecorder{ResponseWriter: w}
+		next(recorder, r)
+
+		if recorder.status == 0 || recorder.status >= http.StatusInternalServerError {
+			if err := s.Db.DeleteIdempotencyRecord(rec.Key, rec.Path, rec.Principal); err != nil {
+				log.Printf("Failed to release idempotency key %q: %v", rec.Key, err)
+			}
+			return
+		}
+		rec.StatusCode = recorder.status
+		rec.ContentType = recorder.Header().Get("Content-Type")
+		rec.Location = recorder.Header().Get("Location")
+		if keepResponse {
+			rec.Response = recorder.body.Bytes()
+		}
+		if err := s.Db.CompleteIdempotencyRecord(rec); err != nil {
+			log.Printf("Failed to store response for idempotency key %q: %v", rec.Key, err)
+		}
+	}
+}
+
+// replayIdempotentResponse writes the stored response for a repeated
+// request, or an error if the stored request differs, is still running or
+// its response was not kept.
+func (s *Server) replayIdempotentResponse(w http.ResponseWriter, req tornjakTypes.IdempotencyRecord, keepResponse bool) {
+	stored, err := s.Db.GetIdempotencyRecord(req.Key, req.Path, req.Principal)
+	var getErr agentdb.GetError
+	if errors.As(err, &getErr) {
+		// released by a failed original request in the meantime
+		retError(w, "Error: request with this Idempotency-Key failed; retry the request", http.StatusConflict)
+		return
+	} else if err != nil {
+		retError(w, "Error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	switch {
+	case stored.RequestHash != req.RequestHash:
+		retError(w, "Error: Idempotency-Key was already used with a different request body", http.StatusUnprocessableEntity)
+	case stored.StatusCode == 0:
+		retError(w, "Error: request with this Idempotency-Key is still in progress", http.StatusConflict)
+	case !keepResponse:
+		retError(w, "Error: request with this Idempotency-Key already completed; its response holds a secret and is not kept", http.StatusConflict)
+	default:
+		corsHeaders(w)
+		if stored.ContentType != "" {
+			w.Header().Set("Content-Type", stored.ContentType)
+		}
+		if stored.Location != "" {
+			w.Header().Set("Location", stored.Location)
+		}
+		w.Header().Set(idempotencyReplayedHeader, "true")
+		w.WriteHeader(stored.StatusCode)
+		if _, err := w.Write(stored.Response); err != nil {
+			log.Printf("Failed to replay response for idempotency key %q: %v", req.Key, err)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	backoff "github.com/cenkalti/backoff/v4"
+
+	"github.com/spiffe/tornjak/pkg/agent/authentication/user"
+	agentdb "github.com/spiffe/tornjak/pkg/agent/db"
+	tornjakTypes "github.com/spiffe/tornjak/pkg/agent/types"
+)
+
+// newTestServer returns a server with a sqlite datastore in a temporary directory
+func newTestServer(t *testing.T) *Server {
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = time.Second
+	db, err := agentdb.NewLocalSqliteDB("sqlite3", filepath.Join(t.TempDir(), "tornjak.db"), expBackoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Server{Db: db}
+}
+
+func TestIdempotencyPrincipal(t *testing.T) {
+	s := newTestServer(t)
+	calls := 0
+	handler := s.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "cluster of "+idempotencyPrincipal(r))
+	})
+	alice := &user.UserInfo{Name: "alice", Authenticator: "Keycloak"}
+	mallory := &user.UserInfo{Name: "mallory", Authenticator: "Keycloak"}
+	aliceCert := &user.UserInfo{Name: "alice", Authenticator: "ClientCert"}
+
+	tests := []struct {
+		name      string
+		userInfo  *user.UserInfo
+		body      string
+		status    int
+		replayed  bool
+		wantCalls int
+	}{
+		{name: "first request", userInfo: alice, status: http.StatusCreated, wantCalls: 1},
+		{name: "repeat by the same caller", userInfo: alice, status: http.StatusCreated, replayed: true, wantCalls: 1},
+		{name: "repeat with another body", userInfo: alice, body: `{"name":"other"}`, status: http.StatusUnprocessableEntity, wantCalls: 1},
+		{name: "same key by another user", userInfo: mallory, status: http.StatusCreated, wantCalls: 2},
+		{name: "same key by the same name from another authenticator", userInfo: aliceCert, status: http.StatusCreated, wantCalls: 3},
+		{name: "same key without authentication", status: http.StatusCreated, wantCalls: 4},
+		{name: "repeat without authentication", status: http.StatusCreated, replayed: true, wantCalls: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := tt.body
+			if body == "" {
+				body = `{"name":"cluster1"}`
+			}
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/tornjak/clusters", strings.NewReader(body))
+			r.Header.Set(idempotencyKeyHeader, "key1")
+			if tt.userInfo != nil {
+				r = r.WithContext(withUserInfo(r.Context(), tt.userInfo))
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d: %s", tt.status, w.Code, w.Body.String())
+			}
+			if replayed := w.Header().Get(idempotencyReplayedHeader) == "true"; replayed != tt.replayed {
+				t.Fatalf("Expected replayed %t, got %t", tt.replayed, replayed)
+			}
+			if want := "cluster of " + idempotencyPrincipal(r); tt.status == http.StatusCreated && w.Body.String() != want {
+				t.Fatalf("Expected response %q, got %q", want, w.Body.String())
+			}
+			if calls != tt.wantCalls {
+				t.Fatalf("Expected the handler to have run %d times, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestIdempotencySecret(t *testing.T) {
+	s := newTestServer(t)
+	calls := 0
+	handler := s.idempotentSecret(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, `{"token":"secret join token"}`)
+	})
+	send := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/api/v2/jointokens", strings.NewReader(`{"ttl":600}`))
+		r.Header.Set(idempotencyKeyHeader, "key1")
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w
+	}
+
+	if w := send(); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "secret join token") {
+		t.Fatalf("Expected the token, got %d: %s", w.Code, w.Body.String())
+	}
+	stored, err := s.Db.GetIdempotencyRecord("key1", "/api/v2/jointokens", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.StatusCode != http.StatusOK || len(stored.Response) != 0 {
+		t.Fatalf("Expected the status without the response stored, got %+v", stored)
+	}
+
+	w := send()
+	if w.Code != http.StatusConflict || strings.Contains(w.Body.String(), "secret join token") {
+		t.Fatalf("Expected status 409 without the token, got %d: %s", w.Code, w.Body.String())
+	}
+	if calls != 1 {
+		t.Fatalf("Expected the handler to run once, got %d", calls)
+	}
+}
+
+func TestIdempotencyInProgressTimeout(t *testing.T) {
+	s := newTestServer(t)
+	calls := 0
+	handler := s.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	})
+	send := func() int {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/tornjak/clusters", strings.NewReader(`{"name":"cluster1"}`))
+		r.Header.Set(idempotencyKeyHeader, "key1")
+		w := httptest.NewRecorder()
+		handler(w, r)
+		return w.Code
+	}
+	// reserve the key as a request that never completed
+	reserve := func(age time.Duration) {
+		sum := sha256.Sum256([]byte(`{"name":"cluster1"}`))
+		err := s.Db.CreateIdempotencyRecord(tornjakTypes.IdempotencyRecord{
+			Key:         "key1",
+			Path:        "/api/v1/tornjak/clusters",
+			RequestHash: hex.EncodeToString(sum[:]),
+			CreatedAt:   time.Now().Add(-age).Unix(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reserve(time.Minute)
+	if status := send(); status != http.StatusConflict || calls != 0 {
+		t.Fatalf("Expected status 409 while in progress, got %d after %d calls", status, calls)
+	}
+	if err := s.Db.DeleteIdempotencyRecord("key1", "/api/v1/tornjak/clusters", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	reserve(idempotencyInProgressTimeout + time.Minute)
+	if status := send(); status != http.StatusCreated || calls != 1 {
+		t.Fatalf("Expected the stale reservation to expire, got %d after %d calls", status, calls)
+	}
+}
+
+func TestResponseRecordersFlush(t *testing.T) {
+	for name, wrap := range map[string]func(http.ResponseWriter) http.ResponseWriter{
+		"audit":       func(w http.ResponseWriter) http.ResponseWriter { return &auditRecorder{ResponseWriter: w} },
+		"idempotency": func(w http.ResponseWriter) http.ResponseWriter { return &idempotencyRecorder{ResponseWriter: w} },
+		"both": func(w http.ResponseWriter) http.ResponseWriter {
+			return &idempotencyRecorder{ResponseWriter: &auditRecorder{ResponseWriter: w}}
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			rec := wrap(w)
+			flusher, ok := rec.(http.Flusher)
+			if !ok {
+				t.Fatal("Recorder is not an http.Flusher")
+			}
+			io.WriteString(rec, "part")
+			flusher.Flush()
+			if !w.Flushed {
+				t.Fatal("Flush was not passed through")
+			}
+			if _, ok := rec.(interface{ Unwrap() http.ResponseWriter }); !ok {
+				t.Fatal("Recorder cannot be unwrapped by http.ResponseController")
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+/*
+
+Instances
+
+Several Tornjak servers can share a postgres or mysql datastore. Each one
+records its jobs and webhook deliveries under its instance ID and sends a
+heartbeat to the datastore. On start, an instance fails the unfinished jobs
+and resumes the pending deliveries of its own previous run; the work of
+instances whose heartbeat has stopped is failed, now and whenever an
+instance stops later.
+
+*/
+
+const (
+	// instanceHeartbeatInterval is how often an instance records that it is
+	// alive, checks for cancellation of its jobs and cleans up after
+	// instances no longer alive
+	instanceHeartbeatInterval = 10 * time.Second
+
+	// instanceTTL is how long after its last heartbeat an instance is no
+	// longer alive
+	instanceTTL = 6 * instanceHeartbeatInterval
+)
+
+// instanceID returns the configured instance ID, or the host name, which is
+// unique per pod and stable across restarts in a StatefulSet
+func (s *Server) instanceID() string {
+	if s.TornjakConfig != nil && s.TornjakConfig.Server != nil && s.TornjakConfig.Server.InstanceID != "" {
+		return s.TornjakConfig.Server.InstanceID
+	}
+	if hostname, err := os.Hostname(); err == nil && hostname != "" {
+		return hostname
+	}
+	// not stable across restarts: work left by a previous run is only
+	// cleaned up once its heartbeat expires
+	id, err := newRandomID()
+	if err != nil {
+		return "tornjak"
+	}
+	return id
+}
+
+// registerInstance sets the instance ID of the server and records it alive
+func (s *Server) registerInstance() error {
+	s.instance = s.instanceID()
+	return s.Db.HeartbeatInstance(s.instance, time.Now().Add(-instanceTTL))
+}
+
+// startInstanceHeartbeat periodically records the instance alive, cancels
+// its jobs canceled through other instances and fails the work left by
+// instances no longer alive
+func (s *Server) startInstanceHeartbeat() {
+	go func() {
+		ticker := time.NewTicker(instanceHeartbeatInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := s.Db.HeartbeatInstance(s.instance, time.Now().Add(-instanceTTL)); err != nil {
+				log.Printf("Failed to record instance heartbeat: %v", err)
+				continue
+			}
+			if s.jobs != nil {
+				s.jobs.cancelRequested()
+			}
+			s.failOrphanedWork()
+		}
+	}()
+}
+
+// failOrphanedWork fails the unfinished jobs and dead-letters the pending
+// webhook deliveries of instances no longer alive
+func (s *Server) failOrphanedWork() {
+	// no owner: only the work of instances no longer alive
+	if n, err := s.Db.FailUnfinishedJobs("", "job interrupted by Tornjak instance stop"); err != nil {
+		log.Printf("Failed to fail orphaned jobs: %v", err)
+	} else if n > 0 {
+		log.Printf("Marked %d orphaned jobs as failed", n)
+	}
+	if n, err := s.Db.DeadLetterPendingWebhookDeliveries("", "delivery interrupted by Tornjak instance stop"); err != nil {
+		log.Printf("Failed to dead-letter orphaned webhook deliveries: %v", err)
+	} else if n > 0 {
+		log.Printf("Moved %d orphaned webhook deliveries to dead letters", n)
+	}
+}
@@ -0,0 +1,482 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/spiffe/tornjak/pkg/agent/notifier"
+	tornjakTypes "github.com/spiffe/tornjak/pkg/agent/types"
+)
+
+/*
+
+Jobs
+
+Long-running bulk operations run as asynchronous jobs. Creating a job stores
+it as queued and returns its ID; a bounded pool of workers runs queued jobs
+and records their progress in the datastore. Jobs are owned by the Tornjak
+instance that created them; jobs left unfinished when an instance stops are
+marked failed on its next start, or once its heartbeat expires.
+
+*/
+
+const (
+	jobDeleteEntries    = "delete_entries"
+	jobBanClusterAgents = "ban_cluster_agents"
+
+	// defaultJobWorkers and defaultJobQueueSize are used when
+	// 'config > server > job_workers' and 'job_queue_size' are not set.
+	defaultJobWorkers   = 4
+	defaultJobQueueSize = 100
+
+	// jobBatchSize is the number of items processed between progress updates
+	jobBatchSize = 50
+)
+
+// errJobQueueFull is returned when a job cannot be queued
+var errJobQueueFull = errors.New("job queue is full; retry later")
+
+// jobRunner processes the items of a job, updating its progress as it goes.
+type jobRunner func(ctx context.Context, s *Server, job *tornjakTypes.JobInfo, progress func()) error
+
+var jobRunners = map[string]jobRunner{
+	jobDeleteEntries:    runDeleteEntriesJob,
+	jobBanClusterAgents: runBanClusterAgentsJob,
+}
+
+// activeJob tracks a queued or running job so that it can be canceled.
+// cancel is set once a worker starts the job.
+type activeJob struct {
+	cancel context.CancelFunc
+}
+
+// jobManager runs queued jobs in a bounded pool of workers.
+type jobManager struct {
+	s     *Server
+	queue chan string
+
+	mu     sync.Mutex
+	active map[string]*activeJob
+}
+
+// startJobs marks jobs left unfinished by a previous run as failed and
+// starts the job workers.
+func (s *Server) startJobs() error {
+	n, err := s.Db.FailUnfinishedJobs(s.instance, "job interrupted by server restart")
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("Marked %d unfinished jobs as failed", n)
+	}
+
+	workers, queueSize := defaultJobWorkers, defaultJobQueueSize
+	if s.TornjakConfig != nil && s.TornjakConfig.Server != nil {
+		if s.TornjakConfig.Server.JobWorkers > 0 {
+			workers = s.TornjakConfig.Server.JobWorkers
+		}
+		if s.TornjakConfig.Server.JobQueueSize > 0 {
+			queueSize = s.TornjakConfig.Server.JobQueueSize
+		}
+	}
+	m := &jobManager{
+		s:      s,
+		queue:  make(chan string, queueSize),
+		active: map[string]*activeJob{},
+	}
+	for i := 0; i < workers; i++ {
+		go m.work()
+	}
+	s.jobs = m
+	return nil
+}
+
+// submit stores a new job and queues it.  Returns an error if the queue is full.
+func (m *jobManager) submit(job tornjakTypes.JobInfo) error {
+	if err := m.s.Db.CreateJob(job); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.active[job.ID] = &activeJob{}
+	m.mu.Unlock()
+
+	select {
+	case m.queue <- job.ID:
+		return nil
+	default:
+		m.mu.Lock()
+		delete(m.active, job.ID)
+		m.mu.Unlock()
+		job.Status = tornjakTypes.JobFailed
+		job.Error = errJobQueueFull.Error()
+		job.UpdatedAt = time.Now()
+		if err := m.s.Db.UpdateJob(job); err != nil {
+			log.Printf("Failed to update job %s: %v", job.ID, err)
+		}
+		return errJobQueueFull
+	}
+}
+
+// cancel cancels a queued or running job.  Returns false if the job is not active.
+func (m *jobManager) cancel(id string) (bool, error) {
+	m.mu.Lock()
+	a, ok := m.active[id]
+	if !ok {
+		m.mu.Unlock()
+		return false, nil
+	}
+	started := a.cancel != nil
+	if started {
+		a.cancel()
+	} else {
+		delete(m.active, id)
+	}
+	m.mu.Unlock()
+
+	// running jobs are marked canceled by their worker
+	if started {
+		return true, nil
+	}
+	job, err := m.s.Db.GetJob(id)
+	if err != nil {
+		return true, err
+	}
+	job.Status = tornjakTypes.JobCanceled
+	job.UpdatedAt = time.Now()
+	return true, m.s.Db.UpdateJob(job)
+}
+
+// cancelRequested cancels the active jobs whose cancellation was requested
+// through another instance
+func (m *jobManager) cancelRequested() {
+	m.mu.Lock()
+	ids := make([]string, 0, len(m.active))
+	for id := range m.active {
+		ids = append(ids, id)
+	}
+	m.mu.Unlock()
+
+	for _, id := range ids {
+		job, err := m.s.Db.GetJob(id)
+		if err != nil || !job.CancelRequested {
+			continue
+		}
+		if _, err := m.cancel(id); err != nil {
+			log.Printf("Failed to cancel job %s: %v", id, err)
+		}
+	}
+}
+
+func (m *jobManager) work() {
+	for id := range m.queue {
+		m.mu.Lock()
+		a, ok := m.active[id]
+		if !ok { // canceled while queued
+			m.mu.Unlock()
+			continue
+		}
+		// events from the job are attributed to it
+		ctx, cancel := context.WithCancel(withActor(context.Background(), notifier.Actor{Job: id}))
+		a.cancel = cancel
+		m.mu.Unlock()
+
+		m.run(ctx, id)
+
+		cancel()
+		m.mu.Lock()
+		delete(m.active, id)
+		m.mu.Unlock()
+	}
+}
+
+func (m *jobManager) run(ctx context.Context, id string) {
+	job, err := m.s.Db.GetJob(id)
+	if err != nil {
+		log.Printf("Failed to load job %s: %v", id, err)
+		return
+	}
+	save := func() {
+		job.UpdatedAt = time.Now()
+		if err := m.s.Db.UpdateJob(job); err != nil {
+			log.Printf("Failed to update job %s: %v", id, err)
+		}
+	}
+
+	job.Status = tornjakTypes.JobRunning
+	save()
+
+	// the status follows from the runner: a job that completed, or failed
+	// for another reason, as it was canceled is not reported canceled
+	err = jobRunners[job.Type](ctx, m.s, &job, save)
+	switch {
+	case errors.Is(err, context.Canceled):
+		job.Status = tornjakTypes.JobCanceled
+	case err != nil:
+		job.Status = tornjakTypes.JobFailed
+		job.Error = err.Error()
+	default:
+		job.Status = tornjakTypes.JobSucceeded
+	}
+	save()
+}
+
+type deleteEntriesJobParams struct {
+	IDs []string `json:"ids"`
+}
+
+// runDeleteEntriesJob deletes entries by ID in batches
+func runDeleteEntriesJob(ctx context.Context, s *Server, job *tornjakTypes.JobInfo, progress func()) error {
+	var params deleteEntriesJobParams
+	if err := json.Unmarshal(job.Params, &params); err != nil {
+		return err
+	}
+	job.Total = len(params.IDs)
+	for start := 0; start < len(params.IDs); start += jobBatchSize {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		ids := params.IDs[start:min(start+jobBatchSize, len(params.IDs))]
+		ret, err := s.deleteEntries(ctx, &BatchDeleteEntryRequest{Ids: ids})
+		for k, id := range ids {
+			msg := ""
+			switch {
+			case err != nil:
+				msg = err.Error()
+			case k >= len(ret.Results):
+				msg = "no result returned by SPIRE"
+			case ret.Results[k].GetStatus().GetCode() != 0:
+				msg = ret.Results[k].GetStatus().GetMessage()
+			}
+			if msg != "" {
+				job.Failed++
+				job.Failures = append(job.Failures, tornjakTypes.JobFailure{Item: id, Error: msg})
+			}
+		}
+		job.Processed += len(ids)
+		progress()
+	}
+	return nil
+}
+
+type banClusterAgentsJobParams struct {
+	Cluster string `json:"cluster"`
+}
+
+// runBanClusterAgentsJob bans every agent assigned to a cluster
+func runBanClusterAgentsJob(ctx context.Context, s *Server, job *tornjakTypes.JobInfo, progress func()) error {
+	var params banClusterAgentsJobParams
+	if err := json.Unmarshal(job.Params, &params); err != nil {
+		return err
+	}
+	agents, err := s.Db.GetClusterAgents(params.Cluster)
+	if err != nil {
+		return err
+	}
+	job.Total = len(agents)
+	for i, spiffeid := range agents {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		id, err := parseSPIFFEID(spiffeid)
+		if err == nil {
+			err = s.banAgent(ctx, &BanAgentRequest{Id: id})
+		}
+		if err != nil {
+			job.Failed++
+			job.Failures = append(job.Failures, tornjakTypes.JobFailure{Item: spiffeid, Error: err.Error()})
+		}
+		job.Processed++
+		if (i+1)%jobBatchSize == 0 {
+			progress()
+		}
+	}
+	return nil
+}
+
+// validateJobParams checks the parameters of a new job
+func validateJobParams(jobType string, params json.RawMessage) error {
+	var err error
+	dec := json.NewDecoder(bytes.NewReader(params))
+	dec.DisallowUnknownFields()
+	switch jobType {
+	case jobDeleteEntries:
+		var p deleteEntriesJobParams
+		if err = dec.Decode(&p); err == nil && len(p.IDs) == 0 {
+			err = errors.New("missing mandatory field - ids")
+		}
+	case jobBanClusterAgents:
+		var p banClusterAgentsJobParams
+		if err = dec.Decode(&p); err == nil && len(p.Cluster) == 0 {
+			err = errors.New("missing mandatory field - cluster")
+		}
+	default:
+		return fmt.Errorf("unknown job type %q", jobType)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid params for job type %s: %v", jobType, err)
+	}
+	return nil
+}
+
+// newRandomID returns a random 128-bit hex ID
+func newRandomID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+type CreateJobRequest struct {
+	Type   string          `json:"type"`
+	Params json.RawMessage `json:"params"`
+}
+
+// CreateJob stores and queues a new job
+func (s *Server) CreateJob(inp CreateJobRequest) (*tornjakTypes.JobInfo, error) {
+	if err := validateJobParams(inp.Type, inp.Params); err != nil {
+		return nil, err
+	}
+	id, err := newRandomID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	job := tornjakTypes.JobInfo{
+		ID:        id,
+		Type:      inp.Type,
+		Status:    tornjakTypes.JobQueued,
+		Params:    inp.Params,
+		Failures:  []tornjakTypes.JobFailure{},
+		CreatedAt: now,
+		UpdatedAt: now,
+		Owner:     s.instance,
+	}
+	if err := s.jobs.submit(job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// jobsEnabled reports whether jobs are enabled, responding with 503 if not
+func (s *Server) jobsEnabled(w http.ResponseWriter) bool {
+	if s.Db == nil || s.jobs == nil {
+		retError(w, "Error: jobs are not enabled", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
+// jobCreate creates an asynchronous job and returns it with 202 Accepted
+func (s *Server) jobCreate(w http.ResponseWriter, r *http.Request) {
+	if !s.jobsEnabled(w) {
+		return
+	}
+	var input CreateJobRequest
+	if _, err := readRequestJSON(r, &input); err != nil {
+		retRequestError(w, err)
+		return
+	}
+
+	job, err := s.CreateJob(input)
+	if errors.Is(err, errJobQueueFull) {
+		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusServiceUnavailable)
+		return
+	} else if err != nil {
+		retTornjakError(w, err)
+		return
+	}
+
+	w.Header().Set("Location", "/api/v1/tornjak/jobs/"+job.ID)
+	if err := writeResponseJSONStatus(w, http.StatusAccepted, job); err != nil {
+		log.Printf("Failed to write job response: %v", err)
+	}
+}
+
+// jobList lists jobs, most recent first
+func (s *Server) jobList(w http.ResponseWriter, r *http.Request) {
+	if !s.jobsEnabled(w) {
+		return
+	}
+	ret, err := s.Db.GetJobs()
+	if err != nil {
+		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
+		return
+	}
+	if err := writeResponseJSON(w, r, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// jobGet returns a job with its progress
+func (s *Server) jobGet(w http.ResponseWriter, r *http.Request) {
+	if !s.jobsEnabled(w) {
+		return
+	}
+	id, err := pathVar(r, "id")
+	if err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ret, err := s.Db.GetJob(id)
+	if err != nil {
+		retTornjakError(w, err)
+		return
+	}
+	if err := writeResponseJSON(w, r, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// jobCancel cancels a queued or running job. Returns 409 if the job has finished.
+// Jobs of other instances are canceled by their owner, on its next heartbeat.
+func (s *Server) jobCancel(w http.ResponseWriter, r *http.Request) {
+	if !s.jobsEnabled(w) {
+		return
+	}
+	id, err := pathVar(r, "id")
+	if err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	job, err := s.Db.GetJob(id)
+	if err != nil {
+		retTornjakError(w, err)
+		return
+	}
+	if job.Owner != s.instance {
+		// PostFailure, 409, if the job has finished
+		if err := s.Db.RequestJobCancel(id); err != nil {
+			retTornjakError(w, err)
+			return
+		}
+	} else {
+		canceled, err := s.jobs.cancel(id)
+		if err != nil {
+			retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
+			return
+		}
+		if !canceled {
+			retError(w, fmt.Sprintf("Error: job %s has already finished", id), http.StatusConflict)
+			return
+		}
+	}
+
+	ret, err := s.Db.GetJob(id)
+	if err != nil {
+		retTornjakError(w, err)
+		return
+	}
+	if err := writeResponseJSONStatus(w, http.StatusAccepted, ret); err != nil {
+		log.Printf("Failed to write job response: %v", err)
+	}
+}
@@ -0,0 +1,259 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	agentdb "github.com/spiffe/tornjak/pkg/agent/db"
+	tornjakTypes "github.com/spiffe/tornjak/pkg/agent/types"
+)
+
+func TestJobsNotEnabled(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler func(*Server) http.HandlerFunc
+		method  string
+		body    string
+	}{
+		{name: "create", handler: func(s *Server) http.HandlerFunc { return s.jobCreate }, method: http.MethodPost, body: `{"type":"delete_entries"}`},
+		{name: "list", handler: func(s *Server) http.HandlerFunc { return s.jobList }, method: http.MethodGet},
+		{name: "get", handler: func(s *Server) http.HandlerFunc { return s.jobGet }, method: http.MethodGet},
+		{name: "cancel", handler: func(s *Server) http.HandlerFunc { return s.jobCancel }, method: http.MethodPost},
+	}
+	for _, tt := range tests {
+		for _, s := range []*Server{{}, newTestServer(t)} {
+			t.Run(tt.name, func(t *testing.T) {
+				r := httptest.NewRequest(tt.method, "/api/v1/tornjak/jobs/job1", strings.NewReader(tt.body))
+				w := httptest.NewRecorder()
+				tt.handler(s)(w, mux.SetURLVars(r, map[string]string{"id": "job1"}))
+				if w.Code != http.StatusServiceUnavailable {
+					t.Fatalf("Expected status 503, got %d: %s", w.Code, w.Body.String())
+				}
+			})
+		}
+	}
+}
+
+const jobTest = "test"
+
+// useTestJobRunner runs jobs of type "test" until the test sends how they
+// end on the returned channel. Started jobs are reported on started.
+func useTestJobRunner(t *testing.T) (finish chan func(context.Context) error, started chan string) {
+	finish = make(chan func(context.Context) error)
+	started = make(chan string, 10)
+	jobRunners[jobTest] = func(ctx context.Context, s *Server, job *tornjakTypes.JobInfo, progress func()) error {
+		started <- job.ID
+		job.Total = 1
+		err := (<-finish)(ctx)
+		job.Processed = 1
+		return err
+	}
+	t.Cleanup(func() { delete(jobRunners, jobTest) })
+	return finish, started
+}
+
+// newTestJobServer returns a server of the instance running jobs in the
+// given number of workers
+func newTestJobServer(t *testing.T, db agentdb.AgentDB, instance string, workers int) *Server {
+	s := &Server{Db: db, instance: instance, TornjakConfig: &TornjakConfig{Server: &serverConfig{JobWorkers: workers}}}
+	if err := s.Db.HeartbeatInstance(s.instance, time.Now().Add(-instanceTTL)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.startJobs(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// submitTestJob queues a job of type "test" on s
+func submitTestJob(t *testing.T, s *Server, id string) {
+	now := time.Now()
+	err := s.jobs.submit(tornjakTypes.JobInfo{ID: id, Type: jobTest, Status: tornjakTypes.JobQueued,
+		Params: json.RawMessage(`{}`), Failures: []tornjakTypes.JobFailure{}, CreatedAt: now, UpdatedAt: now, Owner: s.instance})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// waitJob waits until the job has finished and returns it
+func waitJob(t *testing.T, s *Server, id string) tornjakTypes.JobInfo {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := s.Db.GetJob(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.Status != tornjakTypes.JobQueued && job.Status != tornjakTypes.JobRunning {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Job %s did not finish, status %s", id, job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// cancelJob cancels the job through the API of s and returns the response
+func cancelJob(s *Server, id string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/tornjak/jobs/"+id+"/cancel", nil)
+	w := httptest.NewRecorder()
+	s.jobCancel(w, mux.SetURLVars(r, map[string]string{"id": id}))
+	return w
+}
+
+func TestJobCompletes(t *testing.T) {
+	s := newTestJobServer(t, newTestServer(t).Db, "tornjak-0", 1)
+	if err := s.DefineCluster(RegisterClusterRequest{ClusterInstance: tornjakTypes.ClusterInfo{Name: "cluster1", PlatformType: "Kubernetes"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/tornjak/jobs", strings.NewReader(`{"type":"ban_cluster_agents","params":{"cluster":"cluster1"}}`))
+	w := httptest.NewRecorder()
+	s.jobCreate(w, r)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var created tornjakTypes.JobInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Status != tornjakTypes.JobQueued || w.Header().Get("Location") != "/api/v1/tornjak/jobs/"+created.ID {
+		t.Fatalf("Unexpected created job %+v at %q", created, w.Header().Get("Location"))
+	}
+
+	job := waitJob(t, s, created.ID)
+	if job.Status != tornjakTypes.JobSucceeded || job.Error != "" || job.Owner != "tornjak-0" {
+		t.Fatalf("Expected the job to succeed, got %+v", job)
+	}
+	if w := cancelJob(s, job.ID); w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409 canceling a finished job, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestJobRun(t *testing.T) {
+	errSPIRE := errors.New("SPIRE unavailable")
+	tests := []struct {
+		name       string
+		cancel     bool
+		finish     func(context.Context) error
+		wantStatus string
+		wantError  string
+	}{
+		{name: "succeeds", finish: func(context.Context) error { return nil }, wantStatus: tornjakTypes.JobSucceeded},
+		{name: "fails", finish: func(context.Context) error { return errSPIRE }, wantStatus: tornjakTypes.JobFailed, wantError: errSPIRE.Error()},
+		{
+			name:   "canceled while running",
+			cancel: true,
+			finish: func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+			wantStatus: tornjakTypes.JobCanceled,
+		},
+		{name: "completes as canceled", cancel: true, finish: func(context.Context) error { return nil }, wantStatus: tornjakTypes.JobSucceeded},
+		{name: "fails as canceled", cancel: true, finish: func(context.Context) error { return errSPIRE }, wantStatus: tornjakTypes.JobFailed, wantError: errSPIRE.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			finish, started := useTestJobRunner(t)
+			s := newTestJobServer(t, newTestServer(t).Db, "tornjak-0", 1)
+			submitTestJob(t, s, "job1")
+			<-started
+
+			if tt.cancel {
+				if w := cancelJob(s, "job1"); w.Code != http.StatusAccepted {
+					t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+				}
+			}
+			finish <- tt.finish
+
+			job := waitJob(t, s, "job1")
+			if job.Status != tt.wantStatus || job.Error != tt.wantError {
+				t.Fatalf("Expected status %s with error %q, got %s with %q", tt.wantStatus, tt.wantError, job.Status, job.Error)
+			}
+			if job.Processed != 1 {
+				t.Fatalf("Expected the progress of the runner saved, got %+v", job)
+			}
+		})
+	}
+}
+
+func TestJobCancelQueued(t *testing.T) {
+	finish, started := useTestJobRunner(t)
+	s := newTestJobServer(t, newTestServer(t).Db, "tornjak-0", 1)
+	// job1 takes the only worker, so job2 stays queued
+	submitTestJob(t, s, "job1")
+	<-started
+	submitTestJob(t, s, "job2")
+
+	w := cancelJob(s, "job2")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var canceled tornjakTypes.JobInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &canceled); err != nil {
+		t.Fatal(err)
+	}
+	if canceled.Status != tornjakTypes.JobCanceled {
+		t.Fatalf("Expected the queued job canceled at once, got %+v", canceled)
+	}
+
+	// job3 starts only once the worker has skipped job2
+	submitTestJob(t, s, "job3")
+	finish <- func(context.Context) error { return nil }
+	if id := <-started; id != "job3" {
+		t.Fatalf("Expected job3 to run, got %s", id)
+	}
+	finish <- func(context.Context) error { return nil }
+
+	for id, want := range map[string]string{"job1": tornjakTypes.JobSucceeded, "job2": tornjakTypes.JobCanceled, "job3": tornjakTypes.JobSucceeded} {
+		if job := waitJob(t, s, id); job.Status != want {
+			t.Fatalf("Expected %s %s, got %+v", id, want, job)
+		}
+	}
+}
+
+func TestJobCancelOtherInstance(t *testing.T) {
+	finish, started := useTestJobRunner(t)
+	db := newTestServer(t).Db
+	owner := newTestJobServer(t, db, "tornjak-0", 1)
+	other := newTestJobServer(t, db, "tornjak-1", 1)
+	submitTestJob(t, owner, "job1")
+	<-started
+
+	w := cancelJob(other, "job1")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var requested tornjakTypes.JobInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &requested); err != nil {
+		t.Fatal(err)
+	}
+	if requested.Status != tornjakTypes.JobRunning || !requested.CancelRequested {
+		t.Fatalf("Expected the cancellation requested of the running job, got %+v", requested)
+	}
+
+	// the other instance does not cancel the job itself; its owner does on
+	// its next heartbeat
+	other.jobs.cancelRequested()
+	owner.jobs.cancelRequested()
+	finish <- func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	if job := waitJob(t, owner, "job1"); job.Status != tornjakTypes.JobCanceled {
+		t.Fatalf("Expected the job canceled, got %+v", job)
+	}
+
+	if w := cancelJob(other, "job1"); w.Code != http.StatusConflict {
+		t.Fatalf("Expected status 409 canceling a finished job, got %d: %s", w.Code, w.Body.String())
+	}
+}
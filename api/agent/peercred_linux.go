@@ -0,0 +1,30 @@
+//go:build linux
+
+package api
+
+import (
+	"net"
+	"syscall"
+
+	"github.com/spiffe/tornjak/pkg/agent/authentication/user"
+)
+
+// getPeerCredentials reads SO_PEERCRED of a unix socket connection
+func getPeerCredentials(conn *net.UnixConn) (*user.PeerCredentials, error) {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return nil, err
+	}
+	var ucred *syscall.Ucred
+	var credErr error
+	err = raw.Control(func(fd uintptr) {
+		ucred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if credErr != nil {
+		return nil, credErr
+	}
+	return &user.PeerCredentials{PID: ucred.Pid, UID: ucred.Uid, GID: ucred.Gid}, nil
+}
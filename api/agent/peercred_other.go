@@ -0,0 +1,15 @@
+//go:build !linux
+
+package api
+
+import (
+	"errors"
+	"net"
+
+	"github.com/spiffe/tornjak/pkg/agent/authentication/user"
+)
+
+// getPeerCredentials is only supported on Linux
+func getPeerCredentials(conn *net.UnixConn) (*user.PeerCredentials, error) {
+	return nil, errors.New("peer credentials are not supported on this platform")
+}
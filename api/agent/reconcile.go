@@ -0,0 +1,265 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+
+	tornjakTypes "github.com/spiffe/tornjak/pkg/agent/types"
+)
+
+const (
+	// defaultReconcileInterval is how often agents are reconciled when
+	// 'config > server > reconcile > interval' is not set.
+	defaultReconcileInterval = 10 * time.Minute
+
+	// reconcilePageSize is the number of agents listed from SPIRE at a time
+	reconcilePageSize = 500
+)
+
+// Policies of 'config > server > reconcile > orphans', applied to agents
+// known to Tornjak but no longer to SPIRE
+const (
+	reconcileOrphansFlag  = "flag"
+	reconcileOrphansPrune = "prune"
+)
+
+// defaultAttestationPlugins infers the workload attestor plugin of agents
+// from their node attestation type, for agents registered by reconciliation
+var defaultAttestationPlugins = map[string]string{
+	"k8s_sat":  "Kubernetes",
+	"k8s_psat": "Kubernetes",
+}
+
+// ReconcileStatus reports the agent reconciliation settings and the
+// outcome of its last run, served on /api/v1/tornjak/reconcile
+type ReconcileStatus struct {
+	Enabled      bool   `json:"enabled"`
+	Interval     string `json:"interval,omitempty"`
+	Orphans      string `json:"orphans,omitempty"`
+	AutoRegister bool   `json:"autoRegister"`
+	// LastRun is nil until the first run has finished
+	LastRun *ReconcileReport `json:"lastRun"`
+}
+
+// ReconcileReport is the difference found between the agents of SPIRE and
+// those of the Tornjak datastore by a reconciliation run, and what was done
+// about it
+type ReconcileReport struct {
+	StartedAt     time.Time `json:"startedAt"`
+	FinishedAt    time.Time `json:"finishedAt"`
+	SpireAgents   int       `json:"spireAgents"`
+	TornjakAgents int       `json:"tornjakAgents"`
+	// Orphans are known to Tornjak but not to SPIRE; Pruned are the orphans
+	// removed from the datastore, as set by 'agent_cleanup'
+	Orphans []string `json:"orphans"`
+	Pruned  []string `json:"pruned"`
+	// Untracked are known to SPIRE but not to Tornjak; Registered are the
+	// untracked agents added to the datastore with an inferred plugin
+	Untracked  []string                 `json:"untracked"`
+	Registered []tornjakTypes.AgentInfo `json:"registered"`
+	// Errors are the failures of the run; if SPIRE or the datastore could
+	// not be listed, nothing is compared nor changed
+	Errors []string `json:"errors,omitempty"`
+}
+
+// reconciler serializes reconciliation runs and keeps the last report
+type reconciler struct {
+	run  sync.Mutex
+	mu   sync.Mutex
+	last *ReconcileReport
+
+	// listAgents lists the agents of SPIRE; Server.ListAgents if nil
+	listAgents func(*ListAgentsRequest) (*ListAgentsResponse, error)
+}
+
+// reconcileConfig returns the configured reconciliation, nil if disabled
+func (s *Server) reconcileConfig() *ReconcileConfig {
+	if s.Db == nil || s.TornjakConfig == nil || s.TornjakConfig.Server == nil {
+		return nil
+	}
+	return s.TornjakConfig.Server.ReconcileConfig
+}
+
+// reconcileInterval returns the configured reconciliation interval, or the
+// default if unset
+func (s *Server) reconcileInterval() time.Duration {
+	if config := s.reconcileConfig(); config != nil {
+		// validated in VerifyConfiguration
+		if d, err := time.ParseDuration(config.Interval); err == nil {
+			return d
+		}
+	}
+	return defaultReconcileInterval
+}
+
+// reconcileOrphans returns the configured orphan policy, or flag if unset
+func (s *Server) reconcileOrphans() string {
+	if config := s.reconcileConfig(); config != nil && config.Orphans != "" {
+		return config.Orphans
+	}
+	return reconcileOrphansFlag
+}
+
+// attestationPlugin returns the plugin inferred for agents attested with
+// attestationType, "" if none
+func (s *Server) attestationPlugin(attestationType string) string {
+	if config := s.reconcileConfig(); config != nil {
+		if plugin, ok := config.Plugins[attestationType]; ok {
+			return plugin
+		}
+	}
+	return defaultAttestationPlugins[attestationType]
+}
+
+// startReconcile reconciles agents now and then periodically
+func (s *Server) startReconcile() {
+	go func() {
+		s.reconcileAgents()
+		ticker := time.NewTicker(s.reconcileInterval())
+		defer ticker.Stop()
+		for range ticker.C {
+			s.reconcileAgents()
+		}
+	}()
+}
+
+// reconcileAgents compares the agents of SPIRE with those of the datastore,
+// flags or prunes orphans and registers untracked agents, as configured,
+// and keeps the report of the run
+func (s *Server) reconcileAgents() *ReconcileReport {
+	s.reconcile.run.Lock()
+	defer s.reconcile.run.Unlock()
+
+	report := &ReconcileReport{
+		StartedAt:  time.Now().UTC(),
+		Orphans:    []string{},
+		Pruned:     []string{},
+		Untracked:  []string{},
+		Registered: []tornjakTypes.AgentInfo{},
+	}
+	defer func() {
+		report.FinishedAt = time.Now().UTC()
+		s.reconcile.mu.Lock()
+		s.reconcile.last = report
+		s.reconcile.mu.Unlock()
+		if len(report.Orphans) > 0 || len(report.Untracked) > 0 || len(report.Errors) > 0 {
+			log.Printf("Agent reconciliation: %d orphans (%d pruned), %d untracked (%d registered), %d errors",
+				len(report.Orphans), len(report.Pruned), len(report.Untracked), len(report.Registered), len(report.Errors))
+		}
+	}()
+
+	spireAgents, err := s.listAllAgents()
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("Could not list SPIRE agents: %v", err))
+		return report
+	}
+	tornjakAgents, err := s.Db.GetAgentsMetadata(tornjakTypes.AgentMetadataRequest{})
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("Could not list Tornjak agents: %v", err))
+		return report
+	}
+
+	known := map[string]bool{}
+	for _, a := range tornjakAgents.Agents {
+		known[a.Spiffeid] = true
+	}
+	report.SpireAgents, report.TornjakAgents = len(spireAgents), len(known)
+
+	for id := range known {
+		if _, ok := spireAgents[id]; !ok {
+			report.Orphans = append(report.Orphans, id)
+		}
+	}
+	sort.Strings(report.Orphans)
+	if s.reconcileOrphans() == reconcileOrphansPrune {
+		for _, id := range report.Orphans {
+			if err := s.cleanupAgent(id, tornjakTypes.AgentTombstoneOrphaned); err != nil {
+				report.Errors = append(report.Errors, err.Error())
+				continue
+			}
+			report.Pruned = append(report.Pruned, id)
+		}
+	}
+
+	for id := range spireAgents {
+		if !known[id] {
+			report.Untracked = append(report.Untracked, id)
+		}
+	}
+	sort.Strings(report.Untracked)
+	if config := s.reconcileConfig(); config != nil && config.AutoRegister {
+		for _, id := range report.Untracked {
+			plugin := s.attestationPlugin(spireAgents[id])
+			if plugin == "" {
+				continue
+			}
+			ainfo := tornjakTypes.AgentInfo{Spiffeid: id, Plugin: plugin}
+			if err := s.Db.CreateAgentEntry(ainfo); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("Could not register agent %s: %v", id, err))
+				continue
+			}
+			report.Registered = append(report.Registered, ainfo)
+		}
+	}
+	return report
+}
+
+// listAllAgents pages through the agents of SPIRE and returns their
+// attestation type by SPIFFE ID
+func (s *Server) listAllAgents() (map[string]string, error) {
+	listAgents := s.ListAgents
+	if s.reconcile.listAgents != nil {
+		listAgents = s.reconcile.listAgents
+	}
+	agents := map[string]string{}
+	req := &ListAgentsRequest{
+		PageSize:   reconcilePageSize,
+		OutputMask: &types.AgentMask{AttestationType: true},
+	}
+	for {
+		resp, err := listAgents(req)
+		if err != nil {
+			return nil, err
+		}
+		for _, a := range resp.Agents {
+			agents[spiffeIDToString(a.Id)] = a.AttestationType
+		}
+		if resp.NextPageToken == "" {
+			return agents, nil
+		}
+		req = &ListAgentsRequest{
+			PageSize:   reconcilePageSize,
+			PageToken:  resp.NextPageToken,
+			OutputMask: req.OutputMask,
+		}
+	}
+}
+
+// reconcileStatus returns the reconciliation settings and last report
+func (s *Server) reconcileStatus() ReconcileStatus {
+	status := ReconcileStatus{}
+	if config := s.reconcileConfig(); config != nil {
+		status.Enabled = true
+		status.Interval = s.reconcileInterval().String()
+		status.Orphans = s.reconcileOrphans()
+		status.AutoRegister = config.AutoRegister
+	}
+	s.reconcile.mu.Lock()
+	defer s.reconcile.mu.Unlock()
+	status.LastRun = s.reconcile.last
+	return status
+}
+
+// reconcileGet returns the agent reconciliation settings and the difference
+// found by its last run.
+func (s *Server) reconcileGet(w http.ResponseWriter, r *http.Request) {
+	if err := writeResponseJSON(w, r, s.reconcileStatus()); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+
+	tornjakTypes "github.com/spiffe/tornjak/pkg/agent/types"
+)
+
+// fakeAgentLister lists agents, given as attestation type by path in the
+// trust domain example.org, one per page as SPIRE would with a small page size
+func fakeAgentLister(agents map[string]string, order []string) func(*ListAgentsRequest) (*ListAgentsResponse, error) {
+	return func(req *ListAgentsRequest) (*ListAgentsResponse, error) {
+		page := 0
+		if req.PageToken != "" {
+			var err error
+			if page, err = strconv.Atoi(req.PageToken); err != nil {
+				return nil, err
+			}
+		}
+		resp := &ListAgentsResponse{}
+		if page < len(order) {
+			path := order[page]
+			resp.Agents = []*types.Agent{{
+				Id:              &types.SPIFFEID{TrustDomain: "example.org", Path: path},
+				AttestationType: agents[path],
+			}}
+		}
+		if page+1 < len(order) {
+			resp.NextPageToken = strconv.Itoa(page + 1)
+		}
+		return resp, nil
+	}
+}
+
+func TestReconcileAgents(t *testing.T) {
+	const (
+		agent1 = "spiffe://example.org/agent1"
+		agent2 = "spiffe://example.org/agent2"
+		agent3 = "spiffe://example.org/agent3"
+		agent4 = "spiffe://example.org/agent4"
+		agent5 = "spiffe://example.org/agent5"
+	)
+	// SPIRE knows agent1, agent3, agent4 and agent5; Tornjak knows agent1
+	// and agent2
+	spireAgents := map[string]string{
+		"/agent1": "k8s_psat",
+		"/agent3": "k8s_psat",
+		"/agent4": "join_token",
+		"/agent5": "x509pop",
+	}
+	spireOrder := []string{"/agent1", "/agent3", "/agent4", "/agent5"}
+
+	tests := []struct {
+		name           string
+		config         ReconcileConfig
+		agentCleanup   string
+		listErr        error
+		wantReport     ReconcileReport
+		wantAgents     []string
+		wantTombstones []string
+	}{
+		{
+			name:   "flag",
+			config: ReconcileConfig{},
+			wantReport: ReconcileReport{
+				SpireAgents: 4, TornjakAgents: 2,
+				Orphans: []string{agent2}, Pruned: []string{},
+				Untracked: []string{agent3, agent4, agent5}, Registered: []tornjakTypes.AgentInfo{},
+			},
+			wantAgents: []string{agent1, agent2},
+		},
+		{
+			name:   "prune",
+			config: ReconcileConfig{Orphans: reconcileOrphansPrune},
+			wantReport: ReconcileReport{
+				SpireAgents: 4, TornjakAgents: 2,
+				Orphans: []string{agent2}, Pruned: []string{agent2},
+				Untracked: []string{agent3, agent4, agent5}, Registered: []tornjakTypes.AgentInfo{},
+			},
+			wantAgents: []string{agent1},
+		},
+		{
+			name:         "prune to tombstone",
+			config:       ReconcileConfig{Orphans: reconcileOrphansPrune},
+			agentCleanup: agentCleanupTombstone,
+			wantReport: ReconcileReport{
+				SpireAgents: 4, TornjakAgents: 2,
+				Orphans: []string{agent2}, Pruned: []string{agent2},
+				Untracked: []string{agent3, agent4, agent5}, Registered: []tornjakTypes.AgentInfo{},
+			},
+			wantAgents:     []string{agent1},
+			wantTombstones: []string{agent2},
+		},
+		{
+			name:   "auto register",
+			config: ReconcileConfig{AutoRegister: true, Plugins: map[string]string{"x509pop": "Docker"}},
+			wantReport: ReconcileReport{
+				SpireAgents: 4, TornjakAgents: 2,
+				Orphans: []string{agent2}, Pruned: []string{},
+				// agent4 has no plugin for its attestation type
+				Untracked: []string{agent3, agent4, agent5},
+				Registered: []tornjakTypes.AgentInfo{
+					{Spiffeid: agent3, Plugin: "Kubernetes"},
+					{Spiffeid: agent5, Plugin: "Docker"},
+				},
+			},
+			wantAgents: []string{agent1, agent2, agent3, agent5},
+		},
+		{
+			name:    "SPIRE unavailable",
+			config:  ReconcileConfig{Orphans: reconcileOrphansPrune, AutoRegister: true},
+			listErr: errors.New("connection refused"),
+			wantReport: ReconcileReport{
+				Orphans: []string{}, Pruned: []string{},
+				Untracked: []string{}, Registered: []tornjakTypes.AgentInfo{},
+				Errors: []string{"Could not list SPIRE agents: connection refused"},
+			},
+			wantAgents: []string{agent1, agent2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestServer(t)
+			config := tt.config
+			s.TornjakConfig = &TornjakConfig{Server: &serverConfig{ReconcileConfig: &config, AgentCleanup: tt.agentCleanup}}
+			s.reconcile.listAgents = fakeAgentLister(spireAgents, spireOrder)
+			if tt.listErr != nil {
+				s.reconcile.listAgents = func(*ListAgentsRequest) (*ListAgentsResponse, error) {
+					return nil, tt.listErr
+				}
+			}
+			for _, id := range []string{agent1, agent2} {
+				if err := s.Db.CreateAgentEntry(tornjakTypes.AgentInfo{Spiffeid: id, Plugin: "Kubernetes"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			report := s.reconcileAgents()
+			if report.StartedAt.IsZero() || report.FinishedAt.Before(report.StartedAt) {
+				t.Fatalf("Unexpected run times %v to %v", report.StartedAt, report.FinishedAt)
+			}
+			report.StartedAt, report.FinishedAt = tt.wantReport.StartedAt, tt.wantReport.FinishedAt
+			if !reflect.DeepEqual(*report, tt.wantReport) {
+				t.Fatalf("Expected report %+v, got %+v", tt.wantReport, *report)
+			}
+			if status := s.reconcileStatus(); !status.Enabled || status.LastRun != report {
+				t.Fatalf("Expected status to report the last run, got %+v", status)
+			}
+
+			aList, err := s.Db.GetAgentsMetadata(tornjakTypes.AgentMetadataRequest{IncludeTombstones: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			agents, tombstones := []string{}, []string{}
+			for _, a := range aList.Agents {
+				if a.TombstonedAt != nil {
+					if a.TombstoneReason != tornjakTypes.AgentTombstoneOrphaned {
+						t.Fatalf("Expected tombstone reason %q, got %q", tornjakTypes.AgentTombstoneOrphaned, a.TombstoneReason)
+					}
+					tombstones = append(tombstones, a.Spiffeid)
+					continue
+				}
+				agents = append(agents, a.Spiffeid)
+			}
+			sort.Strings(agents)
+			if strings.Join(agents, ",") != strings.Join(tt.wantAgents, ",") {
+				t.Fatalf("Expected agents %v, got %v", tt.wantAgents, agents)
+			}
+			if strings.Join(tombstones, ",") != strings.Join(tt.wantTombstones, ",") {
+				t.Fatalf("Expected tombstones %v, got %v", tt.wantTombstones, tombstones)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/pkg/errors"
+
+	"github.com/spiffe/tornjak/pkg/agent/authentication/authenticator"
+	"github.com/spiffe/tornjak/pkg/agent/authorization"
+)
+
+// configPollInterval is how often the config file is checked for changes
+const configPollInterval = 5 * time.Second
+
+// ConfigReloadStatus reports the outcome of config reloads, served on /readyz
+type ConfigReloadStatus struct {
+	Ready      bool   `json:"ready"`
+	ConfigFile string `json:"configFile,omitempty"`
+	// Reloads is the number of successful reloads since start
+	Reloads     int        `json:"reloads"`
+	LastAttempt *time.Time `json:"lastAttempt,omitempty"`
+	LastSuccess *time.Time `json:"lastSuccess,omitempty"`
+	// Error is the error of the last reload attempt, if it failed; the
+	// Authenticator and Authorizer configured before it remain in use
+	Error string `json:"error,omitempty"`
+}
+
+// reloader serializes config reloads and keeps their status
+type reloader struct {
+	mu     sync.Mutex
+	status ConfigReloadStatus
+	// sum is the SHA-256 of the config file last seen by the watcher
+	sum [sha256.Size]byte
+}
+
+// accessPlugins returns the Authenticator and Authorizer currently in use
+func (s *Server) accessPlugins() (authenticator.Authenticator, authorization.Authorizer) {
+	s.accessMu.RLock()
+	defer s.accessMu.RUnlock()
+	return s.Authenticator, s.Authorizer
+}
+
+// setAccessPlugins swaps in authn and authz at once, so that no request is
+// checked by a mix of old and new plugins
+func (s *Server) setAccessPlugins(authn authenticator.Authenticator, authz authorization.Authorizer) {
+	s.accessMu.Lock()
+	defer s.accessMu.Unlock()
+	s.Authenticator = authn
+	s.Authorizer = authz
+}
+
+// startConfigReload reloads the config on SIGHUP and when the content of
+// ConfigFile changes. Nothing is reloaded if LoadConfig is not set.
+func (s *Server) startConfigReload() {
+	if s.LoadConfig == nil {
+		return
+	}
+	s.reload.status.ConfigFile = s.ConfigFile
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			s.reloadConfig("SIGHUP")
+		}
+	}()
+
+	if s.ConfigFile == "" {
+		return
+	}
+	// the file is polled rather than watched for events, which also catches
+	// Kubernetes ConfigMap updates that swap a symlink
+	if data, err := os.ReadFile(s.ConfigFile); err == nil {
+		s.reload.sum = sha256.Sum256(data)
+	}
+	go func() {
+		ticker := time.NewTicker(configPollInterval)
+		defer ticker.Stop()
+		var lastErr string
+		for range ticker.C {
+			data, err := os.ReadFile(s.ConfigFile)
+			if err != nil {
+				if err.Error() != lastErr {
+					log.Printf("Cannot read config file for reload: %v", err)
+					lastErr = err.Error()
+				}
+				continue
+			}
+			lastErr = ""
+			if s.configChanged(data) {
+				s.reloadConfig("config file changed")
+			}
+		}
+	}()
+}
+
+// configChanged reports whether data differs from the config file content
+// last seen, and remembers it
+func (s *Server) configChanged(data []byte) bool {
+	sum := sha256.Sum256(data)
+	s.reload.mu.Lock()
+	defer s.reload.mu.Unlock()
+	if bytes.Equal(sum[:], s.reload.sum[:]) {
+		return false
+	}
+	s.reload.sum = sum
+	return true
+}
+
+// reloadConfig loads and verifies the config, then swaps in its
+// Authenticator and Authorizer. On any error the plugins in use are kept.
+// Changes to other parts of the config take effect on restart only.
+func (s *Server) reloadConfig(reason string) error {
+	s.reload.mu.Lock()
+	defer s.reload.mu.Unlock()
+
+	now := time.Now()
+	s.reload.status.LastAttempt = &now
+	authn, authz, err := s.loadAccessPlugins()
+	if err != nil {
+		s.reload.status.Error = err.Error()
+		log.Printf("Config reload (%s) failed, keeping the current Authenticator and Authorizer: %v", reason, err)
+		return err
+	}
+
+	s.setAccessPlugins(authn, authz)
+	s.reload.status.Reloads++
+	s.reload.status.LastSuccess = &now
+	s.reload.status.Error = ""
+	log.Printf("Config reloaded (%s): Authenticator and Authorizer updated", reason)
+	return nil
+}
+
+// loadAccessPlugins creates the Authenticator and Authorizer of a freshly
+// loaded config
+func (s *Server) loadAccessPlugins() (authenticator.Authenticator, authorization.Authorizer, error) {
+	config, err := s.LoadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+	candidate := &Server{TornjakConfig: config}
+	if err := candidate.VerifyConfiguration(); err != nil {
+		return nil, nil, errors.Errorf("Tornjak Config error: %v", err)
+	}
+	pluginConfigs := *config.Plugins
+	pluginList, ok := pluginConfigs.(*ast.ObjectList)
+	if !ok {
+		return nil, nil, fmt.Errorf("expected plugins node type %T but got %T", pluginList, pluginConfigs)
+	}
+	return newAccessPlugins(pluginList)
+}
+
+// ready reports whether the server runs on its current config; it fails
+// with 503 while the last config reload failed
+func (s *Server) ready(w http.ResponseWriter, r *http.Request) {
+	s.reload.mu.Lock()
+	ret := s.reload.status
+	s.reload.mu.Unlock()
+	ret.Ready = ret.Error == ""
+
+	corsHeaders(w)
+	if !ret.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(ret); err != nil {
+		log.Printf("Error encoding response JSON: %v", err)
+	}
+}
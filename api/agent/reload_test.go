@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spiffe/tornjak/pkg/agent/authentication/user"
+)
+
+// reloadTestConfig maps uid 1000 to role and lets only role read the SPIRE
+// server info, so that a request is only allowed by an Authenticator and
+// Authorizer of the same config
+func reloadTestConfig(role string) string {
+	return fmt.Sprintf(`
+server {
+  spire_socket_path = "unix:///tmp/spire-server/private/api.sock"
+}
+
+plugins {
+  Authenticator "UnixPeer" {
+    plugin_data {
+      uid "1000" { roles = ["%[1]s"] }
+    }
+  }
+  Authorizer "RBAC" {
+    plugin_data {
+      name = "%[1]s policy"
+      role "%[1]s" { desc = "%[1]s" }
+      APIv1 "GET /api/v1/spire/serverinfo" { allowed_roles = ["%[1]s"] }
+    }
+  }
+}
+`, role)
+}
+
+func TestReloadConfig(t *testing.T) {
+	var config string
+	s := &Server{LoadConfig: func() (*TornjakConfig, error) {
+		root, err := ParseConfig("tornjak.conf", config)
+		if err != nil {
+			return nil, err
+		}
+		return DecodeConfig(root)
+	}}
+
+	// checkAccess checks that uid 1000 gets role and is allowed by the
+	// Authorizer in use
+	checkAccess := func(t *testing.T, role string) {
+		t.Helper()
+		authn, authz := s.accessPlugins()
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/spire/serverinfo", nil)
+		r = r.WithContext(user.WithPeerCredentials(context.Background(), &user.PeerCredentials{UID: 1000, GID: 1000}))
+		userInfo := authn.AuthenticateRequest(r)
+		if userInfo.AuthenticationError != nil || !reflect.DeepEqual(userInfo.Roles, []string{role}) {
+			t.Fatalf("Expected role %s, got %+v", role, userInfo)
+		}
+		if err := authz.AuthorizeRequest(r, userInfo); err != nil {
+			t.Fatalf("Expected role %s authorized, got %v", role, err)
+		}
+	}
+	// checkReady checks the /readyz status code and reload status
+	checkReady := func(t *testing.T, wantCode int, wantReloads int, wantErr string) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.ready(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+		var status ConfigReloadStatus
+		if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+			t.Fatal(err)
+		}
+		if w.Code != wantCode || status.Ready != (wantErr == "") || status.Reloads != wantReloads {
+			t.Fatalf("Expected %d with %d reloads, got %d: %s", wantCode, wantReloads, w.Code, w.Body.String())
+		}
+		if !strings.Contains(status.Error, wantErr) || (wantErr == "") != (status.Error == "") {
+			t.Fatalf("Expected error containing %q, got %q", wantErr, status.Error)
+		}
+	}
+
+	config = reloadTestConfig("admin")
+	if err := s.reloadConfig("test"); err != nil {
+		t.Fatal(err)
+	}
+	checkAccess(t, "admin")
+	checkReady(t, http.StatusOK, 1, "")
+
+	// a valid reload swaps both plugins
+	config = reloadTestConfig("viewer")
+	if err := s.reloadConfig("test"); err != nil {
+		t.Fatal(err)
+	}
+	checkAccess(t, "viewer")
+	checkReady(t, http.StatusOK, 2, "")
+
+	// an invalid config keeps both plugins in use
+	for _, tt := range []struct {
+		name    string
+		config  string
+		wantErr string
+	}{
+		{name: "syntax error", config: "plugins {", wantErr: "object expected closing RBRACE"},
+		{name: "server not verified", config: strings.Replace(reloadTestConfig("admin"), "spire_socket_path", "spire_socket", 1), wantErr: "spire_socket_path"},
+		{name: "invalid Authorizer", config: strings.Replace(reloadTestConfig("admin"), "GET /api/v1/spire/serverinfo", "/api/v1/spire/serverinfo", 1), wantErr: "Invalid APIv1 block"},
+		{name: "invalid Authenticator", config: strings.Replace(reloadTestConfig("admin"), `uid "1000"`, `uid "root"`, 1), wantErr: "Cannot configure Authenticator plugin"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			config = tt.config
+			if err := s.reloadConfig("test"); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+			checkAccess(t, "viewer")
+			checkReady(t, http.StatusServiceUnavailable, 2, tt.wantErr)
+		})
+	}
+
+	// a later valid reload clears the error
+	config = reloadTestConfig("admin")
+	if err := s.reloadConfig("test"); err != nil {
+		t.Fatal(err)
+	}
+	checkAccess(t, "admin")
+	checkReady(t, http.StatusOK, 3, "")
+}
@@ -1,26 +1,33 @@
 package api
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
 	"net"
 	"net/http"
-	"os"
-	"path/filepath"
-	"strings"
+	"sync"
 
 	"github.com/gorilla/mux"
 	"github.com/hashicorp/hcl/hcl/ast"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	"github.com/spiffe/tornjak/pkg/agent/authentication/authenticator"
 	"github.com/spiffe/tornjak/pkg/agent/authorization"
 	agentdb "github.com/spiffe/tornjak/pkg/agent/db"
+	"github.com/spiffe/tornjak/pkg/agent/notifier"
 	"github.com/spiffe/tornjak/pkg/agent/spirecrd"
+	tornjakTypes "github.com/spiffe/tornjak/pkg/agent/types"
+	"github.com/spiffe/tornjak/pkg/ui"
 )
 
+// defaultMaxRequestBytes is the request body limit used when
+// 'config > server > max_request_bytes' is not set.
+const defaultMaxRequestBytes int64 = 4 << 20
+
 // Server represents a Tornjak server with associated configurations and plugins.
 type Server struct {
 	SpireServerAddr string
@@ -31,6 +38,25 @@ type Server struct {
 	CRDManager    spirecrd.CRDManager
 	Authenticator authenticator.Authenticator
 	Authorizer    authorization.Authorizer
+	Notifiers     []notifier.Notifier
+
+	// ConfigFile and LoadConfig, if set, allow reloading the Authenticator
+	// and Authorizer when the config file changes or on SIGHUP
+	ConfigFile string
+	LoadConfig func() (*TornjakConfig, error)
+
+	// accessMu guards Authenticator and Authorizer, which are swapped on reload
+	accessMu sync.RWMutex
+	reload   reloader
+
+	// instance identifies this server among those sharing the datastore
+	instance string
+	jobs     *jobManager
+
+	reconcile reconciler
+
+	uiOnce    sync.Once
+	uiHandler http.Handler
 }
 
 // hclPluginConfig mirrors SPIRE plugin configuration structure.
@@ -42,23 +68,24 @@ type hclPluginConfig struct {
 	Enabled        *bool    `hcl:"enabled"`
 }
 
-// cors sets CORS and Content-Type headers for responses and writes a 200 OK status.
-func cors(w http.ResponseWriter, _ *http.Request) {
+// corsHeaders sets CORS and Content-Type headers for responses.
+func corsHeaders(w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, DELETE, PATCH")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, access-control-allow-origin, access-control-allow-headers, access-control-allow-credentials, Authorization, access-control-allow-methods")
+	w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, DELETE, PATCH, PUT")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, access-control-allow-origin, access-control-allow-headers, access-control-allow-credentials, Authorization, access-control-allow-methods, Idempotency-Key")
 	w.Header().Set("Access-Control-Expose-Headers", "*, Authorization")
+}
+
+// cors sets CORS and Content-Type headers for responses and writes a 200 OK status.
+func cors(w http.ResponseWriter, _ *http.Request) {
+	corsHeaders(w)
 	w.WriteHeader(http.StatusOK)
 }
 
 // retError sets appropriate headers and writes an error message with the given status code.
 func retError(w http.ResponseWriter, emsg string, status int) {
-	w.Header().Set("Content-Type", "application/json;charset=UTF-8")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "POST, GET, OPTIONS, DELETE, PATCH")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, access-control-allow-origin, access-control-allow-headers, access-control-allow-credentials, Authorization, access-control-allow-methods")
-	w.Header().Set("Access-Control-Expose-Headers", "*, Authorization")
+	corsHeaders(w)
 	http.Error(w, emsg, status)
 }
 
@@ -70,37 +97,55 @@ func (s *Server) verificationMiddleware(next http.Handler) http.Handler {
 			return
 		}
 
-		userInfo := s.Authenticator.AuthenticateRequest(r)
-		err := s.Authorizer.AuthorizeRequest(r, userInfo)
+		authn, authz := s.accessPlugins()
+		userInfo := authn.AuthenticateRequest(r)
+		record := newAuditRecord(r, userInfo)
+		err := authz.AuthorizeRequest(r, userInfo)
 		if err != nil {
 			emsg := fmt.Sprintf("Error authorizing request: %v", err.Error())
+			record.Decision = tornjakTypes.AuditDeny
+			record.Reason = err.Error()
 			retError(w, emsg, http.StatusUnauthorized)
+			s.audit(record, http.StatusUnauthorized)
 			return
 		}
 
+		// resources acted on are added to the trail by the handler
+		trail := &auditTrail{resources: record.Resources}
+		ctx := withUserInfo(withAuditTrail(withActor(r.Context(), newActor(r, userInfo)), trail), userInfo)
+		rec := &auditRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(ctx))
+
+		record.Decision = tornjakTypes.AuditAllow
+		record.Resources = trail.list()
+		s.audit(record, rec.status)
+	})
+}
+
+// requestSizeMiddleware caps request bodies at the configured maximum size.
+func (s *Server) requestSizeMiddleware(next http.Handler) http.Handler {
+	limit := s.maxRequestBytes()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
 		next.ServeHTTP(w, r)
 	})
 }
 
+// maxRequestBytes returns the configured request body limit, or the default if unset.
+func (s *Server) maxRequestBytes() int64 {
+	if s.TornjakConfig != nil && s.TornjakConfig.Server != nil && s.TornjakConfig.Server.MaxRequestBytes > 0 {
+		return s.TornjakConfig.Server.MaxRequestBytes
+	}
+	return defaultMaxRequestBytes
+}
+
 // tornjakGetServerInfo retrieves Tornjak server info. Returns 204 if no server info is available.
 func (s *Server) tornjakGetServerInfo(w http.ResponseWriter, r *http.Request) {
 	var input GetTornjakServerInfoRequest
-	buf := new(strings.Builder)
-	n, err := io.Copy(buf, r.Body)
-	if err != nil {
-		retError(w, fmt.Sprintf("Error parsing data: %v", err.Error()), http.StatusBadRequest)
+	if _, err := readRequestJSON(r, &input); err != nil {
+		retRequestError(w, err)
 		return
 	}
-	data := buf.String()
-
-	if n == 0 {
-		input = GetTornjakServerInfoRequest{}
-	} else {
-		if err := json.Unmarshal([]byte(data), &input); err != nil {
-			retError(w, fmt.Sprintf("Error parsing data: %v", err.Error()), http.StatusBadRequest)
-			return
-		}
-	}
 
 	ret, err := s.GetTornjakServerInfo(input)
 	if err != nil {
@@ -117,41 +162,29 @@ func (s *Server) tornjakGetServerInfo(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// spaHandler serves a Single Page Application, routing non-existing paths to index.html.
-type spaHandler struct {
-	staticPath string
-	indexPath  string
-}
-
-// ServeHTTP serves static files. If a file doesn't exist, serves index.html.
-func (h spaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	relPath := r.URL.Path
-	absPath, err := filepath.Abs(filepath.Join(h.staticPath, relPath))
-	if err != nil || !strings.HasPrefix(absPath, h.staticPath) {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	_, err = os.Stat(absPath)
-	if os.IsNotExist(err) {
-		http.ServeFile(w, r, filepath.Join(h.staticPath, h.indexPath))
-		return
-	} else if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	http.FileServer(http.Dir(h.staticPath)).ServeHTTP(w, r)
+// uiRouteHandler returns the handler serving the UI, from 'config > server > ui_path'
+// if set, otherwise from the embedded assets or the ui-agent directory.
+func (s *Server) uiRouteHandler() http.Handler {
+	s.uiOnce.Do(func() {
+		uiPath := ""
+		if s.TornjakConfig != nil && s.TornjakConfig.Server != nil {
+			uiPath = s.TornjakConfig.Server.UIPath
+		}
+		s.uiHandler = ui.New(uiPath, ui.AgentAssets(), "ui-agent")
+	})
+	return s.uiHandler
 }
 
 // GetRouter configures and returns the main HTTP router.
 func (s *Server) GetRouter() http.Handler {
-	rtr := mux.NewRouter()
+	// API v2 addresses resources by escaped path segments (e.g. SPIFFE IDs)
+	rtr := mux.NewRouter().UseEncodedPath()
 	apiRtr := rtr.PathPrefix("/").Subrouter()
 	healthRtr := rtr.PathPrefix("/healthz").Subrouter()
 
 	// Healthcheck (no auth)
 	healthRtr.HandleFunc("", s.health)
+	rtr.HandleFunc("/readyz", s.ready).Methods(http.MethodGet)
 
 	// Home
 	apiRtr.HandleFunc("/", s.home)
@@ -164,12 +197,14 @@ func (s *Server) GetRouter() http.Handler {
 	apiRtr.HandleFunc("/api/v1/spire/agents", s.agentList).Methods(http.MethodGet, http.MethodOptions)
 	apiRtr.HandleFunc("/api/v1/spire/agents/ban", s.agentBan).Methods(http.MethodPost, http.MethodOptions)
 	apiRtr.HandleFunc("/api/v1/spire/agents", s.agentDelete).Methods(http.MethodDelete, http.MethodOptions)
-	apiRtr.HandleFunc("/api/v1/spire/agents/jointoken", s.agentCreateJoinToken).Methods(http.MethodPost, http.MethodOptions)
+	apiRtr.HandleFunc("/api/v1/spire/agents/jointoken", s.idempotentSecret(s.agentCreateJoinToken)).Methods(http.MethodPost, http.MethodOptions)
 
 	// Entries
 	apiRtr.HandleFunc("/api/v1/spire/entries", s.entryList).Methods(http.MethodGet, http.MethodOptions)
-	apiRtr.HandleFunc("/api/v1/spire/entries", s.entryCreate).Methods(http.MethodPost)
+	apiRtr.HandleFunc("/api/v1/spire/entries", s.idempotent(s.entryCreate)).Methods(http.MethodPost)
 	apiRtr.HandleFunc("/api/v1/spire/entries", s.entryDelete).Methods(http.MethodDelete)
+	apiRtr.HandleFunc("/api/v1/spire/entries/export", s.entryExport).Methods(http.MethodGet, http.MethodOptions)
+	apiRtr.HandleFunc("/api/v1/spire/entries/import", s.entryImport).Methods(http.MethodPost, http.MethodOptions)
 
 	// Bundles
 	apiRtr.HandleFunc("/api/v1/spire/bundle", s.bundleGet).Methods(http.MethodGet, http.MethodOptions)
@@ -186,22 +221,70 @@ func (s *Server) GetRouter() http.Handler {
 
 	// Tornjak
 	apiRtr.HandleFunc("/api/v1/tornjak/serverinfo", s.tornjakGetServerInfo).Methods(http.MethodGet, http.MethodOptions)
+	apiRtr.HandleFunc("/api/v1/tornjak/serverinfo/combined", s.tornjakGetCombinedServerInfo).Methods(http.MethodGet, http.MethodOptions)
 	apiRtr.HandleFunc("/api/v1/tornjak/selectors", s.tornjakPluginDefine).Methods(http.MethodPost, http.MethodOptions)
 	apiRtr.HandleFunc("/api/v1/tornjak/selectors", s.tornjakSelectorsList).Methods(http.MethodGet)
 	apiRtr.HandleFunc("/api/v1/tornjak/agents", s.tornjakAgentsList).Methods(http.MethodGet, http.MethodOptions)
+	apiRtr.HandleFunc("/api/v1/tornjak/agents/{spiffeid}/labels", s.tornjakAgentLabelsGet).Methods(http.MethodGet, http.MethodOptions)
+	apiRtr.HandleFunc("/api/v1/tornjak/agents/{spiffeid}/labels", s.tornjakAgentLabelsSet).Methods(http.MethodPut)
 
 	// Clusters
 	apiRtr.HandleFunc("/api/v1/tornjak/clusters", s.clusterList).Methods(http.MethodGet, http.MethodOptions)
-	apiRtr.HandleFunc("/api/v1/tornjak/clusters", s.clusterCreate).Methods(http.MethodPost)
+	apiRtr.HandleFunc("/api/v1/tornjak/clusters", s.idempotent(s.clusterCreate)).Methods(http.MethodPost)
 	apiRtr.HandleFunc("/api/v1/tornjak/clusters", s.clusterEdit).Methods(http.MethodPatch)
 	apiRtr.HandleFunc("/api/v1/tornjak/clusters", s.clusterDelete).Methods(http.MethodDelete)
 
+	// Jobs
+	apiRtr.HandleFunc("/api/v1/tornjak/jobs", s.jobList).Methods(http.MethodGet, http.MethodOptions)
+	apiRtr.HandleFunc("/api/v1/tornjak/jobs", s.idempotent(s.jobCreate)).Methods(http.MethodPost)
+	apiRtr.HandleFunc("/api/v1/tornjak/jobs/{id}", s.jobGet).Methods(http.MethodGet, http.MethodOptions)
+	apiRtr.HandleFunc("/api/v1/tornjak/jobs/{id}", s.jobCancel).Methods(http.MethodDelete)
+
+	// Audit
+	apiRtr.HandleFunc("/api/v1/tornjak/audit", s.auditList).Methods(http.MethodGet, http.MethodOptions)
+	apiRtr.HandleFunc("/api/v1/tornjak/audit/verify", s.auditVerify).Methods(http.MethodGet, http.MethodOptions)
+
+	// Webhooks
+	apiRtr.HandleFunc("/api/v1/tornjak/webhooks", s.webhookList).Methods(http.MethodGet, http.MethodOptions)
+
+	// Reconciliation
+	apiRtr.HandleFunc("/api/v1/tornjak/reconcile", s.reconcileGet).Methods(http.MethodGet, http.MethodOptions)
+
+	// API v2
+	v2Rtr := apiRtr.PathPrefix(apiV2Prefix).Subrouter()
+
+	// Agents
+	v2Rtr.HandleFunc("/agents", s.agentListV2).Methods(http.MethodGet, http.MethodOptions)
+	v2Rtr.HandleFunc("/agents/{id}", s.agentGetV2).Methods(http.MethodGet, http.MethodOptions)
+	v2Rtr.HandleFunc("/agents/{id}", s.agentDeleteV2).Methods(http.MethodDelete)
+	v2Rtr.HandleFunc("/agents/{id}/ban", s.agentBanV2).Methods(http.MethodPost, http.MethodOptions)
+	v2Rtr.HandleFunc("/jointokens", s.idempotentSecret(s.joinTokenCreateV2)).Methods(http.MethodPost, http.MethodOptions)
+
+	// Entries
+	v2Rtr.HandleFunc("/entries", s.entryListV2).Methods(http.MethodGet, http.MethodOptions)
+	v2Rtr.HandleFunc("/entries", s.idempotent(s.entryCreateV2)).Methods(http.MethodPost)
+	v2Rtr.HandleFunc("/entries/{id}", s.entryGetV2).Methods(http.MethodGet, http.MethodOptions)
+	v2Rtr.HandleFunc("/entries/{id}", s.entryDeleteV2).Methods(http.MethodDelete)
+
+	// Clusters
+	v2Rtr.HandleFunc("/clusters", s.clusterListV2).Methods(http.MethodGet, http.MethodOptions)
+	v2Rtr.HandleFunc("/clusters", s.idempotent(s.clusterCreateV2)).Methods(http.MethodPost)
+	v2Rtr.HandleFunc("/clusters/{name}", s.clusterGetV2).Methods(http.MethodGet, http.MethodOptions)
+	v2Rtr.HandleFunc("/clusters/{name}", s.clusterUpdateV2).Methods(http.MethodPut)
+	v2Rtr.HandleFunc("/clusters/{name}", s.clusterDeleteV2).Methods(http.MethodDelete)
+
 	// Apply AuthN/AuthZ middleware
 	apiRtr.Use(s.verificationMiddleware)
+	// Cap request body size
+	apiRtr.Use(s.requestSizeMiddleware)
+
+	// Connect / gRPC services, authorized by their own interceptor
+	for path, handler := range s.connectHandlers() {
+		rtr.PathPrefix(path).Handler(handler)
+	}
 
 	// UI SPA
-	spa := spaHandler{staticPath: "ui-agent", indexPath: "index.html"}
-	rtr.PathPrefix("/").Handler(spa)
+	rtr.PathPrefix("/").Handler(s.uiRouteHandler())
 
 	return rtr
 }
@@ -231,8 +314,9 @@ func (s *Server) HandleRequests() {
 	if err := s.Configure(); err != nil {
 		log.Fatal("Cannot Configure: ", err)
 	}
+	s.startConfigReload()
 
-	errChannel := make(chan error, 2)
+	errChannel := make(chan error, 3)
 	serverConfig := s.TornjakConfig.Server
 
 	if serverConfig.HTTPConfig == nil {
@@ -262,7 +346,7 @@ func (s *Server) HandleRequests() {
 			canStartHTTPS = false
 		} else {
 			var err error
-			tlsConfig, err = httpsConfig.Parse()
+			tlsConfig, err = httpsConfig.Parse(context.Background(), s.hasAuthenticator("ClientCert"))
 			if err != nil {
 				err = fmt.Errorf("failed parsing HTTPS config: %w. Starting insecure HTTP only...", err)
 				errChannel <- err
@@ -281,18 +365,30 @@ func (s *Server) HandleRequests() {
 				}
 
 				fmt.Printf("Starting https on %s...\n", addr)
-				if err := server.ListenAndServeTLS(httpsConfig.Cert, httpsConfig.Key); err != nil {
+				// certificates are served by tlsConfig.GetCertificate
+				if err := server.ListenAndServeTLS("", ""); err != nil {
 					errChannel <- fmt.Errorf("server error serving on https: %w", err)
 				}
 			}()
 		}
 	}
 
+	// Start unix socket listener
+	if serverConfig.SocketConfig != nil {
+		numPorts++
+		go func() {
+			if err := s.serveUnixSocket(serverConfig.SocketConfig); err != nil {
+				errChannel <- fmt.Errorf("server error serving on unix socket: %w", err)
+			}
+		}()
+	}
+
 	// Start HTTP listener
 	go func() {
 		addr := fmt.Sprintf(":%d", serverConfig.HTTPConfig.ListenPort)
 		fmt.Printf("Starting to listen on %s...\n", addr)
-		if err := http.ListenAndServe(addr, httpHandler); err != nil {
+		// h2c lets gRPC clients use HTTP/2 without TLS
+		if err := http.ListenAndServe(addr, h2c.NewHandler(httpHandler, &http2.Server{})); err != nil {
 			errChannel <- err
 		}
 	}()
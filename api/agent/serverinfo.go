@@ -0,0 +1,149 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// CombinedServerInfo merges what the running SPIRE server reports about
+// itself with what Tornjak parsed from its config file
+type CombinedServerInfo struct {
+	// Config is parsed from --spire-config, nil if none was given
+	Config *TornjakSpireServerInfo `json:"config,omitempty"`
+	// Live is reported by the SPIRE server, nil if it could not be reached
+	Live      *LiveServerInfo `json:"live,omitempty"`
+	LiveError string          `json:"liveError,omitempty"`
+	// Mismatches lists where config and live server disagree; empty unless
+	// both are known
+	Mismatches []ServerInfoMismatch `json:"mismatches"`
+}
+
+// LiveServerInfo is the debug info of the running SPIRE server
+type LiveServerInfo struct {
+	TrustDomain string `json:"trustDomain,omitempty"`
+	// Uptime is in seconds
+	Uptime                int32 `json:"uptime"`
+	AgentsCount           int32 `json:"agentsCount"`
+	EntriesCount          int32 `json:"entriesCount"`
+	FederatedBundlesCount int32 `json:"federatedBundlesCount"`
+	// SVIDChain is the X509-SVID of the server, then its CA chain
+	SVIDChain []LiveSVIDCert `json:"svidChain"`
+}
+
+type LiveSVIDCert struct {
+	SPIFFEID  string    `json:"spiffeID,omitempty"`
+	Subject   string    `json:"subject"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// ServerInfoMismatch is a field whose configured value differs from the
+// live server's
+type ServerInfoMismatch struct {
+	Field   string `json:"field"`
+	Config  string `json:"config"`
+	Live    string `json:"live"`
+	Message string `json:"message"`
+}
+
+// GetCombinedServerInfo fetches the live server info and merges it with the
+// parsed config. It fails only if neither is available.
+func (s *Server) GetCombinedServerInfo() (*CombinedServerInfo, error) {
+	ret := &CombinedServerInfo{Mismatches: []ServerInfoMismatch{}}
+	if s.SpireServerInfo.TrustDomain != "" {
+		config := s.SpireServerInfo
+		ret.Config = &config
+	}
+
+	debug, err := s.DebugServer(&DebugServerRequest{})
+	if err != nil {
+		if ret.Config == nil {
+			return nil, err
+		}
+		ret.LiveError = err.Error()
+		return ret, nil
+	}
+	ret.Live = liveServerInfo(debug)
+	if ret.Config != nil {
+		ret.Mismatches = serverInfoMismatches(ret.Config, ret.Live)
+	}
+	return ret, nil
+}
+
+// liveServerInfo converts the debug info of the SPIRE server; the trust
+// domain is that of the server SVID
+func liveServerInfo(debug *DebugServerResponse) *LiveServerInfo {
+	live := &LiveServerInfo{
+		Uptime:                debug.Uptime,
+		AgentsCount:           debug.AgentsCount,
+		EntriesCount:          debug.EntriesCount,
+		FederatedBundlesCount: debug.FederatedBundlesCount,
+		SVIDChain:             []LiveSVIDCert{},
+	}
+	for _, cert := range debug.SvidChain {
+		svid := LiveSVIDCert{
+			Subject:   cert.Subject,
+			ExpiresAt: time.Unix(cert.ExpiresAt, 0).UTC(),
+		}
+		if id := cert.Id; id != nil {
+			svid.SPIFFEID = fmt.Sprintf("spiffe://%s%s", id.TrustDomain, id.Path)
+			if live.TrustDomain == "" {
+				live.TrustDomain = id.TrustDomain
+			}
+		}
+		live.SVIDChain = append(live.SVIDChain, svid)
+	}
+	return live
+}
+
+// serverInfoMismatches compares the config with the live server info.
+// Federated bundles can also be added through the API, so fewer bundles
+// than configured federation relationships is a mismatch, more is not.
+func serverInfoMismatches(config *TornjakSpireServerInfo, live *LiveServerInfo) []ServerInfoMismatch {
+	mismatches := []ServerInfoMismatch{}
+	if live.TrustDomain != "" && config.TrustDomain != live.TrustDomain {
+		mismatches = append(mismatches, ServerInfoMismatch{
+			Field:   "trustDomain",
+			Config:  config.TrustDomain,
+			Live:    live.TrustDomain,
+			Message: "the SPIRE server runs with another trust domain than its config file sets; the config given to Tornjak may be stale or of another server",
+		})
+	}
+	if config.Federation != nil {
+		configured := len(config.Federation.FederatesWith)
+		if int(live.FederatedBundlesCount) < configured {
+			mismatches = append(mismatches, ServerInfoMismatch{
+				Field:   "federatedBundlesCount",
+				Config:  strconv.Itoa(configured),
+				Live:    strconv.Itoa(int(live.FederatedBundlesCount)),
+				Message: "the SPIRE server has fewer federated bundles than federation relationships configured; some bundles may not have been fetched yet",
+			})
+		}
+	}
+	return mismatches
+}
+
+// tornjakGetCombinedServerInfo retrieves the live SPIRE server info merged
+// with its parsed config
+func (s *Server) tornjakGetCombinedServerInfo(w http.ResponseWriter, r *http.Request) {
+	var input GetTornjakServerInfoRequest
+	if _, err := readRequestJSON(r, &input); err != nil {
+		retRequestError(w, err)
+		return
+	}
+
+	ret, err := s.GetCombinedServerInfo()
+	if err != nil {
+		retError(w, fmt.Sprintf("Error: %v", err.Error()), http.StatusInternalServerError)
+		return
+	}
+	if ret.LiveError != "" {
+		log.Printf("SPIRE server info unavailable, serving config only: %v", ret.LiveError)
+	}
+
+	if err := writeResponseJSON(w, r, ret); err != nil {
+		retError(w, err.Error(), http.StatusBadRequest)
+	}
+}
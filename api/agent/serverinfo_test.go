@@ -0,0 +1,166 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	debugServer "github.com/spiffe/spire-api-sdk/proto/spire/api/server/debug/v1"
+	"github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	"google.golang.org/grpc"
+)
+
+// fakeDebugServer is a SPIRE debug API returning info
+type fakeDebugServer struct {
+	debugServer.UnimplementedDebugServer
+	info *debugServer.GetInfoResponse
+}
+
+func (f *fakeDebugServer) GetInfo(ctx context.Context, req *debugServer.GetInfoRequest) (*debugServer.GetInfoResponse, error) {
+	return f.info, nil
+}
+
+// testDebugInfo is the debug info of a SPIRE server of trustDomain with
+// bundles federated bundles
+func testDebugInfo(trustDomain string, bundles int32) *debugServer.GetInfoResponse {
+	return &debugServer.GetInfoResponse{
+		Uptime:                60,
+		AgentsCount:           2,
+		EntriesCount:          5,
+		FederatedBundlesCount: bundles,
+		SvidChain: []*debugServer.GetInfoResponse_Cert{
+			{Id: &types.SPIFFEID{TrustDomain: trustDomain, Path: "/spire/server"}, ExpiresAt: 1700000000, Subject: "O=SPIFFE"},
+			{ExpiresAt: 1800000000, Subject: "CN=spire-ca,O=SPIFFE"},
+		},
+	}
+}
+
+// testServerInfo is the parsed config of a SPIRE server of example.org
+// federated with two trust domains
+func testServerInfo() TornjakSpireServerInfo {
+	return TornjakSpireServerInfo{
+		TrustDomain: "example.org",
+		Federation: &SpireFederationInfo{FederatesWith: []SpireFederatesWithInfo{
+			{TrustDomain: "other.org"}, {TrustDomain: "web.org"},
+		}},
+	}
+}
+
+func TestLiveServerInfo(t *testing.T) {
+	live := liveServerInfo((*DebugServerResponse)(testDebugInfo("example.org", 2)))
+	want := &LiveServerInfo{
+		TrustDomain:           "example.org",
+		Uptime:                60,
+		AgentsCount:           2,
+		EntriesCount:          5,
+		FederatedBundlesCount: 2,
+		SVIDChain: []LiveSVIDCert{
+			{SPIFFEID: "spiffe://example.org/spire/server", Subject: "O=SPIFFE", ExpiresAt: time.Unix(1700000000, 0).UTC()},
+			{Subject: "CN=spire-ca,O=SPIFFE", ExpiresAt: time.Unix(1800000000, 0).UTC()},
+		},
+	}
+	if !reflect.DeepEqual(live, want) {
+		t.Fatalf("Expected %+v, got %+v", want, live)
+	}
+
+	// without an SVID the trust domain is unknown
+	live = liveServerInfo(&DebugServerResponse{})
+	if live.TrustDomain != "" || live.SVIDChain == nil || len(live.SVIDChain) != 0 {
+		t.Fatalf("Expected no trust domain and an empty SVID chain, got %+v", live)
+	}
+}
+
+func TestServerInfoMismatches(t *testing.T) {
+	noFederation := testServerInfo()
+	noFederation.Federation = nil
+
+	tests := []struct {
+		name       string
+		config     TornjakSpireServerInfo
+		live       *LiveServerInfo
+		wantFields []string
+	}{
+		{name: "matching", config: testServerInfo(), live: &LiveServerInfo{TrustDomain: "example.org", FederatedBundlesCount: 2}},
+		{name: "trust domain mismatch", config: testServerInfo(), live: &LiveServerInfo{TrustDomain: "prod.example.org", FederatedBundlesCount: 2}, wantFields: []string{"trustDomain"}},
+		{name: "fewer bundles than relationships", config: testServerInfo(), live: &LiveServerInfo{TrustDomain: "example.org", FederatedBundlesCount: 1}, wantFields: []string{"federatedBundlesCount"}},
+		// bundles can also be added through the API
+		{name: "more bundles than relationships", config: testServerInfo(), live: &LiveServerInfo{TrustDomain: "example.org", FederatedBundlesCount: 3}},
+		{name: "no federation configured", config: noFederation, live: &LiveServerInfo{TrustDomain: "example.org"}},
+		{name: "live trust domain unknown", config: testServerInfo(), live: &LiveServerInfo{FederatedBundlesCount: 2}},
+		{name: "both", config: testServerInfo(), live: &LiveServerInfo{TrustDomain: "prod.example.org"}, wantFields: []string{"trustDomain", "federatedBundlesCount"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mismatches := serverInfoMismatches(&tt.config, tt.live)
+			fields := []string{}
+			for _, mismatch := range mismatches {
+				fields = append(fields, mismatch.Field)
+			}
+			if !reflect.DeepEqual(fields, append([]string{}, tt.wantFields...)) {
+				t.Fatalf("Expected mismatches %v, got %+v", tt.wantFields, mismatches)
+			}
+		})
+	}
+
+	mismatches := serverInfoMismatches(&TornjakSpireServerInfo{TrustDomain: "example.org"}, &LiveServerInfo{TrustDomain: "prod.example.org"})
+	if got := mismatches[0]; got.Config != "example.org" || got.Live != "prod.example.org" || got.Message == "" {
+		t.Fatalf("Expected config example.org, live prod.example.org and a message, got %+v", got)
+	}
+}
+
+func TestGetCombinedServerInfo(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         TornjakSpireServerInfo
+		live           *debugServer.GetInfoResponse
+		wantConfig     bool
+		wantLive       bool
+		wantMismatches int
+		wantErr        bool
+	}{
+		{name: "config and live", config: testServerInfo(), live: testDebugInfo("prod.example.org", 1), wantConfig: true, wantLive: true, wantMismatches: 2},
+		{name: "config only, SPIRE unreachable", config: testServerInfo(), wantConfig: true},
+		{name: "live only", live: testDebugInfo("example.org", 0), wantLive: true},
+		{name: "neither", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{SpireServerInfo: tt.config}
+			if tt.live != nil {
+				serveFakeSpire(t, s, func(server *grpc.Server) {
+					debugServer.RegisterDebugServer(server, &fakeDebugServer{info: tt.live})
+				})
+			} else {
+				s.SpireServerAddr = "unix://" + filepath.Join(t.TempDir(), "missing.sock")
+			}
+
+			w := httptest.NewRecorder()
+			s.tornjakGetCombinedServerInfo(w, httptest.NewRequest(http.MethodGet, "/api/v1/tornjak/serverinfo/combined", nil))
+			ret, err := s.GetCombinedServerInfo()
+			if tt.wantErr {
+				if err == nil || w.Code != http.StatusInternalServerError {
+					t.Fatalf("Expected an error and %d, got %v and %d", http.StatusInternalServerError, err, w.Code)
+				}
+				return
+			}
+			if err != nil || w.Code != http.StatusOK {
+				t.Fatalf("Expected no error and %d, got %v and %d: %s", http.StatusOK, err, w.Code, w.Body.String())
+			}
+			if (ret.Config != nil) != tt.wantConfig || (ret.Live != nil) != tt.wantLive || len(ret.Mismatches) != tt.wantMismatches {
+				t.Fatalf("Expected config %t, live %t and %d mismatches, got %+v", tt.wantConfig, tt.wantLive, tt.wantMismatches, ret)
+			}
+			if (ret.LiveError != "") == tt.wantLive {
+				t.Fatalf("Expected a live error only without live info, got %q", ret.LiveError)
+			}
+			// mismatches are always a list in JSON
+			if !strings.Contains(w.Body.String(), `"mismatches":[`) {
+				t.Fatalf("Expected a mismatches list, got %s", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+
+	"github.com/spiffe/tornjak/pkg/agent/authentication/user"
+)
+
+// defaultSocketMode is the file mode of the unix socket when
+// 'config > server > socket > mode' is not set
+const defaultSocketMode os.FileMode = 0600
+
+// fileMode returns the configured socket file mode, or the default if unset.
+func (c SocketConfig) fileMode() (os.FileMode, error) {
+	if c.Mode == "" {
+		return defaultSocketMode, nil
+	}
+	mode, err := strconv.ParseUint(c.Mode, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid mode %q: expected octal permissions such as \"0660\"", c.Mode)
+	}
+	return os.FileMode(mode), nil
+}
+
+// listenUnix listens on the socket path with the configured permissions,
+// replacing a socket left behind by a previous run.
+func (c SocketConfig) listenUnix() (net.Listener, error) {
+	mode, err := c.fileMode()
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Lstat(c.Path); err == nil && info.Mode()&os.ModeSocket == 0 {
+		return nil, fmt.Errorf("'%s' exists and is not a socket", c.Path)
+	}
+
+	// The socket is bound in a private directory and only renamed into
+	// place once it has its mode, so it is never reachable with the wider
+	// permissions it is created with.
+	dir, err := os.MkdirTemp(filepath.Dir(c.Path), ".tornjak-socket-")
+	if err != nil {
+		return nil, fmt.Errorf("could not create directory for socket '%s': %w", c.Path, err)
+	}
+	defer os.RemoveAll(dir)
+	tmpPath := filepath.Join(dir, "socket")
+
+	ln, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmpPath, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	// the listener would unlink tmpPath; unixListener unlinks the final path
+	ln.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("could not set mode of socket '%s': %w", c.Path, err)
+	}
+	if err := os.Rename(tmpPath, c.Path); err != nil {
+		ln.Close()
+		return nil, fmt.Errorf("could not move socket into place at '%s': %w", c.Path, err)
+	}
+	return &unixListener{UnixListener: ln, path: c.Path}, nil
+}
+
+// unixListener removes its socket file when closed
+type unixListener struct {
+	*net.UnixListener
+	path string
+}
+
+func (l *unixListener) Close() error {
+	err := l.UnixListener.Close()
+	if removeErr := os.Remove(l.path); removeErr != nil && !os.IsNotExist(removeErr) && err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// peerCredentialsContext attaches the kernel-reported credentials of the
+// process on the other end of a unix socket connection to its context
+func peerCredentialsContext(ctx context.Context, conn net.Conn) context.Context {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return ctx
+	}
+	creds, err := getPeerCredentials(unixConn)
+	if err != nil {
+		log.Printf("Could not read peer credentials of unix socket connection: %v", err)
+		return ctx
+	}
+	return user.WithPeerCredentials(ctx, creds)
+}
+
+// serveUnixSocket serves the Tornjak API on the configured unix socket
+func (s *Server) serveUnixSocket(socketConfig *SocketConfig) error {
+	ln, err := socketConfig.listenUnix()
+	if err != nil {
+		return fmt.Errorf("failed to listen on unix socket: %w", err)
+	}
+	server := &http.Server{
+		Handler:     h2c.NewHandler(s.GetRouter(), &http2.Server{}),
+		ConnContext: peerCredentialsContext,
+	}
+	fmt.Printf("Starting to listen on unix socket %s...\n", socketConfig.Path)
+	return server.Serve(ln)
+}
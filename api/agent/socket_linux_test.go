@@ -0,0 +1,137 @@
+//go:build linux
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/spiffe/tornjak/pkg/agent/authentication/authenticator"
+)
+
+func TestListenUnix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tornjak.sock")
+
+	// a socket left behind by a previous run is replaced
+	stale, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ln, err := SocketConfig{Path: path, Mode: "0660"}.listenUnix()
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode()&os.ModeSocket == 0 || info.Mode().Perm() != 0660 {
+		t.Fatalf("Expected a socket with mode 0660, got %v", info.Mode())
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("Expected only the socket in %s, got %d entries", dir, len(entries))
+	}
+
+	if err := ln.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Fatalf("Expected the socket removed on close, got %v", err)
+	}
+
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (SocketConfig{Path: file}).listenUnix(); err == nil || !strings.Contains(err.Error(), "is not a socket") {
+		t.Fatalf("Expected an error for a regular file, got %v", err)
+	}
+}
+
+func TestUnixPeerRoles(t *testing.T) {
+	uid, gid := uint32(os.Getuid()), uint32(os.Getgid())
+	tests := []struct {
+		name      string
+		uidRoles  map[uint32][]string
+		gidRoles  map[uint32][]string
+		wantRoles []string
+		wantErr   bool
+	}{
+		{name: "uid and gid", uidRoles: map[uint32][]string{uid: {"admin"}}, gidRoles: map[uint32][]string{gid: {"viewer"}}, wantRoles: []string{"admin", "viewer"}},
+		{name: "gid only", gidRoles: map[uint32][]string{gid: {"viewer"}}, wantRoles: []string{"viewer"}},
+		{name: "not mapped", uidRoles: map[uint32][]string{uid + 1: {"admin"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth, err := authenticator.NewUnixPeerAuthenticator(tt.uidRoles, tt.gidRoles)
+			if err != nil {
+				t.Fatal(err)
+			}
+			path := filepath.Join(t.TempDir(), "tornjak.sock")
+			ln, err := SocketConfig{Path: path}.listenUnix()
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := &http.Server{
+				Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if !auth.Recognizes(r) {
+						t.Errorf("Expected the unix socket request recognized")
+					}
+					userInfo := auth.AuthenticateRequest(r)
+					if userInfo.AuthenticationError != nil {
+						w.WriteHeader(http.StatusUnauthorized)
+						return
+					}
+					json.NewEncoder(w).Encode(userInfo)
+				}),
+				ConnContext: peerCredentialsContext,
+			}
+			go server.Serve(ln)
+			defer server.Close()
+
+			client := &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					return (&net.Dialer{}).DialContext(ctx, "unix", path)
+				},
+			}}
+			resp, err := client.Get("http://tornjak/api/v1/tornjak/serverinfo")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+
+			if tt.wantErr {
+				if resp.StatusCode != http.StatusUnauthorized {
+					t.Fatalf("Expected %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+				}
+				return
+			}
+			var userInfo struct {
+				Name  string
+				Roles []string
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
+				t.Fatal(err)
+			}
+			if wantName := "uid:" + strconv.FormatUint(uint64(uid), 10); userInfo.Name != wantName || !reflect.DeepEqual(userInfo.Roles, tt.wantRoles) {
+				t.Fatalf("Expected %s with roles %v, got %s with %v", wantName, tt.wantRoles, userInfo.Name, userInfo.Roles)
+			}
+		})
+	}
+}
@@ -19,8 +19,8 @@ import (
 type HealthcheckRequest grpc_health_v1.HealthCheckRequest
 type HealthcheckResponse grpc_health_v1.HealthCheckResponse
 
-func (s *Server) SPIREHealthcheck(inp HealthcheckRequest) (*HealthcheckResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := grpc_health_v1.HealthCheckRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) SPIREHealthcheck(inp *HealthcheckRequest) (*HealthcheckResponse, error) {
+	inpReq := (*grpc_health_v1.HealthCheckRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -29,7 +29,7 @@ func (s *Server) SPIREHealthcheck(inp HealthcheckRequest) (*HealthcheckResponse,
 	defer conn.Close()
 	client := grpc_health_v1.NewHealthClient(conn)
 
-	resp, err := client.Check(context.Background(), &inpReq)
+	resp, err := client.Check(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -40,8 +40,8 @@ func (s *Server) SPIREHealthcheck(inp HealthcheckRequest) (*HealthcheckResponse,
 type DebugServerRequest debugServer.GetInfoRequest
 type DebugServerResponse debugServer.GetInfoResponse
 
-func (s *Server) DebugServer(inp DebugServerRequest) (*DebugServerResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := debugServer.GetInfoRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) DebugServer(inp *DebugServerRequest) (*DebugServerResponse, error) {
+	inpReq := (*debugServer.GetInfoRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -50,7 +50,7 @@ func (s *Server) DebugServer(inp DebugServerRequest) (*DebugServerResponse, erro
 	defer conn.Close()
 	client := debugServer.NewDebugClient(conn)
 
-	resp, err := client.GetInfo(context.Background(), &inpReq)
+	resp, err := client.GetInfo(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -61,8 +61,8 @@ func (s *Server) DebugServer(inp DebugServerRequest) (*DebugServerResponse, erro
 type ListAgentsRequest agent.ListAgentsRequest
 type ListAgentsResponse agent.ListAgentsResponse
 
-func (s *Server) ListAgents(inp ListAgentsRequest) (*ListAgentsResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := agent.ListAgentsRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) ListAgents(inp *ListAgentsRequest) (*ListAgentsResponse, error) {
+	inpReq := (*agent.ListAgentsRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -71,7 +71,7 @@ func (s *Server) ListAgents(inp ListAgentsRequest) (*ListAgentsResponse, error)
 	defer conn.Close()
 	client := agent.NewAgentClient(conn)
 
-	resp, err := client.ListAgents(context.Background(), &inpReq)
+	resp, err := client.ListAgents(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -79,10 +79,31 @@ func (s *Server) ListAgents(inp ListAgentsRequest) (*ListAgentsResponse, error)
 	return (*ListAgentsResponse)(resp), nil
 }
 
+type GetAgentRequest agent.GetAgentRequest
+type GetAgentResponse types.Agent
+
+func (s *Server) GetAgent(inp *GetAgentRequest) (*GetAgentResponse, error) {
+	inpReq := (*agent.GetAgentRequest)(inp)
+	var conn *grpc.ClientConn
+	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	client := agent.NewAgentClient(conn)
+
+	resp, err := client.GetAgent(context.Background(), inpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return (*GetAgentResponse)(resp), nil
+}
+
 type BanAgentRequest agent.BanAgentRequest
 
-func (s *Server) BanAgent(inp BanAgentRequest) error { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := agent.BanAgentRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) BanAgent(inp *BanAgentRequest) error {
+	inpReq := (*agent.BanAgentRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -91,7 +112,7 @@ func (s *Server) BanAgent(inp BanAgentRequest) error { //nolint:govet //Ignoring
 	defer conn.Close()
 	client := agent.NewAgentClient(conn)
 
-	_, err = client.BanAgent(context.Background(), &inpReq)
+	_, err = client.BanAgent(context.Background(), inpReq)
 	if err != nil {
 		return err
 	}
@@ -101,8 +122,8 @@ func (s *Server) BanAgent(inp BanAgentRequest) error { //nolint:govet //Ignoring
 
 type DeleteAgentRequest agent.DeleteAgentRequest
 
-func (s *Server) DeleteAgent(inp DeleteAgentRequest) error { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := agent.DeleteAgentRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) DeleteAgent(inp *DeleteAgentRequest) error {
+	inpReq := (*agent.DeleteAgentRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -111,7 +132,7 @@ func (s *Server) DeleteAgent(inp DeleteAgentRequest) error { //nolint:govet //Ig
 	defer conn.Close()
 	client := agent.NewAgentClient(conn)
 
-	_, err = client.DeleteAgent(context.Background(), &inpReq)
+	_, err = client.DeleteAgent(context.Background(), inpReq)
 	if err != nil {
 		return err
 	}
@@ -122,8 +143,8 @@ func (s *Server) DeleteAgent(inp DeleteAgentRequest) error { //nolint:govet //Ig
 type CreateJoinTokenRequest agent.CreateJoinTokenRequest
 type CreateJoinTokenResponse types.JoinToken
 
-func (s *Server) CreateJoinToken(inp CreateJoinTokenRequest) (*CreateJoinTokenResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := agent.CreateJoinTokenRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) CreateJoinToken(inp *CreateJoinTokenRequest) (*CreateJoinTokenResponse, error) {
+	inpReq := (*agent.CreateJoinTokenRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -132,7 +153,7 @@ func (s *Server) CreateJoinToken(inp CreateJoinTokenRequest) (*CreateJoinTokenRe
 	defer conn.Close()
 	client := agent.NewAgentClient(conn)
 
-	joinToken, err := client.CreateJoinToken(context.Background(), &inpReq)
+	joinToken, err := client.CreateJoinToken(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -145,8 +166,8 @@ func (s *Server) CreateJoinToken(inp CreateJoinTokenRequest) (*CreateJoinTokenRe
 type ListEntriesRequest entry.ListEntriesRequest
 type ListEntriesResponse entry.ListEntriesResponse
 
-func (s *Server) ListEntries(inp ListEntriesRequest) (*ListEntriesResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := entry.ListEntriesRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) ListEntries(inp *ListEntriesRequest) (*ListEntriesResponse, error) {
+	inpReq := (*entry.ListEntriesRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -155,7 +176,7 @@ func (s *Server) ListEntries(inp ListEntriesRequest) (*ListEntriesResponse, erro
 	defer conn.Close()
 	client := entry.NewEntryClient(conn)
 
-	resp, err := client.ListEntries(context.Background(), &inpReq)
+	resp, err := client.ListEntries(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -163,11 +184,32 @@ func (s *Server) ListEntries(inp ListEntriesRequest) (*ListEntriesResponse, erro
 	return (*ListEntriesResponse)(resp), nil
 }
 
+type GetEntryRequest entry.GetEntryRequest
+type GetEntryResponse types.Entry
+
+func (s *Server) GetEntry(inp *GetEntryRequest) (*GetEntryResponse, error) {
+	inpReq := (*entry.GetEntryRequest)(inp)
+	var conn *grpc.ClientConn
+	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	client := entry.NewEntryClient(conn)
+
+	resp, err := client.GetEntry(context.Background(), inpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return (*GetEntryResponse)(resp), nil
+}
+
 type BatchCreateEntryRequest entry.BatchCreateEntryRequest
 type BatchCreateEntryResponse entry.BatchCreateEntryResponse
 
-func (s *Server) BatchCreateEntry(inp BatchCreateEntryRequest) (*BatchCreateEntryResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := entry.BatchCreateEntryRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) BatchCreateEntry(inp *BatchCreateEntryRequest) (*BatchCreateEntryResponse, error) {
+	inpReq := (*entry.BatchCreateEntryRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -176,7 +218,7 @@ func (s *Server) BatchCreateEntry(inp BatchCreateEntryRequest) (*BatchCreateEntr
 	defer conn.Close()
 	client := entry.NewEntryClient(conn)
 
-	resp, err := client.BatchCreateEntry(context.Background(), &inpReq)
+	resp, err := client.BatchCreateEntry(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -184,11 +226,32 @@ func (s *Server) BatchCreateEntry(inp BatchCreateEntryRequest) (*BatchCreateEntr
 	return (*BatchCreateEntryResponse)(resp), nil
 }
 
+type BatchUpdateEntryRequest entry.BatchUpdateEntryRequest
+type BatchUpdateEntryResponse entry.BatchUpdateEntryResponse
+
+func (s *Server) BatchUpdateEntry(inp *BatchUpdateEntryRequest) (*BatchUpdateEntryResponse, error) {
+	inpReq := (*entry.BatchUpdateEntryRequest)(inp)
+	var conn *grpc.ClientConn
+	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	client := entry.NewEntryClient(conn)
+
+	resp, err := client.BatchUpdateEntry(context.Background(), inpReq)
+	if err != nil {
+		return nil, err
+	}
+
+	return (*BatchUpdateEntryResponse)(resp), nil
+}
+
 type BatchDeleteEntryRequest entry.BatchDeleteEntryRequest
 type BatchDeleteEntryResponse entry.BatchDeleteEntryResponse
 
-func (s *Server) BatchDeleteEntry(inp BatchDeleteEntryRequest) (*BatchDeleteEntryResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := entry.BatchDeleteEntryRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) BatchDeleteEntry(inp *BatchDeleteEntryRequest) (*BatchDeleteEntryResponse, error) {
+	inpReq := (*entry.BatchDeleteEntryRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -197,7 +260,7 @@ func (s *Server) BatchDeleteEntry(inp BatchDeleteEntryRequest) (*BatchDeleteEntr
 	defer conn.Close()
 	client := entry.NewEntryClient(conn)
 
-	resp, err := client.BatchDeleteEntry(context.Background(), &inpReq)
+	resp, err := client.BatchDeleteEntry(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -219,8 +282,8 @@ func (s *Server) GetTornjakServerInfo(inp GetTornjakServerInfoRequest) (*GetTorn
 type GetBundleRequest bundle.GetBundleRequest
 type GetBundleResponse types.Bundle
 
-func (s *Server) GetBundle(inp GetBundleRequest) (*GetBundleResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := bundle.GetBundleRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) GetBundle(inp *GetBundleRequest) (*GetBundleResponse, error) {
+	inpReq := (*bundle.GetBundleRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -229,7 +292,7 @@ func (s *Server) GetBundle(inp GetBundleRequest) (*GetBundleResponse, error) { /
 	defer conn.Close()
 	client := bundle.NewBundleClient(conn)
 
-	bundle, err := client.GetBundle(context.Background(), &inpReq)
+	bundle, err := client.GetBundle(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -240,8 +303,8 @@ func (s *Server) GetBundle(inp GetBundleRequest) (*GetBundleResponse, error) { /
 type ListFederatedBundlesRequest bundle.ListFederatedBundlesRequest
 type ListFederatedBundlesResponse bundle.ListFederatedBundlesResponse
 
-func (s *Server) ListFederatedBundles(inp ListFederatedBundlesRequest) (*ListFederatedBundlesResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := bundle.ListFederatedBundlesRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) ListFederatedBundles(inp *ListFederatedBundlesRequest) (*ListFederatedBundlesResponse, error) {
+	inpReq := (*bundle.ListFederatedBundlesRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -250,7 +313,7 @@ func (s *Server) ListFederatedBundles(inp ListFederatedBundlesRequest) (*ListFed
 	defer conn.Close()
 	client := bundle.NewBundleClient(conn)
 
-	bundle, err := client.ListFederatedBundles(context.Background(), &inpReq)
+	bundle, err := client.ListFederatedBundles(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -261,8 +324,8 @@ func (s *Server) ListFederatedBundles(inp ListFederatedBundlesRequest) (*ListFed
 type CreateFederatedBundleRequest bundle.BatchCreateFederatedBundleRequest
 type CreateFederatedBundleResponse bundle.BatchCreateFederatedBundleResponse
 
-func (s *Server) CreateFederatedBundle(inp CreateFederatedBundleRequest) (*CreateFederatedBundleResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := bundle.BatchCreateFederatedBundleRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) CreateFederatedBundle(inp *CreateFederatedBundleRequest) (*CreateFederatedBundleResponse, error) {
+	inpReq := (*bundle.BatchCreateFederatedBundleRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -271,7 +334,7 @@ func (s *Server) CreateFederatedBundle(inp CreateFederatedBundleRequest) (*Creat
 	defer conn.Close()
 	client := bundle.NewBundleClient(conn)
 
-	bundle, err := client.BatchCreateFederatedBundle(context.Background(), &inpReq)
+	bundle, err := client.BatchCreateFederatedBundle(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -282,8 +345,8 @@ func (s *Server) CreateFederatedBundle(inp CreateFederatedBundleRequest) (*Creat
 type UpdateFederatedBundleRequest bundle.BatchUpdateFederatedBundleRequest
 type UpdateFederatedBundleResponse bundle.BatchUpdateFederatedBundleResponse
 
-func (s *Server) UpdateFederatedBundle(inp UpdateFederatedBundleRequest) (*UpdateFederatedBundleResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := bundle.BatchUpdateFederatedBundleRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) UpdateFederatedBundle(inp *UpdateFederatedBundleRequest) (*UpdateFederatedBundleResponse, error) {
+	inpReq := (*bundle.BatchUpdateFederatedBundleRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -292,7 +355,7 @@ func (s *Server) UpdateFederatedBundle(inp UpdateFederatedBundleRequest) (*Updat
 	defer conn.Close()
 	client := bundle.NewBundleClient(conn)
 
-	bundle, err := client.BatchUpdateFederatedBundle(context.Background(), &inpReq)
+	bundle, err := client.BatchUpdateFederatedBundle(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -303,8 +366,8 @@ func (s *Server) UpdateFederatedBundle(inp UpdateFederatedBundleRequest) (*Updat
 type DeleteFederatedBundleRequest bundle.BatchDeleteFederatedBundleRequest
 type DeleteFederatedBundleResponse bundle.BatchDeleteFederatedBundleResponse
 
-func (s *Server) DeleteFederatedBundle(inp DeleteFederatedBundleRequest) (*DeleteFederatedBundleResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := bundle.BatchDeleteFederatedBundleRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) DeleteFederatedBundle(inp *DeleteFederatedBundleRequest) (*DeleteFederatedBundleResponse, error) {
+	inpReq := (*bundle.BatchDeleteFederatedBundleRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -313,7 +376,7 @@ func (s *Server) DeleteFederatedBundle(inp DeleteFederatedBundleRequest) (*Delet
 	defer conn.Close()
 	client := bundle.NewBundleClient(conn)
 
-	bundle, err := client.BatchDeleteFederatedBundle(context.Background(), &inpReq)
+	bundle, err := client.BatchDeleteFederatedBundle(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -325,8 +388,8 @@ func (s *Server) DeleteFederatedBundle(inp DeleteFederatedBundleRequest) (*Delet
 type ListFederationRelationshipsRequest trustdomain.ListFederationRelationshipsRequest
 type ListFederationRelationshipsResponse trustdomain.ListFederationRelationshipsResponse
 
-func (s *Server) ListFederationRelationships(inp ListFederationRelationshipsRequest) (*ListFederationRelationshipsResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := trustdomain.ListFederationRelationshipsRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) ListFederationRelationships(inp *ListFederationRelationshipsRequest) (*ListFederationRelationshipsResponse, error) {
+	inpReq := (*trustdomain.ListFederationRelationshipsRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -335,7 +398,7 @@ func (s *Server) ListFederationRelationships(inp ListFederationRelationshipsRequ
 	defer conn.Close()
 	client := trustdomain.NewTrustDomainClient(conn)
 
-	bundle, err := client.ListFederationRelationships(context.Background(), &inpReq)
+	bundle, err := client.ListFederationRelationships(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -346,8 +409,8 @@ func (s *Server) ListFederationRelationships(inp ListFederationRelationshipsRequ
 type CreateFederationRelationshipRequest trustdomain.BatchCreateFederationRelationshipRequest
 type CreateFederationRelationshipResponse trustdomain.BatchCreateFederationRelationshipResponse
 
-func (s *Server) CreateFederationRelationship(inp CreateFederationRelationshipRequest) (*CreateFederationRelationshipResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := trustdomain.BatchCreateFederationRelationshipRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) CreateFederationRelationship(inp *CreateFederationRelationshipRequest) (*CreateFederationRelationshipResponse, error) {
+	inpReq := (*trustdomain.BatchCreateFederationRelationshipRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -356,7 +419,7 @@ func (s *Server) CreateFederationRelationship(inp CreateFederationRelationshipRe
 	defer conn.Close()
 	client := trustdomain.NewTrustDomainClient(conn)
 
-	bundle, err := client.BatchCreateFederationRelationship(context.Background(), &inpReq)
+	bundle, err := client.BatchCreateFederationRelationship(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -367,8 +430,8 @@ func (s *Server) CreateFederationRelationship(inp CreateFederationRelationshipRe
 type UpdateFederationRelationshipRequest trustdomain.BatchUpdateFederationRelationshipRequest
 type UpdateFederationRelationshipResponse trustdomain.BatchUpdateFederationRelationshipResponse
 
-func (s *Server) UpdateFederationRelationship(inp UpdateFederationRelationshipRequest) (*UpdateFederationRelationshipResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := trustdomain.BatchUpdateFederationRelationshipRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) UpdateFederationRelationship(inp *UpdateFederationRelationshipRequest) (*UpdateFederationRelationshipResponse, error) {
+	inpReq := (*trustdomain.BatchUpdateFederationRelationshipRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -377,7 +440,7 @@ func (s *Server) UpdateFederationRelationship(inp UpdateFederationRelationshipRe
 	defer conn.Close()
 	client := trustdomain.NewTrustDomainClient(conn)
 
-	bundle, err := client.BatchUpdateFederationRelationship(context.Background(), &inpReq)
+	bundle, err := client.BatchUpdateFederationRelationship(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -388,8 +451,8 @@ func (s *Server) UpdateFederationRelationship(inp UpdateFederationRelationshipRe
 type DeleteFederationRelationshipRequest trustdomain.BatchDeleteFederationRelationshipRequest
 type DeleteFederationRelationshipResponse trustdomain.BatchDeleteFederationRelationshipResponse
 
-func (s *Server) DeleteFederationRelationship(inp DeleteFederationRelationshipRequest) (*DeleteFederationRelationshipResponse, error) { //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
-	inpReq := trustdomain.BatchDeleteFederationRelationshipRequest(inp) //nolint:govet //Ignoring mutex (not being used) - sync.Mutex by value is unused for linter govet
+func (s *Server) DeleteFederationRelationship(inp *DeleteFederationRelationshipRequest) (*DeleteFederationRelationshipResponse, error) {
+	inpReq := (*trustdomain.BatchDeleteFederationRelationshipRequest)(inp)
 	var conn *grpc.ClientConn
 	conn, err := grpc.Dial(s.SpireServerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -398,7 +461,7 @@ func (s *Server) DeleteFederationRelationship(inp DeleteFederationRelationshipRe
 	defer conn.Close()
 	client := trustdomain.NewTrustDomainClient(conn)
 
-	bundle, err := client.BatchDeleteFederationRelationship(context.Background(), &inpReq)
+	bundle, err := client.BatchDeleteFederationRelationship(context.Background(), inpReq)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/printer"
+	"github.com/pkg/errors"
+	"github.com/spiffe/spire/pkg/common/catalog"
+)
+
+// ParseSpireServerInfo extracts what Tornjak reports about the SPIRE server
+// from its config. Values of keys marked by redactKeys are redacted, in the
+// plugin data as well as in the verbose config.
+func ParseSpireServerInfo(configData string, redactKeys []string) (TornjakSpireServerInfo, error) {
+	root, err := hcl.Parse(configData)
+	if err != nil {
+		return TornjakSpireServerInfo{}, errors.Errorf("Could not parse SPIRE Config: %v", err)
+	}
+	RedactConfig(root, redactKeys)
+
+	config := &SPIREConfig{}
+	if err := hcl.DecodeObject(config, root); err != nil {
+		return TornjakSpireServerInfo{}, errors.Errorf("Could not parse SPIRE Config: %v", err)
+	}
+	if config.Server == nil {
+		return TornjakSpireServerInfo{}, errors.New("config server section should not be nil")
+	}
+	if config.Plugins == nil {
+		return TornjakSpireServerInfo{}, errors.New("config plugins map should not be nil")
+	}
+
+	pluginConfigs, err := catalog.PluginConfigsFromHCLNode(config.Plugins)
+	if err != nil {
+		return TornjakSpireServerInfo{}, errors.Errorf("Unable to parse plugin HCL: %v", err)
+	}
+
+	verbose := "Plugin Info\n"
+	pluginMap := map[string][]string{}
+	plugins := []SpirePluginInfo{}
+	for _, pc := range pluginConfigs {
+		verbose += fmt.Sprintf("%v Plugin: %v\n", pc.Type, pc.Name)
+		verbose += fmt.Sprintf("Data: %v\n\n", pc.Data)
+		pluginMap[pc.Type] = append(pluginMap[pc.Type], pc.Name)
+
+		// the data was redacted with the rest of the config
+		data := map[string]interface{}{}
+		if pc.Data != "" {
+			dataRoot, err := hcl.Parse(pc.Data)
+			if err != nil {
+				return TornjakSpireServerInfo{}, errors.Errorf("Unable to parse plugin data of %s %s: %v", pc.Type, pc.Name, err)
+			}
+			if m, ok := ConfigValue(dataRoot).(map[string]interface{}); ok {
+				data = m
+			}
+		}
+		plugins = append(plugins, SpirePluginInfo{
+			Type:      pc.Type,
+			Name:      pc.Name,
+			Enabled:   pc.IsEnabled(),
+			PluginCmd: pc.Path,
+			Data:      data,
+		})
+	}
+
+	var redacted bytes.Buffer
+	if err := printer.Fprint(&redacted, root); err != nil {
+		return TornjakSpireServerInfo{}, errors.Errorf("Could not print SPIRE Config: %v", err)
+	}
+	verbose += "\n\n"
+	verbose += "Server Info\n"
+	verbose += redacted.String()
+
+	server := config.Server
+	info := TornjakSpireServerInfo{
+		Plugins:            pluginMap,
+		TrustDomain:        server.TrustDomain,
+		CATTL:              server.CATTL,
+		DefaultX509SVIDTTL: server.DefaultX509SVIDTTL,
+		DefaultJWTSVIDTTL:  server.DefaultJWTSVIDTTL,
+		PluginConfigs:      plugins,
+		VerboseConfig:      verbose,
+	}
+	if server.BindAddress != "" || server.BindPort != 0 {
+		info.BindAddress = net.JoinHostPort(server.BindAddress, strconv.Itoa(server.BindPort))
+	}
+	if subject := server.CASubject; subject != nil {
+		info.CASubject = &SpireCASubject{
+			Country:      subject.Country,
+			Organization: subject.Organization,
+			CommonName:   subject.CommonName,
+		}
+	}
+	if server.Federation != nil {
+		info.Federation, err = spireFederationInfo(server.Federation)
+		if err != nil {
+			return TornjakSpireServerInfo{}, err
+		}
+	}
+	return info, nil
+}
+
+// spireFederationInfo summarizes the federation section of the SPIRE config
+func spireFederationInfo(config *spireFederationConfig) (*SpireFederationInfo, error) {
+	info := &SpireFederationInfo{}
+	if endpoint := config.BundleEndpoint; endpoint != nil {
+		info.BundleEndpoint = &SpireBundleEndpointInfo{
+			Address: endpoint.Address,
+			Port:    endpoint.Port,
+		}
+		if endpoint.ACME != nil {
+			info.BundleEndpoint.ACMEDomainName = endpoint.ACME.DomainName
+		}
+	}
+
+	trustDomains := make([]string, 0, len(config.FederatesWith))
+	for td := range config.FederatesWith {
+		trustDomains = append(trustDomains, td)
+	}
+	sort.Strings(trustDomains)
+	for _, td := range trustDomains {
+		relationship := config.FederatesWith[td]
+		federatesWith := SpireFederatesWithInfo{
+			TrustDomain:       td,
+			BundleEndpointURL: relationship.BundleEndpointURL,
+		}
+		if relationship.BundleEndpointProfile != nil {
+			var profile spireBundleEndpointProfileConfig
+			if err := hcl.DecodeObject(&profile, relationship.BundleEndpointProfile); err != nil {
+				return nil, errors.Errorf("Could not parse bundle endpoint profile of %s: %v", td, err)
+			}
+			switch {
+			case profile.HTTPSSPIFFE != nil:
+				federatesWith.BundleEndpointProfile = "https_spiffe"
+				federatesWith.EndpointSPIFFEID = profile.HTTPSSPIFFE.EndpointSPIFFEID
+			case profile.HTTPSWeb != nil:
+				federatesWith.BundleEndpointProfile = "https_web"
+			}
+		}
+		info.FederatesWith = append(info.FederatesWith, federatesWith)
+	}
+	return info, nil
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/printer"
+)
+
+const testSpireConfig = `
+server {
+    bind_address = "0.0.0.0"
+    bind_port = 8081
+    trust_domain = "example.org"
+    data_dir = "/run/spire/data"
+    ca_ttl = "168h"
+    default_x509_svid_ttl = "1h"
+    default_jwt_svid_ttl = "5m"
+    ca_subject {
+        country = ["US"]
+        organization = ["SPIFFE"]
+        common_name = "spire-ca"
+    }
+    federation {
+        bundle_endpoint {
+            address = "0.0.0.0"
+            port = 8443
+        }
+        federates_with "web.org" {
+            bundle_endpoint_url = "https://web.org/bundle"
+            bundle_endpoint_profile "https_web" {}
+        }
+        federates_with "other.org" {
+            bundle_endpoint_url = "https://other.org:8443"
+            bundle_endpoint_profile "https_spiffe" {
+                endpoint_spiffe_id = "spiffe://other.org/spire/server"
+            }
+        }
+    }
+}
+
+plugins {
+    DataStore "sql" {
+        plugin_data {
+            database_type = "postgres"
+            connection_string = "dbname=spire user=spire password=hunter2 host=db"
+        }
+    }
+    KeyManager "aws_kms" {
+        plugin_data {
+            region = "us-east-1"
+            access_key_id = "AKIAEXAMPLE"
+            secret_access_key = "kms-secret"
+        }
+    }
+    UpstreamAuthority "vault" {
+        plugin_data {
+            vault_addr = "https://vault.example.org"
+            token_auth {
+                token = "s.vaulttoken"
+            }
+            pin = "1234"
+        }
+    }
+}
+`
+
+func TestParseSpireServerInfo(t *testing.T) {
+	info, err := ParseSpireServerInfo(testSpireConfig, DefaultRedactKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.TrustDomain != "example.org" || info.BindAddress != "0.0.0.0:8081" {
+		t.Fatalf("Expected example.org at 0.0.0.0:8081, got %s at %s", info.TrustDomain, info.BindAddress)
+	}
+	if info.CATTL != "168h" || info.DefaultX509SVIDTTL != "1h" || info.DefaultJWTSVIDTTL != "5m" {
+		t.Fatalf("Expected TTLs 168h, 1h and 5m, got %s, %s and %s", info.CATTL, info.DefaultX509SVIDTTL, info.DefaultJWTSVIDTTL)
+	}
+	wantSubject := &SpireCASubject{Country: []string{"US"}, Organization: []string{"SPIFFE"}, CommonName: "spire-ca"}
+	if !reflect.DeepEqual(info.CASubject, wantSubject) {
+		t.Fatalf("Expected CA subject %+v, got %+v", wantSubject, info.CASubject)
+	}
+	wantFederation := &SpireFederationInfo{
+		BundleEndpoint: &SpireBundleEndpointInfo{Address: "0.0.0.0", Port: 8443},
+		// sorted by trust domain
+		FederatesWith: []SpireFederatesWithInfo{
+			{TrustDomain: "other.org", BundleEndpointURL: "https://other.org:8443", BundleEndpointProfile: "https_spiffe", EndpointSPIFFEID: "spiffe://other.org/spire/server"},
+			{TrustDomain: "web.org", BundleEndpointURL: "https://web.org/bundle", BundleEndpointProfile: "https_web"},
+		},
+	}
+	if !reflect.DeepEqual(info.Federation, wantFederation) {
+		t.Fatalf("Expected federation %+v, got %+v", wantFederation, info.Federation)
+	}
+	wantPlugins := map[string][]string{"DataStore": {"sql"}, "KeyManager": {"aws_kms"}, "UpstreamAuthority": {"vault"}}
+	if !reflect.DeepEqual(info.Plugins, wantPlugins) {
+		t.Fatalf("Expected plugins %v, got %v", wantPlugins, info.Plugins)
+	}
+	if len(info.PluginConfigs) != 3 || info.PluginConfigs[1].Type != "KeyManager" || !info.PluginConfigs[1].Enabled ||
+		info.PluginConfigs[1].Data["region"] != "us-east-1" {
+		t.Fatalf("Unexpected plugin configs %+v", info.PluginConfigs)
+	}
+}
+
+func TestParseSpireServerInfoRedaction(t *testing.T) {
+	tests := []struct {
+		name       string
+		redactKeys []string
+		// wantRedacted are the paths of the plugin data redacted
+		wantRedacted [][]string
+		wantSecrets  []string
+		wantKept     []string
+	}{
+		{
+			name:       "default keys",
+			redactKeys: DefaultRedactKeys,
+			wantRedacted: [][]string{
+				{"DataStore", "connection_string"},
+				{"KeyManager", "access_key_id"},
+				{"KeyManager", "secret_access_key"},
+				{"UpstreamAuthority", "token_auth", "token"},
+			},
+			wantSecrets: []string{"hunter2", "AKIAEXAMPLE", "kms-secret", "s.vaulttoken"},
+			wantKept:    []string{"postgres", "us-east-1", "https://vault.example.org", "1234"},
+		},
+		{
+			name:       "redact_keys added",
+			redactKeys: RedactKeys(&TornjakConfig{Server: &serverConfig{RedactKeys: []string{"PIN", "region"}}}),
+			wantRedacted: [][]string{
+				{"DataStore", "connection_string"},
+				{"KeyManager", "region"},
+				{"UpstreamAuthority", "pin"},
+			},
+			wantSecrets: []string{"hunter2", "AKIAEXAMPLE", "kms-secret", "s.vaulttoken", "1234", "us-east-1"},
+			wantKept:    []string{"postgres", "https://vault.example.org"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParseSpireServerInfo(testSpireConfig, tt.redactKeys)
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := json.Marshal(info.PluginConfigs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, secret := range tt.wantSecrets {
+				if strings.Contains(string(data), secret) || strings.Contains(info.VerboseConfig, secret) {
+					t.Fatalf("Expected %q redacted, got plugin configs %s and verbose config %s", secret, data, info.VerboseConfig)
+				}
+			}
+			for _, value := range tt.wantKept {
+				if !strings.Contains(string(data), value) || !strings.Contains(info.VerboseConfig, value) {
+					t.Fatalf("Expected %q kept, got plugin configs %s and verbose config %s", value, data, info.VerboseConfig)
+				}
+			}
+
+			plugins := map[string]interface{}{}
+			for _, plugin := range info.PluginConfigs {
+				plugins[plugin.Type] = plugin.Data
+			}
+			for _, path := range tt.wantRedacted {
+				var value interface{} = plugins
+				for _, key := range path {
+					m, _ := value.(map[string]interface{})
+					value = m[key]
+				}
+				if value != redactedValue {
+					t.Fatalf("Expected %s to be %s, got %v", strings.Join(path, " > "), redactedValue, value)
+				}
+			}
+		})
+	}
+}
+
+func TestRedactConfig(t *testing.T) {
+	root, err := hcl.Parse(`
+db_password = "a"
+Password = "b"
+auth_token {
+    id = "c"
+    value = "d"
+}
+note = "e"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	RedactConfig(root, append([]string{"value"}, DefaultRedactKeys...))
+
+	var out bytes.Buffer
+	if err := printer.Fprint(&out, root); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"db_password": redactedValue,
+		"Password":    redactedValue,
+		// blocks are not replaced, only the secrets in them
+		"auth_token": map[string]interface{}{"id": "c", "value": redactedValue},
+		"note":       "e",
+	}
+	if got := ConfigValue(root); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Expected %v, got %v printed as %s", want, got, out.String())
+	}
+}
@@ -0,0 +1,399 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultCertReloadInterval is how often certificate files are checked for
+// changes when 'config > server > https > cert_reload_interval' is not set.
+const defaultCertReloadInterval = time.Minute
+
+var tlsVersions = map[string]uint16{
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// parseTLSVersion returns the TLS version for the given name, TLS 1.2 if empty.
+func parseTLSVersion(name string) (uint16, error) {
+	if name == "" {
+		return tls.VersionTLS12, nil
+	}
+	version, ok := tlsVersions[name]
+	if !ok {
+		return 0, fmt.Errorf("unsupported TLS version %q: expected 1.2 or 1.3", name)
+	}
+	return version, nil
+}
+
+// parseCipherSuites returns the IDs of the named cipher suites. Only suites
+// considered secure by crypto/tls are accepted.
+func parseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	known := map[string]uint16{}
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// fileStamp identifies a version of a file on disk
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// certReloader serves a certificate, client CA bundle and CRL read from
+// files, and reloads them when the files change. Any part may be unset.
+type certReloader struct {
+	certPath     string
+	keyPath      string
+	clientCAPath string
+	crlPath      string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	revoked   map[string]map[string]struct{} // CRL issuer -> revoked serials
+	stamps    map[string]fileStamp
+}
+
+func newCertReloader(certPath, keyPath, clientCAPath, crlPath string) (*certReloader, error) {
+	c := &certReloader{
+		certPath:     certPath,
+		keyPath:      keyPath,
+		clientCAPath: clientCAPath,
+		crlPath:      crlPath,
+	}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *certReloader) paths() []string {
+	var paths []string
+	for _, path := range []string{c.certPath, c.keyPath, c.clientCAPath, c.crlPath} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func (c *certReloader) statFiles() (map[string]fileStamp, error) {
+	stamps := map[string]fileStamp{}
+	for _, path := range c.paths() {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		stamps[path] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps, nil
+}
+
+// load reads all files and swaps them in only if all of them are valid
+func (c *certReloader) load() error {
+	stamps, err := c.statFiles()
+	if err != nil {
+		return err
+	}
+
+	var cert *tls.Certificate
+	if c.certPath != "" {
+		pair, err := tls.LoadX509KeyPair(c.certPath, c.keyPath)
+		if err != nil {
+			return fmt.Errorf("could not load server cert '%s' and key '%s': %w", c.certPath, c.keyPath, err)
+		}
+		cert = &pair
+	}
+
+	var clientCAs *x509.CertPool
+	var caCerts []*x509.Certificate
+	if c.clientCAPath != "" {
+		clientCAs, caCerts, err = loadClientCAs(c.clientCAPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	var revoked map[string]map[string]struct{}
+	if c.crlPath != "" {
+		revoked, err = loadCRL(c.crlPath, caCerts)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cert = cert
+	c.clientCAs = clientCAs
+	c.revoked = revoked
+	c.stamps = stamps
+	return nil
+}
+
+// loadClientCAs reads the certificates of a PEM client CA bundle
+func loadClientCAs(path string) (*x509.CertPool, []*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not read client CA '%s': %w", path, err)
+	}
+	pool := x509.NewCertPool()
+	var certs []*x509.Certificate
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not parse client CA '%s': %w", path, err)
+		}
+		pool.AddCert(cert)
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, nil, fmt.Errorf("client CA '%s' contains no PEM certificates", path)
+	}
+	return pool, certs, nil
+}
+
+// loadCRL reads the revoked serials of each CRL in a PEM or DER file,
+// keyed by CRL issuer. Each CRL must be signed by one of caCerts.
+func loadCRL(path string, caCerts []*x509.Certificate) (map[string]map[string]struct{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read CRL '%s': %w", path, err)
+	}
+
+	var ders [][]byte
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type == "X509 CRL" {
+			ders = append(ders, block.Bytes)
+		}
+	}
+	if len(ders) == 0 {
+		ders = [][]byte{data} // not PEM, try DER
+	}
+
+	revoked := map[string]map[string]struct{}{}
+	for _, der := range ders {
+		crl, err := x509.ParseRevocationList(der)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse CRL '%s': %w", path, err)
+		}
+		if err := checkCRLSignature(crl, caCerts); err != nil {
+			return nil, fmt.Errorf("could not verify CRL '%s': %w", path, err)
+		}
+		if !crl.NextUpdate.IsZero() && time.Now().After(crl.NextUpdate) {
+			log.Printf("WARNING: CRL '%s' issued by %s is past its next update time %s", path, crl.Issuer, crl.NextUpdate)
+		}
+		serials, ok := revoked[string(crl.RawIssuer)]
+		if !ok {
+			serials = map[string]struct{}{}
+			revoked[string(crl.RawIssuer)] = serials
+		}
+		for _, entry := range crl.RevokedCertificateEntries {
+			serials[entry.SerialNumber.Text(16)] = struct{}{}
+		}
+	}
+	return revoked, nil
+}
+
+// checkCRLSignature verifies that crl is signed by the CA among caCerts
+// named as its issuer
+func checkCRLSignature(crl *x509.RevocationList, caCerts []*x509.Certificate) error {
+	var err error = fmt.Errorf("issuer %s is not a client CA", crl.Issuer)
+	for _, ca := range caCerts {
+		if !bytes.Equal(ca.RawSubject, crl.RawIssuer) {
+			continue
+		}
+		if err = crl.CheckSignatureFrom(ca); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// changed reports whether any file differs from the last successful load
+func (c *certReloader) changed() bool {
+	stamps, err := c.statFiles()
+	if err != nil {
+		// files being replaced; try again on the next check
+		return false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for path, stamp := range stamps {
+		if old := c.stamps[path]; !old.modTime.Equal(stamp.modTime) || old.size != stamp.size {
+			return true
+		}
+	}
+	return false
+}
+
+// watch reloads the files whenever they change, until ctx is done. A
+// failed reload keeps the previous certificates.
+func (c *certReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !c.changed() {
+				continue
+			}
+			if err := c.load(); err != nil {
+				log.Printf("Failed to reload HTTPS certificates, keeping previous ones: %v", err)
+				continue
+			}
+			log.Printf("Reloaded HTTPS certificates from %v", c.paths())
+		}
+	}
+}
+
+func (c *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cert, nil
+}
+
+func (c *certReloader) clientCAPool() *x509.CertPool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.clientCAs
+}
+
+// isRevoked reports whether cert is listed in a CRL from its issuer
+func (c *certReloader) isRevoked(cert *x509.Certificate) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.revoked[string(cert.RawIssuer)][cert.SerialNumber.Text(16)]
+	return ok
+}
+
+// clientDenyList rejects client certificates by serial or SPIFFE ID
+type clientDenyList struct {
+	serials   map[string]struct{}
+	spiffeIDs map[string]struct{}
+}
+
+// parseSerial parses a certificate serial in hex, optionally colon separated
+func parseSerial(serial string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(strings.ReplaceAll(serial, ":", ""), 16)
+	if !ok {
+		return nil, fmt.Errorf("invalid certificate serial %q: expected hex", serial)
+	}
+	return n, nil
+}
+
+func newClientDenyList(serials, spiffeIDs []string) (*clientDenyList, error) {
+	d := &clientDenyList{
+		serials:   map[string]struct{}{},
+		spiffeIDs: map[string]struct{}{},
+	}
+	for _, serial := range serials {
+		n, err := parseSerial(serial)
+		if err != nil {
+			return nil, err
+		}
+		d.serials[n.Text(16)] = struct{}{}
+	}
+	for _, id := range spiffeIDs {
+		if _, err := parseSPIFFEID(id); err != nil {
+			return nil, err
+		}
+		d.spiffeIDs[id] = struct{}{}
+	}
+	return d, nil
+}
+
+// certSPIFFEID returns the SPIFFE ID in the URI SANs of cert, if any
+func certSPIFFEID(cert *x509.Certificate) string {
+	for _, uri := range cert.URIs {
+		if uri.Scheme == "spiffe" {
+			return uri.String()
+		}
+	}
+	return ""
+}
+
+// verifyClientConnection returns a VerifyConnection hook rejecting client
+// certificates that are revoked by the CRL or on the deny list. It runs
+// after the chain has been verified against the client CA bundle, on full
+// handshakes and session resumptions alike, so that a certificate revoked
+// or denied after its first connection cannot resume a session. Connections
+// without a client certificate are left to the ClientAuth of the config.
+func verifyClientConnection(reloader *certReloader, denied *clientDenyList) func(tls.ConnectionState) error {
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return nil
+		}
+		verifiedChains := cs.VerifiedChains
+		if len(verifiedChains) == 0 || len(verifiedChains[0]) == 0 {
+			return errors.New("no verified client certificate chain")
+		}
+		leaf := verifiedChains[0][0]
+		spiffeID := certSPIFFEID(leaf)
+
+		reason := ""
+		if _, ok := denied.serials[leaf.SerialNumber.Text(16)]; ok {
+			reason = "serial is on the deny list"
+		} else if _, ok := denied.spiffeIDs[spiffeID]; ok && spiffeID != "" {
+			reason = "SPIFFE ID is on the deny list"
+		} else {
+			for _, chain := range verifiedChains {
+				for _, cert := range chain {
+					if reloader.isRevoked(cert) {
+						reason = fmt.Sprintf("certificate %q (serial %s) is revoked", cert.Subject, cert.SerialNumber.Text(16))
+						break
+					}
+				}
+				if reason != "" {
+					break
+				}
+			}
+		}
+		if reason == "" {
+			return nil
+		}
+
+		log.Printf("Rejected client certificate %q (serial %s, SPIFFE ID %q, resumed %t): %s", leaf.Subject, leaf.SerialNumber.Text(16), spiffeID, cs.DidResume, reason)
+		return fmt.Errorf("client certificate rejected: %s", reason)
+	}
+}
@@ -0,0 +1,388 @@
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+
+	"github.com/spiffe/tornjak/pkg/agent/authentication/authenticator"
+	"github.com/spiffe/tornjak/pkg/agent/authentication/user"
+	tornjakv1 "github.com/spiffe/tornjak/pkg/proto/tornjak/api/v1"
+	"github.com/spiffe/tornjak/pkg/proto/tornjak/api/v1/tornjakv1connect"
+)
+
+// testCA is a certificate authority issuing test certificates and CRLs
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCA{cert: cert, key: key}
+}
+
+// issue returns a certificate for a server (with a localhost SAN) or for a
+// client (with the given SPIFFE ID, if any)
+func (ca *testCA) issue(t *testing.T, serial int64, server bool, spiffeID string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	if server {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+		template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	} else {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+		if spiffeID != "" {
+			u, err := url.Parse(spiffeID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			template.URIs = []*url.URL{u}
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+// crl returns a PEM CRL revoking serials, signed by ca
+func (ca *testCA) crl(t *testing.T, number int64, serials ...int64) []byte {
+	template := &x509.RevocationList{
+		Number:     big.NewInt(number),
+		ThisUpdate: time.Now().Add(-time.Minute),
+		NextUpdate: time.Now().Add(time.Hour),
+	}
+	for _, serial := range serials {
+		template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   big.NewInt(serial),
+			RevocationTime: time.Now(),
+		})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, template, ca.cert, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+func certPtr(cert tls.Certificate) *tls.Certificate {
+	return &cert
+}
+
+func writeTestFile(t *testing.T, path string, data []byte) {
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func writeTestCert(t *testing.T, dir string, cert tls.Certificate) (string, string) {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPath, keyPath := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key")
+	writeTestFile(t, certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}))
+	writeTestFile(t, keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+	return certPath, keyPath
+}
+
+// tlsTestEnv serves HTTPS with the TLS config parsed from an HTTPSConfig
+// whose server cert, client CA and CRL files are in dir
+type tlsTestEnv struct {
+	dir          string
+	ca           *testCA
+	config       HTTPSConfig
+	certOptional bool
+	// handler serves the requests, replying "ok" if nil
+	handler http.Handler
+	server  *httptest.Server
+}
+
+func newTLSTestEnv(t *testing.T) *tlsTestEnv {
+	dir := t.TempDir()
+	ca := newTestCA(t, "client CA")
+	certPath, keyPath := writeTestCert(t, dir, ca.issue(t, 100, true, ""))
+	caPath := filepath.Join(dir, "ca.pem")
+	writeTestFile(t, caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw}))
+	return &tlsTestEnv{
+		dir: dir,
+		ca:  ca,
+		config: HTTPSConfig{
+			Cert:               certPath,
+			Key:                keyPath,
+			ClientCA:           caPath,
+			CertReloadInterval: "10ms",
+		},
+	}
+}
+
+func (e *tlsTestEnv) writeCRL(t *testing.T, crl []byte) {
+	e.config.CRL = filepath.Join(e.dir, "ca.crl")
+	writeTestFile(t, e.config.CRL, crl)
+}
+
+func (e *tlsTestEnv) start(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	tlsConfig, err := e.config.Parse(ctx, e.certOptional)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := e.handler
+	if handler == nil {
+		handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "ok")
+		})
+	}
+	e.server = httptest.NewUnstartedServer(handler)
+	e.server.TLS = tlsConfig
+	e.server.StartTLS()
+	t.Cleanup(e.server.Close)
+}
+
+// client returns an HTTPS client presenting cert, if any, resuming sessions
+// from cache if set
+func (e *tlsTestEnv) client(cert *tls.Certificate, cache tls.ClientSessionCache) *http.Client {
+	roots := x509.NewCertPool()
+	roots.AddCert(e.ca.cert)
+	var certs []tls.Certificate
+	if cert != nil {
+		certs = []tls.Certificate{*cert}
+	}
+	return &http.Client{Transport: &http.Transport{
+		TLSClientConfig: &tls.Config{
+			RootCAs:            roots,
+			Certificates:       certs,
+			ClientSessionCache: cache,
+		},
+		DisableKeepAlives: true,
+	}}
+}
+
+// get requests the server and returns whether the TLS session was resumed
+func (e *tlsTestEnv) get(client *http.Client) (bool, error) {
+	resp, err := client.Get(e.server.URL)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		return false, err
+	}
+	return resp.TLS.DidResume, nil
+}
+
+func TestClientCertificateRevoked(t *testing.T) {
+	env := newTLSTestEnv(t)
+	env.writeCRL(t, env.ca.crl(t, 1, 2))
+	env.start(t)
+
+	if _, err := env.get(env.client(certPtr(env.ca.issue(t, 1, false, "")), nil)); err != nil {
+		t.Fatalf("Failed connecting with a valid client certificate: %v", err)
+	}
+	if _, err := env.get(env.client(certPtr(env.ca.issue(t, 2, false, "")), nil)); err == nil {
+		t.Fatal("Connected with a revoked client certificate")
+	}
+}
+
+func TestClientCertificateDenied(t *testing.T) {
+	env := newTLSTestEnv(t)
+	env.config.DeniedSerials = []string{"0a"}
+	env.config.DeniedSPIFFEIDs = []string{"spiffe://example.org/denied"}
+	env.start(t)
+
+	tests := []struct {
+		name     string
+		serial   int64
+		spiffeID string
+		denied   bool
+	}{
+		{name: "allowed", serial: 1, spiffeID: "spiffe://example.org/allowed"},
+		{name: "denied serial", serial: 10, spiffeID: "spiffe://example.org/allowed", denied: true},
+		{name: "denied SPIFFE ID", serial: 2, spiffeID: "spiffe://example.org/denied", denied: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := env.get(env.client(certPtr(env.ca.issue(t, tt.serial, false, tt.spiffeID)), nil))
+			if tt.denied && err == nil {
+				t.Fatal("Connected with a denied client certificate")
+			}
+			if !tt.denied && err != nil {
+				t.Fatalf("Failed connecting with an allowed client certificate: %v", err)
+			}
+		})
+	}
+}
+
+func TestClientCertificateRevokedOnResumption(t *testing.T) {
+	env := newTLSTestEnv(t)
+	env.writeCRL(t, env.ca.crl(t, 1))
+	env.start(t)
+
+	client := env.client(certPtr(env.ca.issue(t, 3, false, "")), tls.NewLRUClientSessionCache(1))
+	if _, err := env.get(client); err != nil {
+		t.Fatalf("Failed connecting with a valid client certificate: %v", err)
+	}
+	resumed, err := env.get(client)
+	if err != nil {
+		t.Fatalf("Failed resuming with a valid client certificate: %v", err)
+	}
+	if !resumed {
+		t.Fatal("Expected the second connection to resume the TLS session")
+	}
+
+	// revoke the certificate, then wait for the CRL to be reloaded
+	env.writeCRL(t, env.ca.crl(t, 2, 3))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err = env.get(env.client(certPtr(env.ca.issue(t, 3, false, "")), nil)); err != nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("CRL was not reloaded")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if _, err := env.get(client); err == nil {
+		t.Fatal("Resumed a TLS session with a revoked client certificate")
+	}
+}
+
+func TestCRLSignature(t *testing.T) {
+	env := newTLSTestEnv(t)
+	other := newTestCA(t, "client CA")
+	// same issuer name as the client CA, but signed by another key
+	env.writeCRL(t, other.crl(t, 1, 2))
+
+	_, err := env.config.Parse(context.Background(), false)
+	if err == nil || !strings.Contains(err.Error(), "could not verify CRL") {
+		t.Fatalf("Expected CRL verification error, got %v", err)
+	}
+}
+
+func TestClientCertificateOptional(t *testing.T) {
+	for _, optional := range []bool{false, true} {
+		env := newTLSTestEnv(t)
+		env.writeCRL(t, env.ca.crl(t, 1, 2))
+		env.certOptional = optional
+		env.start(t)
+
+		_, err := env.get(env.client(nil, nil))
+		if optional && err != nil {
+			t.Fatalf("Failed connecting without a client certificate when optional: %v", err)
+		}
+		if !optional && err == nil {
+			t.Fatal("Connected without a client certificate when required")
+		}
+		if _, err := env.get(env.client(certPtr(env.ca.issue(t, 1, false, "")), nil)); err != nil {
+			t.Fatalf("Failed connecting with a valid client certificate: %v", err)
+		}
+		if _, err := env.get(env.client(certPtr(env.ca.issue(t, 2, false, "")), nil)); err == nil {
+			t.Fatalf("Connected with a revoked client certificate, optional %t", optional)
+		}
+	}
+}
+
+// recordingAuthorizer allows every request, recording the user of the last
+type recordingAuthorizer struct {
+	mu   sync.Mutex
+	user *user.UserInfo
+}
+
+func (a *recordingAuthorizer) AuthorizeRequest(r *http.Request, u *user.UserInfo) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.user = u
+	return nil
+}
+
+func (a *recordingAuthorizer) lastUser() *user.UserInfo {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.user
+}
+
+func TestConnectClientCertificate(t *testing.T) {
+	clientCert, err := authenticator.NewClientCertAuthenticator(map[string][]string{
+		"spiffe://example.org/ci": {"admin"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	authn, err := authenticator.NewChainAuthenticator([]authenticator.NamedAuthenticator{{Name: "ClientCert", Authenticator: clientCert}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	authz := &recordingAuthorizer{}
+	s := newTestServer(t)
+	s.Authenticator, s.Authorizer = authn, authz
+
+	env := newTLSTestEnv(t)
+	env.certOptional = true
+	env.handler = s.GetRouter()
+	env.start(t)
+
+	client := tornjakv1connect.NewClusterServiceClient(env.client(certPtr(env.ca.issue(t, 1, false, "spiffe://example.org/ci")), nil), env.server.URL)
+	if _, err := client.ListClusters(context.Background(), connect.NewRequest(&tornjakv1.ListClustersRequest{})); err != nil {
+		t.Fatalf("Connect call with a client certificate failed: %v", err)
+	}
+	u := authz.lastUser()
+	if u == nil || u.AuthenticationError != nil || u.Name != "spiffe://example.org/ci" || u.Authenticator != "ClientCert" {
+		t.Fatalf("Expected the call authenticated as spiffe://example.org/ci by ClientCert, got %+v", u)
+	}
+}
@@ -1,8 +1,11 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 
+	agentdb "github.com/spiffe/tornjak/pkg/agent/db"
 	tornjakTypes "github.com/spiffe/tornjak/pkg/agent/types"
 )
 
@@ -58,31 +61,123 @@ type ListAgentMetadataResponse tornjakTypes.AgentInfoList
 // spiffeid string
 // plugin string
 // cluster string
+// labels map
+// annotations map
 // if no metadata found, no row is included
 // if no spiffeids are specified, all agent metadata is returned
+// if a label selector is given, only the agents whose labels match it are returned
 func (s *Server) ListAgentMetadata(inp ListAgentMetadataRequest) (*ListAgentMetadataResponse, error) {
 	inpReq := tornjakTypes.AgentMetadataRequest(inp)
+	selector, err := tornjakTypes.ParseLabelSelector(inpReq.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
 	resp, err := s.Db.GetAgentsMetadata(inpReq)
 	if err != nil {
 		return nil, err
 	}
+	if len(selector) > 0 {
+		agents := []tornjakTypes.AgentInfo{}
+		for _, ainfo := range resp.Agents {
+			if selector.Matches(ainfo.Labels) {
+				agents = append(agents, ainfo)
+			}
+		}
+		resp.Agents = agents
+	}
 	return (*ListAgentMetadataResponse)(&resp), nil
 }
 
-type ListClustersRequest struct{}
+type GetAgentLabelsRequest struct {
+	Spiffeid string
+}
+type GetAgentLabelsResponse tornjakTypes.AgentLabels
+
+// GetAgentLabels returns the labels and annotations of an agent from the
+// local DB, empty if it has none
+func (s *Server) GetAgentLabels(inp GetAgentLabelsRequest) (*GetAgentLabelsResponse, error) {
+	if len(inp.Spiffeid) == 0 {
+		return nil, errors.New("input missing mandatory field - Spiffeid")
+	}
+	resp, err := s.Db.GetAgentLabels(inp.Spiffeid)
+	if err != nil {
+		return nil, err
+	}
+	return (*GetAgentLabelsResponse)(&resp), nil
+}
+
+type SetAgentLabelsRequest tornjakTypes.AgentLabels
+
+// SetAgentLabels replaces the labels and annotations of an agent in the
+// local DB
+func (s *Server) SetAgentLabels(inp SetAgentLabelsRequest) error {
+	alabels := tornjakTypes.AgentLabels(inp)
+	if len(alabels.Spiffeid) == 0 {
+		return errors.New("input missing mandatory field - Spiffeid")
+	}
+	if err := alabels.Labels.Validate(); err != nil {
+		return fmt.Errorf("agent has invalid labels: %v", err)
+	}
+	if err := alabels.Annotations.Validate(); err != nil {
+		return fmt.Errorf("agent has invalid annotations: %v", err)
+	}
+	return s.Db.SetAgentLabels(alabels)
+}
+
+type ListClustersRequest struct {
+	// LabelSelector selects clusters by label, e.g. "region=us-east,env!=dev"
+	LabelSelector string `json:"labelSelector,omitempty"`
+}
 type ListClustersResponse tornjakTypes.ClusterInfoList
 
 // ListClusters returns list of clusters from the local DB with the following info
 // name string
+// labels map
 // details json
+// if a label selector is given, only the clusters whose labels match it are returned
 func (s *Server) ListClusters(inp ListClustersRequest) (*ListClustersResponse, error) {
+	selector, err := tornjakTypes.ParseLabelSelector(inp.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
 	retVal, err := s.Db.GetClusters()
 	if err != nil {
 		return nil, err
 	}
+	if len(selector) > 0 {
+		clusters := []tornjakTypes.ClusterInfo{}
+		for _, cinfo := range retVal.Clusters {
+			if selector.Matches(cinfo.Labels) {
+				clusters = append(clusters, cinfo)
+			}
+		}
+		retVal.Clusters = clusters
+	}
 	return (*ListClustersResponse)(&retVal), nil
 }
 
+type GetClusterRequest struct {
+	Name string
+}
+type GetClusterResponse tornjakTypes.ClusterInfo
+
+// GetCluster returns the cluster with the given name from the local DB
+func (s *Server) GetCluster(inp GetClusterRequest) (*GetClusterResponse, error) {
+	if len(inp.Name) == 0 {
+		return nil, errors.New("input missing mandatory field - Name")
+	}
+	clusters, err := s.Db.GetClusters()
+	if err != nil {
+		return nil, err
+	}
+	for _, cinfo := range clusters.Clusters {
+		if cinfo.Name == inp.Name {
+			return (*GetClusterResponse)(&cinfo), nil
+		}
+	}
+	return nil, agentdb.GetError{Message: fmt.Sprintf("Cluster %v not registered", inp.Name)}
+}
+
 type RegisterClusterRequest tornjakTypes.ClusterInput
 
 // DefineCluster registers cluster to local DB
@@ -94,6 +189,8 @@ func (s *Server) DefineCluster(inp RegisterClusterRequest) error {
 		return errors.New("cluster definition missing mandatory field - PlatformType")
 	} else if len(cinfo.EditedName) > 0 {
 		return errors.New("cluster definition attempts renaming on create cluster - EditedName")
+	} else if err := validateClusterLabelsDetails(cinfo); err != nil {
+		return err
 	}
 	return s.Db.CreateClusterEntry(cinfo)
 }
@@ -109,10 +206,51 @@ func (s *Server) EditCluster(inp EditClusterRequest) error {
 		return errors.New("cluster definition missing mandatory field - PlatformType")
 	} else if len(cinfo.EditedName) == 0 {
 		return errors.New("cluster definition missing mandatory field - EditedName")
+	} else if err := validateClusterLabelsDetails(cinfo); err != nil {
+		return err
+	}
+
+	// labels and details omitted from the new definition are kept; an empty
+	// object clears them
+	if cinfo.Labels == nil || cinfo.Details == nil {
+		current, err := s.GetCluster(GetClusterRequest{Name: cinfo.Name})
+		if err != nil {
+			return err
+		}
+		if cinfo.Labels == nil {
+			cinfo.Labels = current.Labels
+		}
+		if cinfo.Details == nil {
+			cinfo.Details = current.Details
+		}
+	}
+	if isEmptyJSONObject(cinfo.Details) {
+		cinfo.Details = nil
 	}
 	return s.Db.EditClusterEntry(cinfo)
 }
 
+// isEmptyJSONObject reports whether raw is the JSON object {}
+func isEmptyJSONObject(raw json.RawMessage) bool {
+	var m map[string]interface{}
+	return json.Unmarshal(raw, &m) == nil && m != nil && len(m) == 0
+}
+
+// validateClusterLabelsDetails checks that the labels of the cluster can be
+// selected on and that its details, if any, are a JSON object
+func validateClusterLabelsDetails(cinfo tornjakTypes.ClusterInfo) error {
+	if err := cinfo.Labels.Validate(); err != nil {
+		return fmt.Errorf("cluster definition has invalid labels: %v", err)
+	}
+	if len(cinfo.Details) > 0 {
+		var details map[string]interface{}
+		if err := json.Unmarshal(cinfo.Details, &details); err != nil || details == nil {
+			return errors.New("cluster definition has invalid details: expected a JSON object")
+		}
+	}
+	return nil
+}
+
 type DeleteClusterRequest tornjakTypes.ClusterInput
 
 // DeleteCluster deletes cluster with name cinfo.Name and assignment to agents
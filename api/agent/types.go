@@ -1,12 +1,15 @@
 package api
 
 import (
+	"context"
 	"crypto/tls"
-	"crypto/x509"
+	"errors"
 	"fmt"
-	"os"
+	"time"
 
 	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
 )
 
 // TornjakServerInfo provides insight into the configuration of the SPIRE server
@@ -16,14 +19,102 @@ type TornjakSpireServerInfo struct {
 	Plugins map[string][]string `json:"plugins"`
 	// TrustDomain specifies the trust domain of the SPIRE server configured with tornjak
 	TrustDomain string `json:"trustDomain"`
-	// Verbose config contains unstructured information on the config on the agent
+	// BindAddress is the address:port the SPIRE server API listens on, if configured
+	BindAddress string `json:"bindAddress,omitempty"`
+	// CATTL, DefaultX509SVIDTTL and DefaultJWTSVIDTTL are as configured;
+	// empty means the SPIRE default
+	CATTL              string `json:"caTTL,omitempty"`
+	DefaultX509SVIDTTL string `json:"defaultX509SVIDTTL,omitempty"`
+	DefaultJWTSVIDTTL  string `json:"defaultJWTSVIDTTL,omitempty"`
+	// CASubject is the subject of the CA certificates, if configured
+	CASubject *SpireCASubject `json:"caSubject,omitempty"`
+	// Federation holds the bundle endpoint and federation relationships, if configured
+	Federation *SpireFederationInfo `json:"federation,omitempty"`
+	// PluginConfigs lists the configured plugins in order, with secrets in
+	// their data redacted
+	PluginConfigs []SpirePluginInfo `json:"pluginConfigs"`
+	// Verbose config contains unstructured information on the config on the
+	// agent, with secrets redacted
 	VerboseConfig string `json:"verboseConfig"`
 }
 
+type SpireCASubject struct {
+	Country      []string `json:"country,omitempty"`
+	Organization []string `json:"organization,omitempty"`
+	CommonName   string   `json:"commonName,omitempty"`
+}
+
+type SpireFederationInfo struct {
+	BundleEndpoint *SpireBundleEndpointInfo `json:"bundleEndpoint,omitempty"`
+	FederatesWith  []SpireFederatesWithInfo `json:"federatesWith,omitempty"`
+}
+
+type SpireBundleEndpointInfo struct {
+	Address string `json:"address"`
+	Port    int    `json:"port"`
+	// ACMEDomainName is set when the endpoint certificate is obtained with ACME
+	ACMEDomainName string `json:"acmeDomainName,omitempty"`
+}
+
+type SpireFederatesWithInfo struct {
+	TrustDomain       string `json:"trustDomain"`
+	BundleEndpointURL string `json:"bundleEndpointURL"`
+	// BundleEndpointProfile is https_spiffe or https_web
+	BundleEndpointProfile string `json:"bundleEndpointProfile"`
+	EndpointSPIFFEID      string `json:"endpointSPIFFEID,omitempty"`
+}
+
+type SpirePluginInfo struct {
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Enabled   bool   `json:"enabled"`
+	PluginCmd string `json:"pluginCmd,omitempty"`
+	// Data is the plugin_data of the plugin
+	Data map[string]interface{} `json:"data"`
+}
+
 // pared down version of full Server Config type spire/cmd/spire-server/cli/run
-// we currently need only extract the trust domain
+// we extract only what Tornjak reports in serverinfo
 type SpireServerConfig struct {
-	TrustDomain string `hcl:"trust_domain"`
+	TrustDomain        string                 `hcl:"trust_domain"`
+	BindAddress        string                 `hcl:"bind_address"`
+	BindPort           int                    `hcl:"bind_port"`
+	CATTL              string                 `hcl:"ca_ttl"`
+	DefaultX509SVIDTTL string                 `hcl:"default_x509_svid_ttl"`
+	DefaultJWTSVIDTTL  string                 `hcl:"default_jwt_svid_ttl"`
+	CASubject          *spireCASubjectConfig  `hcl:"ca_subject"`
+	Federation         *spireFederationConfig `hcl:"federation"`
+}
+
+type spireCASubjectConfig struct {
+	Country      []string `hcl:"country"`
+	Organization []string `hcl:"organization"`
+	CommonName   string   `hcl:"common_name"`
+}
+
+type spireFederationConfig struct {
+	BundleEndpoint *spireBundleEndpointConfig          `hcl:"bundle_endpoint"`
+	FederatesWith  map[string]spireFederatesWithConfig `hcl:"federates_with"`
+}
+
+type spireBundleEndpointConfig struct {
+	Address string `hcl:"address"`
+	Port    int    `hcl:"port"`
+	ACME    *struct {
+		DomainName string `hcl:"domain_name"`
+	} `hcl:"acme"`
+}
+
+type spireFederatesWithConfig struct {
+	BundleEndpointURL     string   `hcl:"bundle_endpoint_url"`
+	BundleEndpointProfile ast.Node `hcl:"bundle_endpoint_profile"`
+}
+
+type spireBundleEndpointProfileConfig struct {
+	HTTPSSPIFFE *struct {
+		EndpointSPIFFEID string `hcl:"endpoint_spiffe_id"`
+	} `hcl:"https_spiffe"`
+	HTTPSWeb *struct{} `hcl:"https_web"`
 }
 
 type SPIREConfig struct {
@@ -39,73 +130,152 @@ type TornjakConfig struct {
 /* Server configuration*/
 
 type serverConfig struct {
-	SPIRESocket string       `hcl:"spire_socket_path"`
-	HTTPConfig  *HTTPConfig  `hcl:"http"`
-	HTTPSConfig *HTTPSConfig `hcl:"https"`
+	SPIRESocket       string           `hcl:"spire_socket_path"`
+	MaxRequestBytes   int64            `hcl:"max_request_bytes"`
+	IdempotencyWindow string           `hcl:"idempotency_window"`
+	JobWorkers        int              `hcl:"job_workers"`
+	JobQueueSize      int              `hcl:"job_queue_size"`
+	UIPath            string           `hcl:"ui_path"`
+	RedactKeys        []string         `hcl:"redact_keys"`
+	AgentCleanup      string           `hcl:"agent_cleanup"`
+	InstanceID        string           `hcl:"instance_id"`
+	HTTPConfig        *HTTPConfig      `hcl:"http"`
+	HTTPSConfig       *HTTPSConfig     `hcl:"https"`
+	SocketConfig      *SocketConfig    `hcl:"socket"`
+	AuditConfig       *AuditConfig     `hcl:"audit"`
+	ReconcileConfig   *ReconcileConfig `hcl:"reconcile"`
 }
 
 type HTTPConfig struct {
 	ListenPort int `hcl:"port"`
 }
 
-type HTTPSConfig struct {
-	ListenPort int    `hcl:"port"`
-	Cert       string `hcl:"cert"`
-	Key        string `hcl:"key"`
-	ClientCA   string `hcl:"client_ca"`
+type SocketConfig struct {
+	Path string `hcl:"path"`
+	Mode string `hcl:"mode"`
 }
 
-func (h HTTPSConfig) Parse() (*tls.Config, error) {
-	serverCertPath := h.Cert
-	serverKeyPath := h.Key
-	clientCAPath := h.ClientCA
+type AuditConfig struct {
+	Disabled  bool   `hcl:"disabled"`
+	Retention string `hcl:"retention"`
+}
 
-	mtls := (clientCAPath != "")
+type ReconcileConfig struct {
+	Interval     string `hcl:"interval"`
+	Orphans      string `hcl:"orphans"`
+	AutoRegister bool   `hcl:"auto_register"`
+	// Plugins maps node attestation types to the plugin of agents registered
+	Plugins map[string]string `hcl:"plugins"`
+}
 
-	if _, err := os.Stat(serverCertPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("server cert path '%s': %w", serverCertPath, err)
+type HTTPSConfig struct {
+	ListenPort         int      `hcl:"port"`
+	Cert               string   `hcl:"cert"`
+	Key                string   `hcl:"key"`
+	ClientCA           string   `hcl:"client_ca"`
+	WorkloadAPISocket  string   `hcl:"workload_api_socket"`
+	CertReloadInterval string   `hcl:"cert_reload_interval"`
+	MinTLSVersion      string   `hcl:"min_tls_version"`
+	CipherSuites       []string `hcl:"cipher_suites"`
+	CRL                string   `hcl:"crl"`
+	DeniedSerials      []string `hcl:"denied_serials"`
+	DeniedSPIFFEIDs    []string `hcl:"denied_spiffe_ids"`
+}
+
+// Parse builds the TLS config for the HTTPS listener. The server certificate
+// comes from the Workload API as an auto-rotating X509-SVID if
+// workload_api_socket is set, and otherwise from the cert and key files.
+// Certificate files, the client CA bundle and the CRL are reloaded when they
+// change, until ctx is done. Client certificates that are revoked or on the
+// deny list are rejected. With client_ca, clients must present a certificate
+// unless certOptional is set, as it is when the ClientCert Authenticator is
+// configured, so that clients authenticated otherwise can connect without one.
+func (h HTTPSConfig) Parse(ctx context.Context, certOptional bool) (*tls.Config, error) {
+	minVersion, err := parseTLSVersion(h.MinTLSVersion)
+	if err != nil {
+		return nil, err
 	}
-	if _, err := os.Stat(serverKeyPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("server key path '%s': %w", serverKeyPath, err)
+	cipherSuites, err := parseCipherSuites(h.CipherSuites)
+	if err != nil {
+		return nil, err
+	}
+	reloadInterval := defaultCertReloadInterval
+	if h.CertReloadInterval != "" {
+		reloadInterval, err = time.ParseDuration(h.CertReloadInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cert_reload_interval: %w", err)
+		}
+		if reloadInterval <= 0 {
+			return nil, errors.New("cert_reload_interval must be positive")
+		}
 	}
 
-	// Create a CA certificate pool and add cert.pem to it
-	serverCert, err := os.ReadFile(serverCertPath)
-	if err != nil {
-		return nil, fmt.Errorf("server ca pool error: %w", err)
+	tlsConfig := &tls.Config{
+		MinVersion:   minVersion,
+		CipherSuites: cipherSuites,
 	}
-	caCertPool := x509.NewCertPool()
-	caCertPool.AppendCertsFromPEM(serverCert)
 
-	if mtls {
-		// add mTLS CA path to cert pool as well
-		if _, err := os.Stat(clientCAPath); os.IsNotExist(err) {
-			return nil, fmt.Errorf("server file does not exist %s", clientCAPath)
-		}
-		clientCA, err := os.ReadFile(clientCAPath)
+	certPath, keyPath := h.Cert, h.Key
+	if h.WorkloadAPISocket != "" {
+		// the SVID replaces the certificate files
+		certPath, keyPath = "", ""
+		source, err := workloadapi.NewX509Source(ctx, workloadapi.WithClientOptions(workloadapi.WithAddr(h.WorkloadAPISocket)))
 		if err != nil {
-			return nil, fmt.Errorf("server: could not read file %s: %w", clientCAPath, err)
+			return nil, fmt.Errorf("could not fetch X509-SVID from workload API '%s': %w", h.WorkloadAPISocket, err)
 		}
-		caCertPool.AppendCertsFromPEM(clientCA)
+		go func() {
+			<-ctx.Done()
+			source.Close()
+		}()
+		tlsConfig.GetCertificate = tlsconfig.GetCertificate(source)
+	} else if certPath == "" || keyPath == "" {
+		return nil, errors.New("cert and key are required unless workload_api_socket is set")
 	}
 
-	// Create the TLS Config with the CA pool and enable Client certificate validation
-	tlsConfig := &tls.Config{
-		ClientCAs: caCertPool,
+	if h.ClientCA == "" && (h.CRL != "" || len(h.DeniedSerials) > 0 || len(h.DeniedSPIFFEIDs) > 0) {
+		return nil, errors.New("crl, denied_serials and denied_spiffe_ids require client_ca")
+	}
+	denied, err := newClientDenyList(h.DeniedSerials, h.DeniedSPIFFEIDs)
+	if err != nil {
+		return nil, err
 	}
 
-	if mtls {
+	if certPath == "" && h.ClientCA == "" {
+		return tlsConfig, nil
+	}
+	reloader, err := newCertReloader(certPath, keyPath, h.ClientCA, h.CRL)
+	if err != nil {
+		return nil, err
+	}
+	go reloader.watch(ctx, reloadInterval)
+
+	if certPath != "" {
+		tlsConfig.GetCertificate = reloader.getCertificate
+	}
+	if h.ClientCA != "" {
+		// mTLS: verify clients against the client CA bundle only
 		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		if certOptional {
+			tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		}
+		tlsConfig.ClientCAs = reloader.clientCAPool()
+		tlsConfig.VerifyConnection = verifyClientConnection(reloader, denied)
+		base := tlsConfig.Clone()
+		tlsConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			cfg := base.Clone()
+			cfg.ClientCAs = reloader.clientCAPool()
+			return cfg, nil
+		}
 	}
-	//tlsConfig.BuildNameToCertificate()
 
 	return tlsConfig, nil
 }
 
 /* Plugin types */
 type pluginDataStoreSQL struct {
-	Drivername string `hcl:"drivername"`
-	Filename   string `hcl:"filename"`
+	Drivername       string `hcl:"drivername"`
+	Filename         string `hcl:"filename"`
+	ConnectionString string `hcl:"connection_string"`
 }
 
 type pluginControllerManager struct {
@@ -117,6 +287,31 @@ type pluginAuthenticatorKeycloak struct {
 	Audience  string `hcl:"audience"`
 }
 
+type PeerRoleMapping struct {
+	ID    string   `hcl:",key"`
+	Roles []string `hcl:"roles"`
+}
+
+type pluginAuthenticatorUnixPeer struct {
+	UIDRoleMappings []PeerRoleMapping `hcl:"uid"`
+	GIDRoleMappings []PeerRoleMapping `hcl:"gid"`
+}
+
+type pluginAuthenticatorClientCert struct {
+	SPIFFEIDRoleMappings   []PeerRoleMapping `hcl:"spiffe_id"`
+	CommonNameRoleMappings []PeerRoleMapping `hcl:"common_name"`
+}
+
+type pluginNotifierWebhook struct {
+	URL         string   `hcl:"url"`
+	Secret      string   `hcl:"secret"`
+	Events      []string `hcl:"events"`
+	MaxAttempts int      `hcl:"max_attempts"`
+	Timeout     string   `hcl:"timeout"`
+	QueueSize   int      `hcl:"queue_size"`
+	Workers     int      `hcl:"workers"`
+}
+
 type AuthRole struct {
 	Name string `hcl:",key"`
 	Desc string `hcl:"desc"`
@@ -129,8 +324,16 @@ type APIv1RoleMapping struct {
 	AllowedRoles []string `hcl:"allowed_roles"`
 }
 
+type APIv2RoleMapping struct {
+	Name         string   `hcl:",key"`
+	Method       string   `hcl:"-"`
+	Path         string   `hcl:"-"`
+	AllowedRoles []string `hcl:"allowed_roles"`
+}
+
 type pluginAuthorizerRBAC struct {
 	Name              string              `hcl:"name"`
 	RoleList          []*AuthRole         `hcl:"role,block"`
 	APIv1RoleMappings []*APIv1RoleMapping `hcl:"APIv1,block"`
+	APIv2RoleMappings []*APIv2RoleMapping `hcl:"APIv2,block"`
 }
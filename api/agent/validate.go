@@ -0,0 +1,177 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/pkg/errors"
+)
+
+// ConfigError is an error in the Tornjak config file
+type ConfigError struct {
+	// Line is the line of the config file the error is about, 0 if unknown
+	Line int
+	Err  error
+}
+
+func (e ConfigError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return e.Err.Error()
+}
+
+// ValidateConfig checks the Tornjak config parsed into root as Configure
+// would, in dry run: plugins are decoded and checked, but no database is
+// opened, no network calls are made and nothing is started. It returns all
+// errors found rather than the first.
+func ValidateConfig(root *ast.File) []ConfigError {
+	config, err := DecodeConfig(root)
+	if err != nil {
+		return []ConfigError{{Err: errors.Errorf("unable to decode tornjak configuration: %v", err)}}
+	}
+
+	var errs []ConfigError
+	s := &Server{TornjakConfig: config}
+	for _, fe := range s.verifyConfiguration() {
+		errs = append(errs, ConfigError{Line: keyLine(root.Node, fe.keys), Err: fe.err})
+	}
+	if config.Server != nil && config.Server.HTTPConfig == nil {
+		errs = append(errs, ConfigError{
+			Line: keyLine(root.Node, []string{"server"}),
+			Err:  errors.New("'config > server > http' field not defined"),
+		})
+	}
+	if config.Plugins == nil {
+		return errs
+	}
+
+	pluginConfigs := *config.Plugins
+	pluginList, ok := pluginConfigs.(*ast.ObjectList)
+	if !ok {
+		return append(errs, ConfigError{
+			Line: keyLine(root.Node, []string{"plugins"}),
+			Err:  fmt.Errorf("expected plugins node type %T but got %T", pluginList, pluginConfigs),
+		})
+	}
+	// plugin types other than Notifier and Authenticator are configured
+	// once; the last one given would silently win
+	seen := map[string]bool{}
+	authenticators := map[string]bool{}
+	notifierNames := map[string]bool{}
+	var notifierLine int
+	for _, pluginObject := range pluginList.Items {
+		line := pluginObject.Pos().Line
+		pluginType, err := stringFromToken(pluginObject.Keys[0].Token)
+		if err != nil {
+			errs = append(errs, ConfigError{Line: line, Err: fmt.Errorf("invalid plugin type key %q: %w", pluginObject.Keys[0].Token.Text, err)})
+			continue
+		}
+		if pluginType == "Notifier" {
+			if notifierLine == 0 {
+				notifierLine = line
+			}
+			if len(pluginObject.Keys) == 3 {
+				if name, err := stringFromToken(pluginObject.Keys[2].Token); err == nil {
+					if notifierNames[name] {
+						errs = append(errs, ConfigError{Line: line, Err: errors.Errorf("Cannot configure Notifier plugin: duplicate name %s", name)})
+					}
+					notifierNames[name] = true
+				}
+			}
+		} else if pluginType == "Authenticator" {
+			if len(pluginObject.Keys) == 2 {
+				if name, err := stringFromToken(pluginObject.Keys[1].Token); err == nil {
+					if authenticators[name] {
+						errs = append(errs, ConfigError{Line: line, Err: errors.Errorf("Cannot configure Authenticator plugin: %s configured more than once", name)})
+					}
+					authenticators[name] = true
+				}
+			}
+		} else if seen[pluginType] {
+			errs = append(errs, ConfigError{Line: line, Err: errors.Errorf("plugin %s configured more than once", pluginType)})
+		}
+		seen[pluginType] = true
+
+		if err := validatePlugin(pluginType, pluginObject); err != nil {
+			errs = append(errs, ConfigError{Line: line, Err: err})
+		}
+	}
+	if seen["Notifier"] && !seen["DataStore"] {
+		errs = append(errs, ConfigError{Line: notifierLine, Err: errors.New("plugin Notifier requires a DataStore plugin")})
+	}
+	return errs
+}
+
+// validatePlugin runs the constructor of the plugin of pluginType in dry run
+func validatePlugin(pluginType string, pluginObject *ast.ObjectItem) error {
+	var err error
+	switch pluginType {
+	case "DataStore":
+		if len(pluginObject.Keys) != 2 {
+			return fmt.Errorf("plugin DataStore expected to have two keys (type then name)")
+		}
+		if _, err = newAgentsDB(pluginObject, true); err != nil {
+			return errors.Errorf("Cannot configure datastore plugin: %v", err)
+		}
+	case "SPIRECRDManager":
+		if len(pluginObject.Keys) != 1 {
+			return fmt.Errorf("plugin SPIRECRDManager expected to have one key (type)")
+		}
+		if _, err = NewCRDManager(pluginObject); err != nil {
+			return errors.Errorf("Cannot configure CRD management plugin: %v", err)
+		}
+	case "Authenticator":
+		if len(pluginObject.Keys) != 2 {
+			return fmt.Errorf("plugin Authenticator expected to have two keys (type then name)")
+		}
+		if _, err = newAuthenticator(pluginObject, true); err != nil {
+			return errors.Errorf("Cannot configure Authenticator plugin: %v", err)
+		}
+	case "Authorizer":
+		if len(pluginObject.Keys) != 2 {
+			return fmt.Errorf("plugin Authorizer expected to have two keys (type then name)")
+		}
+		if _, err = NewAuthorizer(pluginObject); err != nil {
+			return errors.Errorf("Cannot configure Authorizer plugin: %v", err)
+		}
+	case "Notifier":
+		if len(pluginObject.Keys) != 3 {
+			return fmt.Errorf("plugin Notifier expected to have three keys (type, kind then name)")
+		}
+		if _, err = newNotifier(pluginObject, nil, "", true); err != nil {
+			return errors.Errorf("Cannot configure Notifier plugin: %v", err)
+		}
+	default:
+		return errors.Errorf("unknown plugin type %s", pluginType)
+	}
+	return nil
+}
+
+// keyLine returns the line of the innermost block or field along keys that
+// is present in node, 0 if none is
+func keyLine(node ast.Node, keys []string) int {
+	line := 0
+	list, _ := node.(*ast.ObjectList)
+	for _, key := range keys {
+		if list == nil {
+			break
+		}
+		var next *ast.ObjectList
+		for _, item := range list.Items {
+			if len(item.Keys) == 0 {
+				continue
+			}
+			if name, err := stringFromToken(item.Keys[0].Token); err != nil || name != key {
+				continue
+			}
+			line = item.Pos().Line
+			if obj, ok := item.Val.(*ast.ObjectType); ok {
+				next = obj.List
+			}
+			break
+		}
+		list = next
+	}
+	return line
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+// validateTestServer is the server block of the configs below, lines 1-6
+const validateTestServer = `server {
+  spire_socket_path = "unix:///tmp/spire-server/private/api.sock"
+  http {
+    port = 10000
+  }
+}
+`
+
+func TestValidateConfig(t *testing.T) {
+	type wantError struct {
+		line int
+		err  string
+	}
+	tests := []struct {
+		name string
+		data string
+		want []wantError
+	}{
+		{
+			name: "valid",
+			data: validateTestServer + `
+plugins {
+  DataStore "sql" {
+    plugin_data {
+      drivername = "sqlite3"
+      filename = "/run/tornjak.sqlite3"
+    }
+  }
+  Authorizer "RBAC" {
+    plugin_data {
+      name = "policy"
+      role "admin" { desc = "admin" }
+      APIv1 "GET /api/v1/spire/serverinfo" { allowed_roles = ["admin"] }
+    }
+  }
+}
+`,
+		},
+		{
+			name: "bad RBAC APIv1 path",
+			data: validateTestServer + `
+plugins {
+  Authorizer "RBAC" {
+    plugin_data {
+      name = "policy"
+      APIv1 "/api/v1/spire/serverinfo" { allowed_roles = ["admin"] }
+    }
+  }
+}
+`,
+			want: []wantError{{line: 9, err: "Invalid APIv1 block"}},
+		},
+		{
+			name: "missing plugin_data",
+			data: validateTestServer + `
+plugins {
+  Authorizer "RBAC" {
+  }
+}
+`,
+			want: []wantError{{line: 9, err: "plugin_data') not populated"}},
+		},
+		{
+			name: "DataStore with wrong key count",
+			data: validateTestServer + `
+plugins {
+  DataStore {
+    plugin_data {
+      drivername = "sqlite3"
+      filename = "/run/tornjak.sqlite3"
+    }
+  }
+}
+`,
+			want: []wantError{{line: 9, err: "plugin DataStore expected to have two keys"}},
+		},
+		{
+			name: "all errors",
+			data: `server {
+  spire_socket_path = "unix:///tmp/spire-server/private/api.sock"
+  socket {
+    path = "/run/tornjak/api.sock"
+    mode = "rw"
+  }
+}
+
+plugins {
+  DataStore "sql" "sqlite3" {
+    plugin_data {
+      drivername = "sqlite3"
+      filename = "/run/tornjak.sqlite3"
+    }
+  }
+  Authorizer "RBAC" {
+  }
+  Authorizer "RBAC" {
+    plugin_data {
+      name = "policy"
+      APIv1 "/api/v1/spire/serverinfo" { allowed_roles = ["admin"] }
+    }
+  }
+}
+`,
+			want: []wantError{
+				{line: 5, err: "'config > server > socket > mode' invalid mode"},
+				{line: 1, err: "'config > server > http' field not defined"},
+				{line: 10, err: "plugin DataStore expected to have two keys"},
+				{line: 16, err: "plugin_data') not populated"},
+				{line: 18, err: "plugin Authorizer configured more than once"},
+				{line: 18, err: "Invalid APIv1 block"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := ParseConfig("tornjak.conf", tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs := ValidateConfig(root)
+			if len(errs) != len(tt.want) {
+				t.Fatalf("Expected %d errors, got %d: %v", len(tt.want), len(errs), errs)
+			}
+			for i, want := range tt.want {
+				if errs[i].Line != want.line || !strings.Contains(errs[i].Err.Error(), want.err) {
+					t.Fatalf("Expected error %d at line %d containing %q, got %v", i, want.line, want.err, errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestKeyLine(t *testing.T) {
+	root, err := ParseConfig("tornjak.conf", validateTestServer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		keys []string
+		want int
+	}{
+		{keys: []string{"server"}, want: 1},
+		{keys: []string{"server", "http", "port"}, want: 4},
+		// missing keys fall back to the innermost block present
+		{keys: []string{"server", "socket", "mode"}, want: 1},
+		{keys: []string{"plugins"}, want: 0},
+		{keys: nil, want: 0},
+	}
+	for _, tt := range tests {
+		if got := keyLine(root.Node, tt.keys); got != tt.want {
+			t.Fatalf("Expected line %d for %v, got %d", tt.want, tt.keys, got)
+		}
+	}
+}
@@ -6,12 +6,11 @@ import (
 	"io"
 	"log"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strings"
 
 	"github.com/gorilla/mux"
 	managerdb "github.com/spiffe/tornjak/pkg/manager/db"
+	"github.com/spiffe/tornjak/pkg/ui"
 )
 
 const (
@@ -21,6 +20,7 @@ const (
 
 type Server struct {
 	listenAddr string
+	uiPath     string
 	db         managerdb.ManagerDB
 }
 
@@ -134,48 +134,6 @@ func (s *Server) apiServerProxyFunc(apiPath string, apiMethod string) func(w htt
 	}
 }
 
-// spaHandler implements the http.Handler interface, so we can use it
-// to respond to HTTP requests. The path to the static directory and
-// path to the index file within that static directory are used to
-// serve the SPA in the given static directory.
-type spaHandler struct {
-	staticPath string
-	indexPath  string
-}
-
-// ServeHTTP inspects the URL path to locate a file within the static dir
-// on the SPA handler. If a file is found, it will be served. If not, the
-// file located at the index path on the SPA handler will be served. This
-// is suitable behavior for serving an SPA (single page application).
-func (h spaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// get the absolute path to prevent directory traversal
-	path, err := filepath.Abs(r.URL.Path)
-	if err != nil {
-		// if we failed to get the absolute path respond with a 400 bad request
-		// and stop
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// prepend the path with the path to the static directory
-	path = filepath.Join(h.staticPath, path)
-
-	// check whether a file exists at the given path
-	_, err = os.Stat(path)
-	if os.IsNotExist(err) {
-		// file does not exist, serve index.html
-		http.ServeFile(w, r, filepath.Join(h.staticPath, h.indexPath))
-		return
-	} else if err != nil {
-		// if we got an error (that wasn't that the file doesn't exist) stating the
-		// file, return a 500 internal server error and stop
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-	// otherwise, use http.FileServer to serve the static dir
-	http.FileServer(http.Dir(h.staticPath)).ServeHTTP(w, r)
-}
-
 func (s *Server) HandleRequests() {
 	// TO implement
 	rtr := mux.NewRouter()
@@ -201,6 +159,7 @@ func (s *Server) HandleRequests() {
 
 	// Tornjak-specific
 	rtr.HandleFunc("/manager-api/tornjak/serverinfo/{server:.*}", corsHandler(s.apiServerProxyFunc("/api/v1/tornjak/serverinfo", http.MethodGet)))
+	rtr.HandleFunc("/manager-api/tornjak/serverinfo-combined/{server:.*}", corsHandler(s.apiServerProxyFunc("/api/v1/tornjak/serverinfo/combined", http.MethodGet)))
 	// Agents Selectors
 	rtr.HandleFunc("/manager-api/tornjak/selectors/register/{server:.*}", corsHandler(s.apiServerProxyFunc("/api/v1/tornjak/selectors", http.MethodPost)))
 	rtr.HandleFunc("/manager-api/tornjak/selectors/list/{server:.*}", corsHandler(s.apiServerProxyFunc("/api/v1/tornjak/selectors", http.MethodGet)))
@@ -214,8 +173,9 @@ func (s *Server) HandleRequests() {
 	//http.HandleFunc("/manager-api/get-server-info", s.agentList)
 	//http.HandleFunc("/manager-api/agent/list/:id", s.agentList)
 
-	spa := spaHandler{staticPath: "ui-manager", indexPath: "index.html"}
-	rtr.PathPrefix("/").Handler(spa)
+	// UI SPA, from the override path if set, otherwise from the embedded
+	// assets or the ui-manager directory
+	rtr.PathPrefix("/").Handler(ui.New(s.uiPath, ui.ManagerAssets(), "ui-manager"))
 
 	fmt.Println("Starting to listen...")
 	log.Fatal(http.ListenAndServe(s.listenAddr, rtr))
@@ -233,13 +193,14 @@ func main() {
 
 // NewManagerServer returns a new manager server, given a listening address for the
 // server, and a DB connection string
-func NewManagerServer(listenAddr, dbString string) (*Server, error) {
+func NewManagerServer(listenAddr, dbString, uiPath string) (*Server, error) {
 	db, err := managerdb.NewLocalSqliteDB(dbString)
 	if err != nil {
 		return nil, err
 	}
 	return &Server{
 		listenAddr: listenAddr,
+		uiPath:     uiPath,
 		db:         db,
 	}, nil
 }
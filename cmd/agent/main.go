@@ -1,23 +1,25 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 
-	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
 	"github.com/pkg/errors"
-	"github.com/spiffe/spire/pkg/common/catalog"
 	agentapi "github.com/spiffe/tornjak/api/agent"
+	agentdb "github.com/spiffe/tornjak/pkg/agent/db"
 	cli "github.com/urfave/cli/v2"
 )
 
 type cliOptions struct {
 	genericOptions struct {
-		spireFile   string
-		tornjakFile string
-		expandEnv   bool
+		spireFile            string
+		tornjakFile          string
+		expandEnv            bool
+		printEffectiveConfig bool
 	}
 }
 
@@ -46,6 +48,22 @@ func main() {
 				Destination: &opt.genericOptions.expandEnv,
 				Required:    false,
 			},
+			&cli.BoolFlag{
+				Name:        "print-effective-config",
+				Value:       false,
+				Usage:       "Print the tornjak config after environment overrides, with secrets redacted, and exit",
+				Destination: &opt.genericOptions.printEffectiveConfig,
+				Required:    false,
+			},
+		},
+		Before: func(c *cli.Context) error {
+			if !opt.genericOptions.printEffectiveConfig {
+				return nil
+			}
+			if err := printEffectiveConfig(opt); err != nil {
+				return cli.Exit(err, 1)
+			}
+			return cli.Exit("", 0)
 		},
 		Commands: []*cli.Command{
 			{
@@ -55,6 +73,13 @@ func main() {
 					return runTornjakCmd("http", opt)
 				},
 			},
+			{
+				Name:  "validate-config",
+				Usage: "Check the tornjak config without starting the server",
+				Action: func(c *cli.Context) error {
+					return validateTornjakConfig(opt)
+				},
+			},
 			{
 				Name:  "serverinfo",
 				Usage: "Get the serverinfo of the SPIRE server where tornjak resides",
@@ -62,6 +87,28 @@ func main() {
 					return runTornjakCmd("serverinfo", opt)
 				},
 			},
+			{
+				Name:  "migrate",
+				Usage: "Apply the pending schema migrations of the tornjak datastore",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "Print the pending migrations without applying them",
+					},
+				},
+				Action: func(c *cli.Context) error {
+					return migrateDataStore(opt, c.Bool("dry-run"))
+				},
+				Subcommands: []*cli.Command{
+					{
+						Name:  "status",
+						Usage: "Print the schema version of the tornjak datastore and its pending migrations",
+						Action: func(c *cli.Context) error {
+							return printDataStoreStatus(opt)
+						},
+					},
+				},
+			},
 		},
 	}
 
@@ -72,7 +119,13 @@ func main() {
 }
 
 func runTornjakCmd(cmd string, opt cliOptions) error {
-	// parse configs
+	// parse configs; the tornjak config first, as it lists the keys to
+	// redact from the SPIRE config
+	tornjakConfigs, err := parseTornjakConfig(opt.genericOptions.tornjakFile, opt.genericOptions.expandEnv)
+	if err != nil {
+		return errors.Errorf("Unable to parse the tornjak config file provided %v", err)
+	}
+
 	spire_config_file := opt.genericOptions.spireFile
 	var serverInfo = agentapi.TornjakSpireServerInfo{}
 	if spire_config_file != "" { // SPIRE config given
@@ -80,34 +133,37 @@ func runTornjakCmd(cmd string, opt cliOptions) error {
 		if err != nil {
 			return errors.Errorf("Could not find given SPIRE Config file: %v", err)
 		}
-		serverInfo, err = GetServerInfo(configData)
+		serverInfo, err = agentapi.ParseSpireServerInfo(configData, agentapi.RedactKeys(tornjakConfigs))
 		if err != nil {
 			log.Fatalf("Error: %v", err)
 		}
 	}
 
-	tornjakConfigs, err := parseTornjakConfig(opt.genericOptions.tornjakFile, opt.genericOptions.expandEnv)
-	if err != nil {
-		return errors.Errorf("Unable to parse the tornjak config file provided %v", err)
-	}
-
 	switch cmd {
 	case "serverinfo":
 		if serverInfo.TrustDomain == "" {
 			fmt.Println("No SPIRE config provided to Tornjak")
 		} else {
-			fmt.Println(serverInfo)
+			out, err := json.MarshalIndent(serverInfo, "", "  ")
+			if err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+			fmt.Println(string(out))
 		}
-		tornjakInfo, err := getConfigString(opt.genericOptions.tornjakFile, opt.genericOptions.expandEnv)
-		if err != nil {
-			log.Fatalf("Error: %v", err)
+		if opt.genericOptions.tornjakFile != "" {
+			if err := printEffectiveConfig(opt); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
 		}
-		fmt.Println(tornjakInfo)
 	case "http":
 
 		apiServer := &agentapi.Server{
 			SpireServerInfo: serverInfo,
 			TornjakConfig:   tornjakConfigs,
+			ConfigFile:      opt.genericOptions.tornjakFile,
+			LoadConfig: func() (*agentapi.TornjakConfig, error) {
+				return parseTornjakConfig(opt.genericOptions.tornjakFile, opt.genericOptions.expandEnv)
+			},
 		}
 		apiServer.HandleRequests()
 	default:
@@ -117,40 +173,86 @@ func runTornjakCmd(cmd string, opt cliOptions) error {
 
 }
 
-func GetServerInfo(configData string) (agentapi.TornjakSpireServerInfo, error) {
-	// we extract TrustDomain and Plugin Info
-	config, err := parseSPIREConfig(configData)
+// validateTornjakConfig prints all errors found in the tornjak config, and
+// fails if there are any
+func validateTornjakConfig(opt cliOptions) error {
+	path := opt.genericOptions.tornjakFile
+	root, err := loadTornjakConfig(path, opt.genericOptions.expandEnv)
 	if err != nil {
-		return agentapi.TornjakSpireServerInfo{}, errors.Errorf("Could not parse SPIRE Config: %v", err)
+		return err
 	}
 
-	if config.Plugins == nil {
-		return agentapi.TornjakSpireServerInfo{}, errors.New("config plugins map should not be nil")
+	errs := agentapi.ValidateConfig(root)
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+	}
+	if len(errs) > 0 {
+		return errors.Errorf("%d error(s) found in tornjak configuration at %q", len(errs), path)
+	}
+	fmt.Printf("Tornjak configuration at %q is valid\n", path)
+	return nil
+}
+
+// migrateDataStore applies the pending migrations of the datastore; in dry
+// run, it prints them
+func migrateDataStore(opt cliOptions, dryRun bool) error {
+	migrator, err := openDataStoreMigrator(opt)
+	if err != nil {
+		return err
 	}
+	defer migrator.Close()
 
-	pluginConfigs, err := catalog.PluginConfigsFromHCLNode(config.Plugins)
+	migrations, err := migrator.Migrate(dryRun)
 	if err != nil {
-		return agentapi.TornjakSpireServerInfo{}, errors.Errorf("Unable to parse plugin HCL: %v", err)
+		return err
 	}
+	if len(migrations) == 0 {
+		fmt.Println("Tornjak datastore schema is up to date")
+		return nil
+	}
+	for _, migration := range migrations {
+		if dryRun {
+			fmt.Printf("Would apply migration %d: %s\n", migration.Version, migration.Description)
+			for _, statement := range migration.Statements {
+				fmt.Printf("%s;\n", statement)
+			}
+		} else {
+			fmt.Printf("Applied migration %d: %s\n", migration.Version, migration.Description)
+		}
+	}
+	return nil
+}
 
-	serverInfo := ""
-	serverInfo += "Plugin Info\n"
-	pluginMap := map[string][]string{}
-	for _, pc := range pluginConfigs {
-		serverInfo += fmt.Sprintf("%v Plugin: %v\n", pc.Type, pc.Name)
-		serverInfo += fmt.Sprintf("Data: %v\n\n", pc.Data)
-		pluginMap[pc.Type] = append(pluginMap[pc.Type], pc.Name)
+// printDataStoreStatus prints the schema version of the datastore and its
+// pending migrations
+func printDataStoreStatus(opt cliOptions) error {
+	migrator, err := openDataStoreMigrator(opt)
+	if err != nil {
+		return err
 	}
+	defer migrator.Close()
 
-	serverInfo += "\n\n"
-	serverInfo += "Server Info"
-	serverInfo += configData
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Schema version: %d\n", status.Version)
+	fmt.Printf("Latest version: %d\n", status.Latest)
+	if len(status.Pending) == 0 {
+		fmt.Println("No pending migrations")
+	}
+	for _, migration := range status.Pending {
+		fmt.Printf("Pending migration %d: %s\n", migration.Version, migration.Description)
+	}
+	return nil
+}
 
-	return agentapi.TornjakSpireServerInfo{
-		Plugins:       pluginMap,
-		TrustDomain:   config.Server.TrustDomain,
-		VerboseConfig: serverInfo,
-	}, nil
+func openDataStoreMigrator(opt cliOptions) (*agentdb.Migrator, error) {
+	tornjakConfigs, err := parseTornjakConfig(opt.genericOptions.tornjakFile, opt.genericOptions.expandEnv)
+	if err != nil {
+		return nil, errors.Errorf("Unable to parse the tornjak config file provided %v", err)
+	}
+	return agentapi.NewDataStoreMigrator(tornjakConfigs)
 }
 
 func getConfigString(path string, expandEnv bool) (string, error) {
@@ -182,35 +284,58 @@ func getConfigString(path string, expandEnv bool) (string, error) {
 	return data, nil
 }
 
-// we use our own returned SPIRE Config type to extract information
-// needed specifically by the frontend
-func parseSPIREConfig(data string) (*agentapi.SPIREConfig, error) {
-	c := &agentapi.SPIREConfig{}
-
-	if err := hcl.Decode(&c, data); err != nil {
-		return nil, fmt.Errorf("unable to decode SPIRE configuration: %w", err)
+// loadTornjakConfig parses the tornjak config file at path, in the format
+// given by its extension, and applies the TORNJAK_* environment overrides
+func loadTornjakConfig(path string, expandEnv bool) (*ast.File, error) {
+	// friendly error if file is missing
+	data, err := getConfigString(path, expandEnv)
+	if err != nil {
+		return nil, err
 	}
 
-	return c, nil
+	root, err := agentapi.ParseConfig(path, data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse tornjak configuration at %q: %w", path, err)
+	}
+	applied, err := agentapi.ApplyEnvOverrides(root, os.Environ())
+	if err != nil {
+		return nil, fmt.Errorf("unable to apply environment overrides to tornjak configuration: %w", err)
+	}
+	for _, name := range applied {
+		log.Printf("Tornjak config overridden by %s", name)
+	}
+	return root, nil
 }
 
-// below copied from spire/cmd/spire-server/cli/run/run.go, but with TornjakConfig
+// parseTornjakConfig loads and decodes the tornjak config file at path
 func parseTornjakConfig(path string, expandEnv bool) (*agentapi.TornjakConfig, error) {
-	c := &agentapi.TornjakConfig{}
-
 	if path == "" {
 		return nil, nil
 	}
 
-	// friendly error if file is missing
-	data, err := getConfigString(path, expandEnv)
+	root, err := loadTornjakConfig(path, expandEnv)
 	if err != nil {
 		return nil, err
 	}
-
-	if err := hcl.Decode(&c, data); err != nil {
+	c, err := agentapi.DecodeConfig(root)
+	if err != nil {
 		return nil, fmt.Errorf("unable to decode tornjak configuration at %q: %w", path, err)
 	}
 
 	return c, nil
 }
+
+// printEffectiveConfig prints the tornjak config as the server would use it,
+// with secrets redacted
+func printEffectiveConfig(opt cliOptions) error {
+	root, err := loadTornjakConfig(opt.genericOptions.tornjakFile, opt.genericOptions.expandEnv)
+	if err != nil {
+		return err
+	}
+	config, err := agentapi.DecodeConfig(root)
+	if err != nil {
+		return fmt.Errorf("unable to decode tornjak configuration: %w", err)
+	}
+	agentapi.RedactConfig(root, agentapi.RedactKeys(config))
+	return agentapi.PrintConfig(os.Stdout, root)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestMain runs main instead of the tests when re-executed by runBackend
+func TestMain(m *testing.M) {
+	if args := os.Getenv("TORNJAK_TEST_ARGS"); args != "" {
+		os.Args = append([]string{"tornjak-backend"}, strings.Fields(args)...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runBackend runs tornjak-backend with args and returns its exit code and output
+func runBackend(t *testing.T, args ...string) (int, string, string) {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "TORNJAK_TEST_ARGS="+strings.Join(args, " "))
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), stdout.String(), stderr.String()
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return 0, stdout.String(), stderr.String()
+}
+
+func TestValidateConfigCommand(t *testing.T) {
+	const server = `server {
+  spire_socket_path = "unix:///tmp/spire-server/private/api.sock"
+  http {
+    port = 10000
+  }
+}
+`
+	tests := []struct {
+		name       string
+		config     string
+		wantCode   int
+		wantOutput []string
+	}{
+		{
+			name: "valid",
+			config: server + `
+plugins {
+  DataStore "sql" {
+    plugin_data {
+      drivername = "sqlite3"
+      filename = "/run/tornjak.sqlite3"
+    }
+  }
+}
+`,
+			wantOutput: []string{"is valid"},
+		},
+		{
+			name: "invalid",
+			config: server + `
+plugins {
+  DataStore {
+    plugin_data {
+      drivername = "sqlite3"
+    }
+  }
+  Authorizer "RBAC" {
+    plugin_data {
+      name = "policy"
+      APIv1 "/api/v1/spire/serverinfo" { allowed_roles = ["admin"] }
+    }
+  }
+  Authenticator "Keycloak" {
+  }
+}
+`,
+			wantCode: 1,
+			wantOutput: []string{
+				"tornjak.conf: line 9: plugin DataStore expected to have two keys",
+				"tornjak.conf: line 14: Cannot configure Authorizer plugin: Invalid APIv1 block",
+				"tornjak.conf: line 20: Cannot configure Authenticator plugin",
+				"3 error(s) found in tornjak configuration",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "tornjak.conf")
+			if err := os.WriteFile(path, []byte(tt.config), 0600); err != nil {
+				t.Fatal(err)
+			}
+			code, stdout, stderr := runBackend(t, "--tornjak-config", path, "validate-config")
+			if code != tt.wantCode {
+				t.Fatalf("Expected exit code %d, got %d: %s%s", tt.wantCode, code, stdout, stderr)
+			}
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(stdout+stderr, want) {
+					t.Fatalf("Expected output containing %q, got %s%s", want, stdout, stderr)
+				}
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"log"
 
 	managerapi "github.com/spiffe/tornjak/api/manager"
@@ -10,8 +11,12 @@ func main() {
 	var (
 		dbString   = "./serverlocaldb"
 		listenAddr = ":50000"
+		uiPath     string
 	)
-	s, err := managerapi.NewManagerServer(listenAddr, dbString)
+	flag.StringVar(&uiPath, "ui-path", "", "Directory to serve the UI from, overriding the embedded UI and ./ui-manager")
+	flag.Parse()
+
+	s, err := managerapi.NewManagerServer(listenAddr, dbString, uiPath)
 	if err != nil {
 		log.Fatalf("err: %v", err)
 	}
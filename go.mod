@@ -5,22 +5,29 @@ go 1.22
 toolchain go1.22.2
 
 require (
+	connectrpc.com/connect v1.17.0
 	github.com/MicahParks/keyfunc/v2 v2.1.0
 	github.com/cenkalti/backoff/v4 v4.2.0
+	github.com/go-sql-driver/mysql v1.8.1
 	github.com/golang-jwt/jwt/v5 v5.2.1
 	github.com/gorilla/mux v1.8.0
 	github.com/hashicorp/hcl v1.0.1-0.20190430135223-99e2f22d1c94
+	github.com/lib/pq v1.10.9
 	github.com/mattn/go-sqlite3 v1.14.19
 	github.com/pardot/oidc v1.0.1
 	github.com/pkg/errors v0.9.1
+	github.com/spiffe/go-spiffe/v2 v2.1.4
 	github.com/spiffe/spire v1.6.4
 	github.com/spiffe/spire-api-sdk v1.2.5-0.20230413135745-699e242b965d
 	github.com/urfave/cli/v2 v2.3.0
+	golang.org/x/net v0.23.0
 	google.golang.org/grpc v1.56.3
 	google.golang.org/protobuf v1.34.2
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
+	filippo.io/edwards25519 v1.1.0 // indirect
 	github.com/DataDog/datadog-go v3.2.0+incompatible // indirect
 	github.com/Microsoft/go-winio v0.6.1 // indirect
 	github.com/armon/go-metrics v0.4.1 // indirect
@@ -46,7 +53,6 @@ require (
 	github.com/prometheus/procfs v0.9.0 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/sirupsen/logrus v1.9.3 // indirect
-	github.com/spiffe/go-spiffe/v2 v2.1.4 // indirect
 	github.com/spiffe/spire-plugin-sdk v1.4.4-0.20230224144655-648f8c740f73 // indirect
 	github.com/stretchr/testify v1.9.0 // indirect
 	github.com/twmb/murmur3 v1.1.6 // indirect
@@ -55,7 +61,6 @@ require (
 	go.uber.org/atomic v1.10.0 // indirect
 	golang.org/x/crypto v0.21.0 // indirect
 	golang.org/x/mod v0.16.0 // indirect
-	golang.org/x/net v0.23.0 // indirect
 	golang.org/x/sys v0.18.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
 	golang.org/x/tools v0.19.0 // indirect
@@ -1,7 +1,11 @@
 package authenticator
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
 
 	"github.com/spiffe/tornjak/pkg/agent/authentication/user"
 )
@@ -12,3 +16,88 @@ type Authenticator interface {
 	// or error upon verification error
 	AuthenticateRequest(r *http.Request) *user.UserInfo
 }
+
+// Recognizer is implemented by authenticators that can tell whether a
+// request carries a credential of the kind they check, valid or not
+type Recognizer interface {
+	Recognizes(r *http.Request) bool
+}
+
+// TokenAuthenticator is implemented by authenticators of bearer tokens. A
+// bearer token takes priority over the other credentials of a request, such
+// as the client certificate presented by a browser.
+type TokenAuthenticator interface {
+	AuthenticatesBearerTokens()
+}
+
+// hasBearerToken reports whether r carries an "Authorization: Bearer" header
+func hasBearerToken(r *http.Request) bool {
+	fields := strings.Fields(r.Header.Get("Authorization"))
+	return len(fields) == 2 && fields[0] == "Bearer"
+}
+
+// NamedAuthenticator is an Authenticator with the name of its plugin
+type NamedAuthenticator struct {
+	Name string
+	Authenticator
+}
+
+// ChainAuthenticator tries authenticators in order: the first one that
+// recognises the credential of the request authenticates it, even if the
+// credential turns out to be invalid. Authenticators that do not implement
+// Recognizer recognise every request. Requests with a bearer token are
+// tried on TokenAuthenticators first, wherever they are in the chain.
+type ChainAuthenticator struct {
+	authenticators []NamedAuthenticator
+	// tokenFirst is authenticators with TokenAuthenticators moved first
+	tokenFirst []NamedAuthenticator
+}
+
+func NewChainAuthenticator(authenticators []NamedAuthenticator) (*ChainAuthenticator, error) {
+	if len(authenticators) == 0 {
+		return nil, errors.New("no authenticator in chain")
+	}
+	var tokenFirst, others []NamedAuthenticator
+	for _, a := range authenticators {
+		if _, ok := a.Authenticator.(TokenAuthenticator); ok {
+			tokenFirst = append(tokenFirst, a)
+		} else {
+			others = append(others, a)
+		}
+	}
+	return &ChainAuthenticator{
+		authenticators: authenticators,
+		tokenFirst:     append(tokenFirst, others...),
+	}, nil
+}
+
+func (c *ChainAuthenticator) AuthenticateRequest(r *http.Request) *user.UserInfo {
+	authenticators := c.authenticators
+	if hasBearerToken(r) {
+		authenticators = c.tokenFirst
+	}
+
+	var unrecognized []*user.UserInfo
+	var reasons []string
+	for _, a := range authenticators {
+		if rec, ok := a.Authenticator.(Recognizer); ok && !rec.Recognizes(r) {
+			// the error of an unrecognised credential tells the client what is expected
+			if u := a.AuthenticateRequest(r); u != nil && u.AuthenticationError != nil {
+				u.Authenticator = a.Name
+				unrecognized = append(unrecognized, u)
+				reasons = append(reasons, fmt.Sprintf("%s: %v", a.Name, u.AuthenticationError))
+			}
+			continue
+		}
+		u := a.AuthenticateRequest(r)
+		if u != nil {
+			u.Authenticator = a.Name
+		}
+		return u
+	}
+
+	if len(unrecognized) == 1 {
+		return unrecognized[0]
+	}
+	return wrapAuthenticationError(errors.Errorf("No authenticator recognised the request credentials: %s", strings.Join(reasons, "; ")))
+}
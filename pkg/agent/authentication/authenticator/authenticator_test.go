@@ -0,0 +1,114 @@
+package authenticator
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/spiffe/tornjak/pkg/agent/authentication/user"
+)
+
+// fakeTokenAuthenticator authenticates the bearer token "valid"
+type fakeTokenAuthenticator struct{}
+
+func (fakeTokenAuthenticator) AuthenticatesBearerTokens() {}
+
+func (fakeTokenAuthenticator) Recognizes(r *http.Request) bool {
+	return hasBearerToken(r)
+}
+
+func (fakeTokenAuthenticator) AuthenticateRequest(r *http.Request) *user.UserInfo {
+	if r.Header.Get("Authorization") != "Bearer valid" {
+		return wrapAuthenticationError(errors.New("invalid token"))
+	}
+	return &user.UserInfo{Name: "token-user", Roles: []string{"admin"}}
+}
+
+// newTestRequest returns a request with the bearer token, if any, and the
+// verified client certificate with the SPIFFE ID, if any
+func newTestRequest(t *testing.T, token, spiffeID string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "https://localhost/api/v1/spire/serverinfo", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	if spiffeID != "" {
+		u, err := url.Parse(spiffeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaf := &x509.Certificate{Subject: pkix.Name{CommonName: "browser"}, URIs: []*url.URL{u}}
+		r.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{leaf},
+			VerifiedChains:   [][]*x509.Certificate{{leaf}},
+		}
+	} else {
+		r.TLS = &tls.ConnectionState{}
+	}
+	return r
+}
+
+func TestChainAuthenticator(t *testing.T) {
+	clientCert, err := NewClientCertAuthenticator(map[string][]string{
+		"spiffe://example.org/viewer": {"viewer"},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert := NamedAuthenticator{Name: "ClientCert", Authenticator: clientCert}
+	token := NamedAuthenticator{Name: "Keycloak", Authenticator: fakeTokenAuthenticator{}}
+
+	tests := []struct {
+		name      string
+		chain     []NamedAuthenticator
+		token     string
+		spiffeID  string
+		wantUser  string
+		wantAuthn string
+		wantErr   string
+	}{
+		{name: "token with cert, cert first", chain: []NamedAuthenticator{cert, token}, token: "valid", spiffeID: "spiffe://example.org/viewer", wantUser: "token-user", wantAuthn: "Keycloak"},
+		{name: "token with cert, token first", chain: []NamedAuthenticator{token, cert}, token: "valid", spiffeID: "spiffe://example.org/viewer", wantUser: "token-user", wantAuthn: "Keycloak"},
+		{name: "invalid token with cert", chain: []NamedAuthenticator{cert, token}, token: "invalid", spiffeID: "spiffe://example.org/viewer", wantAuthn: "Keycloak", wantErr: "invalid token"},
+		{name: "cert only", chain: []NamedAuthenticator{cert, token}, spiffeID: "spiffe://example.org/viewer", wantUser: "spiffe://example.org/viewer", wantAuthn: "ClientCert"},
+		{name: "unmapped cert", chain: []NamedAuthenticator{token, cert}, spiffeID: "spiffe://example.org/other", wantAuthn: "ClientCert", wantErr: "not mapped to any role"},
+		{name: "no credentials", chain: []NamedAuthenticator{cert, token}, wantErr: "No authenticator recognised the request credentials"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := NewChainAuthenticator(tt.chain)
+			if err != nil {
+				t.Fatal(err)
+			}
+			u := chain.AuthenticateRequest(newTestRequest(t, tt.token, tt.spiffeID))
+			if u == nil {
+				t.Fatal("Expected user info, got nil")
+			}
+			if tt.wantErr != "" {
+				if u.AuthenticationError == nil || !strings.Contains(u.AuthenticationError.Error(), tt.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.wantErr, u.AuthenticationError)
+				}
+			} else if u.AuthenticationError != nil {
+				t.Fatalf("Unexpected authentication error: %v", u.AuthenticationError)
+			}
+			if u.Name != tt.wantUser {
+				t.Fatalf("Expected user %q, got %q", tt.wantUser, u.Name)
+			}
+			if u.Authenticator != tt.wantAuthn {
+				t.Fatalf("Expected authenticator %q, got %q", tt.wantAuthn, u.Authenticator)
+			}
+		})
+	}
+}
+
+func TestChainAuthenticatorEmpty(t *testing.T) {
+	if _, err := NewChainAuthenticator(nil); err == nil {
+		t.Fatal("Expected error for an empty chain")
+	}
+}
@@ -0,0 +1,71 @@
+package authenticator
+
+import (
+	"net/http"
+
+	"github.com/pkg/errors"
+
+	"github.com/spiffe/tornjak/pkg/agent/authentication/user"
+)
+
+// ClientCertAuthenticator authenticates requests by the client certificate
+// verified by the HTTPS server with mTLS, mapping its SPIFFE ID or subject
+// common name to roles
+type ClientCertAuthenticator struct {
+	spiffeIDRoles   map[string][]string
+	commonNameRoles map[string][]string
+}
+
+func NewClientCertAuthenticator(spiffeIDRoles map[string][]string, commonNameRoles map[string][]string) (*ClientCertAuthenticator, error) {
+	if len(spiffeIDRoles) == 0 && len(commonNameRoles) == 0 {
+		return nil, errors.New("no SPIFFE ID or common name mapped to roles")
+	}
+	return &ClientCertAuthenticator{
+		spiffeIDRoles:   spiffeIDRoles,
+		commonNameRoles: commonNameRoles,
+	}, nil
+}
+
+// Recognizes reports whether the client presented a certificate
+func (a *ClientCertAuthenticator) Recognizes(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.PeerCertificates) > 0
+}
+
+func (a *ClientCertAuthenticator) AuthenticateRequest(r *http.Request) *user.UserInfo {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return wrapAuthenticationError(errors.New("No verified client certificate: request did not come over HTTPS with mTLS"))
+	}
+	leaf := r.TLS.VerifiedChains[0][0]
+
+	spiffeID := ""
+	for _, uri := range leaf.URIs {
+		if uri.Scheme == "spiffe" {
+			spiffeID = uri.String()
+			break
+		}
+	}
+	commonName := leaf.Subject.CommonName
+
+	idRoles, idOk := a.spiffeIDRoles[spiffeID]
+	if spiffeID == "" {
+		idOk = false
+	}
+	cnRoles, cnOk := a.commonNameRoles[commonName]
+	if commonName == "" {
+		cnOk = false
+	}
+	if !idOk && !cnOk {
+		return wrapAuthenticationError(errors.Errorf("Client certificate SPIFFE ID %q common name %q not mapped to any role", spiffeID, commonName))
+	}
+
+	name := spiffeID
+	if name == "" {
+		name = "cn:" + commonName
+	}
+	roles := append([]string{}, idRoles...)
+	roles = append(roles, cnRoles...)
+	return &user.UserInfo{
+		Name:  name,
+		Roles: roles,
+	}
+}
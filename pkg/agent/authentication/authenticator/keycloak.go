@@ -21,7 +21,8 @@ type RealmAccessSubclaim struct {
 }
 
 type KeycloakClaim struct {
-	RealmAccess RealmAccessSubclaim `json:"realm_access"`
+	RealmAccess       RealmAccessSubclaim `json:"realm_access"`
+	PreferredUsername string              `json:"preferred_username"`
 	jwt.RegisteredClaims
 }
 
@@ -103,6 +104,15 @@ func wrapAuthenticationError(err error) *user.UserInfo {
 	}
 }
 
+// Recognizes reports whether r carries a bearer token
+func (a *KeycloakAuthenticator) Recognizes(r *http.Request) bool {
+	return hasBearerToken(r)
+}
+
+// AuthenticatesBearerTokens gives bearer tokens priority over the other
+// credentials of a request in a ChainAuthenticator
+func (a *KeycloakAuthenticator) AuthenticatesBearerTokens() {}
+
 func (a *KeycloakAuthenticator) AuthenticateRequest(r *http.Request) *user.UserInfo {
 	token, err := getToken(r, a.jwksURL)
 	if err != nil {
@@ -122,7 +132,12 @@ func (a *KeycloakAuthenticator) AuthenticateRequest(r *http.Request) *user.UserI
 		return wrapAuthenticationError(errors.New("Token invalid"))
 	}
 
+	name := claims.PreferredUsername
+	if name == "" {
+		name = claims.Subject
+	}
 	return &user.UserInfo{
+		Name:  name,
 		Roles: claims.RealmAccess.Roles,
 	}
 }
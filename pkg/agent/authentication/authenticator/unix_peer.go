@@ -0,0 +1,52 @@
+package authenticator
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/spiffe/tornjak/pkg/agent/authentication/user"
+)
+
+// UnixPeerAuthenticator authenticates requests made over the Tornjak unix
+// socket by the uid and gid of the calling process
+type UnixPeerAuthenticator struct {
+	uidRoles map[uint32][]string
+	gidRoles map[uint32][]string
+}
+
+func NewUnixPeerAuthenticator(uidRoles map[uint32][]string, gidRoles map[uint32][]string) (*UnixPeerAuthenticator, error) {
+	if len(uidRoles) == 0 && len(gidRoles) == 0 {
+		return nil, errors.New("no uid or gid mapped to roles")
+	}
+	return &UnixPeerAuthenticator{
+		uidRoles: uidRoles,
+		gidRoles: gidRoles,
+	}, nil
+}
+
+// Recognizes reports whether r came over the Tornjak unix socket
+func (a *UnixPeerAuthenticator) Recognizes(r *http.Request) bool {
+	return user.PeerCredentialsFromContext(r.Context()) != nil
+}
+
+func (a *UnixPeerAuthenticator) AuthenticateRequest(r *http.Request) *user.UserInfo {
+	creds := user.PeerCredentialsFromContext(r.Context())
+	if creds == nil {
+		return wrapAuthenticationError(errors.New("No peer credentials: request did not come over the Tornjak unix socket"))
+	}
+
+	uidRoles, uidOk := a.uidRoles[creds.UID]
+	gidRoles, gidOk := a.gidRoles[creds.GID]
+	if !uidOk && !gidOk {
+		return wrapAuthenticationError(errors.Errorf("Peer uid %d gid %d not mapped to any role", creds.UID, creds.GID))
+	}
+
+	roles := append([]string{}, uidRoles...)
+	roles = append(roles, gidRoles...)
+	return &user.UserInfo{
+		Name:  "uid:" + strconv.FormatUint(uint64(creds.UID), 10),
+		Roles: roles,
+	}
+}
@@ -1,6 +1,35 @@
 package user
 
+import "context"
+
 type UserInfo struct {
 	AuthenticationError error
-	Roles               []string
+	// Name identifies the authenticated user, if the authenticator knows it
+	Name  string
+	Roles []string
+	// Authenticator names the Authenticator plugin that produced this
+	// UserInfo, e.g. "Keycloak"
+	Authenticator string
+}
+
+// PeerCredentials identify the local process on the other end of a unix
+// socket connection, as reported by the kernel
+type PeerCredentials struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+type peerCredentialsKey struct{}
+
+// WithPeerCredentials returns a copy of ctx carrying the peer credentials of a connection
+func WithPeerCredentials(ctx context.Context, creds *PeerCredentials) context.Context {
+	return context.WithValue(ctx, peerCredentialsKey{}, creds)
+}
+
+// PeerCredentialsFromContext returns the peer credentials of the connection
+// a request came in on, or nil if it did not come in on a unix socket
+func PeerCredentialsFromContext(ctx context.Context) *PeerCredentials {
+	creds, _ := ctx.Value(peerCredentialsKey{}).(*PeerCredentials)
+	return creds
 }
@@ -3,6 +3,7 @@ package authorization
 import (
 	"github.com/pkg/errors"
 	"net/http"
+	"strings"
 
 	"github.com/spiffe/tornjak/pkg/agent/authentication/user"
 )
@@ -11,6 +12,7 @@ type RBACAuthorizer struct {
 	name       string
 	roleList   map[string]string
 	apiV1Mapping map[string]map[string][]string
+	apiV2Mapping map[string]map[string][]string
 }
 
 // TODO put this in a common constants file
@@ -18,32 +20,79 @@ var staticAPIV1List = map[string]map[string]struct{}{
 	"/api/v1/spire/serverinfo" :{"GET": {}},
 	"/api/v1/spire/healthcheck" :{"GET": {}},
 	"/api/v1/spire/entries" :{"GET": {}, "POST": {}, "DELETE": {}},
+	"/api/v1/spire/entries/export" :{"GET": {}},
+	"/api/v1/spire/entries/import" :{"POST": {}},
 	"/api/v1/spire/agents" :{"GET": {}, "POST": {}, "DELETE": {}},
 	"/api/v1/spire/agents/ban" :{"POST": {}},
 	"/api/v1/spire/agents/jointoken" :{"POST": {}},
 	"/api/v1/tornjak/clusters" :{"GET": {}, "POST": {}, "PATCH": {}, "DELETE": {}},
 	"/api/v1/tornjak/selectors" :{"GET": {}, "POST": {}},
 	"/api/v1/tornjak/agents" :{"GET": {}},
+	"/api/v1/tornjak/agents/{spiffeid}/labels" :{"GET": {}, "PUT": {}},
 	"/api/v1/tornjak/serverinfo" :{"GET": {}},
+	"/api/v1/tornjak/serverinfo/combined" :{"GET": {}},
+	"/api/v1/tornjak/jobs" :{"GET": {}, "POST": {}},
+	"/api/v1/tornjak/jobs/{id}" :{"GET": {}, "DELETE": {}},
+	"/api/v1/tornjak/webhooks" :{"GET": {}},
+	"/api/v1/tornjak/reconcile" :{"GET": {}},
+	"/api/v1/tornjak/audit" :{"GET": {}},
+	"/api/v1/tornjak/audit/verify" :{"GET": {}},
 	"/api/v1/spire/bundle" :{"GET": {}},
 	"/api/v1/spire/federations/bundles" :{"GET": {}, "POST": {}, "DELETE": {}, "PATCH": {}},
 }
 
-func validateInitParameters(roleList map[string]string, apiV1Mapping map[string]map[string][]string) error {
-	if roleList == nil {
-		return errors.Errorf("No roles defined")
+// API paths are templates: a segment in braces matches any single
+// (escaped) path segment, e.g. "/api/v2/entries/{id}"
+var staticAPIV2List = map[string]map[string]struct{}{
+	"/api/v2/agents" :{"GET": {}},
+	"/api/v2/agents/{id}" :{"GET": {}, "DELETE": {}},
+	"/api/v2/agents/{id}/ban" :{"POST": {}},
+	"/api/v2/jointokens" :{"POST": {}},
+	"/api/v2/entries" :{"GET": {}, "POST": {}},
+	"/api/v2/entries/{id}" :{"GET": {}, "DELETE": {}},
+	"/api/v2/clusters" :{"GET": {}, "POST": {}},
+	"/api/v2/clusters/{name}" :{"GET": {}, "PUT": {}, "DELETE": {}},
+}
+
+// matchAPIPath returns the path template in staticAPIList matching the given escaped request path
+func matchAPIPath(staticAPIList map[string]map[string]struct{}, path string) (string, bool) {
+	pathSegments := strings.Split(path, "/")
+	for template := range staticAPIList {
+		templateSegments := strings.Split(template, "/")
+		if len(templateSegments) != len(pathSegments) {
+			continue
+		}
+		matched := true
+		for i, segment := range templateSegments {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+				if pathSegments[i] == "" {
+					matched = false
+					break
+				}
+			} else if segment != pathSegments[i] {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return template, true
+		}
 	}
-	for path, method_dict := range apiV1Mapping {
+	return "", false
+}
+
+func validateAPIMapping(version string, staticAPIList map[string]map[string]struct{}, roleList map[string]string, apiMapping map[string]map[string][]string) error {
+	for path, method_dict := range apiMapping {
 		for method, allowList := range method_dict {
 			// check that API exists
-			if _, ok := staticAPIV1List[path][method]; !ok {
-				return errors.Errorf("API V1 path %s does not exist with method %s", path, method)
+			if _, ok := staticAPIList[path][method]; !ok {
+				return errors.Errorf("API %s path %s does not exist with method %s", version, path, method)
 			}
 
 			// check that each role exists in roleList
 			for _, allowedRole := range allowList {
 				if _, ok := roleList[allowedRole]; !ok {
-					return errors.Errorf("API V1  %s lists undefined role %s", path, allowedRole)
+					return errors.Errorf("API %s  %s lists undefined role %s", version, path, allowedRole)
 				}
 			}
 		}
@@ -51,8 +100,18 @@ func validateInitParameters(roleList map[string]string, apiV1Mapping map[string]
 	return nil
 }
 
-func NewRBACAuthorizer(policyName string, roleList map[string]string, apiV1Mapping map[string]map[string][]string) (*RBACAuthorizer, error) {
-	err := validateInitParameters(roleList, apiV1Mapping)
+func validateInitParameters(roleList map[string]string, apiV1Mapping map[string]map[string][]string, apiV2Mapping map[string]map[string][]string) error {
+	if roleList == nil {
+		return errors.Errorf("No roles defined")
+	}
+	if err := validateAPIMapping("V1", staticAPIV1List, roleList, apiV1Mapping); err != nil {
+		return err
+	}
+	return validateAPIMapping("V2", staticAPIV2List, roleList, apiV2Mapping)
+}
+
+func NewRBACAuthorizer(policyName string, roleList map[string]string, apiV1Mapping map[string]map[string][]string, apiV2Mapping map[string]map[string][]string) (*RBACAuthorizer, error) {
+	err := validateInitParameters(roleList, apiV1Mapping, apiV2Mapping)
 	if err != nil {
 		return nil, errors.Errorf("Could not parse policy %s: invalid mapping: %v", policyName, err)
 	}
@@ -60,16 +119,11 @@ func NewRBACAuthorizer(policyName string, roleList map[string]string, apiV1Mappi
 		name:       policyName,
 		roleList:   roleList,
 		apiV1Mapping: apiV1Mapping,
+		apiV2Mapping: apiV2Mapping,
 	}, nil
 }
 
-func (a *RBACAuthorizer) authorizeAPIV1Request(r *http.Request, u *user.UserInfo) error {
-	userRoles := u.Roles
-	apiPath := r.URL.Path
-	apiMethod := r.Method
-
-	allowedRoles := a.apiV1Mapping[apiPath][apiMethod]
-
+func authorizeRoles(allowedRoles []string, userRoles []string) error {
 	// if no role listed for api, reject
 	if len(allowedRoles) == 0 {
 		return errors.New("Unauthorized request")
@@ -90,6 +144,26 @@ func (a *RBACAuthorizer) authorizeAPIV1Request(r *http.Request, u *user.UserInfo
 	return errors.New("Unauthorized Request")
 }
 
+func (a *RBACAuthorizer) authorizeAPIV1Request(r *http.Request, u *user.UserInfo) error {
+	apiPath, ok := matchAPIPath(staticAPIV1List, r.URL.EscapedPath())
+	if !ok {
+		return errors.New("Unauthorized request")
+	}
+	apiMethod := r.Method
+
+	return authorizeRoles(a.apiV1Mapping[apiPath][apiMethod], u.Roles)
+}
+
+func (a *RBACAuthorizer) authorizeAPIV2Request(r *http.Request, u *user.UserInfo) error {
+	apiPath, ok := matchAPIPath(staticAPIV2List, r.URL.EscapedPath())
+	if !ok {
+		return errors.New("Unauthorized request")
+	}
+	apiMethod := r.Method
+
+	return authorizeRoles(a.apiV2Mapping[apiPath][apiMethod], u.Roles)
+}
+
 func (a *RBACAuthorizer) AuthorizeRequest(r *http.Request, u *user.UserInfo) error {
 	// if not authenticated fail and return error
 	if u.AuthenticationError != nil {
@@ -97,6 +171,13 @@ func (a *RBACAuthorizer) AuthorizeRequest(r *http.Request, u *user.UserInfo) err
 	}
 
 	// if not authorized fail and return error
+	if strings.HasPrefix(r.URL.Path, "/api/v2/") {
+		err := a.authorizeAPIV2Request(r, u)
+		if err != nil {
+			return errors.Errorf("Tornjak API V2 Authorization error: %v", err)
+		}
+		return nil
+	}
 	err := a.authorizeAPIV1Request(r, u)
 	if err != nil {
 		return errors.Errorf("Tornjak API V1 Authorization error: %v", err)
@@ -1,15 +1,17 @@
 package authorization
 
 import (
+	"net/http/httptest"
 	"testing"
 	"strings"
-	//"github.com/spiffe/tornjak/pkg/agent/authentication/user"
+
+	"github.com/spiffe/tornjak/pkg/agent/authentication/user"
 )
 
 func TestNewRBACAuthorizer(t *testing.T) {
 	// INIT failures
 	// fail when no roles defined
-	_, err := NewRBACAuthorizer("", nil, nil)
+	_, err := NewRBACAuthorizer("", nil, nil, nil)
 	if err == nil {
 		t.Fatal("ERROR: successfully initialized RBAC without roles")
 	}
@@ -32,7 +34,7 @@ func TestNewRBACAuthorizer(t *testing.T) {
 	apiV1Mapping_5 := map[string]map[string][]string{"/api/v1/spire/serverinfo": {"POST": {"admin", "viewer"}}}
 
 	// fail when roles in apiMapping not in roleList
-	_, err = NewRBACAuthorizer(policyName, roleList_1, apiV1Mapping_1)
+	_, err = NewRBACAuthorizer(policyName, roleList_1, apiV1Mapping_1, nil)
 	expectedErr := "Could not parse policy testPolicy: invalid mapping: API V1  /api/v1/spire/serverinfo lists undefined role viewer"
 	if err == nil {
 		t.Fatal("ERROR: successfully initialized RBAC without roles")
@@ -41,13 +43,13 @@ func TestNewRBACAuthorizer(t *testing.T) {
 	}
 
 	// pass when roles in apiMapping in roleList
-	_, err = NewRBACAuthorizer(policyName, roleList_2, apiV1Mapping_2)
+	_, err = NewRBACAuthorizer(policyName, roleList_2, apiV1Mapping_2, nil)
 	if err != nil {
 		t.Fatalf("ERROR: failed to initialize RBAC: %s", err.Error())
 	}
 
 	// fail when typo in apiMapping
-	_, err = NewRBACAuthorizer(policyName, roleList_3, apiV1Mapping_3)
+	_, err = NewRBACAuthorizer(policyName, roleList_3, apiV1Mapping_3, nil)
 	if err == nil {
         t.Fatalf("expected an error but got nil")
     }
@@ -57,7 +59,7 @@ func TestNewRBACAuthorizer(t *testing.T) {
     }
 
 	// fail when apiV1Mapping has path not in staticAPIV1List
-	_, err = NewRBACAuthorizer(policyName, roleList_4, apiV1Mapping_4)
+	_, err = NewRBACAuthorizer(policyName, roleList_4, apiV1Mapping_4, nil)
     if err == nil {
         t.Fatal("ERROR: successfully initialized RBAC without roles")
     }
@@ -67,7 +69,7 @@ func TestNewRBACAuthorizer(t *testing.T) {
     }
 
 	// fail when apiV1Mapping has method not in staticAPIV1List
-	_, err = NewRBACAuthorizer(policyName, roleList_5, apiV1Mapping_5)
+	_, err = NewRBACAuthorizer(policyName, roleList_5, apiV1Mapping_5, nil)
 	if err == nil {
         t.Fatal("ERROR: successfully initialized RBAC without roles")
     }
@@ -77,4 +79,92 @@ func TestNewRBACAuthorizer(t *testing.T) {
     }
 
 }
+func TestNewRBACAuthorizerAPIV2(t *testing.T) {
+	policyName := "testPolicy"
+	roleList := map[string]string{"admin": "admin", "viewer": "viewer"}
+
+	// pass when APIv2 template and method exist
+	apiV2Mapping := map[string]map[string][]string{"/api/v2/entries/{id}": {"GET": {"admin", "viewer"}, "DELETE": {"admin"}}}
+	_, err := NewRBACAuthorizer(policyName, roleList, nil, apiV2Mapping)
+	if err != nil {
+		t.Fatalf("ERROR: failed to initialize RBAC: %s", err.Error())
+	}
+
+	// fail when APIv2 path is not a known template
+	apiV2Mapping = map[string]map[string][]string{"/api/v2/entries/abc": {"GET": {"admin"}}}
+	_, err = NewRBACAuthorizer(policyName, roleList, nil, apiV2Mapping)
+	if err == nil {
+		t.Fatal("ERROR: successfully initialized RBAC with unknown API V2 path")
+	}
+	expectedPhrase := "API V2 path /api/v2/entries/abc does not exist"
+	if !strings.Contains(err.Error(), expectedPhrase) {
+		t.Fatalf("expected error to contain %q but got %q", expectedPhrase, err.Error())
+	}
+
+	// fail when APIv2 mapping lists undefined role
+	apiV2Mapping = map[string]map[string][]string{"/api/v2/clusters": {"POST": {"operator"}}}
+	_, err = NewRBACAuthorizer(policyName, roleList, nil, apiV2Mapping)
+	if err == nil {
+		t.Fatal("ERROR: successfully initialized RBAC with undefined role")
+	}
+	expectedPhrase = "undefined role operator"
+	if !strings.Contains(err.Error(), expectedPhrase) {
+		t.Fatalf("expected error to contain %q but got %q", expectedPhrase, err.Error())
+	}
+}
+
+func TestAuthorizeRequestAPIV2(t *testing.T) {
+	roleList := map[string]string{"admin": "admin", "viewer": "viewer"}
+	apiV1Mapping := map[string]map[string][]string{
+		"/api/v1/spire/entries":     {"GET": {"viewer"}},
+		"/api/v1/tornjak/jobs/{id}": {"GET": {"viewer"}, "DELETE": {"admin"}},
+	}
+	apiV2Mapping := map[string]map[string][]string{
+		"/api/v2/agents/{id}":     {"GET": {"admin", "viewer"}, "DELETE": {"admin"}},
+		"/api/v2/agents/{id}/ban": {"POST": {"admin"}},
+	}
+	authorizer, err := NewRBACAuthorizer("testPolicy", roleList, apiV1Mapping, apiV2Mapping)
+	if err != nil {
+		t.Fatalf("ERROR: failed to initialize RBAC: %s", err.Error())
+	}
+	viewer := &user.UserInfo{Roles: []string{"viewer"}}
+	admin := &user.UserInfo{Roles: []string{"admin"}}
+	agentPath := "/api/v2/agents/spiffe%3A%2F%2Fexample.org%2Fspire%2Fagent%2Fjoin_token%2Fabc"
+
+	testCases := []struct {
+		method  string
+		path    string
+		user    *user.UserInfo
+		allowed bool
+	}{
+		{"GET", agentPath, viewer, true},
+		{"DELETE", agentPath, viewer, false},
+		{"DELETE", agentPath, admin, true},
+		{"POST", agentPath + "/ban", admin, true},
+		{"POST", agentPath + "/ban", viewer, false},
+		// unescaped SPIFFE ID does not match the single segment template
+		{"GET", "/api/v2/agents/spiffe://example.org/spire/agent/x", viewer, false},
+		// unmapped API V2 template
+		{"GET", "/api/v2/entries", admin, false},
+		// API V1 still authorized through API V1 mapping
+		{"GET", "/api/v1/spire/entries", viewer, true},
+		{"GET", "/api/v1/spire/entries", admin, false},
+		// API V1 path templates
+		{"GET", "/api/v1/tornjak/jobs/0a1b2c", viewer, true},
+		{"DELETE", "/api/v1/tornjak/jobs/0a1b2c", viewer, false},
+		{"DELETE", "/api/v1/tornjak/jobs/0a1b2c", admin, true},
+		{"GET", "/api/v1/tornjak/jobs/", viewer, false},
+	}
+	for _, tc := range testCases {
+		r := httptest.NewRequest(tc.method, tc.path, nil)
+		err := authorizer.AuthorizeRequest(r, tc.user)
+		if tc.allowed && err != nil {
+			t.Fatalf("ERROR: expected %s %s to be allowed for roles %v, got %s", tc.method, tc.path, tc.user.Roles, err.Error())
+		}
+		if !tc.allowed && err == nil {
+			t.Fatalf("ERROR: expected %s %s to be rejected for roles %v", tc.method, tc.path, tc.user.Roles)
+		}
+	}
+}
+
 // func TestAuthorizeRequest(t *testing.T) {
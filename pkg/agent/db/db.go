@@ -1,6 +1,8 @@
 package db
 
 import (
+	"time"
+
 	"github.com/spiffe/tornjak/pkg/agent/types"
 )
 
@@ -9,6 +11,10 @@ type AgentDB interface {
 	CreateAgentEntry(sinfo types.AgentInfo) error
 	GetAgentSelectors() (types.AgentInfoList, error)
 	GetAgentPluginInfo(name string) (types.AgentInfo, error)
+	SetAgentLabels(alabels types.AgentLabels) error
+	GetAgentLabels(spiffeid string) (types.AgentLabels, error)
+	DeleteAgentEntry(spiffeid string) error
+	TombstoneAgentEntry(spiffeid string, reason string) error
 
 	// CLUSTER interface
 	GetClusters() (types.ClusterInfoList, error)
@@ -20,4 +26,42 @@ type AgentDB interface {
 	GetAgentClusterName(spiffeid string) (string, error)
 	GetClusterAgents(name string) ([]string, error)
 	GetAgentsMetadata(req types.AgentMetadataRequest) (types.AgentInfoList, error)
+
+	// ENTRY METADATA interface
+	CreateEntryMetadata(meta types.EntryMetadata) error
+	GetEntriesMetadata(ids []string) (types.EntryMetadataList, error)
+	DeleteEntriesMetadata(ids []string) error
+
+	// IDEMPOTENCY interface
+	CreateIdempotencyRecord(rec types.IdempotencyRecord) error
+	GetIdempotencyRecord(key string, path string, principal string) (types.IdempotencyRecord, error)
+	CompleteIdempotencyRecord(rec types.IdempotencyRecord) error
+	DeleteIdempotencyRecord(key string, path string, principal string) error
+	DeleteIdempotencyRecordsBefore(createdAt int64, inProgressCreatedAt int64) error
+
+	// JOB interface
+	CreateJob(job types.JobInfo) error
+	GetJob(id string) (types.JobInfo, error)
+	GetJobs() (types.JobInfoList, error)
+	UpdateJob(job types.JobInfo) error
+	RequestJobCancel(id string) error
+	FailUnfinishedJobs(owner string, message string) (int64, error)
+
+	// INSTANCE interface
+	HeartbeatInstance(id string, staleBefore time.Time) error
+
+	// WEBHOOK interface
+	CreateWebhookDelivery(delivery types.WebhookDelivery) error
+	UpdateWebhookDelivery(delivery types.WebhookDelivery) error
+	DeadLetterWebhookDelivery(delivery types.WebhookDelivery) error
+	DeadLetterPendingWebhookDeliveries(owner string, message string) (int64, error)
+	GetPendingWebhookDeliveries(owner string) ([]types.WebhookDelivery, error)
+	GetWebhookDeliveries(webhook string, status string, limit int) (types.WebhookDeliveryList, error)
+	GetWebhookDeadLetters(webhook string, limit int) (types.WebhookDeadLetterList, error)
+
+	// AUDIT interface
+	AppendAuditRecord(record types.AuditRecord) (types.AuditRecord, error)
+	GetAuditRecords(filter types.AuditFilter) (types.AuditRecordList, error)
+	DeleteAuditRecordsBefore(t time.Time) (int64, error)
+	VerifyAuditRecords() (types.AuditVerification, error)
 }
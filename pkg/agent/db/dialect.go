@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+
+	backoff "github.com/cenkalti/backoff/v4"
+	"github.com/pkg/errors"
+)
+
+// sqlDialect covers what differs between the SQL databases Tornjak can store
+// its metadata in. Queries are written for sqlite, with ? placeholders and
+// double-quoted identifiers, and rewritten by rebind.
+type sqlDialect interface {
+	// migrations returns the schema migrations, in order of version
+	migrations() []Migration
+	// tableExistsQuery returns the query counting the tables named ?
+	tableExistsQuery() string
+	// columnExistsQuery returns the query counting the columns named ? of
+	// the table named ?, "" if DDL is transactional so that a failed
+	// migration leaves no column behind
+	columnExistsQuery() string
+	// lockMigrations waits for the lock serializing schema migrations among
+	// the Tornjak servers sharing the database, held by conn until
+	// unlockMigrations
+	lockMigrations(ctx context.Context, conn *sql.Conn) error
+	unlockMigrations(ctx context.Context, conn *sql.Conn) error
+	// rebind rewrites a query written for sqlite
+	rebind(query string) string
+	// insertOrIgnore turns an INSERT INTO statement into one that skips rows
+	// violating a unique constraint
+	insertOrIgnore(cmd string) string
+	// upsert returns the clause making an INSERT update columns of the row
+	// conflicting on the unique conflict columns instead
+	upsert(conflict []string, columns ...string) string
+	// concat returns the SQL expression concatenating exprs
+	concat(exprs ...string) string
+	// forUpdate returns the clause locking rows read in a transaction
+	forUpdate() string
+	// isConstraintError reports whether err is a constraint violation
+	isConstraintError(err error) bool
+}
+
+// NewAgentDB returns the AgentDB of the given driver: sqlite3 with the path
+// of the database file, postgres or mysql with a connection string
+func NewAgentDB(driverName string, dataSourceName string, backOffParams backoff.BackOff) (AgentDB, error) {
+	switch driverName {
+	case "sqlite3":
+		return NewLocalSqliteDB(driverName, dataSourceName, backOffParams)
+	case "postgres":
+		return NewPostgresDB(dataSourceName, backOffParams)
+	case "mysql":
+		return NewMySQLDB(dataSourceName, backOffParams)
+	default:
+		return nil, errors.Errorf("Unsupported DB driver %s; use sqlite3, postgres or mysql", driverName)
+	}
+}
+
+// newSQLAgentDB opens the database and applies its pending migrations
+func newSQLAgentDB(driverName string, dataSourceName string, backOffParams backoff.BackOff) (*SQLAgentDB, error) {
+	db, err := openDialectDB(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator := &Migrator{database: db, migrations: db.dialect.migrations()}
+	if _, err = migrator.Migrate(false); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLAgentDB{
+		database:   db,
+		dialect:    db.dialect,
+		expBackoff: &backOffParams,
+	}, nil
+}
+
+// openDialectDB opens the database of the given driver
+func openDialectDB(driverName string, dataSourceName string) (*dialectDB, error) {
+	var dialect sqlDialect
+	switch driverName {
+	case "sqlite3":
+		dialect = sqliteDialect{}
+	case "postgres":
+		dialect = postgresDialect{}
+	case "mysql":
+		dialect = mysqlDialect{}
+		var err error
+		if dataSourceName, err = mysqlDataSource(dataSourceName); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("Unsupported DB driver %s; use sqlite3, postgres or mysql", driverName)
+	}
+
+	database, err := sql.Open(driverName, dataSourceName)
+	if err != nil {
+		return nil, errors.New("Unable to open connection to DB")
+	}
+	return &dialectDB{database, dialect}, nil
+}
+
+// dialectDB rebinds the queries run on the database for its dialect
+type dialectDB struct {
+	*sql.DB
+	dialect sqlDialect
+}
+
+func (d *dialectDB) Prepare(query string) (*sql.Stmt, error) {
+	return d.DB.Prepare(d.dialect.rebind(query))
+}
+
+func (d *dialectDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return d.DB.Exec(d.dialect.rebind(query), args...)
+}
+
+func (d *dialectDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return d.DB.Query(d.dialect.rebind(query), args...)
+}
+
+func (d *dialectDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return d.DB.QueryRow(d.dialect.rebind(query), args...)
+}
+
+func (d *dialectDB) Begin() (*dialectTx, error) {
+	return d.BeginTx(context.Background(), nil)
+}
+
+func (d *dialectDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*dialectTx, error) {
+	tx, err := d.DB.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &dialectTx{tx, d.dialect}, nil
+}
+
+// dialectTx rebinds the queries run in the transaction for its dialect
+type dialectTx struct {
+	*sql.Tx
+	dialect sqlDialect
+}
+
+func (t *dialectTx) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return t.Tx.PrepareContext(ctx, t.dialect.rebind(query))
+}
+
+func (t *dialectTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return t.Tx.Exec(t.dialect.rebind(query), args...)
+}
+
+func (t *dialectTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return t.Tx.QueryRow(t.dialect.rebind(query), args...)
+}
+
+// rewriteQuery replaces the ? placeholders of query by placeholder(n), n
+// counting from 1, and its double quotes by quote, leaving string literals
+// alone
+func rewriteQuery(query string, placeholder func(n int) string, quote string) string {
+	var b strings.Builder
+	n := 0
+	inString := false
+	for _, c := range query {
+		switch {
+		case c == '\'':
+			inString = !inString
+			b.WriteRune(c)
+		case inString:
+			b.WriteRune(c)
+		case c == '?' && placeholder != nil:
+			n++
+			b.WriteString(placeholder(n))
+		case c == '"' && quote != "":
+			b.WriteString(quote)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// upsertExcluded is the upsert clause of sqlite and PostgreSQL
+func upsertExcluded(conflict []string, columns []string) string {
+	set := make([]string, len(columns))
+	for i, column := range columns {
+		set[i] = column + "=excluded." + column
+	}
+	return ` ON CONFLICT(` + strings.Join(conflict, ", ") + `) DO UPDATE SET ` + strings.Join(set, ", ")
+}
+
+// postgresPlaceholder is the nth placeholder of PostgreSQL
+func postgresPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
@@ -0,0 +1,240 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// migrationLockID and migrationLockName identify the lock serializing the
+// migrations of Tornjak servers sharing a database, in PostgreSQL and MySQL
+const (
+	migrationLockID   = 0x746f726e6a616b // "tornjak" in ASCII
+	migrationLockName = "tornjak_schema_migration"
+)
+
+// addColumnStatement matches the statements of migrations adding a column,
+// capturing the table and the column
+var addColumnStatement = regexp.MustCompile(`^ALTER TABLE (\w+) ADD COLUMN (\w+) `)
+
+// schema version table with one row per migration applied to the database
+const initSchemaVersionTable = `CREATE TABLE IF NOT EXISTS schema_version
+                            (version INTEGER PRIMARY KEY, description TEXT, applied_at BIGINT)`
+
+// migration 2 statements, the same on all databases: labels and details of
+// clusters, as JSON
+const (
+	addClusterLabelsColumn  = `ALTER TABLE clusters ADD COLUMN labels TEXT`
+	addClusterDetailsColumn = `ALTER TABLE clusters ADD COLUMN details TEXT`
+)
+
+// migration 3 statements: labels and annotations of agents, as JSON
+const (
+	addAgentLabelsColumn      = `ALTER TABLE agents ADD COLUMN labels TEXT`
+	addAgentAnnotationsColumn = `ALTER TABLE agents ADD COLUMN annotations TEXT`
+)
+
+// migration 5 statements: tombstones of agents deleted or banned in SPIRE
+const (
+	addAgentTombstonedAtColumn    = `ALTER TABLE agents ADD COLUMN tombstoned_at BIGINT`
+	addAgentTombstoneReasonColumn = `ALTER TABLE agents ADD COLUMN tombstone_reason TEXT`
+)
+
+// migration 6 statements: the Tornjak instance owning each job and webhook
+// delivery, and cancellation of jobs owned by another instance
+const (
+	addJobOwnerColumn             = `ALTER TABLE jobs ADD COLUMN owner TEXT`
+	addJobCancelRequestedColumn   = `ALTER TABLE jobs ADD COLUMN cancel_requested BOOLEAN`
+	addWebhookDeliveryOwnerColumn = `ALTER TABLE webhook_deliveries ADD COLUMN owner TEXT`
+)
+
+// migration 7 statements: the caller of requests made with an Idempotency-Key
+const addIdempotencyPrincipalColumn = `ALTER TABLE idempotency_keys ADD COLUMN principal TEXT`
+
+// migration 8 statements: idempotency keys are recreated unique per caller,
+// dropping the stored responses, which only matter for a retry
+const dropIdempotencyTable = `DROP TABLE IF EXISTS idempotency_keys`
+
+// Migration is a change of the schema of the database. Migrations are
+// applied in order of version, each in a transaction where the database
+// supports transactional DDL.
+type Migration struct {
+	Version     int
+	Description string
+	Statements  []string
+}
+
+// SchemaStatus is the schema version of a database and the migrations this
+// binary would apply to it
+type SchemaStatus struct {
+	// Version is the latest migration applied, 0 if none
+	Version int
+	// Latest is the latest migration known to this binary
+	Latest  int
+	Pending []Migration
+}
+
+// SchemaVersionError is returned for a database migrated by a newer Tornjak,
+// whose schema this binary does not understand
+type SchemaVersionError struct {
+	Version int
+	Latest  int
+}
+
+func (e SchemaVersionError) Error() string {
+	return fmt.Sprintf("DB schema version %d is newer than version %d supported by this Tornjak; upgrade Tornjak", e.Version, e.Latest)
+}
+
+// Migrator applies the schema migrations of its dialect to a database
+type Migrator struct {
+	database   *dialectDB
+	migrations []Migration
+}
+
+// NewMigrator opens the database of the given driver, as NewAgentDB does,
+// without creating or migrating its tables
+func NewMigrator(driverName string, dataSourceName string) (*Migrator, error) {
+	db, err := openDialectDB(driverName, dataSourceName)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{database: db, migrations: db.dialect.migrations()}, nil
+}
+
+// Close closes the database
+func (m *Migrator) Close() error {
+	return m.database.Close()
+}
+
+// Status returns the schema version of the database and its pending
+// migrations. It fails with a SchemaVersionError if the schema is newer
+// than this binary.
+func (m *Migrator) Status() (SchemaStatus, error) {
+	status := SchemaStatus{Latest: m.latest(), Pending: []Migration{}}
+	exists := 0
+	cmd := m.database.dialect.tableExistsQuery()
+	if err := m.database.QueryRow(cmd, "schema_version").Scan(&exists); err != nil {
+		return SchemaStatus{}, SQLError{cmd, err}
+	}
+	if exists > 0 {
+		var version sql.NullInt64
+		cmd = "SELECT MAX(version) FROM schema_version"
+		if err := m.database.QueryRow(cmd).Scan(&version); err != nil {
+			return SchemaStatus{}, SQLError{cmd, err}
+		}
+		status.Version = int(version.Int64)
+	}
+	if status.Version > status.Latest {
+		return status, SchemaVersionError{Version: status.Version, Latest: status.Latest}
+	}
+	for _, migration := range m.migrations {
+		if migration.Version > status.Version {
+			status.Pending = append(status.Pending, migration)
+		}
+	}
+	return status, nil
+}
+
+// Migrate applies the pending migrations and returns them; in dry run, it
+// only returns them. Servers sharing the database migrate it one at a time:
+// the pending migrations are those left once the lock is held.
+func (m *Migrator) Migrate(dryRun bool) ([]Migration, error) {
+	if dryRun {
+		status, err := m.Status()
+		if err != nil {
+			return nil, err
+		}
+		return status.Pending, nil
+	}
+
+	ctx := context.Background()
+	conn, err := m.database.Conn(ctx)
+	if err != nil {
+		return nil, errors.Errorf("Unable to lock migrations: %v", err)
+	}
+	defer conn.Close()
+	if err := m.database.dialect.lockMigrations(ctx, conn); err != nil {
+		return nil, errors.Errorf("Unable to lock migrations: %v", err)
+	}
+	defer func() {
+		if err := m.database.dialect.unlockMigrations(ctx, conn); err != nil {
+			log.Printf("Unable to unlock migrations: %v", err)
+		}
+	}()
+
+	status, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	if len(status.Pending) == 0 {
+		return status.Pending, nil
+	}
+
+	if err := createDBTable(m.database, initSchemaVersionTable); err != nil {
+		return nil, err
+	}
+	for _, migration := range status.Pending {
+		if err := m.apply(migration); err != nil {
+			return nil, err
+		}
+	}
+	return status.Pending, nil
+}
+
+// apply runs the statements of migration and records it in one transaction
+func (m *Migrator) apply(migration Migration) error {
+	tx, err := m.database.Begin()
+	if err != nil {
+		return errors.Errorf("Unable to begin migration %d: %v", migration.Version, err)
+	}
+	for _, cmd := range migration.Statements {
+		exists, err := m.columnExists(cmd)
+		if err != nil {
+			tx.Rollback()
+			return errors.Errorf("Migration %d (%s) failed: %v", migration.Version, migration.Description, err)
+		}
+		if exists {
+			continue
+		}
+		if _, err := tx.Exec(cmd); err != nil {
+			tx.Rollback()
+			return errors.Errorf("Migration %d (%s) failed: %v", migration.Version, migration.Description, SQLError{cmd, err})
+		}
+	}
+	cmd := "INSERT INTO schema_version (version, description, applied_at) VALUES (?,?,?)"
+	if _, err := tx.Exec(cmd, migration.Version, migration.Description, time.Now().UnixNano()); err != nil {
+		tx.Rollback()
+		return errors.Errorf("Migration %d (%s) failed: %v", migration.Version, migration.Description, SQLError{cmd, err})
+	}
+	if err := tx.Commit(); err != nil {
+		return errors.Errorf("Migration %d (%s) failed: %v", migration.Version, migration.Description, err)
+	}
+	return nil
+}
+
+// columnExists reports whether cmd adds a column that exists already, on
+// databases where a failed migration can leave columns behind
+func (m *Migrator) columnExists(cmd string) (bool, error) {
+	query := m.database.dialect.columnExistsQuery()
+	match := addColumnStatement.FindStringSubmatch(cmd)
+	if query == "" || match == nil {
+		return false, nil
+	}
+	count := 0
+	if err := m.database.QueryRow(query, match[1], match[2]).Scan(&count); err != nil {
+		return false, SQLError{query, err}
+	}
+	return count > 0, nil
+}
+
+func (m *Migrator) latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
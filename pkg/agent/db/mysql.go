@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	backoff "github.com/cenkalti/backoff/v4"
+	"github.com/go-sql-driver/mysql"
+	"github.com/pkg/errors"
+)
+
+// MySQL indexes at most 3072 bytes of a key, so unique columns are VARCHAR
+// sized to fit in utf8mb4
+const (
+	mysqlAgentsTable = `CREATE TABLE IF NOT EXISTS agents
+                            (id BIGINT AUTO_INCREMENT PRIMARY KEY, spiffeid VARCHAR(512), plugin TEXT, UNIQUE (spiffeid))`
+	mysqlClustersTable = `CREATE TABLE IF NOT EXISTS clusters
+                            (id BIGINT AUTO_INCREMENT PRIMARY KEY, name VARCHAR(255), created_at TEXT,
+                            domain_name TEXT, platform_type TEXT, managed_by TEXT, UNIQUE (name))`
+	mysqlClusterMemberTable = `CREATE TABLE IF NOT EXISTS cluster_memberships
+                            (id BIGINT AUTO_INCREMENT PRIMARY KEY, agent_id BIGINT, cluster_id BIGINT,
+                            FOREIGN KEY (agent_id) REFERENCES agents(id),
+                            FOREIGN KEY (cluster_id) REFERENCES clusters(id), UNIQUE (agent_id))`
+	mysqlIdempotencyTable = "CREATE TABLE IF NOT EXISTS idempotency_keys " + `
+                            (id BIGINT AUTO_INCREMENT PRIMARY KEY, ` + "`key`" + ` VARCHAR(255), path VARCHAR(512), request_hash TEXT,
+                            status_code INTEGER, content_type TEXT, location TEXT, response LONGBLOB, created_at BIGINT,
+                            UNIQUE (` + "`key`" + `, path))`
+	mysqlIdempotencyTableV8 = "CREATE TABLE IF NOT EXISTS idempotency_keys " + `
+                            (id BIGINT AUTO_INCREMENT PRIMARY KEY, ` + "`key`" + ` VARCHAR(255), path VARCHAR(255),
+                            principal VARCHAR(255) NOT NULL DEFAULT '', request_hash TEXT, status_code INTEGER,
+                            content_type TEXT, location TEXT, response LONGBLOB, created_at BIGINT,
+                            UNIQUE (` + "`key`" + `, path, principal))`
+	mysqlJobsTable = `CREATE TABLE IF NOT EXISTS jobs
+                            (id VARCHAR(255) PRIMARY KEY, type TEXT, status VARCHAR(32), params LONGTEXT,
+                            total INTEGER, processed INTEGER, failed INTEGER, failures LONGTEXT, error TEXT,
+                            created_at BIGINT, updated_at BIGINT)`
+	mysqlWebhookDeliveriesTable = `CREATE TABLE IF NOT EXISTS webhook_deliveries
+                            (id VARCHAR(255) PRIMARY KEY, webhook TEXT, event_id TEXT, action TEXT, status VARCHAR(32),
+                            attempts INTEGER, response_code INTEGER, last_error TEXT, payload LONGBLOB,
+                            created_at BIGINT, updated_at BIGINT)`
+	mysqlWebhookDeadLettersTable = `CREATE TABLE IF NOT EXISTS webhook_dead_letters
+                            (delivery_id VARCHAR(255) PRIMARY KEY, webhook TEXT, event_id TEXT, action TEXT,
+                            payload LONGBLOB, error TEXT, created_at BIGINT)`
+	mysqlAuditTable = `CREATE TABLE IF NOT EXISTS audit_log
+                            (seq BIGINT PRIMARY KEY, time BIGINT, ` + "`user`" + ` TEXT, roles TEXT, remote_addr TEXT,
+                            method TEXT, route TEXT, path TEXT, decision TEXT, reason TEXT, resources TEXT,
+                            status INTEGER, outcome TEXT, prev_hash TEXT, hash TEXT)`
+	// MySQL has no CREATE INDEX IF NOT EXISTS; the index is created with the table
+	mysqlAuditResourcesTable = `CREATE TABLE IF NOT EXISTS audit_resources
+                            (seq BIGINT, resource VARCHAR(512), PRIMARY KEY (seq, resource),
+                            INDEX audit_resources_resource (resource))`
+	mysqlAuditChainTable = `CREATE TABLE IF NOT EXISTS audit_chain
+                            (id INTEGER PRIMARY KEY CHECK (id = 1), seq BIGINT, hash TEXT)`
+	mysqlInstancesTable = `CREATE TABLE IF NOT EXISTS instances
+                            (id VARCHAR(255) PRIMARY KEY, last_seen BIGINT)`
+	mysqlEntryMetadataTable = `CREATE TABLE IF NOT EXISTS entry_metadata
+                            (entry_id VARCHAR(255) PRIMARY KEY, owner TEXT, description TEXT, ticket TEXT, tags TEXT,
+                            created_by TEXT, created_at BIGINT)`
+)
+
+// NewMySQLDB returns an AgentDB storing Tornjak metadata in the MySQL
+// database of the connection string, in the format of
+// github.com/go-sql-driver/mysql
+func NewMySQLDB(connectionString string, backOffParams backoff.BackOff) (AgentDB, error) {
+	db, err := newSQLAgentDB("mysql", connectionString, backOffParams)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// mysqlDataSource checks the connection string and sets the options Tornjak
+// needs
+func mysqlDataSource(connectionString string) (string, error) {
+	config, err := mysql.ParseDSN(connectionString)
+	if err != nil {
+		return "", errors.Errorf("Invalid MySQL connection string: %v", err)
+	}
+	// updates report the rows matched rather than changed, as other
+	// databases do, so that updates checking their row count work
+	config.ClientFoundRows = true
+	return config.FormatDSN(), nil
+}
+
+type mysqlDialect struct{}
+
+// migrations of MySQL are not transactional, as DDL statements commit
+// implicitly; a failed migration may have to be completed by hand
+func (mysqlDialect) migrations() []Migration {
+	return []Migration{
+		{Version: 1, Description: "create tables", Statements: []string{mysqlAgentsTable, mysqlClustersTable,
+			mysqlClusterMemberTable, mysqlIdempotencyTable, mysqlJobsTable, mysqlWebhookDeliveriesTable,
+			mysqlWebhookDeadLettersTable, mysqlAuditTable, mysqlAuditResourcesTable, mysqlAuditChainTable}},
+		{Version: 2, Description: "add cluster labels and details", Statements: []string{addClusterLabelsColumn,
+			addClusterDetailsColumn}},
+		{Version: 3, Description: "add agent labels and annotations", Statements: []string{addAgentLabelsColumn,
+			addAgentAnnotationsColumn}},
+		{Version: 4, Description: "create entry metadata table", Statements: []string{mysqlEntryMetadataTable}},
+		{Version: 5, Description: "add agent tombstones", Statements: []string{addAgentTombstonedAtColumn,
+			addAgentTombstoneReasonColumn}},
+		{Version: 6, Description: "add job and webhook delivery owners", Statements: []string{addJobOwnerColumn,
+			addJobCancelRequestedColumn, addWebhookDeliveryOwnerColumn, mysqlInstancesTable}},
+		{Version: 7, Description: "add idempotency key principals", Statements: []string{addIdempotencyPrincipalColumn}},
+		{Version: 8, Description: "make idempotency keys unique per principal", Statements: []string{dropIdempotencyTable,
+			mysqlIdempotencyTableV8}},
+	}
+}
+
+func (mysqlDialect) tableExistsQuery() string {
+	return `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?`
+}
+
+// columnExistsQuery lets migrations skip the columns already added by a
+// failed run, as DDL statements commit implicitly in MySQL
+func (mysqlDialect) columnExistsQuery() string {
+	return `SELECT COUNT(*) FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ? AND column_name = ?`
+}
+
+// lockMigrations takes a named lock on conn, waiting for it without timeout
+func (mysqlDialect) lockMigrations(ctx context.Context, conn *sql.Conn) error {
+	cmd := `SELECT GET_LOCK(?, -1)`
+	var locked sql.NullInt64
+	if err := conn.QueryRowContext(ctx, cmd, migrationLockName).Scan(&locked); err != nil {
+		return SQLError{cmd, err}
+	}
+	if locked.Int64 != 1 {
+		return SQLError{cmd, errors.Errorf("could not get lock %s", migrationLockName)}
+	}
+	return nil
+}
+
+func (mysqlDialect) unlockMigrations(ctx context.Context, conn *sql.Conn) error {
+	cmd := `SELECT RELEASE_LOCK(?)`
+	if _, err := conn.ExecContext(ctx, cmd, migrationLockName); err != nil {
+		return SQLError{cmd, err}
+	}
+	return nil
+}
+
+func (mysqlDialect) rebind(query string) string {
+	return rewriteQuery(query, nil, "`")
+}
+
+func (mysqlDialect) insertOrIgnore(cmd string) string {
+	return strings.Replace(cmd, "INSERT INTO", "INSERT IGNORE INTO", 1)
+}
+
+func (mysqlDialect) upsert(conflict []string, columns ...string) string {
+	set := make([]string, len(columns))
+	for i, column := range columns {
+		set[i] = column + "=VALUES(" + column + ")"
+	}
+	return ` ON DUPLICATE KEY UPDATE ` + strings.Join(set, ", ")
+}
+
+func (mysqlDialect) concat(exprs ...string) string {
+	return "CONCAT(" + strings.Join(exprs, ", ") + ")"
+}
+
+func (mysqlDialect) forUpdate() string {
+	return ` FOR UPDATE`
+}
+
+// isConstraintError reports duplicate keys and violated foreign key, not
+// null and check constraints
+func (mysqlDialect) isConstraintError(err error) bool {
+	merr, ok := err.(*mysql.MySQLError)
+	if !ok {
+		return false
+	}
+	switch merr.Number {
+	case 1062, 1451, 1452, 1048, 3819:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	backoff "github.com/cenkalti/backoff/v4"
+	"github.com/lib/pq"
+)
+
+const (
+	postgresAgentsTable = `CREATE TABLE IF NOT EXISTS agents
+                            (id BIGSERIAL PRIMARY KEY, spiffeid TEXT, plugin TEXT, UNIQUE (spiffeid))`
+	postgresClustersTable = `CREATE TABLE IF NOT EXISTS clusters
+                            (id BIGSERIAL PRIMARY KEY, name TEXT, created_at TEXT,
+                            domain_name TEXT, platform_type TEXT, managed_by TEXT, UNIQUE (name))`
+	postgresClusterMemberTable = `CREATE TABLE IF NOT EXISTS cluster_memberships
+                            (id BIGSERIAL PRIMARY KEY, agent_id BIGINT, cluster_id BIGINT,
+                            FOREIGN KEY (agent_id) REFERENCES agents(id),
+                            FOREIGN KEY (cluster_id) REFERENCES clusters(id), UNIQUE (agent_id))`
+	postgresIdempotencyTable = `CREATE TABLE IF NOT EXISTS idempotency_keys
+                            (id BIGSERIAL PRIMARY KEY, "key" TEXT, path TEXT, request_hash TEXT,
+                            status_code INTEGER, content_type TEXT, location TEXT, response BYTEA, created_at BIGINT,
+                            UNIQUE ("key", path))`
+	postgresIdempotencyTableV8 = `CREATE TABLE IF NOT EXISTS idempotency_keys
+                            (id BIGSERIAL PRIMARY KEY, "key" TEXT, path TEXT, principal TEXT NOT NULL DEFAULT '',
+                            request_hash TEXT, status_code INTEGER, content_type TEXT, location TEXT, response BYTEA,
+                            created_at BIGINT, UNIQUE ("key", path, principal))`
+	postgresJobsTable = `CREATE TABLE IF NOT EXISTS jobs
+                            (id TEXT PRIMARY KEY, type TEXT, status TEXT, params TEXT,
+                            total INTEGER, processed INTEGER, failed INTEGER, failures TEXT, error TEXT,
+                            created_at BIGINT, updated_at BIGINT)`
+	postgresWebhookDeliveriesTable = `CREATE TABLE IF NOT EXISTS webhook_deliveries
+                            (id TEXT PRIMARY KEY, webhook TEXT, event_id TEXT, action TEXT, status TEXT,
+                            attempts INTEGER, response_code INTEGER, last_error TEXT, payload BYTEA,
+                            created_at BIGINT, updated_at BIGINT)`
+	postgresWebhookDeadLettersTable = `CREATE TABLE IF NOT EXISTS webhook_dead_letters
+                            (delivery_id TEXT PRIMARY KEY, webhook TEXT, event_id TEXT, action TEXT,
+                            payload BYTEA, error TEXT, created_at BIGINT)`
+	postgresAuditTable = `CREATE TABLE IF NOT EXISTS audit_log
+                            (seq BIGINT PRIMARY KEY, time BIGINT, "user" TEXT, roles TEXT, remote_addr TEXT,
+                            method TEXT, route TEXT, path TEXT, decision TEXT, reason TEXT, resources TEXT,
+                            status INTEGER, outcome TEXT, prev_hash TEXT, hash TEXT)`
+	postgresAuditResourcesTable = `CREATE TABLE IF NOT EXISTS audit_resources
+                            (seq BIGINT, resource TEXT, PRIMARY KEY (seq, resource))`
+	postgresAuditResourcesIndex = `CREATE INDEX IF NOT EXISTS audit_resources_resource ON audit_resources (resource)`
+	postgresAuditChainTable     = `CREATE TABLE IF NOT EXISTS audit_chain
+                            (id INTEGER PRIMARY KEY CHECK (id = 1), seq BIGINT, hash TEXT)`
+	postgresInstancesTable = `CREATE TABLE IF NOT EXISTS instances
+                            (id TEXT PRIMARY KEY, last_seen BIGINT)`
+	postgresEntryMetadataTable = `CREATE TABLE IF NOT EXISTS entry_metadata
+                            (entry_id TEXT PRIMARY KEY, owner TEXT, description TEXT, ticket TEXT, tags TEXT,
+                            created_by TEXT, created_at BIGINT)`
+)
+
+// NewPostgresDB returns an AgentDB storing Tornjak metadata in the PostgreSQL
+// database of the connection string
+func NewPostgresDB(connectionString string, backOffParams backoff.BackOff) (AgentDB, error) {
+	db, err := newSQLAgentDB("postgres", connectionString, backOffParams)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) migrations() []Migration {
+	return []Migration{
+		{Version: 1, Description: "create tables", Statements: []string{postgresAgentsTable, postgresClustersTable,
+			postgresClusterMemberTable, postgresIdempotencyTable, postgresJobsTable, postgresWebhookDeliveriesTable,
+			postgresWebhookDeadLettersTable, postgresAuditTable, postgresAuditResourcesTable, postgresAuditResourcesIndex,
+			postgresAuditChainTable}},
+		{Version: 2, Description: "add cluster labels and details", Statements: []string{addClusterLabelsColumn,
+			addClusterDetailsColumn}},
+		{Version: 3, Description: "add agent labels and annotations", Statements: []string{addAgentLabelsColumn,
+			addAgentAnnotationsColumn}},
+		{Version: 4, Description: "create entry metadata table", Statements: []string{postgresEntryMetadataTable}},
+		{Version: 5, Description: "add agent tombstones", Statements: []string{addAgentTombstonedAtColumn,
+			addAgentTombstoneReasonColumn}},
+		{Version: 6, Description: "add job and webhook delivery owners", Statements: []string{addJobOwnerColumn,
+			addJobCancelRequestedColumn, addWebhookDeliveryOwnerColumn, postgresInstancesTable}},
+		{Version: 7, Description: "add idempotency key principals", Statements: []string{addIdempotencyPrincipalColumn}},
+		{Version: 8, Description: "make idempotency keys unique per principal", Statements: []string{dropIdempotencyTable,
+			postgresIdempotencyTableV8}},
+	}
+}
+
+func (postgresDialect) tableExistsQuery() string {
+	return `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = ?`
+}
+
+// columnExistsQuery is not needed, DDL is transactional in PostgreSQL
+func (postgresDialect) columnExistsQuery() string {
+	return ""
+}
+
+// lockMigrations takes a session-level advisory lock on conn
+func (postgresDialect) lockMigrations(ctx context.Context, conn *sql.Conn) error {
+	cmd := `SELECT pg_advisory_lock($1)`
+	if _, err := conn.ExecContext(ctx, cmd, migrationLockID); err != nil {
+		return SQLError{cmd, err}
+	}
+	return nil
+}
+
+func (postgresDialect) unlockMigrations(ctx context.Context, conn *sql.Conn) error {
+	cmd := `SELECT pg_advisory_unlock($1)`
+	if _, err := conn.ExecContext(ctx, cmd, migrationLockID); err != nil {
+		return SQLError{cmd, err}
+	}
+	return nil
+}
+
+func (postgresDialect) rebind(query string) string {
+	return rewriteQuery(query, postgresPlaceholder, "")
+}
+
+func (postgresDialect) insertOrIgnore(cmd string) string {
+	return cmd + ` ON CONFLICT DO NOTHING`
+}
+
+func (postgresDialect) upsert(conflict []string, columns ...string) string {
+	return upsertExcluded(conflict, columns)
+}
+
+func (postgresDialect) concat(exprs ...string) string {
+	return strings.Join(exprs, " || ")
+}
+
+func (postgresDialect) forUpdate() string {
+	return ` FOR UPDATE`
+}
+
+// isConstraintError reports integrity constraint violations, class 23
+func (postgresDialect) isConstraintError(err error) bool {
+	perr, ok := err.(*pq.Error)
+	return ok && perr.Code.Class() == "23"
+}
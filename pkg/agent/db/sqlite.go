@@ -3,11 +3,14 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
 	backoff "github.com/cenkalti/backoff/v4"
-	_ "github.com/mattn/go-sqlite3"
+	sqlite3 "github.com/mattn/go-sqlite3"
 	"github.com/pkg/errors"
 
 	"github.com/spiffe/tornjak/pkg/agent/types"
@@ -27,14 +30,66 @@ const (
                             (id INTEGER PRIMARY KEY AUTOINCREMENT, agent_id int, cluster_id int,
                             FOREIGN KEY (agent_id) REFERENCES agents(id), 
                             FOREIGN KEY (cluster_id) REFERENCES clusters(id), UNIQUE (agent_id))`
+	// idempotency key table storing the response to a request made with an Idempotency-Key
+	//                                status_code is 0 while the request is in progress
+	initIdempotencyTable = `CREATE TABLE IF NOT EXISTS idempotency_keys 
+                            (id INTEGER PRIMARY KEY AUTOINCREMENT, key TEXT, path TEXT, request_hash TEXT,
+                            status_code INTEGER, content_type TEXT, location TEXT, response BLOB, created_at INTEGER,
+                            UNIQUE (key, path))`
+	// idempotency key table of migration 8, unique per caller; principal is
+	//                                "" rather than NULL for unauthenticated requests, as NULLs never collide
+	initIdempotencyTableV8 = `CREATE TABLE IF NOT EXISTS idempotency_keys 
+                            (id INTEGER PRIMARY KEY AUTOINCREMENT, key TEXT, path TEXT, principal TEXT NOT NULL DEFAULT '',
+                            request_hash TEXT, status_code INTEGER, content_type TEXT, location TEXT, response BLOB,
+                            created_at INTEGER, UNIQUE (key, path, principal))`
+	// job table with status, progress and failures (JSON) of asynchronous jobs
+	initJobsTable = `CREATE TABLE IF NOT EXISTS jobs 
+                            (id TEXT PRIMARY KEY, type TEXT, status TEXT, params TEXT,
+                            total INTEGER, processed INTEGER, failed INTEGER, failures TEXT, error TEXT,
+                            created_at INTEGER, updated_at INTEGER)`
+	// webhook delivery table with the status of each event sent to each webhook
+	initWebhookDeliveriesTable = `CREATE TABLE IF NOT EXISTS webhook_deliveries 
+                            (id TEXT PRIMARY KEY, webhook TEXT, event_id TEXT, action TEXT, status TEXT,
+                            attempts INTEGER, response_code INTEGER, last_error TEXT, payload BLOB,
+                            created_at INTEGER, updated_at INTEGER)`
+	// dead letter table keeping the payload of webhook deliveries that failed all attempts
+	initWebhookDeadLettersTable = `CREATE TABLE IF NOT EXISTS webhook_dead_letters 
+                            (delivery_id TEXT PRIMARY KEY, webhook TEXT, event_id TEXT, action TEXT,
+                            payload BLOB, error TEXT, created_at INTEGER)`
+	// instance table with the last heartbeat of each Tornjak instance sharing the database
+	initInstancesTable = `CREATE TABLE IF NOT EXISTS instances 
+                            (id TEXT PRIMARY KEY, last_seen INTEGER)`
+	// audit table with one hash-chained record per API request; roles and resources are JSON
+	initAuditTable = `CREATE TABLE IF NOT EXISTS audit_log 
+                            (seq INTEGER PRIMARY KEY, time INTEGER, user TEXT, roles TEXT, remote_addr TEXT,
+                            method TEXT, route TEXT, path TEXT, decision TEXT, reason TEXT, resources TEXT,
+                            status INTEGER, outcome TEXT, prev_hash TEXT, hash TEXT)`
+	// audit resource table indexing the resources of each audit record
+	initAuditResourcesTable = `CREATE TABLE IF NOT EXISTS audit_resources 
+                            (seq INTEGER, resource TEXT, PRIMARY KEY (seq, resource))`
+	initAuditResourcesIndex = `CREATE INDEX IF NOT EXISTS audit_resources_resource ON audit_resources (resource)`
+	// audit chain table with the sequence number and hash of the latest audit record,
+	// kept when old records are removed
+	initAuditChainTable = `CREATE TABLE IF NOT EXISTS audit_chain 
+                            (id INTEGER PRIMARY KEY CHECK (id = 1), seq INTEGER, hash TEXT)`
+	// entry metadata table keeping what Tornjak knows of SPIRE entries; tags are JSON
+	initEntryMetadataTable = `CREATE TABLE IF NOT EXISTS entry_metadata 
+                            (entry_id TEXT PRIMARY KEY, owner TEXT, description TEXT, ticket TEXT, tags TEXT,
+                            created_by TEXT, created_at INTEGER)`
 )
 
-type LocalSqliteDb struct {
-	database   *sql.DB
+// SQLAgentDB stores Tornjak metadata in a SQL database: sqlite, PostgreSQL
+// or MySQL, as set by its dialect
+type SQLAgentDB struct {
+	database   *dialectDB
+	dialect    sqlDialect
 	expBackoff *backoff.BackOff
+
+	// auditMu serializes appends to the audit hash chain
+	auditMu sync.Mutex
 }
 
-func createDBTable(database *sql.DB, cmd string) error {
+func createDBTable(database *dialectDB, cmd string) error {
 	statement, err := database.Prepare(cmd)
 	if err != nil {
 		return SQLError{cmd, err}
@@ -47,56 +102,128 @@ func createDBTable(database *sql.DB, cmd string) error {
 }
 
 func NewLocalSqliteDB(driverName string, dbpath string, backOffParams backoff.BackOff) (AgentDB, error) {
-	database, err := sql.Open(driverName, dbpath)
+	db, err := newSQLAgentDB(driverName, dbpath, backOffParams)
 	if err != nil {
-		return nil, errors.New("Unable to open connection to DB")
+		return nil, err
 	}
+	return db, nil
+}
 
-	initTableList := []string{initAgentsTable, initClustersTable, initClusterMemberTable}
+type sqliteDialect struct{}
 
-	for i := 0; i < len(initTableList); i++ {
-		err = createDBTable(database, initTableList[i])
-		if err != nil {
-			return nil, err
-		}
+// migrations of sqlite; version 1 creates the tables if they do not exist,
+// adopting databases created before schema versions were recorded
+func (sqliteDialect) migrations() []Migration {
+	return []Migration{
+		{Version: 1, Description: "create tables", Statements: []string{initAgentsTable, initClustersTable,
+			initClusterMemberTable, initIdempotencyTable, initJobsTable, initWebhookDeliveriesTable,
+			initWebhookDeadLettersTable, initAuditTable, initAuditResourcesTable, initAuditResourcesIndex,
+			initAuditChainTable}},
+		{Version: 2, Description: "add cluster labels and details", Statements: []string{addClusterLabelsColumn,
+			addClusterDetailsColumn}},
+		{Version: 3, Description: "add agent labels and annotations", Statements: []string{addAgentLabelsColumn,
+			addAgentAnnotationsColumn}},
+		{Version: 4, Description: "create entry metadata table", Statements: []string{initEntryMetadataTable}},
+		{Version: 5, Description: "add agent tombstones", Statements: []string{addAgentTombstonedAtColumn,
+			addAgentTombstoneReasonColumn}},
+		{Version: 6, Description: "add job and webhook delivery owners", Statements: []string{addJobOwnerColumn,
+			addJobCancelRequestedColumn, addWebhookDeliveryOwnerColumn, initInstancesTable}},
+		{Version: 7, Description: "add idempotency key principals", Statements: []string{addIdempotencyPrincipalColumn}},
+		{Version: 8, Description: "make idempotency keys unique per principal", Statements: []string{dropIdempotencyTable,
+			initIdempotencyTableV8}},
 	}
+}
 
-	return &LocalSqliteDb{
-		database:   database,
-		expBackoff: &backOffParams,
-	}, nil
+func (sqliteDialect) tableExistsQuery() string {
+	return `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`
 }
 
-// AGENT - SELECTOR/PLUGIN HANDLERS
+// columnExistsQuery is not needed, DDL is transactional in sqlite
+func (sqliteDialect) columnExistsQuery() string {
+	return ""
+}
 
-func (db *LocalSqliteDb) CreateAgentEntry(sinfo types.AgentInfo) error {
-	cmdInsert := `INSERT INTO agents (spiffeid, plugin) VALUES `
-	cmdUpdate := ` ON CONFLICT(spiffeid) DO UPDATE SET plugin=`
-	if len(sinfo.Plugin) > 0 {
-		cmdInsert += `(?, ?)`
-		cmdUpdate += `(?)`
-	} else {
-		cmdInsert += `(?, NULL)`
-		cmdUpdate += `NULL`
+// lockMigrations is not needed, a sqlite database file is not shared by
+// Tornjak servers and the migration transaction locks the whole database
+func (sqliteDialect) lockMigrations(context.Context, *sql.Conn) error {
+	return nil
+}
+
+func (sqliteDialect) unlockMigrations(context.Context, *sql.Conn) error {
+	return nil
+}
+
+func (sqliteDialect) rebind(query string) string {
+	return query
+}
+
+func (sqliteDialect) insertOrIgnore(cmd string) string {
+	return strings.Replace(cmd, "INSERT INTO", "INSERT OR IGNORE INTO", 1)
+}
+
+func (sqliteDialect) upsert(conflict []string, columns ...string) string {
+	return upsertExcluded(conflict, columns)
+}
+
+func (sqliteDialect) concat(exprs ...string) string {
+	return strings.Join(exprs, " || ")
+}
+
+// forUpdate is not needed, sqlite locks the whole database on write
+func (sqliteDialect) forUpdate() string {
+	return ""
+}
+
+func (sqliteDialect) isConstraintError(err error) bool {
+	serr, ok := err.(sqlite3.Error)
+	return ok && serr.Code == sqlite3.ErrConstraint
+}
+
+// encodeStringMap returns m as stored in a JSON column, NULL if empty
+func encodeStringMap(m map[string]string) (sql.NullString, error) {
+	if len(m) == 0 {
+		return sql.NullString{}, nil
+	}
+	encoded, err := json.Marshal(m)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(encoded), Valid: true}, nil
+}
+
+// decodeStringMap returns the map stored in a JSON column, nil if NULL
+func decodeStringMap(s sql.NullString) (map[string]string, error) {
+	if !s.Valid {
+		return nil, nil
 	}
-	cmd := cmdInsert + cmdUpdate
+	var m map[string]string
+	if err := json.Unmarshal([]byte(s.String), &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// AGENT - SELECTOR/PLUGIN HANDLERS
+
+func (db *SQLAgentDB) CreateAgentEntry(sinfo types.AgentInfo) error {
+	// an agent registered again is no longer a tombstone
+	cmd := `INSERT INTO agents (spiffeid, plugin, tombstoned_at, tombstone_reason) VALUES (?, ?, NULL, NULL)` +
+		db.dialect.upsert([]string{"spiffeid"}, "plugin", "tombstoned_at", "tombstone_reason")
 	statement, err := db.database.Prepare(cmd)
 	if err != nil {
 		return SQLError{cmd, err}
 	}
-	if len(sinfo.Plugin) > 0 {
-		_, err = statement.Exec(sinfo.Spiffeid, sinfo.Plugin, sinfo.Plugin)
-	} else {
-		_, err = statement.Exec(sinfo.Spiffeid)
-	}
+	defer statement.Close()
+	plugin := sql.NullString{String: sinfo.Plugin, Valid: len(sinfo.Plugin) > 0}
+	_, err = statement.Exec(sinfo.Spiffeid, plugin)
 	if err != nil {
 		return SQLError{cmd, err}
 	}
 	return nil
 }
 
-func (db *LocalSqliteDb) GetAgentSelectors() (types.AgentInfoList, error) {
-	cmd := `SELECT spiffeid, plugin FROM agents WHERE plugin IS NOT NULL`
+func (db *SQLAgentDB) GetAgentSelectors() (types.AgentInfoList, error) {
+	cmd := `SELECT spiffeid, plugin FROM agents WHERE plugin IS NOT NULL AND tombstoned_at IS NULL`
 	rows, err := db.database.Query(cmd)
 	if err != nil {
 		return types.AgentInfoList{}, SQLError{cmd, err}
@@ -123,46 +250,108 @@ func (db *LocalSqliteDb) GetAgentSelectors() (types.AgentInfoList, error) {
 	}, nil
 }
 
-func (db *LocalSqliteDb) GetAgentPluginInfo(spiffeid string) (types.AgentInfo, error) {
+func (db *SQLAgentDB) GetAgentPluginInfo(spiffeid string) (types.AgentInfo, error) {
 	cmd := `SELECT spiffeid, plugin FROM agents WHERE spiffeid=?`
 	row := db.database.QueryRow(cmd, spiffeid)
 
 	sinfo := types.AgentInfo{}
-	err := row.Scan(&sinfo.Spiffeid, &sinfo.Plugin)
-	if err == sql.ErrNoRows {
+	// the plugin is NULL for agents only given labels
+	var plugin sql.NullString
+	err := row.Scan(&sinfo.Spiffeid, &plugin)
+	if err == sql.ErrNoRows || (err == nil && !plugin.Valid) {
 		return types.AgentInfo{}, GetError{fmt.Sprintf("Agent %v has no assigned plugin", spiffeid)}
 	} else if err != nil {
 		return types.AgentInfo{}, SQLError{cmd, err}
 	}
+	sinfo.Plugin = plugin.String
 	return sinfo, nil
 }
 
+// AGENT - LABEL HANDLERS
+
+// SetAgentLabels replaces the labels and annotations of the agent, which is
+// registered if unknown
+func (db *SQLAgentDB) SetAgentLabels(alabels types.AgentLabels) error {
+	labels, err := encodeStringMap(alabels.Labels)
+	if err != nil {
+		return errors.Errorf("Unable to encode agent labels: %v", err)
+	}
+	annotations, err := encodeStringMap(alabels.Annotations)
+	if err != nil {
+		return errors.Errorf("Unable to encode agent annotations: %v", err)
+	}
+	cmd := `INSERT INTO agents (spiffeid, plugin, labels, annotations) VALUES (?, NULL, ?, ?)` +
+		db.dialect.upsert([]string{"spiffeid"}, "labels", "annotations")
+	statement, err := db.database.Prepare(cmd)
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	defer statement.Close()
+	_, err = statement.Exec(alabels.Spiffeid, labels, annotations)
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	return nil
+}
+
+// GetAgentLabels returns the labels and annotations of the agent, empty if
+// the agent is unknown
+func (db *SQLAgentDB) GetAgentLabels(spiffeid string) (types.AgentLabels, error) {
+	cmd := `SELECT labels, annotations FROM agents WHERE spiffeid=?`
+	alabels := types.AgentLabels{Spiffeid: spiffeid, Labels: types.Labels{}, Annotations: types.Annotations{}}
+	var labels, annotations sql.NullString
+	err := db.database.QueryRow(cmd, spiffeid).Scan(&labels, &annotations)
+	if err == sql.ErrNoRows {
+		return alabels, nil
+	} else if err != nil {
+		return types.AgentLabels{}, SQLError{cmd, err}
+	}
+	if labels.Valid {
+		if alabels.Labels, err = decodeStringMap(labels); err != nil {
+			return types.AgentLabels{}, errors.Errorf("Unable to decode labels of agent %s: %v", spiffeid, err)
+		}
+	}
+	if annotations.Valid {
+		if alabels.Annotations, err = decodeStringMap(annotations); err != nil {
+			return types.AgentLabels{}, errors.Errorf("Unable to decode annotations of agent %s: %v", spiffeid, err)
+		}
+	}
+	return alabels, nil
+}
+
 // CLUSTER HANDLERS
 
 // GetClusterAgents takes in string cluster name and outputs array of spiffeids of agents assigned to the cluster
-func (db *LocalSqliteDb) GetClusterAgents(name string) ([]string, error) {
-	// search in clusterMemberships table
-	cmdGetMemberships := `SELECT GROUP_CONCAT(agents.spiffeid) 
+func (db *SQLAgentDB) GetClusterAgents(name string) ([]string, error) {
+	// search in clusterMemberships table; a cluster without agents has one row with NULL spiffeid
+	cmdGetMemberships := `SELECT agents.spiffeid 
                         FROM clusters 
                         LEFT JOIN cluster_memberships ON clusters.id=cluster_memberships.cluster_id
                         LEFT JOIN agents ON cluster_memberships.agent_id=agents.id
-                        WHERE clusters.name=? 
-                        GROUP BY clusters.name`
-	row := db.database.QueryRow(cmdGetMemberships, name)
-
-	var spiffeidList []string
-	var spiffeids sql.NullString
+                        WHERE clusters.name=?`
+	rows, err := db.database.Query(cmdGetMemberships, name)
+	if err != nil {
+		return nil, SQLError{cmdGetMemberships, err}
+	}
+	defer rows.Close()
 
-	err := row.Scan(&spiffeids)
-	if err == sql.ErrNoRows {
-		return nil, GetError{fmt.Sprintf("Cluster %v not registered", name)}
-	} else if err != nil {
+	spiffeidList := []string{}
+	found := false
+	var spiffeid sql.NullString
+	for rows.Next() {
+		if err = rows.Scan(&spiffeid); err != nil {
+			return nil, SQLError{cmdGetMemberships, err}
+		}
+		found = true
+		if spiffeid.Valid {
+			spiffeidList = append(spiffeidList, spiffeid.String)
+		}
+	}
+	if err = rows.Err(); err != nil {
 		return nil, SQLError{cmdGetMemberships, err}
 	}
-	if spiffeids.Valid {
-		spiffeidList = strings.Split(spiffeids.String, ",")
-	} else {
-		spiffeidList = []string{}
+	if !found {
+		return nil, GetError{fmt.Sprintf("Cluster %v not registered", name)}
 	}
 
 	return spiffeidList, nil
@@ -170,7 +359,7 @@ func (db *LocalSqliteDb) GetClusterAgents(name string) ([]string, error) {
 }
 
 // GetAgentClusterName takes in string of spiffeid of agent and outputs the name of the cluster
-func (db *LocalSqliteDb) GetAgentClusterName(spiffeid string) (string, error) {
+func (db *SQLAgentDB) GetAgentClusterName(spiffeid string) (string, error) {
 	var clusterName sql.NullString
 	cmdGetName := `SELECT clusters.name 
                  FROM agents 
@@ -194,40 +383,45 @@ func (db *LocalSqliteDb) GetAgentClusterName(spiffeid string) (string, error) {
 // GetAgentsMetadata takes a AgentMetadataRequest with a list of agent spiffeids
 // outputs list of agentinfo objects, where spiffeids must be in the input list
 // includes info on plugin and clustername
-func (db *LocalSqliteDb) GetAgentsMetadata(req types.AgentMetadataRequest) (types.AgentInfoList, error) {
+func (db *SQLAgentDB) GetAgentsMetadata(req types.AgentMetadataRequest) (types.AgentInfoList, error) {
 	spiffeids := req.Agents
-	cmd := `SELECT agents.spiffeid, agents.plugin, clusters.name 
+	cmd := `SELECT agents.spiffeid, agents.plugin, agents.labels, agents.annotations, agents.tombstoned_at,
+          agents.tombstone_reason, clusters.name 
           FROM agents 
           LEFT JOIN cluster_memberships ON agents.id = cluster_memberships.agent_id
           LEFT JOIN clusters ON cluster_memberships.cluster_id = clusters.id`
-	var err error
-	var rows *sql.Rows
+	conditions := []string{}
+	vals := []interface{}{}
 	if len(spiffeids) > 0 {
-		cmd += ` WHERE agents.spiffeid IN (`
-		vals := []interface{}{}
+		conditions = append(conditions, `agents.spiffeid IN (`+strings.TrimSuffix(strings.Repeat("?,", len(spiffeids)), ",")+`)`)
 		for i := 0; i < len(spiffeids); i++ {
-			cmd += "?,"
 			vals = append(vals, spiffeids[i])
 		}
-		vals = append(vals, vals...)
-		cmd = strings.TrimSuffix(cmd, ",") + ")"
-		rows, err = db.database.Query(cmd, vals...)
-	} else {
-		rows, err = db.database.Query(cmd)
 	}
-
+	if !req.IncludeTombstones {
+		conditions = append(conditions, `agents.tombstoned_at IS NULL`)
+	}
+	if len(conditions) > 0 {
+		cmd += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	rows, err := db.database.Query(cmd, vals...)
 	if err != nil {
 		return types.AgentInfoList{}, SQLError{cmd, err}
 	}
+	defer rows.Close()
 
 	ainfos := []types.AgentInfo{}
 	var (
-		spiffeid string
-		plugin   sql.NullString
-		cluster  sql.NullString
+		spiffeid        string
+		plugin          sql.NullString
+		labels          sql.NullString
+		annotations     sql.NullString
+		tombstonedAt    sql.NullInt64
+		tombstoneReason sql.NullString
+		cluster         sql.NullString
 	)
 	for rows.Next() {
-		if err = rows.Scan(&spiffeid, &plugin, &cluster); err != nil {
+		if err = rows.Scan(&spiffeid, &plugin, &labels, &annotations, &tombstonedAt, &tombstoneReason, &cluster); err != nil {
 			return types.AgentInfoList{}, SQLError{cmd, err}
 		}
 
@@ -242,6 +436,16 @@ func (db *LocalSqliteDb) GetAgentsMetadata(req types.AgentMetadataRequest) (type
 		if cluster.Valid {
 			newAgent.Cluster = cluster.String
 		}
+		if newAgent.Labels, err = decodeStringMap(labels); err != nil {
+			return types.AgentInfoList{}, errors.Errorf("Unable to decode labels of agent %s: %v", spiffeid, err)
+		}
+		if newAgent.Annotations, err = decodeStringMap(annotations); err != nil {
+			return types.AgentInfoList{}, errors.Errorf("Unable to decode annotations of agent %s: %v", spiffeid, err)
+		}
+		if tombstonedAt.Valid {
+			t := time.Unix(0, tombstonedAt.Int64).UTC()
+			newAgent.TombstonedAt, newAgent.TombstoneReason = &t, tombstoneReason.String
+		}
 
 		ainfos = append(ainfos, newAgent)
 	}
@@ -252,48 +456,63 @@ func (db *LocalSqliteDb) GetAgentsMetadata(req types.AgentMetadataRequest) (type
 }
 
 // GetClusters outputs a list of ClusterInfo structs with information on currently registered clusters
-func (db *LocalSqliteDb) GetClusters() (types.ClusterInfoList, error) {
+func (db *SQLAgentDB) GetClusters() (types.ClusterInfoList, error) {
 	// BEGIN transaction
+	// one row per cluster and agent, grouped here rather than with GROUP_CONCAT,
+	// which is not portable
 	cmd := `SELECT clusters.name, clusters.created_at, clusters.domain_name, clusters.managed_by, 
-          clusters.platform_type, GROUP_CONCAT(agents.spiffeid) 
+          clusters.platform_type, clusters.labels, clusters.details, agents.spiffeid 
           FROM clusters 
           LEFT JOIN cluster_memberships ON clusters.id=cluster_memberships.cluster_id
           LEFT JOIN agents ON cluster_memberships.agent_id=agents.id
-          GROUP BY clusters.name`
+          ORDER BY clusters.name, agents.id`
 
 	rows, err := db.database.Query(cmd)
 	if err != nil {
 		return types.ClusterInfoList{}, SQLError{cmd, err}
 	}
+	defer rows.Close()
 
 	sinfos := []types.ClusterInfo{}
 	var (
-		name                string
-		createdAt           string
-		domainName          string
-		managedBy           string
-		platformType        string
-		agentsListConcatted sql.NullString
-		agentsList          []string
+		name         string
+		createdAt    string
+		domainName   string
+		managedBy    string
+		platformType string
+		labels       sql.NullString
+		details      sql.NullString
+		spiffeid     sql.NullString
 	)
 	for rows.Next() {
-		if err = rows.Scan(&name, &createdAt, &domainName, &managedBy, &platformType, &agentsListConcatted); err != nil {
+		if err = rows.Scan(&name, &createdAt, &domainName, &managedBy, &platformType, &labels, &details, &spiffeid); err != nil {
 			return types.ClusterInfoList{}, SQLError{cmd, err}
 		}
 
-		if agentsListConcatted.Valid { // handle clusters with no assigned agents
-			agentsList = strings.Split(agentsListConcatted.String, ",")
-		} else {
-			agentsList = []string{}
+		if len(sinfos) == 0 || sinfos[len(sinfos)-1].Name != name {
+			cinfo := types.ClusterInfo{
+				Name:         name,
+				CreationTime: createdAt,
+				DomainName:   domainName,
+				ManagedBy:    managedBy,
+				PlatformType: platformType,
+				AgentsList:   []string{},
+			}
+			if cinfo.Labels, err = decodeStringMap(labels); err != nil {
+				return types.ClusterInfoList{}, errors.Errorf("Unable to decode labels of cluster %s: %v", name, err)
+			}
+			if details.Valid {
+				cinfo.Details = json.RawMessage(details.String)
+			}
+			sinfos = append(sinfos, cinfo)
+		}
+		if spiffeid.Valid { // handle clusters with no assigned agents
+			cluster := &sinfos[len(sinfos)-1]
+			cluster.AgentsList = append(cluster.AgentsList, spiffeid.String)
 		}
-		sinfos = append(sinfos, types.ClusterInfo{
-			Name:         name,
-			CreationTime: createdAt,
-			DomainName:   domainName,
-			ManagedBy:    managedBy,
-			PlatformType: platformType,
-			AgentsList:   agentsList,
-		})
+	}
+	if err = rows.Err(); err != nil {
+		return types.ClusterInfoList{}, SQLError{cmd, err}
 	}
 
 	return types.ClusterInfoList{
@@ -302,7 +521,7 @@ func (db *LocalSqliteDb) GetClusters() (types.ClusterInfoList, error) {
 }
 
 // CreateClusterEntry takes in struct cinfo of type ClusterInfo.  If a cluster with cinfo.Name already registered, returns error.
-func (db *LocalSqliteDb) createClusterEntryOp(cinfo types.ClusterInfo) error {
+func (db *SQLAgentDB) createClusterEntryOp(cinfo types.ClusterInfo) error {
 	// BEGIN transaction
 	ctx := context.Background()
 	tx, err := db.database.BeginTx(ctx, nil)
@@ -326,7 +545,7 @@ func (db *LocalSqliteDb) createClusterEntryOp(cinfo types.ClusterInfo) error {
 }
 
 // EditClusterEntry takes in struct cinfo of type ClusterInfo.  If cluster with cinfo.Name does not exist, throws error.
-func (db *LocalSqliteDb) editClusterEntryOp(cinfo types.ClusterInfo) error {
+func (db *SQLAgentDB) editClusterEntryOp(cinfo types.ClusterInfo) error {
 	// BEGIN transaction
 	ctx := context.Background()
 	tx, err := db.database.BeginTx(ctx, nil)
@@ -357,7 +576,7 @@ func (db *LocalSqliteDb) editClusterEntryOp(cinfo types.ClusterInfo) error {
 }
 
 // DeleteClusterEntry takes in string name of cluster and removes cluster information and agent membership of cluster from the database.  If not all agents can be removed from the cluster, cluster information remains in the database.
-func (db *LocalSqliteDb) deleteClusterEntryOp(clusterName string) error {
+func (db *SQLAgentDB) deleteClusterEntryOp(clusterName string) error {
 	// BEGIN transaction
 	ctx := context.Background()
 	tx, err := db.database.BeginTx(ctx, nil)
@@ -381,7 +600,923 @@ func (db *LocalSqliteDb) deleteClusterEntryOp(clusterName string) error {
 	return tx.Commit()
 }
 
-func (db *LocalSqliteDb) retryOp(operation func() error) error {
+// DeleteAgentEntry takes in string spiffeid of agent and removes the agent and its cluster membership from the database.  An unknown agent is not an error.
+func (db *SQLAgentDB) deleteAgentEntryOp(spiffeid string) error {
+	// BEGIN transaction
+	ctx := context.Background()
+	tx, err := db.database.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Errorf("Error initializing context: %v", err)
+	}
+	txHelper := getTornjakTxHelper(ctx, tx)
+
+	// REMOVE cluster membership of agent (requires agent still entered)
+	err = txHelper.deleteAgentMemberships(spiffeid)
+	if err != nil {
+		return backoff.Permanent(txHelper.rollbackHandler(err))
+	}
+
+	// REMOVE agent metadata
+	err = txHelper.deleteAgentMetadata(spiffeid)
+	if err != nil {
+		return backoff.Permanent(txHelper.rollbackHandler(err))
+	}
+
+	return tx.Commit()
+}
+
+// TombstoneAgentEntry takes in string spiffeid of agent and removes its cluster membership from the database, keeping its metadata marked with reason as a tombstone.  An unknown agent is not an error.
+func (db *SQLAgentDB) tombstoneAgentEntryOp(spiffeid string, reason string) error {
+	// BEGIN transaction
+	ctx := context.Background()
+	tx, err := db.database.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Errorf("Error initializing context: %v", err)
+	}
+	txHelper := getTornjakTxHelper(ctx, tx)
+
+	// REMOVE cluster membership of agent
+	err = txHelper.deleteAgentMemberships(spiffeid)
+	if err != nil {
+		return backoff.Permanent(txHelper.rollbackHandler(err))
+	}
+
+	// MARK agent metadata
+	err = txHelper.tombstoneAgentMetadata(spiffeid, reason)
+	if err != nil {
+		return backoff.Permanent(txHelper.rollbackHandler(err))
+	}
+
+	return tx.Commit()
+}
+
+// ENTRY METADATA HANDLERS
+
+// entryMetadataBatchSize bounds the number of entry IDs in one query
+const entryMetadataBatchSize = 500
+
+// CreateEntryMetadata stores the metadata of an entry, replacing any kept
+// for its ID
+func (db *SQLAgentDB) CreateEntryMetadata(meta types.EntryMetadata) error {
+	tags := sql.NullString{}
+	if len(meta.Tags) > 0 {
+		encoded, err := json.Marshal(meta.Tags)
+		if err != nil {
+			return errors.Errorf("Unable to encode entry tags: %v", err)
+		}
+		tags = sql.NullString{String: string(encoded), Valid: true}
+	}
+	cmd := `INSERT INTO entry_metadata (entry_id, owner, description, ticket, tags, created_by, created_at) VALUES (?,?,?,?,?,?,?)` +
+		db.dialect.upsert([]string{"entry_id"}, "owner", "description", "ticket", "tags", "created_by", "created_at")
+	_, err := db.database.Exec(cmd, meta.EntryID, meta.Owner, meta.Description, meta.Ticket, tags, meta.CreatedBy, meta.CreatedAt.UnixNano())
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	return nil
+}
+
+// GetEntriesMetadata returns the metadata kept of the entries with the
+// given IDs, or of all entries if none are given. Entries without metadata
+// are left out.
+func (db *SQLAgentDB) GetEntriesMetadata(ids []string) (types.EntryMetadataList, error) {
+	cmd := `SELECT entry_id, owner, description, ticket, tags, created_by, created_at FROM entry_metadata`
+	metadata := []types.EntryMetadata{}
+	if len(ids) == 0 {
+		if err := db.queryEntryMetadata(cmd, nil, &metadata); err != nil {
+			return types.EntryMetadataList{}, err
+		}
+		return types.EntryMetadataList{Metadata: metadata}, nil
+	}
+	for start := 0; start < len(ids); start += entryMetadataBatchSize {
+		end := start + entryMetadataBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		vals := make([]interface{}, 0, end-start)
+		for _, id := range ids[start:end] {
+			vals = append(vals, id)
+		}
+		batchCmd := cmd + ` WHERE entry_id IN (` + strings.TrimSuffix(strings.Repeat("?,", len(vals)), ",") + `)`
+		if err := db.queryEntryMetadata(batchCmd, vals, &metadata); err != nil {
+			return types.EntryMetadataList{}, err
+		}
+	}
+	return types.EntryMetadataList{Metadata: metadata}, nil
+}
+
+func (db *SQLAgentDB) queryEntryMetadata(cmd string, vals []interface{}, metadata *[]types.EntryMetadata) error {
+	rows, err := db.database.Query(cmd, vals...)
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	defer rows.Close()
+	var (
+		owner, description, ticket, tags, createdBy sql.NullString
+		createdAt                                   sql.NullInt64
+	)
+	for rows.Next() {
+		meta := types.EntryMetadata{}
+		if err = rows.Scan(&meta.EntryID, &owner, &description, &ticket, &tags, &createdBy, &createdAt); err != nil {
+			return SQLError{cmd, err}
+		}
+		meta.Owner, meta.Description, meta.Ticket, meta.CreatedBy = owner.String, description.String, ticket.String, createdBy.String
+		if tags.Valid {
+			if err = json.Unmarshal([]byte(tags.String), &meta.Tags); err != nil {
+				return errors.Errorf("Unable to decode tags of entry %s: %v", meta.EntryID, err)
+			}
+		}
+		if createdAt.Valid {
+			meta.CreatedAt = time.Unix(0, createdAt.Int64).UTC()
+		}
+		*metadata = append(*metadata, meta)
+	}
+	if err = rows.Err(); err != nil {
+		return SQLError{cmd, err}
+	}
+	return nil
+}
+
+// DeleteEntriesMetadata removes the metadata of the entries with the given
+// IDs; IDs without metadata are ignored
+func (db *SQLAgentDB) DeleteEntriesMetadata(ids []string) error {
+	for start := 0; start < len(ids); start += entryMetadataBatchSize {
+		end := start + entryMetadataBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		vals := make([]interface{}, 0, end-start)
+		for _, id := range ids[start:end] {
+			vals = append(vals, id)
+		}
+		cmd := `DELETE FROM entry_metadata WHERE entry_id IN (` + strings.TrimSuffix(strings.Repeat("?,", len(vals)), ",") + `)`
+		if _, err := db.database.Exec(cmd, vals...); err != nil {
+			return SQLError{cmd, err}
+		}
+	}
+	return nil
+}
+
+// IDEMPOTENCY HANDLERS
+
+// CreateIdempotencyRecord reserves rec.Key for rec.Path and rec.Principal with the hash of the request.
+// Returns PostFailure if the principal already uses the key for the path
+func (db *SQLAgentDB) CreateIdempotencyRecord(rec types.IdempotencyRecord) error {
+	cmd := `INSERT INTO idempotency_keys ("key", path, request_hash, principal, status_code, created_at) VALUES (?, ?, ?, ?, 0, ?)`
+	statement, err := db.database.Prepare(cmd)
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	defer statement.Close()
+	_, err = statement.Exec(rec.Key, rec.Path, rec.RequestHash, rec.Principal, rec.CreatedAt)
+	if err != nil {
+		if db.dialect.isConstraintError(err) {
+			return PostFailure{fmt.Sprintf("Idempotency key %v already used for %v", rec.Key, rec.Path)}
+		}
+		return SQLError{cmd, err}
+	}
+	return nil
+}
+
+// GetIdempotencyRecord returns the record of key for path and principal, or GetError if there is none
+func (db *SQLAgentDB) GetIdempotencyRecord(key string, path string, principal string) (types.IdempotencyRecord, error) {
+	cmd := `SELECT "key", path, request_hash, principal, status_code, content_type, location, response, created_at 
+          FROM idempotency_keys WHERE "key"=? AND path=? AND principal=?`
+	row := db.database.QueryRow(cmd, key, path, principal)
+
+	rec := types.IdempotencyRecord{}
+	var contentType, location sql.NullString
+	err := row.Scan(&rec.Key, &rec.Path, &rec.RequestHash, &rec.Principal, &rec.StatusCode, &contentType, &location, &rec.Response, &rec.CreatedAt)
+	if err == sql.ErrNoRows {
+		return types.IdempotencyRecord{}, GetError{fmt.Sprintf("Idempotency key %v not registered for %v", key, path)}
+	} else if err != nil {
+		return types.IdempotencyRecord{}, SQLError{cmd, err}
+	}
+	rec.ContentType = contentType.String
+	rec.Location = location.String
+	return rec, nil
+}
+
+// CompleteIdempotencyRecord stores the response of the request that reserved rec.Key for rec.Path and rec.Principal
+func (db *SQLAgentDB) CompleteIdempotencyRecord(rec types.IdempotencyRecord) error {
+	cmd := `UPDATE idempotency_keys SET status_code=?, content_type=?, location=?, response=? WHERE "key"=? AND path=? AND principal=?`
+	statement, err := db.database.Prepare(cmd)
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	defer statement.Close()
+	res, err := statement.Exec(rec.StatusCode, rec.ContentType, rec.Location, rec.Response, rec.Key, rec.Path, rec.Principal)
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	if numRows != 1 {
+		return PostFailure{fmt.Sprintf("Idempotency key %v not registered for %v", rec.Key, rec.Path)}
+	}
+	return nil
+}
+
+// DeleteIdempotencyRecord removes the record of key for path and principal, if any
+func (db *SQLAgentDB) DeleteIdempotencyRecord(key string, path string, principal string) error {
+	cmd := `DELETE FROM idempotency_keys WHERE "key"=? AND path=? AND principal=?`
+	statement, err := db.database.Prepare(cmd)
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	defer statement.Close()
+	if _, err = statement.Exec(key, path, principal); err != nil {
+		return SQLError{cmd, err}
+	}
+	return nil
+}
+
+// DeleteIdempotencyRecordsBefore removes records created before the unix time createdAt, and
+// records of requests still in progress created before the unix time inProgressCreatedAt
+func (db *SQLAgentDB) DeleteIdempotencyRecordsBefore(createdAt int64, inProgressCreatedAt int64) error {
+	cmd := `DELETE FROM idempotency_keys WHERE created_at<? OR (status_code=0 AND created_at<?)`
+	statement, err := db.database.Prepare(cmd)
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	defer statement.Close()
+	if _, err = statement.Exec(createdAt, inProgressCreatedAt); err != nil {
+		return SQLError{cmd, err}
+	}
+	return nil
+}
+
+// JOB HANDLERS
+
+// CreateJob inserts a new job.  Returns PostFailure if a job with job.ID exists
+func (db *SQLAgentDB) CreateJob(job types.JobInfo) error {
+	failures, err := json.Marshal(job.Failures)
+	if err != nil {
+		return errors.Errorf("Error encoding job failures: %v", err)
+	}
+	cmd := `INSERT INTO jobs (id, type, status, params, total, processed, failed, failures, error, created_at, updated_at,
+          owner, cancel_requested) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	statement, err := db.database.Prepare(cmd)
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	defer statement.Close()
+	_, err = statement.Exec(job.ID, job.Type, job.Status, string(job.Params), job.Total, job.Processed, job.Failed,
+		string(failures), job.Error, job.CreatedAt.UnixNano(), job.UpdatedAt.UnixNano(), job.Owner, job.CancelRequested)
+	if err != nil {
+		if db.dialect.isConstraintError(err) {
+			return PostFailure{fmt.Sprintf("Job %v already exists", job.ID)}
+		}
+		return SQLError{cmd, err}
+	}
+	return nil
+}
+
+const cmdSelectJobs = `SELECT id, type, status, params, total, processed, failed, failures, error, created_at, updated_at,
+          owner, cancel_requested FROM jobs`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanJob(row rowScanner) (types.JobInfo, error) {
+	job := types.JobInfo{}
+	var (
+		params          string
+		failures        string
+		createdAt       int64
+		updatedAt       int64
+		owner           sql.NullString
+		cancelRequested sql.NullBool
+	)
+	err := row.Scan(&job.ID, &job.Type, &job.Status, &params, &job.Total, &job.Processed, &job.Failed,
+		&failures, &job.Error, &createdAt, &updatedAt, &owner, &cancelRequested)
+	if err != nil {
+		return types.JobInfo{}, err
+	}
+	job.Owner, job.CancelRequested = owner.String, cancelRequested.Bool
+	if len(params) > 0 {
+		job.Params = json.RawMessage(params)
+	}
+	if err := json.Unmarshal([]byte(failures), &job.Failures); err != nil {
+		return types.JobInfo{}, err
+	}
+	job.CreatedAt = time.Unix(0, createdAt).UTC()
+	job.UpdatedAt = time.Unix(0, updatedAt).UTC()
+	return job, nil
+}
+
+// GetJob returns the job with the given id, or GetError if there is none
+func (db *SQLAgentDB) GetJob(id string) (types.JobInfo, error) {
+	cmd := cmdSelectJobs + ` WHERE id=?`
+	job, err := scanJob(db.database.QueryRow(cmd, id))
+	if err == sql.ErrNoRows {
+		return types.JobInfo{}, GetError{fmt.Sprintf("Job %v not found", id)}
+	} else if err != nil {
+		return types.JobInfo{}, SQLError{cmd, err}
+	}
+	return job, nil
+}
+
+// GetJobs returns all jobs, most recent first
+func (db *SQLAgentDB) GetJobs() (types.JobInfoList, error) {
+	cmd := cmdSelectJobs + ` ORDER BY created_at DESC`
+	rows, err := db.database.Query(cmd)
+	if err != nil {
+		return types.JobInfoList{}, SQLError{cmd, err}
+	}
+	defer rows.Close()
+
+	jobs := []types.JobInfo{}
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return types.JobInfoList{}, SQLError{cmd, err}
+		}
+		jobs = append(jobs, job)
+	}
+	if err = rows.Err(); err != nil {
+		return types.JobInfoList{}, SQLError{cmd, err}
+	}
+	return types.JobInfoList{Jobs: jobs}, nil
+}
+
+// UpdateJob stores the status, progress and failures of job.  Returns PostFailure if the job does not exist
+func (db *SQLAgentDB) UpdateJob(job types.JobInfo) error {
+	failures, err := json.Marshal(job.Failures)
+	if err != nil {
+		return errors.Errorf("Error encoding job failures: %v", err)
+	}
+	cmd := `UPDATE jobs SET status=?, total=?, processed=?, failed=?, failures=?, error=?, updated_at=? WHERE id=?`
+	statement, err := db.database.Prepare(cmd)
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	defer statement.Close()
+	res, err := statement.Exec(job.Status, job.Total, job.Processed, job.Failed, string(failures), job.Error,
+		job.UpdatedAt.UnixNano(), job.ID)
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	if numRows != 1 {
+		return PostFailure{fmt.Sprintf("Job %v does not exist", job.ID)}
+	}
+	return nil
+}
+
+// RequestJobCancel asks the instance owning a queued or running job to cancel it.  Returns
+// PostFailure if the job does not exist or has finished
+func (db *SQLAgentDB) RequestJobCancel(id string) error {
+	cmd := `UPDATE jobs SET cancel_requested=? WHERE id=? AND status IN (?, ?)`
+	res, err := db.database.Exec(cmd, true, id, types.JobQueued, types.JobRunning)
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	if numRows != 1 {
+		return PostFailure{fmt.Sprintf("Job %v has already finished", id)}
+	}
+	return nil
+}
+
+// orphanedBy is the condition matching rows owned by the instance ? or by no
+// live instance, those whose owner is missing from the instance table
+const orphanedBy = `(owner=? OR owner IS NULL OR owner NOT IN (SELECT id FROM instances))`
+
+// FailUnfinishedJobs marks the queued and running jobs of owner, and those of instances no
+// longer alive, as failed with the given message and returns the number of jobs marked.
+// Jobs of other live instances are left alone.
+func (db *SQLAgentDB) FailUnfinishedJobs(owner string, message string) (int64, error) {
+	cmd := `UPDATE jobs SET status=?, error=?, updated_at=? WHERE status IN (?, ?) AND ` + orphanedBy
+	statement, err := db.database.Prepare(cmd)
+	if err != nil {
+		return 0, SQLError{cmd, err}
+	}
+	defer statement.Close()
+	res, err := statement.Exec(types.JobFailed, message, time.Now().UnixNano(), types.JobQueued, types.JobRunning, owner)
+	if err != nil {
+		return 0, SQLError{cmd, err}
+	}
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		return 0, SQLError{cmd, err}
+	}
+	return numRows, nil
+}
+
+// INSTANCE HANDLERS
+
+// HeartbeatInstance records that the instance id is alive and removes the instances not
+// seen since staleBefore, whose jobs and webhook deliveries are then orphaned
+func (db *SQLAgentDB) HeartbeatInstance(id string, staleBefore time.Time) error {
+	cmd := `INSERT INTO instances (id, last_seen) VALUES (?, ?)` + db.dialect.upsert([]string{"id"}, "last_seen")
+	if _, err := db.database.Exec(cmd, id, time.Now().UnixNano()); err != nil {
+		return SQLError{cmd, err}
+	}
+	cmd = `DELETE FROM instances WHERE last_seen < ? AND id <> ?`
+	if _, err := db.database.Exec(cmd, staleBefore.UnixNano(), id); err != nil {
+		return SQLError{cmd, err}
+	}
+	return nil
+}
+
+// WEBHOOK HANDLERS
+
+// CreateWebhookDelivery inserts a new delivery.  Returns PostFailure if a delivery with delivery.ID exists
+func (db *SQLAgentDB) CreateWebhookDelivery(delivery types.WebhookDelivery) error {
+	cmd := `INSERT INTO webhook_deliveries (id, webhook, event_id, action, status, attempts, response_code, last_error,
+          payload, created_at, updated_at, owner) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	statement, err := db.database.Prepare(cmd)
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	defer statement.Close()
+	_, err = statement.Exec(delivery.ID, delivery.Webhook, delivery.EventID, delivery.Action, delivery.Status,
+		delivery.Attempts, delivery.ResponseCode, delivery.LastError, []byte(delivery.Payload),
+		delivery.CreatedAt.UnixNano(), delivery.UpdatedAt.UnixNano(), delivery.Owner)
+	if err != nil {
+		if db.dialect.isConstraintError(err) {
+			return PostFailure{fmt.Sprintf("Webhook delivery %v already exists", delivery.ID)}
+		}
+		return SQLError{cmd, err}
+	}
+	return nil
+}
+
+// UpdateWebhookDelivery stores the status and attempts of delivery.  Returns PostFailure if the delivery does not exist
+func (db *SQLAgentDB) UpdateWebhookDelivery(delivery types.WebhookDelivery) error {
+	cmd := `UPDATE webhook_deliveries SET status=?, attempts=?, response_code=?, last_error=?, updated_at=? WHERE id=?`
+	statement, err := db.database.Prepare(cmd)
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	defer statement.Close()
+	res, err := statement.Exec(delivery.Status, delivery.Attempts, delivery.ResponseCode, delivery.LastError,
+		delivery.UpdatedAt.UnixNano(), delivery.ID)
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		return SQLError{cmd, err}
+	}
+	if numRows != 1 {
+		return PostFailure{fmt.Sprintf("Webhook delivery %v does not exist", delivery.ID)}
+	}
+	return nil
+}
+
+// DeadLetterWebhookDelivery marks delivery dead and keeps its payload and last error in the
+// dead letter table, in one transaction.  Returns PostFailure if the delivery does not exist
+func (db *SQLAgentDB) DeadLetterWebhookDelivery(delivery types.WebhookDelivery) error {
+	tx, err := db.database.Begin()
+	if err != nil {
+		return SQLError{"begin transaction", err}
+	}
+	cmdUpdate := `UPDATE webhook_deliveries SET status=?, attempts=?, response_code=?, last_error=?, updated_at=? WHERE id=?`
+	res, err := tx.Exec(cmdUpdate, types.WebhookDead, delivery.Attempts, delivery.ResponseCode, delivery.LastError,
+		delivery.UpdatedAt.UnixNano(), delivery.ID)
+	if err != nil {
+		tx.Rollback()
+		return SQLError{cmdUpdate, err}
+	}
+	if numRows, err := res.RowsAffected(); err != nil || numRows != 1 {
+		tx.Rollback()
+		return PostFailure{fmt.Sprintf("Webhook delivery %v does not exist", delivery.ID)}
+	}
+	cmdInsert := `INSERT INTO webhook_dead_letters (delivery_id, webhook, event_id, action, payload, error, created_at)
+          SELECT id, webhook, event_id, action, payload, last_error, updated_at FROM webhook_deliveries WHERE id=?`
+	if _, err = tx.Exec(cmdInsert, delivery.ID); err != nil {
+		tx.Rollback()
+		if db.dialect.isConstraintError(err) {
+			return PostFailure{fmt.Sprintf("Webhook delivery %v already dead-lettered", delivery.ID)}
+		}
+		return SQLError{cmdInsert, err}
+	}
+	if err = tx.Commit(); err != nil {
+		return SQLError{"commit transaction", err}
+	}
+	return nil
+}
+
+// DeadLetterPendingWebhookDeliveries moves the pending deliveries of owner, and those of
+// instances no longer alive, to the dead letter table with the given message and returns
+// the number of deliveries moved.  Deliveries of other live instances are left alone.
+func (db *SQLAgentDB) DeadLetterPendingWebhookDeliveries(owner string, message string) (int64, error) {
+	tx, err := db.database.Begin()
+	if err != nil {
+		return 0, SQLError{"begin transaction", err}
+	}
+	now := time.Now().UnixNano()
+	cmdUpdate := `UPDATE webhook_deliveries SET status=?, last_error=?, updated_at=? WHERE status=? AND ` + orphanedBy
+	res, err := tx.Exec(cmdUpdate, types.WebhookDead, message, now, types.WebhookPending, owner)
+	if err != nil {
+		tx.Rollback()
+		return 0, SQLError{cmdUpdate, err}
+	}
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return 0, SQLError{cmdUpdate, err}
+	}
+	// dead deliveries without dead letter are those just marked
+	cmdInsert := `INSERT INTO webhook_dead_letters (delivery_id, webhook, event_id, action, payload, error, created_at)
+          SELECT id, webhook, event_id, action, payload, last_error, updated_at FROM webhook_deliveries
+          WHERE status=? AND id NOT IN (SELECT delivery_id FROM webhook_dead_letters)`
+	if _, err = tx.Exec(cmdInsert, types.WebhookDead); err != nil {
+		tx.Rollback()
+		return 0, SQLError{cmdInsert, err}
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, SQLError{"commit transaction", err}
+	}
+	return numRows, nil
+}
+
+// GetPendingWebhookDeliveries returns the pending deliveries of owner with their payloads,
+// oldest first
+func (db *SQLAgentDB) GetPendingWebhookDeliveries(owner string) ([]types.WebhookDelivery, error) {
+	cmd := `SELECT id, webhook, event_id, action, status, attempts, response_code, last_error, payload, created_at,
+          updated_at, owner FROM webhook_deliveries WHERE status=? AND owner=? ORDER BY created_at`
+	rows, err := db.database.Query(cmd, types.WebhookPending, owner)
+	if err != nil {
+		return nil, SQLError{cmd, err}
+	}
+	defer rows.Close()
+
+	deliveries := []types.WebhookDelivery{}
+	for rows.Next() {
+		var (
+			delivery  types.WebhookDelivery
+			payload   []byte
+			createdAt int64
+			updatedAt int64
+			lastError sql.NullString
+		)
+		if err = rows.Scan(&delivery.ID, &delivery.Webhook, &delivery.EventID, &delivery.Action, &delivery.Status,
+			&delivery.Attempts, &delivery.ResponseCode, &lastError, &payload, &createdAt, &updatedAt, &delivery.Owner); err != nil {
+			return nil, SQLError{cmd, err}
+		}
+		delivery.LastError = lastError.String
+		delivery.Payload = payload
+		delivery.CreatedAt = time.Unix(0, createdAt).UTC()
+		delivery.UpdatedAt = time.Unix(0, updatedAt).UTC()
+		deliveries = append(deliveries, delivery)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, SQLError{cmd, err}
+	}
+	return deliveries, nil
+}
+
+// GetWebhookDeliveries returns at most limit deliveries, most recent first, optionally
+// only those to the given webhook or with the given status
+func (db *SQLAgentDB) GetWebhookDeliveries(webhook string, status string, limit int) (types.WebhookDeliveryList, error) {
+	cmd := `SELECT id, webhook, event_id, action, status, attempts, response_code, last_error, created_at, updated_at,
+          owner FROM webhook_deliveries`
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if webhook != "" {
+		conditions = append(conditions, "webhook = ?")
+		args = append(args, webhook)
+	}
+	if status != "" {
+		conditions = append(conditions, "status = ?")
+		args = append(args, status)
+	}
+	if len(conditions) > 0 {
+		cmd += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	cmd += ` ORDER BY created_at DESC LIMIT ?`
+	args = append(args, limit)
+	rows, err := db.database.Query(cmd, args...)
+	if err != nil {
+		return types.WebhookDeliveryList{}, SQLError{cmd, err}
+	}
+	defer rows.Close()
+
+	deliveries := []types.WebhookDelivery{}
+	for rows.Next() {
+		var (
+			delivery  types.WebhookDelivery
+			createdAt int64
+			updatedAt int64
+			owner     sql.NullString
+		)
+		if err = rows.Scan(&delivery.ID, &delivery.Webhook, &delivery.EventID, &delivery.Action, &delivery.Status,
+			&delivery.Attempts, &delivery.ResponseCode, &delivery.LastError, &createdAt, &updatedAt, &owner); err != nil {
+			return types.WebhookDeliveryList{}, SQLError{cmd, err}
+		}
+		delivery.Owner = owner.String
+		delivery.CreatedAt = time.Unix(0, createdAt).UTC()
+		delivery.UpdatedAt = time.Unix(0, updatedAt).UTC()
+		deliveries = append(deliveries, delivery)
+	}
+	if err = rows.Err(); err != nil {
+		return types.WebhookDeliveryList{}, SQLError{cmd, err}
+	}
+	return types.WebhookDeliveryList{Deliveries: deliveries}, nil
+}
+
+// GetWebhookDeadLetters returns at most limit dead letters, most recent first, optionally
+// only those for the given webhook
+func (db *SQLAgentDB) GetWebhookDeadLetters(webhook string, limit int) (types.WebhookDeadLetterList, error) {
+	cmd := `SELECT delivery_id, webhook, event_id, action, payload, error, created_at
+          FROM webhook_dead_letters`
+	args := []interface{}{}
+	if webhook != "" {
+		cmd += ` WHERE webhook = ?`
+		args = append(args, webhook)
+	}
+	cmd += ` ORDER BY created_at DESC LIMIT ?`
+	args = append(args, limit)
+	rows, err := db.database.Query(cmd, args...)
+	if err != nil {
+		return types.WebhookDeadLetterList{}, SQLError{cmd, err}
+	}
+	defer rows.Close()
+
+	deadLetters := []types.WebhookDeadLetter{}
+	for rows.Next() {
+		var (
+			deadLetter types.WebhookDeadLetter
+			payload    []byte
+			createdAt  int64
+		)
+		if err = rows.Scan(&deadLetter.DeliveryID, &deadLetter.Webhook, &deadLetter.EventID, &deadLetter.Action,
+			&payload, &deadLetter.Error, &createdAt); err != nil {
+			return types.WebhookDeadLetterList{}, SQLError{cmd, err}
+		}
+		deadLetter.Payload = json.RawMessage(payload)
+		deadLetter.CreatedAt = time.Unix(0, createdAt).UTC()
+		deadLetters = append(deadLetters, deadLetter)
+	}
+	if err = rows.Err(); err != nil {
+		return types.WebhookDeadLetterList{}, SQLError{cmd, err}
+	}
+	return types.WebhookDeadLetterList{DeadLetters: deadLetters}, nil
+}
+
+// AppendAuditRecord assigns record the next sequence number, links it to the latest record
+// and stores it.  Returns the stored record with its hash
+func (db *SQLAgentDB) AppendAuditRecord(record types.AuditRecord) (types.AuditRecord, error) {
+	db.auditMu.Lock()
+	defer db.auditMu.Unlock()
+
+	tx, err := db.database.Begin()
+	if err != nil {
+		return types.AuditRecord{}, SQLError{"begin transaction", err}
+	}
+	// the chain head is locked, so that databases shared by several Tornjak
+	// servers get a single chain
+	cmdChain := `SELECT seq, hash FROM audit_chain WHERE id=1` + db.dialect.forUpdate()
+	var (
+		seq      int64
+		prevHash string
+	)
+	err = tx.QueryRow(cmdChain).Scan(&seq, &prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		tx.Rollback()
+		return types.AuditRecord{}, SQLError{cmdChain, err}
+	}
+
+	record.Normalize()
+	record.Seq = seq + 1
+	record.PrevHash = prevHash
+	record.Hash, err = record.ComputeHash()
+	if err != nil {
+		tx.Rollback()
+		return types.AuditRecord{}, err
+	}
+	roles, err := json.Marshal(record.Roles)
+	if err != nil {
+		tx.Rollback()
+		return types.AuditRecord{}, err
+	}
+	resources, err := json.Marshal(record.Resources)
+	if err != nil {
+		tx.Rollback()
+		return types.AuditRecord{}, err
+	}
+
+	cmdInsert := `INSERT INTO audit_log (seq, time, "user", roles, remote_addr, method, route, path, decision, reason,
+          resources, status, outcome, prev_hash, hash) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(cmdInsert, record.Seq, record.Time.UnixNano(), record.User, string(roles), record.RemoteAddr,
+		record.Method, record.Route, record.Path, record.Decision, record.Reason, string(resources), record.Status,
+		record.Outcome, record.PrevHash, record.Hash)
+	if err != nil {
+		tx.Rollback()
+		return types.AuditRecord{}, SQLError{cmdInsert, err}
+	}
+	cmdResource := db.dialect.insertOrIgnore(`INSERT INTO audit_resources (seq, resource) VALUES (?, ?)`)
+	for _, resource := range record.Resources {
+		if _, err = tx.Exec(cmdResource, record.Seq, resource); err != nil {
+			tx.Rollback()
+			return types.AuditRecord{}, SQLError{cmdResource, err}
+		}
+	}
+	cmdUpdate := `INSERT INTO audit_chain (id, seq, hash) VALUES (1, ?, ?)` + db.dialect.upsert([]string{"id"}, "seq", "hash")
+	if _, err = tx.Exec(cmdUpdate, record.Seq, record.Hash); err != nil {
+		tx.Rollback()
+		return types.AuditRecord{}, SQLError{cmdUpdate, err}
+	}
+	if err = tx.Commit(); err != nil {
+		return types.AuditRecord{}, SQLError{"commit transaction", err}
+	}
+	return record, nil
+}
+
+const auditColumns = `seq, time, "user", roles, remote_addr, method, route, path, decision, reason, resources,
+          status, outcome, prev_hash, hash`
+
+func scanAuditRecord(row rowScanner) (types.AuditRecord, error) {
+	var (
+		record    types.AuditRecord
+		t         int64
+		roles     string
+		resources string
+	)
+	if err := row.Scan(&record.Seq, &t, &record.User, &roles, &record.RemoteAddr, &record.Method, &record.Route,
+		&record.Path, &record.Decision, &record.Reason, &resources, &record.Status, &record.Outcome,
+		&record.PrevHash, &record.Hash); err != nil {
+		return types.AuditRecord{}, err
+	}
+	record.Time = time.Unix(0, t).UTC()
+	if err := json.Unmarshal([]byte(roles), &record.Roles); err != nil {
+		return types.AuditRecord{}, err
+	}
+	if err := json.Unmarshal([]byte(resources), &record.Resources); err != nil {
+		return types.AuditRecord{}, err
+	}
+	return record, nil
+}
+
+// GetAuditRecords returns the audit records matching filter, most recent first
+func (db *SQLAgentDB) GetAuditRecords(filter types.AuditFilter) (types.AuditRecordList, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if !filter.Since.IsZero() {
+		conditions = append(conditions, "time >= ?")
+		args = append(args, filter.Since.UnixNano())
+	}
+	if !filter.Until.IsZero() {
+		conditions = append(conditions, "time < ?")
+		args = append(args, filter.Until.UnixNano())
+	}
+	if filter.User != "" {
+		conditions = append(conditions, `"user" = ?`)
+		args = append(args, filter.User)
+	}
+	if filter.Action != "" {
+		conditions = append(conditions, db.dialect.concat("method", "' '", "route")+" = ?")
+		args = append(args, filter.Action)
+	}
+	if filter.Resource != "" {
+		conditions = append(conditions, "seq IN (SELECT seq FROM audit_resources WHERE resource = ?)")
+		args = append(args, filter.Resource)
+	}
+	if filter.Decision != "" {
+		conditions = append(conditions, "decision = ?")
+		args = append(args, filter.Decision)
+	}
+	cmd := `SELECT ` + auditColumns + ` FROM audit_log`
+	if len(conditions) > 0 {
+		cmd += ` WHERE ` + strings.Join(conditions, " AND ")
+	}
+	cmd += ` ORDER BY seq DESC`
+	if filter.Limit > 0 {
+		cmd += ` LIMIT ?`
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := db.database.Query(cmd, args...)
+	if err != nil {
+		return types.AuditRecordList{}, SQLError{cmd, err}
+	}
+	defer rows.Close()
+	records := []types.AuditRecord{}
+	for rows.Next() {
+		record, err := scanAuditRecord(rows)
+		if err != nil {
+			return types.AuditRecordList{}, SQLError{cmd, err}
+		}
+		records = append(records, record)
+	}
+	if err = rows.Err(); err != nil {
+		return types.AuditRecordList{}, SQLError{cmd, err}
+	}
+	return types.AuditRecordList{Records: records}, nil
+}
+
+// DeleteAuditRecordsBefore removes audit records older than t, except the latest record, and
+// returns the number removed.  The chain stays verifiable from the oldest remaining record
+func (db *SQLAgentDB) DeleteAuditRecordsBefore(t time.Time) (int64, error) {
+	db.auditMu.Lock()
+	defer db.auditMu.Unlock()
+
+	tx, err := db.database.Begin()
+	if err != nil {
+		return 0, SQLError{"begin transaction", err}
+	}
+	// the latest record is kept so that truncating the log remains detectable
+	latest := `(SELECT COALESCE(MAX(seq), 0) FROM audit_chain)`
+	cmdResources := `DELETE FROM audit_resources WHERE seq IN (SELECT seq FROM audit_log WHERE time < ? AND seq < ` + latest + `)`
+	if _, err = tx.Exec(cmdResources, t.UnixNano()); err != nil {
+		tx.Rollback()
+		return 0, SQLError{cmdResources, err}
+	}
+	cmd := `DELETE FROM audit_log WHERE time < ? AND seq < ` + latest
+	res, err := tx.Exec(cmd, t.UnixNano())
+	if err != nil {
+		tx.Rollback()
+		return 0, SQLError{cmd, err}
+	}
+	numRows, err := res.RowsAffected()
+	if err != nil {
+		tx.Rollback()
+		return 0, SQLError{cmd, err}
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, SQLError{"commit transaction", err}
+	}
+	return numRows, nil
+}
+
+// VerifyAuditRecords checks that every audit record matches its hash and links to the
+// record before it, and that the latest record is the head of the chain
+func (db *SQLAgentDB) VerifyAuditRecords() (types.AuditVerification, error) {
+	db.auditMu.Lock()
+	defer db.auditMu.Unlock()
+
+	cmdChain := `SELECT seq, hash FROM audit_chain WHERE id=1`
+	var (
+		headSeq  int64
+		headHash string
+	)
+	err := db.database.QueryRow(cmdChain).Scan(&headSeq, &headHash)
+	if err != nil && err != sql.ErrNoRows {
+		return types.AuditVerification{}, SQLError{cmdChain, err}
+	}
+
+	cmd := `SELECT ` + auditColumns + ` FROM audit_log ORDER BY seq ASC`
+	rows, err := db.database.Query(cmd)
+	if err != nil {
+		return types.AuditVerification{}, SQLError{cmd, err}
+	}
+	defer rows.Close()
+
+	result := types.AuditVerification{Valid: true, HeadSeq: headSeq, HeadHash: headHash}
+	var prev *types.AuditRecord
+	for rows.Next() {
+		record, err := scanAuditRecord(rows)
+		if err != nil {
+			return types.AuditVerification{}, SQLError{cmd, err}
+		}
+		result.Checked++
+		invalid := ""
+		if hash, err := record.ComputeHash(); err != nil || hash != record.Hash {
+			invalid = "record content does not match its hash"
+		} else if prev != nil && (record.Seq != prev.Seq+1 || record.PrevHash != prev.Hash) {
+			invalid = "record does not link to the record before it"
+		}
+		if invalid != "" {
+			result.Valid = false
+			result.FirstInvalidSeq = record.Seq
+			result.Error = invalid
+			return result, nil
+		}
+		prev = &record
+	}
+	if err = rows.Err(); err != nil {
+		return types.AuditVerification{}, SQLError{cmd, err}
+	}
+	// records removed by retention are fine, records removed from the end are not;
+	// retention always keeps the latest record
+	if prev != nil && (prev.Seq != headSeq || prev.Hash != headHash) {
+		result.Valid = false
+		result.FirstInvalidSeq = prev.Seq + 1
+		result.Error = "latest records are missing"
+	} else if prev == nil && headSeq > 0 {
+		result.Valid = false
+		result.FirstInvalidSeq = headSeq
+		result.Error = "latest records are missing"
+	}
+	return result, nil
+}
+
+func (db *SQLAgentDB) retryOp(operation func() error) error {
 	err := backoff.Retry(operation, *db.expBackoff)
 	if err != nil {
 		if serr, ok := err.(*backoff.PermanentError); ok {
@@ -391,23 +1526,37 @@ func (db *LocalSqliteDb) retryOp(operation func() error) error {
 	return err
 }
 
-func (db *LocalSqliteDb) CreateClusterEntry(cinfo types.ClusterInfo) error {
+func (db *SQLAgentDB) CreateClusterEntry(cinfo types.ClusterInfo) error {
 	operation := func() error {
 		return db.createClusterEntryOp(cinfo)
 	}
 	return db.retryOp(operation)
 }
 
-func (db *LocalSqliteDb) EditClusterEntry(cinfo types.ClusterInfo) error {
+func (db *SQLAgentDB) EditClusterEntry(cinfo types.ClusterInfo) error {
 	operation := func() error {
 		return db.editClusterEntryOp(cinfo)
 	}
 	return db.retryOp(operation)
 }
 
-func (db *LocalSqliteDb) DeleteClusterEntry(clustername string) error {
+func (db *SQLAgentDB) DeleteClusterEntry(clustername string) error {
 	operation := func() error {
 		return db.deleteClusterEntryOp(clustername)
 	}
 	return db.retryOp(operation)
 }
+
+func (db *SQLAgentDB) DeleteAgentEntry(spiffeid string) error {
+	operation := func() error {
+		return db.deleteAgentEntryOp(spiffeid)
+	}
+	return db.retryOp(operation)
+}
+
+func (db *SQLAgentDB) TombstoneAgentEntry(spiffeid string, reason string) error {
+	operation := func() error {
+		return db.tombstoneAgentEntryOp(spiffeid, reason)
+	}
+	return db.retryOp(operation)
+}
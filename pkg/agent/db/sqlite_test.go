@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/pkg/errors"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
@@ -16,13 +17,40 @@ func cleanup() {
 	os.Remove("./local-agentstest-db")
 }
 
+// testTables are dropped before tests on postgres or mysql
+var testTables = []string{"cluster_memberships", "agents", "clusters", "idempotency_keys", "jobs", "webhook_deliveries",
+	"webhook_dead_letters", "audit_log", "audit_resources", "audit_chain", "entry_metadata", "instances", "schema_version"}
+
+// newTestDB opens the DB under test: sqlite by default, or the backend of
+// TORNJAK_TEST_DB_DRIVER (postgres or mysql) at TORNJAK_TEST_DB_CONN, whose
+// tables are dropped first
+func newTestDB(backOff backoff.BackOff) (AgentDB, error) {
+	driverName := os.Getenv("TORNJAK_TEST_DB_DRIVER")
+	if driverName == "" || driverName == "sqlite3" {
+		return NewLocalSqliteDB("sqlite3", "./local-agentstest-db", backOff)
+	}
+	connectionString := os.Getenv("TORNJAK_TEST_DB_CONN")
+	db, err := NewAgentDB(driverName, connectionString, backOff)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB := db.(*SQLAgentDB)
+	for _, table := range testTables {
+		if _, err = sqlDB.database.Exec(`DROP TABLE IF EXISTS ` + table); err != nil {
+			return nil, err
+		}
+	}
+	sqlDB.database.Close()
+	return NewAgentDB(driverName, connectionString, backOff)
+}
+
 // TestSelectorDB checks correctness of functions dealing with Agent Selector table
 // Uses functions NewLocalSqliteDB, db.CreateAgentsEntry, db.GetAgentSelectors, db.GetAgentPluginInfo
 func TestSelectorDB(t *testing.T) {
 	defer cleanup()
 	expBackoff := backoff.NewExponentialBackOff()
 	expBackoff.MaxElapsedTime = time.Second
-	db, err := NewLocalSqliteDB("sqlite3", "./local-agentstest-db", expBackoff)
+	db, err := newTestDB(expBackoff)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -195,7 +223,7 @@ func TestClusterCreate(t *testing.T) {
 	defer cleanup()
 	expBackoff := backoff.NewExponentialBackOff()
 	expBackoff.MaxElapsedTime = time.Second
-	db, err := NewLocalSqliteDB("sqlite3", "./local-agentstest-db", expBackoff)
+	db, err := newTestDB(expBackoff)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -392,7 +420,7 @@ func TestClusterEdit(t *testing.T) {
 	defer cleanup()
 	expBackoff := backoff.NewExponentialBackOff()
 	expBackoff.MaxElapsedTime = time.Second
-	db, err := NewLocalSqliteDB("sqlite3", "./local-agentstest-db", expBackoff)
+	db, err := newTestDB(expBackoff)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -619,7 +647,7 @@ func TestClusterDelete(t *testing.T) {
 	defer cleanup()
 	expBackoff := backoff.NewExponentialBackOff()
 	expBackoff.MaxElapsedTime = time.Second
-	db, err := NewLocalSqliteDB("sqlite3", "./local-agentstest-db", expBackoff)
+	db, err := newTestDB(expBackoff)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -755,7 +783,1180 @@ func TestClusterDelete(t *testing.T) {
 
 }
 
-/**** HELPER SECTION ****/
+// TestIdempotencyRecords checks correctness of functions dealing with the Idempotency key table
+// Uses functions CreateIdempotencyRecord, GetIdempotencyRecord, CompleteIdempotencyRecord,
+// DeleteIdempotencyRecord, DeleteIdempotencyRecordsBefore
+func TestIdempotencyRecords(t *testing.T) {
+	defer cleanup()
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = time.Second
+	db, err := newTestDB(expBackoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := "/api/v1/tornjak/clusters"
+	rec := types.IdempotencyRecord{
+		Key:         "key1",
+		Path:        path,
+		RequestHash: "hash1",
+		Principal:   "Keycloak:alice",
+		CreatedAt:   100,
+	}
+
+	// CHECK nonexistent key returns GetError [GetIdempotencyRecord]
+	_, err = db.GetIdempotencyRecord(rec.Key, path, rec.Principal)
+	if _, ok := err.(GetError); !ok {
+		t.Fatalf("expected GetError, got %v", err)
+	}
+
+	// TEST reservation of key [CreateIdempotencyRecord, GetIdempotencyRecord]
+	err = db.CreateIdempotencyRecord(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := db.GetIdempotencyRecord(rec.Key, path, rec.Principal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.RequestHash != rec.RequestHash || stored.Principal != rec.Principal || stored.StatusCode != 0 || stored.CreatedAt != rec.CreatedAt {
+		t.Fatalf("unexpected reserved record %+v", stored)
+	}
+
+	// TEST reservation of key already in use fails with PostFailure [CreateIdempotencyRecord]
+	err = db.CreateIdempotencyRecord(rec)
+	if _, ok := err.(PostFailure); !ok {
+		t.Fatalf("expected PostFailure, got %v", err)
+	}
+
+	// TEST same key on a different path is separate [CreateIdempotencyRecord]
+	recOther := rec
+	recOther.Path = "/api/v1/spire/entries"
+	recOther.CreatedAt = 200
+	err = db.CreateIdempotencyRecord(recOther)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// TEST same key by another principal, or without one, is separate [CreateIdempotencyRecord, GetIdempotencyRecord]
+	for _, principal := range []string{"Keycloak:mallory", ""} {
+		recCaller := rec
+		recCaller.Principal = principal
+		recCaller.RequestHash = "hash2"
+		recCaller.CreatedAt = 200
+		err = db.CreateIdempotencyRecord(recCaller)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = db.CreateIdempotencyRecord(recCaller)
+		if _, ok := err.(PostFailure); !ok {
+			t.Fatalf("expected PostFailure for principal %q, got %v", principal, err)
+		}
+		stored, err = db.GetIdempotencyRecord(rec.Key, path, principal)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.RequestHash != "hash2" || stored.Principal != principal {
+			t.Fatalf("unexpected record of principal %q: %+v", principal, stored)
+		}
+	}
+
+	// TEST storing the response [CompleteIdempotencyRecord, GetIdempotencyRecord]
+	rec.StatusCode = 200
+	rec.ContentType = "application/json"
+	rec.Response = []byte(`{"ok":true}`)
+	err = db.CompleteIdempotencyRecord(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err = db.GetIdempotencyRecord(rec.Key, path, rec.Principal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.StatusCode != 200 || stored.ContentType != rec.ContentType || string(stored.Response) != string(rec.Response) {
+		t.Fatalf("unexpected completed record %+v", stored)
+	}
+	stored, err = db.GetIdempotencyRecord(rec.Key, path, "Keycloak:mallory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.StatusCode != 0 || stored.Response != nil {
+		t.Fatalf("record of another principal completed: %+v", stored)
+	}
+
+	// TEST completing an unknown key fails with PostFailure [CompleteIdempotencyRecord]
+	err = db.CompleteIdempotencyRecord(types.IdempotencyRecord{Key: "key2", Path: path, Principal: rec.Principal, StatusCode: 200})
+	if _, ok := err.(PostFailure); !ok {
+		t.Fatalf("expected PostFailure, got %v", err)
+	}
+
+	// TEST expiry removes only older records, and in progress records after their timeout [DeleteIdempotencyRecordsBefore]
+	err = db.DeleteIdempotencyRecordsBefore(150, 150)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.GetIdempotencyRecord(rec.Key, path, rec.Principal); err == nil {
+		t.Fatal("expired record not removed")
+	}
+	if _, err = db.GetIdempotencyRecord(recOther.Key, recOther.Path, recOther.Principal); err != nil {
+		t.Fatal(err)
+	}
+	recOther.StatusCode = 201
+	err = db.CompleteIdempotencyRecord(recOther)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.DeleteIdempotencyRecordsBefore(150, 250)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.GetIdempotencyRecord(rec.Key, path, "Keycloak:mallory"); err == nil {
+		t.Fatal("stale in progress record not removed")
+	}
+	if _, err = db.GetIdempotencyRecord(recOther.Key, recOther.Path, recOther.Principal); err != nil {
+		t.Fatal(err)
+	}
+
+	// TEST release of key [DeleteIdempotencyRecord]
+	err = db.DeleteIdempotencyRecord(recOther.Key, recOther.Path, "Keycloak:mallory")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.GetIdempotencyRecord(recOther.Key, recOther.Path, recOther.Principal); err != nil {
+		t.Fatal("record released by another principal")
+	}
+	err = db.DeleteIdempotencyRecord(recOther.Key, recOther.Path, recOther.Principal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.GetIdempotencyRecord(recOther.Key, recOther.Path, recOther.Principal); err == nil {
+		t.Fatal("released record not removed")
+	}
+}
+
+func TestJobs(t *testing.T) {
+	defer cleanup()
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = time.Second
+	db, err := newTestDB(expBackoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Unix(100, 0)
+	job1 := types.JobInfo{
+		ID:        "job1",
+		Type:      "delete_entries",
+		Status:    types.JobQueued,
+		Params:    []byte(`{"ids":["a","b"]}`),
+		Failures:  []types.JobFailure{},
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+	job2 := job1
+	job2.ID = "job2"
+	job2.Status = types.JobRunning
+	job2.CreatedAt = created.Add(time.Second)
+	job3 := job1
+	job3.ID = "job3"
+	job3.Status = types.JobSucceeded
+	job3.CreatedAt = created.Add(2 * time.Second)
+
+	// CHECK nonexistent job returns GetError [GetJob]
+	_, err = db.GetJob(job1.ID)
+	if _, ok := err.(GetError); !ok {
+		t.Fatalf("expected GetError, got %v", err)
+	}
+
+	// TEST creating jobs [CreateJob, GetJob]
+	for _, job := range []types.JobInfo{job1, job2, job3} {
+		err = db.CreateJob(job)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	stored, err := db.GetJob(job1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Type != job1.Type || stored.Status != job1.Status || string(stored.Params) != string(job1.Params) || !stored.CreatedAt.Equal(created) {
+		t.Fatalf("unexpected job %+v", stored)
+	}
+
+	// TEST creating a job with an existing ID fails with PostFailure [CreateJob]
+	err = db.CreateJob(job1)
+	if _, ok := err.(PostFailure); !ok {
+		t.Fatalf("expected PostFailure, got %v", err)
+	}
+
+	// CHECK jobs are listed most recent first [GetJobs]
+	jobs, err := db.GetJobs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs.Jobs) != 3 || jobs.Jobs[0].ID != job3.ID || jobs.Jobs[2].ID != job1.ID {
+		t.Fatalf("unexpected job list %+v", jobs.Jobs)
+	}
+
+	// TEST updating progress [UpdateJob, GetJob]
+	job1.Status = types.JobRunning
+	job1.Total = 2
+	job1.Processed = 2
+	job1.Failed = 1
+	job1.Failures = []types.JobFailure{{Item: "b", Error: "not found"}}
+	err = db.UpdateJob(job1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err = db.GetJob(job1.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != types.JobRunning || stored.Processed != 2 || stored.Failed != 1 || len(stored.Failures) != 1 || stored.Failures[0].Item != "b" {
+		t.Fatalf("unexpected updated job %+v", stored)
+	}
+
+	// TEST updating an unknown job fails with PostFailure [UpdateJob]
+	err = db.UpdateJob(types.JobInfo{ID: "job4", Status: types.JobFailed})
+	if _, ok := err.(PostFailure); !ok {
+		t.Fatalf("expected PostFailure, got %v", err)
+	}
+
+	// TEST unfinished jobs without owner are marked failed [FailUnfinishedJobs]
+	n, err := db.FailUnfinishedJobs("", "interrupted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 jobs marked failed, got %d", n)
+	}
+	for _, id := range []string{job1.ID, job2.ID} {
+		stored, err = db.GetJob(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status != types.JobFailed || stored.Error != "interrupted" {
+			t.Fatalf("unexpected interrupted job %+v", stored)
+		}
+	}
+	stored, err = db.GetJob(job3.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != types.JobSucceeded {
+		t.Fatalf("finished job changed: %+v", stored)
+	}
+}
+
+func TestWebhookDeliveries(t *testing.T) {
+	defer cleanup()
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = time.Second
+	db, err := newTestDB(expBackoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Unix(100, 0)
+	d1 := types.WebhookDelivery{
+		ID:        "d1",
+		Webhook:   "hook1",
+		EventID:   "e1",
+		Action:    "entry.create",
+		Status:    types.WebhookPending,
+		Payload:   []byte(`{"id":"e1"}`),
+		CreatedAt: created,
+		UpdatedAt: created,
+	}
+	d2 := d1
+	d2.ID = "d2"
+	d2.Webhook = "hook2"
+	d2.CreatedAt = created.Add(time.Second)
+	d3 := d1
+	d3.ID = "d3"
+	d3.EventID = "e3"
+	d3.Payload = []byte(`{"id":"e3"}`)
+	d3.CreatedAt = created.Add(2 * time.Second)
+
+	// TEST creating deliveries [CreateWebhookDelivery, GetWebhookDeliveries]
+	for _, d := range []types.WebhookDelivery{d1, d2, d3} {
+		err = db.CreateWebhookDelivery(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	deliveries, err := db.GetWebhookDeliveries("", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries.Deliveries) != 3 || deliveries.Deliveries[0].ID != d3.ID || deliveries.Deliveries[2].ID != d1.ID {
+		t.Fatalf("unexpected delivery list %+v", deliveries.Deliveries)
+	}
+
+	// TEST creating a delivery with an existing ID fails with PostFailure [CreateWebhookDelivery]
+	err = db.CreateWebhookDelivery(d1)
+	if _, ok := err.(PostFailure); !ok {
+		t.Fatalf("expected PostFailure, got %v", err)
+	}
+
+	// TEST recording a successful delivery [UpdateWebhookDelivery]
+	d1.Status = types.WebhookDelivered
+	d1.Attempts = 2
+	d1.ResponseCode = 204
+	err = db.UpdateWebhookDelivery(d1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// TEST updating an unknown delivery fails with PostFailure [UpdateWebhookDelivery]
+	err = db.UpdateWebhookDelivery(types.WebhookDelivery{ID: "d4", Status: types.WebhookDelivered})
+	if _, ok := err.(PostFailure); !ok {
+		t.Fatalf("expected PostFailure, got %v", err)
+	}
+
+	// CHECK deliveries are filtered by webhook, status and limit [GetWebhookDeliveries]
+	deliveries, err = db.GetWebhookDeliveries("hook1", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries.Deliveries) != 2 {
+		t.Fatalf("expected 2 deliveries to hook1, got %+v", deliveries.Deliveries)
+	}
+	deliveries, err = db.GetWebhookDeliveries("", types.WebhookDelivered, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries.Deliveries) != 1 || deliveries.Deliveries[0].Attempts != 2 || deliveries.Deliveries[0].ResponseCode != 204 {
+		t.Fatalf("unexpected delivered list %+v", deliveries.Deliveries)
+	}
+	deliveries, err = db.GetWebhookDeliveries("", "", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries.Deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %+v", deliveries.Deliveries)
+	}
+
+	// TEST a failed delivery is moved to dead letters [DeadLetterWebhookDelivery, GetWebhookDeadLetters]
+	d3.Attempts = 5
+	d3.LastError = "status 500"
+	d3.UpdatedAt = created.Add(time.Minute)
+	err = db.DeadLetterWebhookDelivery(d3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadLetters, err := db.GetWebhookDeadLetters("", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deadLetters.DeadLetters) != 1 || deadLetters.DeadLetters[0].DeliveryID != d3.ID ||
+		deadLetters.DeadLetters[0].Error != d3.LastError || string(deadLetters.DeadLetters[0].Payload) != string(d3.Payload) {
+		t.Fatalf("unexpected dead letters %+v", deadLetters.DeadLetters)
+	}
+
+	// TEST dead-lettering twice fails with PostFailure [DeadLetterWebhookDelivery]
+	err = db.DeadLetterWebhookDelivery(d3)
+	if _, ok := err.(PostFailure); !ok {
+		t.Fatalf("expected PostFailure, got %v", err)
+	}
+
+	// TEST pending deliveries without owner are dead-lettered [DeadLetterPendingWebhookDeliveries]
+	n, err := db.DeadLetterPendingWebhookDeliveries("", "interrupted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 delivery dead-lettered, got %d", n)
+	}
+	deadLetters, err = db.GetWebhookDeadLetters("hook2", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deadLetters.DeadLetters) != 1 || deadLetters.DeadLetters[0].DeliveryID != d2.ID || deadLetters.DeadLetters[0].Error != "interrupted" {
+		t.Fatalf("unexpected dead letters %+v", deadLetters.DeadLetters)
+	}
+	deliveries, err = db.GetWebhookDeliveries("", types.WebhookPending, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries.Deliveries) != 0 {
+		t.Fatalf("expected no pending deliveries, got %+v", deliveries.Deliveries)
+	}
+}
+
+func TestInstanceOwnership(t *testing.T) {
+	defer cleanup()
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = time.Second
+	db, err := newTestDB(expBackoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// instances a and b are alive; c never was
+	now := time.Now()
+	for _, id := range []string{"a", "b"} {
+		if err = db.HeartbeatInstance(id, now.Add(-time.Minute)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, job := range []types.JobInfo{
+		{ID: "job-a", Type: "delete_entries", Status: types.JobRunning, Owner: "a"},
+		{ID: "job-b", Type: "delete_entries", Status: types.JobRunning, Owner: "b"},
+		{ID: "job-c", Type: "delete_entries", Status: types.JobQueued, Owner: "c"},
+	} {
+		job.Failures = []types.JobFailure{}
+		job.CreatedAt, job.UpdatedAt = now, now
+		if err = db.CreateJob(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, delivery := range []types.WebhookDelivery{
+		{ID: "d-a", Webhook: "hook", EventID: "e1", Action: "entry.create", Status: types.WebhookPending, Owner: "a"},
+		{ID: "d-b", Webhook: "hook", EventID: "e2", Action: "entry.create", Status: types.WebhookPending, Owner: "b"},
+		{ID: "d-c", Webhook: "hook", EventID: "e3", Action: "entry.create", Status: types.WebhookPending, Owner: "c"},
+	} {
+		delivery.Payload = []byte(`{}`)
+		delivery.CreatedAt, delivery.UpdatedAt = now, now
+		if err = db.CreateWebhookDelivery(delivery); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// CHECK the owner is stored [CreateJob, GetJob]
+	stored, err := db.GetJob("job-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Owner != "b" || stored.CancelRequested {
+		t.Fatalf("unexpected job %+v", stored)
+	}
+
+	// TEST cancellation of a job of another instance is recorded [RequestJobCancel]
+	if err = db.RequestJobCancel("job-b"); err != nil {
+		t.Fatal(err)
+	}
+	stored, err = db.GetJob("job-b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !stored.CancelRequested || stored.Status != types.JobRunning {
+		t.Fatalf("unexpected job %+v", stored)
+	}
+
+	// TEST a restart of a fails its jobs and those of c, not those of b [FailUnfinishedJobs]
+	n, err := db.FailUnfinishedJobs("a", "interrupted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 jobs marked failed, got %d", n)
+	}
+	for id, status := range map[string]string{"job-a": types.JobFailed, "job-b": types.JobRunning, "job-c": types.JobFailed} {
+		stored, err = db.GetJob(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status != status {
+			t.Fatalf("expected job %s %s, got %+v", id, status, stored)
+		}
+	}
+
+	// TEST cancellation of a finished job fails with PostFailure [RequestJobCancel]
+	err = db.RequestJobCancel("job-a")
+	if _, ok := err.(PostFailure); !ok {
+		t.Fatalf("expected PostFailure, got %v", err)
+	}
+
+	// CHECK the pending deliveries of an instance are listed with their payloads [GetPendingWebhookDeliveries]
+	pending, err := db.GetPendingWebhookDeliveries("b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ID != "d-b" || pending[0].Owner != "b" || string(pending[0].Payload) != `{}` {
+		t.Fatalf("unexpected pending deliveries of b %+v", pending)
+	}
+
+	// TEST a restart of a dead-letters its deliveries and those of c, not those of b [DeadLetterPendingWebhookDeliveries]
+	n, err = db.DeadLetterPendingWebhookDeliveries("a", "interrupted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 deliveries dead-lettered, got %d", n)
+	}
+	deliveries, err := db.GetWebhookDeliveries("", types.WebhookPending, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries.Deliveries) != 1 || deliveries.Deliveries[0].ID != "d-b" || deliveries.Deliveries[0].Owner != "b" {
+		t.Fatalf("unexpected pending deliveries %+v", deliveries.Deliveries)
+	}
+
+	// TEST once b stops sending heartbeats, its work is orphaned [HeartbeatInstance, FailUnfinishedJobs]
+	if err = db.HeartbeatInstance("a", time.Now().Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	n, err = db.FailUnfinishedJobs("", "interrupted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 job marked failed, got %d", n)
+	}
+	n, err = db.DeadLetterPendingWebhookDeliveries("", "interrupted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 delivery dead-lettered, got %d", n)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	defer cleanup()
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = time.Second
+	db, err := newTestDB(expBackoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// CHECK empty log is valid [VerifyAuditRecords]
+	verification, err := db.VerifyAuditRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verification.Valid || verification.Checked != 0 {
+		t.Fatalf("unexpected verification of empty log %+v", verification)
+	}
+
+	start := time.Unix(1000, 0)
+	records := []types.AuditRecord{
+		{Time: start, User: "alice", Roles: []string{"admin"}, Method: "DELETE", Route: "/api/v2/entries/{id}",
+			Path: "/api/v2/entries/e1", Decision: types.AuditAllow, Resources: []string{"e1"}, Status: 204, Outcome: types.AuditSuccess},
+		{Time: start.Add(time.Minute), User: "bob", Method: "DELETE", Route: "/api/v2/entries/{id}",
+			Path: "/api/v2/entries/e2", Decision: types.AuditDeny, Reason: "Unauthorized", Resources: []string{"e2"}, Status: 401, Outcome: types.AuditFailure},
+		{Time: start.Add(2 * time.Minute), User: "alice", Method: "GET", Route: "/api/v2/entries",
+			Path: "/api/v2/entries", Decision: types.AuditAllow, Status: 200, Outcome: types.AuditSuccess},
+	}
+
+	// TEST appending records links them in a chain [AppendAuditRecord]
+	prevHash := ""
+	for i, record := range records {
+		stored, err := db.AppendAuditRecord(record)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Seq != int64(i+1) || stored.PrevHash != prevHash || stored.Hash == "" {
+			t.Fatalf("unexpected stored record %+v", stored)
+		}
+		prevHash = stored.Hash
+	}
+
+	// CHECK records are filtered [GetAuditRecords]
+	list, err := db.GetAuditRecords(types.AuditFilter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Records) != 3 || list.Records[0].Seq != 3 || list.Records[2].User != "alice" || list.Records[2].Resources[0] != "e1" {
+		t.Fatalf("unexpected audit records %+v", list.Records)
+	}
+	for _, tc := range []struct {
+		filter types.AuditFilter
+		seqs   []int64
+	}{
+		{types.AuditFilter{User: "alice"}, []int64{3, 1}},
+		{types.AuditFilter{Action: "DELETE /api/v2/entries/{id}"}, []int64{2, 1}},
+		{types.AuditFilter{Resource: "e2"}, []int64{2}},
+		{types.AuditFilter{Decision: types.AuditDeny}, []int64{2}},
+		{types.AuditFilter{Since: start.Add(time.Minute), Until: start.Add(2 * time.Minute)}, []int64{2}},
+		{types.AuditFilter{Limit: 1}, []int64{3}},
+	} {
+		list, err = db.GetAuditRecords(tc.filter)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list.Records) != len(tc.seqs) {
+			t.Fatalf("filter %+v: expected records %v, got %+v", tc.filter, tc.seqs, list.Records)
+		}
+		for i, seq := range tc.seqs {
+			if list.Records[i].Seq != seq {
+				t.Fatalf("filter %+v: expected records %v, got %+v", tc.filter, tc.seqs, list.Records)
+			}
+		}
+	}
+
+	// CHECK untouched chain is valid [VerifyAuditRecords]
+	verification, err = db.VerifyAuditRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verification.Valid || verification.Checked != 3 || verification.HeadSeq != 3 || verification.HeadHash != prevHash {
+		t.Fatalf("unexpected verification %+v", verification)
+	}
+
+	// TEST retention removes old records but keeps the chain valid [DeleteAuditRecordsBefore]
+	n, err := db.DeleteAuditRecordsBefore(start.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 record removed, got %d", n)
+	}
+	verification, err = db.VerifyAuditRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !verification.Valid || verification.Checked != 2 {
+		t.Fatalf("unexpected verification after retention %+v", verification)
+	}
+	list, err = db.GetAuditRecords(types.AuditFilter{Resource: "e1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Records) != 0 {
+		t.Fatalf("expected no records for removed resource, got %+v", list.Records)
+	}
+
+	// TEST retention keeps the latest record [DeleteAuditRecordsBefore]
+	n, err = db.DeleteAuditRecordsBefore(start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("expected 1 record removed, got %d", n)
+	}
+
+	// TEST new records continue the chain [AppendAuditRecord]
+	stored, err := db.AppendAuditRecord(types.AuditRecord{Time: start.Add(time.Hour), User: "carol", Method: "GET",
+		Route: "/api/v2/agents", Path: "/api/v2/agents", Decision: types.AuditAllow, Status: 200, Outcome: types.AuditSuccess})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Seq != 4 || stored.PrevHash != prevHash {
+		t.Fatalf("unexpected stored record %+v", stored)
+	}
+
+	// CHECK tampering with a record is detected [VerifyAuditRecords]
+	localDb := db.(*SQLAgentDB)
+	if _, err = localDb.database.Exec(`UPDATE audit_log SET "user"='mallory' WHERE seq=3`); err != nil {
+		t.Fatal(err)
+	}
+	verification, err = db.VerifyAuditRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verification.Valid || verification.FirstInvalidSeq != 3 {
+		t.Fatalf("expected tampered record 3 to be detected, got %+v", verification)
+	}
+
+	// CHECK removing the latest record is detected [VerifyAuditRecords]
+	if _, err = localDb.database.Exec(`UPDATE audit_log SET "user"='alice' WHERE seq=3`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = localDb.database.Exec(`DELETE FROM audit_log WHERE seq=4`); err != nil {
+		t.Fatal(err)
+	}
+	verification, err = db.VerifyAuditRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verification.Valid || verification.FirstInvalidSeq != 4 {
+		t.Fatalf("expected missing record 4 to be detected, got %+v", verification)
+	}
+}
+
+/**** HELPER SECTION ****/
+
+// TestAgentLabels checks that labels and annotations of agents are stored,
+// kept when the plugin is set and listed with the agent metadata
+// Uses functions NewLocalSqliteDB, db.SetAgentLabels, db.GetAgentLabels, db.CreateAgentEntry, db.GetAgentsMetadata
+func TestAgentLabels(t *testing.T) {
+	cleanup()
+	defer cleanup()
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = time.Second
+	db, err := newTestDB(expBackoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	agent1 := "spiffe://example.org/agent1"
+	alabels, err := db.GetAgentLabels(agent1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alabels.Spiffeid != agent1 || len(alabels.Labels) != 0 || len(alabels.Annotations) != 0 {
+		t.Fatalf("Unknown agent should have no labels, got %+v", alabels)
+	}
+
+	// labels register an unknown agent
+	err = db.SetAgentLabels(types.AgentLabels{
+		Spiffeid:    agent1,
+		Labels:      types.Labels{"rack": "r12"},
+		Annotations: types.Annotations{"notes": "replaced disk"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an agent with only labels has no plugin
+	_, err = db.GetAgentPluginInfo(agent1)
+	if _, ok := err.(GetError); !ok {
+		t.Fatalf("Expected GetError for agent with only labels, got %v", err)
+	}
+	// setting the plugin keeps the labels
+	if err = db.CreateAgentEntry(types.AgentInfo{Spiffeid: agent1, Plugin: "Docker"}); err != nil {
+		t.Fatal(err)
+	}
+	alabels, err = db.GetAgentLabels(agent1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if alabels.Labels["rack"] != "r12" || alabels.Annotations["notes"] != "replaced disk" {
+		t.Fatalf("Unexpected labels of agent1: %+v", alabels)
+	}
+	info, err := db.GetAgentPluginInfo(agent1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Spiffeid != agent1 || info.Plugin != "Docker" {
+		t.Fatalf("Unexpected plugin info of agent1: %+v", info)
+	}
+
+	aList, err := db.GetAgentsMetadata(types.AgentMetadataRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aList.Agents) != 1 || aList.Agents[0].Plugin != "Docker" || aList.Agents[0].Labels["rack"] != "r12" ||
+		aList.Agents[0].Annotations["notes"] != "replaced disk" {
+		t.Fatalf("Unexpected agent metadata: %+v", aList.Agents)
+	}
+
+	// setting labels replaces them, keeping the plugin
+	if err = db.SetAgentLabels(types.AgentLabels{Spiffeid: agent1, Labels: types.Labels{"hardware-class": "gpu"}}); err != nil {
+		t.Fatal(err)
+	}
+	aList, err = db.GetAgentsMetadata(types.AgentMetadataRequest{Agents: []string{agent1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aList.Agents) != 1 || aList.Agents[0].Plugin != "Docker" || len(aList.Agents[0].Labels) != 1 ||
+		aList.Agents[0].Labels["hardware-class"] != "gpu" || aList.Agents[0].Annotations != nil {
+		t.Fatalf("Unexpected agent metadata after replacing labels: %+v", aList.Agents)
+	}
+}
+
+// TestAgentDeletion checks that deleting an agent removes it and its cluster
+// membership, and that a tombstone keeps its metadata until it is registered again
+// Uses functions NewLocalSqliteDB, db.DeleteAgentEntry, db.TombstoneAgentEntry, db.GetAgentsMetadata, db.GetClusters
+func TestAgentDeletion(t *testing.T) {
+	cleanup()
+	defer cleanup()
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = time.Second
+	db, err := newTestDB(expBackoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	agent1, agent2, agent3 := "spiffe://example.org/agent1", "spiffe://example.org/agent2", "spiffe://example.org/agent3"
+	cinfo := types.ClusterInfo{Name: "cluster1", PlatformType: "Kubernetes", AgentsList: []string{agent1, agent2, agent3}}
+	if err = db.CreateClusterEntry(cinfo); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.CreateAgentEntry(types.AgentInfo{Spiffeid: agent2, Plugin: "Docker"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// deleting removes the agent and its membership; unknown agents are ignored
+	if err = db.DeleteAgentEntry(agent1); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.DeleteAgentEntry("spiffe://example.org/unknown"); err != nil {
+		t.Fatal(err)
+	}
+	aList, err := db.GetAgentsMetadata(types.AgentMetadataRequest{IncludeTombstones: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aList.Agents) != 2 {
+		t.Fatalf("Expected 2 agents after deleting agent1, got %+v", aList.Agents)
+	}
+
+	// a tombstone keeps the agent metadata, without its membership
+	if err = db.TombstoneAgentEntry(agent2, types.AgentTombstoneBanned); err != nil {
+		t.Fatal(err)
+	}
+	cList, err := db.GetClusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cList.Clusters) != 1 || len(cList.Clusters[0].AgentsList) != 1 || cList.Clusters[0].AgentsList[0] != agent3 {
+		t.Fatalf("Expected cluster1 to only have agent3, got %+v", cList.Clusters)
+	}
+	aList, err = db.GetAgentsMetadata(types.AgentMetadataRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aList.Agents) != 1 || aList.Agents[0].Spiffeid != agent3 {
+		t.Fatalf("Expected tombstones to be left out, got %+v", aList.Agents)
+	}
+	aList, err = db.GetAgentsMetadata(types.AgentMetadataRequest{Agents: []string{agent2}, IncludeTombstones: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aList.Agents) != 1 || aList.Agents[0].Plugin != "Docker" || aList.Agents[0].Cluster != "" ||
+		aList.Agents[0].TombstonedAt == nil || aList.Agents[0].TombstoneReason != types.AgentTombstoneBanned {
+		t.Fatalf("Unexpected tombstone of agent2: %+v", aList.Agents)
+	}
+	sList, err := db.GetAgentSelectors()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sList.Agents) != 0 {
+		t.Fatalf("Expected no agent selectors of tombstones, got %+v", sList.Agents)
+	}
+
+	// registering the agent again removes the tombstone
+	if err = db.CreateAgentEntry(types.AgentInfo{Spiffeid: agent2, Plugin: "Kubernetes"}); err != nil {
+		t.Fatal(err)
+	}
+	aList, err = db.GetAgentsMetadata(types.AgentMetadataRequest{Agents: []string{agent2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aList.Agents) != 1 || aList.Agents[0].TombstonedAt != nil || aList.Agents[0].Plugin != "Kubernetes" {
+		t.Fatalf("Expected agent2 to be registered again, got %+v", aList.Agents)
+	}
+
+	// as does adding it to a cluster
+	if err = db.TombstoneAgentEntry(agent3, types.AgentTombstoneDeleted); err != nil {
+		t.Fatal(err)
+	}
+	cinfo.EditedName, cinfo.AgentsList = cinfo.Name, []string{agent3}
+	if err = db.EditClusterEntry(cinfo); err != nil {
+		t.Fatal(err)
+	}
+	aList, err = db.GetAgentsMetadata(types.AgentMetadataRequest{Agents: []string{agent3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(aList.Agents) != 1 || aList.Agents[0].TombstonedAt != nil || aList.Agents[0].Cluster != "cluster1" {
+		t.Fatalf("Expected agent3 to be back in cluster1, got %+v", aList.Agents)
+	}
+}
+
+// TestClusterLabels checks that labels and details of clusters are stored,
+// replaced on edit and read back
+// Uses functions NewLocalSqliteDB, db.CreateClusterEntry, db.EditClusterEntry, db.GetClusters
+func TestClusterLabels(t *testing.T) {
+	cleanup()
+	defer cleanup()
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = time.Second
+	db, err := newTestDB(expBackoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cinfo := types.ClusterInfo{
+		Name:         "cluster1",
+		PlatformType: "Kubernetes",
+		Labels:       types.Labels{"region": "us-east", "env": "prod"},
+		Details:      []byte(`{"k8sVersion":"1.29","costCenter":42}`),
+	}
+	if err = db.CreateClusterEntry(cinfo); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.CreateClusterEntry(types.ClusterInfo{Name: "cluster2", PlatformType: "VMs"}); err != nil {
+		t.Fatal(err)
+	}
+
+	cList, err := db.GetClusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cList.Clusters) != 2 {
+		t.Fatalf("Expected 2 clusters, got %d", len(cList.Clusters))
+	}
+	c1, c2 := cList.Clusters[0], cList.Clusters[1]
+	if len(c1.Labels) != 2 || c1.Labels["region"] != "us-east" || c1.Labels["env"] != "prod" {
+		t.Fatalf("Unexpected labels of cluster1: %v", c1.Labels)
+	}
+	if string(c1.Details) != `{"k8sVersion":"1.29","costCenter":42}` {
+		t.Fatalf("Unexpected details of cluster1: %s", c1.Details)
+	}
+	if c2.Labels != nil || c2.Details != nil {
+		t.Fatalf("cluster2 should have no labels nor details, got %v %s", c2.Labels, c2.Details)
+	}
+
+	// edit replaces labels and details
+	cinfo.EditedName = cinfo.Name
+	cinfo.Labels = types.Labels{"region": "eu-west"}
+	cinfo.Details = nil
+	if err = db.EditClusterEntry(cinfo); err != nil {
+		t.Fatal(err)
+	}
+	cList, err = db.GetClusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c1 = cList.Clusters[0]
+	if len(c1.Labels) != 1 || c1.Labels["region"] != "eu-west" || c1.Details != nil {
+		t.Fatalf("Unexpected labels or details of cluster1 after edit: %v %s", c1.Labels, c1.Details)
+	}
+}
+
+// TestEntryMetadata checks that entry metadata is stored, replaced, read back
+// by entry ID in batches and deleted
+// Uses functions NewLocalSqliteDB, db.CreateEntryMetadata, db.GetEntriesMetadata, db.DeleteEntriesMetadata
+func TestEntryMetadata(t *testing.T) {
+	cleanup()
+	defer cleanup()
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = time.Second
+	db, err := newTestDB(expBackoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mList, err := db.GetEntriesMetadata(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mList.Metadata) != 0 {
+		t.Fatalf("Expected no entry metadata, got %+v", mList.Metadata)
+	}
+
+	createdAt := time.Unix(1700000000, 0).UTC()
+	meta1 := types.EntryMetadata{
+		EntryID:     "entry1",
+		Owner:       "payments",
+		Description: "checkout service",
+		Ticket:      "OPS-1234",
+		Tags:        []string{"prod", "pci"},
+		CreatedBy:   "alice",
+		CreatedAt:   createdAt,
+	}
+	if err = db.CreateEntryMetadata(meta1); err != nil {
+		t.Fatal(err)
+	}
+	if err = db.CreateEntryMetadata(types.EntryMetadata{EntryID: "entry2", CreatedAt: createdAt}); err != nil {
+		t.Fatal(err)
+	}
+	mList, err = db.GetEntriesMetadata([]string{"entry1", "unknown"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mList.Metadata) != 1 || mList.Metadata[0].Owner != "payments" || mList.Metadata[0].Ticket != "OPS-1234" ||
+		len(mList.Metadata[0].Tags) != 2 || mList.Metadata[0].Tags[1] != "pci" || mList.Metadata[0].CreatedBy != "alice" ||
+		!mList.Metadata[0].CreatedAt.Equal(createdAt) {
+		t.Fatalf("Unexpected metadata of entry1: %+v", mList.Metadata)
+	}
+
+	// creating again replaces the metadata
+	meta1.Owner, meta1.Tags = "billing", nil
+	if err = db.CreateEntryMetadata(meta1); err != nil {
+		t.Fatal(err)
+	}
+	mList, err = db.GetEntriesMetadata(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mList.Metadata) != 2 {
+		t.Fatalf("Expected metadata of 2 entries, got %+v", mList.Metadata)
+	}
+	for _, meta := range mList.Metadata {
+		if meta.EntryID == "entry1" && (meta.Owner != "billing" || meta.Tags != nil) {
+			t.Fatalf("Unexpected metadata of entry1 after replacing it: %+v", meta)
+		}
+	}
+
+	// IDs beyond one batch are all looked up
+	ids := []string{}
+	for i := 0; i < entryMetadataBatchSize+10; i++ {
+		ids = append(ids, fmt.Sprintf("missing%d", i))
+	}
+	ids = append(ids, "entry2")
+	mList, err = db.GetEntriesMetadata(ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mList.Metadata) != 1 || mList.Metadata[0].EntryID != "entry2" {
+		t.Fatalf("Expected metadata of entry2 only, got %+v", mList.Metadata)
+	}
+
+	if err = db.DeleteEntriesMetadata(append(ids, "entry1")); err != nil {
+		t.Fatal(err)
+	}
+	mList, err = db.GetEntriesMetadata(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mList.Metadata) != 0 {
+		t.Fatalf("Expected no entry metadata after delete, got %+v", mList.Metadata)
+	}
+}
+
+// TestSchemaMigrations checks that migrations are recorded, that databases
+// created before schema versions are adopted, and that a newer schema is
+// refused
+// Uses functions NewMigrator, migrator.Status, migrator.Migrate, NewLocalSqliteDB
+func TestSchemaMigrations(t *testing.T) {
+	cleanup()
+	defer cleanup()
+	expBackoff := backoff.NewExponentialBackOff()
+	expBackoff.MaxElapsedTime = time.Second
+
+	// a database without schema version, as created by older versions
+	migrator, err := NewMigrator("sqlite3", "./local-agentstest-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer migrator.Close()
+	if err := createDBTable(migrator.database, initAgentsTable); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.database.Exec("INSERT INTO agents (spiffeid, plugin) VALUES (?,?)", "spiffe://example.org/agent", "K8S"); err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := len(sqliteDialect{}.migrations())
+	if status.Version != 0 || status.Latest != latest || len(status.Pending) != latest {
+		t.Fatalf("Unexpected status before migration: %+v", status)
+	}
+
+	// dry run does not change the database
+	pending, err := migrator.Migrate(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != latest {
+		t.Fatalf("Dry run should return %d migrations, got %d", latest, len(pending))
+	}
+	status, err = migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != 0 {
+		t.Fatalf("Dry run should not migrate, got version %d", status.Version)
+	}
+
+	// opening the database migrates it, keeping its data
+	db, err := NewLocalSqliteDB("sqlite3", "./local-agentstest-db", expBackoff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plugin, err := db.GetAgentPluginInfo("spiffe://example.org/agent")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plugin.Plugin != "K8S" {
+		t.Fatalf("Agent should be kept by migration, got %+v", plugin)
+	}
+	status, err = migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Version != latest || len(status.Pending) != 0 {
+		t.Fatalf("Unexpected status after migration: %+v", status)
+	}
+	pending, err = migrator.Migrate(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 0 {
+		t.Fatalf("Migrated database should have no pending migrations, got %d", len(pending))
+	}
+
+	// a schema newer than this binary is refused
+	if _, err := migrator.database.Exec("INSERT INTO schema_version (version, description, applied_at) VALUES (?,?,?)", latest+1, "from the future", 0); err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewLocalSqliteDB("sqlite3", "./local-agentstest-db", expBackoff)
+	if serr, ok := err.(SchemaVersionError); !ok || serr.Version != latest+1 || serr.Latest != latest {
+		t.Fatalf("Newer schema should be refused with SchemaVersionError, got %v", err)
+	}
+	if _, err := migrator.Migrate(true); err == nil {
+		t.Fatal("Dry run should fail on a newer schema")
+	}
+}
+
+func TestMigrationStatements(t *testing.T) {
+	// columns are only skipped when added by a recognized statement, so that
+	// a migration failed on MySQL can be run again
+	for _, dialect := range []sqlDialect{sqliteDialect{}, postgresDialect{}, mysqlDialect{}} {
+		for _, migration := range dialect.migrations() {
+			for _, cmd := range migration.Statements {
+				if strings.Contains(cmd, "ADD COLUMN") && addColumnStatement.FindStringSubmatch(cmd) == nil {
+					t.Errorf("%T migration %d: statement %q adds a column but does not match addColumnStatement", dialect, migration.Version, cmd)
+				}
+			}
+		}
+	}
+	match := addColumnStatement.FindStringSubmatch(addJobOwnerColumn)
+	if match == nil || match[1] != "jobs" || match[2] != "owner" {
+		t.Fatalf("Unexpected match %v", match)
+	}
+}
+
+// TestConcurrentMigrations migrates a postgres or mysql database from several
+// servers at once; sqlite databases are not shared
+func TestConcurrentMigrations(t *testing.T) {
+	driverName := os.Getenv("TORNJAK_TEST_DB_DRIVER")
+	if driverName == "" || driverName == "sqlite3" {
+		t.Skip("requires TORNJAK_TEST_DB_DRIVER postgres or mysql")
+	}
+	migrator, err := NewMigrator(driverName, os.Getenv("TORNJAK_TEST_DB_CONN"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, table := range testTables {
+		if _, err = migrator.database.Exec(`DROP TABLE IF EXISTS ` + table); err != nil {
+			t.Fatal(err)
+		}
+	}
+	latest := migrator.latest()
+	migrator.Close()
+
+	const servers = 4
+	applied := make(chan int, servers)
+	errs := make(chan error, servers)
+	for i := 0; i < servers; i++ {
+		go func() {
+			migrator, err := NewMigrator(driverName, os.Getenv("TORNJAK_TEST_DB_CONN"))
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer migrator.Close()
+			migrations, err := migrator.Migrate(false)
+			if err != nil {
+				errs <- err
+				return
+			}
+			applied <- len(migrations)
+		}()
+	}
+	total := 0
+	for i := 0; i < servers; i++ {
+		select {
+		case err := <-errs:
+			t.Fatal(err)
+		case n := <-applied:
+			total += n
+		}
+	}
+	// each migration is applied by exactly one server
+	if total != latest {
+		t.Fatalf("Expected %d migrations applied in total, got %d", latest, total)
+	}
+}
 
 func agentInfoCmp(agentInfo1 types.AgentInfo, agentInfo2 types.AgentInfo) bool {
 	return agentInfo1.Spiffeid == agentInfo2.Spiffeid && agentInfo1.Plugin == agentInfo2.Plugin
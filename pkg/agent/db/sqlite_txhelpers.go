@@ -7,7 +7,6 @@ import (
 	"strings"
 	"time"
 
-	sqlite3 "github.com/mattn/go-sqlite3"
 	"github.com/pkg/errors"
 
 	"github.com/spiffe/tornjak/pkg/agent/types"
@@ -15,10 +14,10 @@ import (
 
 type tornjakTxHelper struct {
 	ctx context.Context
-	tx  *sql.Tx
+	tx  *dialectTx
 }
 
-func getTornjakTxHelper(ctx context.Context, tx *sql.Tx) *tornjakTxHelper {
+func getTornjakTxHelper(ctx context.Context, tx *dialectTx) *tornjakTxHelper {
 	return &tornjakTxHelper{ctx, tx}
 }
 
@@ -48,15 +47,19 @@ func (t *tornjakTxHelper) rollbackHandler(err error) error {
 // insertClusterMetadata attempts insert into table clusters
 // returns SQLError upon failure and PostFailure on cluster existence
 func (t *tornjakTxHelper) insertClusterMetadata(cinfo types.ClusterInfo) error {
-	cmdInsert := `INSERT INTO clusters (name, created_at, domain_name, managed_by, platform_type) VALUES (?,?,?,?,?)`
+	cmdInsert := `INSERT INTO clusters (name, created_at, domain_name, managed_by, platform_type, labels, details) VALUES (?,?,?,?,?,?,?)`
+	labels, details, err := encodeClusterLabelsDetails(cinfo)
+	if err != nil {
+		return err
+	}
 	statement, err := t.tx.PrepareContext(t.ctx, cmdInsert)
 	if err != nil {
 		return SQLError{cmdInsert, err}
 	}
 	defer statement.Close()
-	_, err = statement.ExecContext(t.ctx, cinfo.Name, time.Now().Format("Jan 02 2006 15:04:05"), cinfo.DomainName, cinfo.ManagedBy, cinfo.PlatformType)
+	_, err = statement.ExecContext(t.ctx, cinfo.Name, time.Now().Format("Jan 02 2006 15:04:05"), cinfo.DomainName, cinfo.ManagedBy, cinfo.PlatformType, labels, details)
 	if err != nil {
-		if serr, ok := err.(sqlite3.Error); ok && serr.Code == sqlite3.ErrConstraint {
+		if t.tx.dialect.isConstraintError(err) {
 			return PostFailure{"Cluster already exists; use Edit Cluster"}
 		}
 		return SQLError{cmdInsert, err}
@@ -64,18 +67,36 @@ func (t *tornjakTxHelper) insertClusterMetadata(cinfo types.ClusterInfo) error {
 	return nil
 }
 
+// encodeClusterLabelsDetails returns the labels and details of cinfo as
+// stored, NULL if not set
+func encodeClusterLabelsDetails(cinfo types.ClusterInfo) (sql.NullString, sql.NullString, error) {
+	var details sql.NullString
+	labels, err := encodeStringMap(cinfo.Labels)
+	if err != nil {
+		return labels, details, errors.Errorf("Unable to encode cluster labels: %v", err)
+	}
+	if len(cinfo.Details) > 0 {
+		details = sql.NullString{String: string(cinfo.Details), Valid: true}
+	}
+	return labels, details, nil
+}
+
 // updateClusterMetadata attempts update of entry in table clusters
 // returns SQLError on failure and PostFailure on cluster non-existence
 func (t *tornjakTxHelper) updateClusterMetadata(cinfo types.ClusterInfo) error {
-	cmdUpdate := `UPDATE clusters SET name=?, domain_name=?, managed_by=?, platform_type=? WHERE name=?`
+	cmdUpdate := `UPDATE clusters SET name=?, domain_name=?, managed_by=?, platform_type=?, labels=?, details=? WHERE name=?`
+	labels, details, err := encodeClusterLabelsDetails(cinfo)
+	if err != nil {
+		return err
+	}
 	statement, err := t.tx.PrepareContext(t.ctx, cmdUpdate)
 	if err != nil {
 		return SQLError{cmdUpdate, err}
 	}
 	defer statement.Close()
-	res, err := statement.ExecContext(t.ctx, cinfo.EditedName, cinfo.DomainName, cinfo.ManagedBy, cinfo.PlatformType, cinfo.Name)
+	res, err := statement.ExecContext(t.ctx, cinfo.EditedName, cinfo.DomainName, cinfo.ManagedBy, cinfo.PlatformType, labels, details, cinfo.Name)
 	if err != nil {
-		if serr, ok := err.(sqlite3.Error); ok && serr.Code == sqlite3.ErrConstraint {
+		if t.tx.dialect.isConstraintError(err) {
 			return PostFailure{"Cluster already exists; use Edit Cluster"}
 		}
 		return SQLError{cmdUpdate, err}
@@ -123,13 +144,13 @@ func (t *tornjakTxHelper) addAgentBatchToCluster(clustername string, agentsList
 		return nil
 	}
 	// Add into agents table
-	cmdAgents := "INSERT OR IGNORE INTO agents (spiffeid, plugin) VALUES "
+	cmdAgents := "INSERT INTO agents (spiffeid, plugin) VALUES "
 	agents := []interface{}{}
 	for i := 0; i < len(agentsList); i++ {
 		cmdAgents += "(?, NULL),"
 		agents = append(agents, agentsList[i])
 	}
-	cmdAgents = strings.TrimSuffix(cmdAgents, ",")
+	cmdAgents = t.tx.dialect.insertOrIgnore(strings.TrimSuffix(cmdAgents, ","))
 	statementAgentInsert, err := t.tx.PrepareContext(t.ctx, cmdAgents)
 	if err != nil {
 		return SQLError{cmdAgents, err}
@@ -139,8 +160,16 @@ func (t *tornjakTxHelper) addAgentBatchToCluster(clustername string, agentsList
 		return SQLError{cmdAgents, err}
 	}
 
+	// agents added to a cluster again are no longer tombstones
+	cmdRevive := "UPDATE agents SET tombstoned_at=NULL, tombstone_reason=NULL WHERE tombstoned_at IS NOT NULL AND spiffeid IN (" +
+		strings.TrimSuffix(strings.Repeat("?,", len(agentsList)), ",") + ")"
+	_, err = t.tx.ExecContext(t.ctx, cmdRevive, agents...)
+	if err != nil {
+		return SQLError{cmdRevive, err}
+	}
+
 	// generate single statement
-	cmdBatch := "INSERT OR ABORT INTO cluster_memberships (agent_id, cluster_id) VALUES "
+	cmdBatch := "INSERT INTO cluster_memberships (agent_id, cluster_id) VALUES "
 	vals := []interface{}{}
 	for i := 0; i < len(agentsList); i++ {
 		cmdBatch += "((SELECT id FROM agents WHERE spiffeid=?), (SELECT id FROM clusters WHERE name=?)),"
@@ -156,9 +185,9 @@ func (t *tornjakTxHelper) addAgentBatchToCluster(clustername string, agentsList
 	// execute single statement and check error
 	_, err = statementInsert.ExecContext(t.ctx, vals...)
 	if err != nil {
-		if serr, ok := err.(sqlite3.Error); ok && serr.Code == sqlite3.ErrConstraint {
+		if t.tx.dialect.isConstraintError(err) {
 			// TODO add more details of agent conflict?
-			return PostFailure{serr.Error()}
+			return PostFailure{err.Error()}
 		}
 		return SQLError{cmdBatch, err}
 	}
@@ -180,3 +209,51 @@ func (t *tornjakTxHelper) deleteClusterAgents(clustername string) error {
 	}
 	return nil
 }
+
+// deleteAgentMemberships attempts removal of the agent-cluster pair of the agent in clusterMemberships table
+// returns SQLError on failure
+func (t *tornjakTxHelper) deleteAgentMemberships(spiffeid string) error {
+	cmdDelete := "DELETE FROM cluster_memberships WHERE agent_id IN (SELECT id FROM agents WHERE spiffeid=?)"
+	statementDelete, err := t.tx.PrepareContext(t.ctx, cmdDelete)
+	if err != nil {
+		return SQLError{cmdDelete, err}
+	}
+	defer statementDelete.Close()
+	_, err = statementDelete.ExecContext(t.ctx, spiffeid)
+	if err != nil {
+		return SQLError{cmdDelete, err}
+	}
+	return nil
+}
+
+// deleteAgentMetadata attempts delete of entry in table agents
+// returns SQLError on failure
+func (t *tornjakTxHelper) deleteAgentMetadata(spiffeid string) error {
+	cmdDelete := "DELETE FROM agents WHERE spiffeid=?"
+	statementDelete, err := t.tx.PrepareContext(t.ctx, cmdDelete)
+	if err != nil {
+		return SQLError{cmdDelete, err}
+	}
+	defer statementDelete.Close()
+	_, err = statementDelete.ExecContext(t.ctx, spiffeid)
+	if err != nil {
+		return SQLError{cmdDelete, err}
+	}
+	return nil
+}
+
+// tombstoneAgentMetadata attempts marking entry in table agents as a tombstone, keeping the time of its first marking
+// returns SQLError on failure
+func (t *tornjakTxHelper) tombstoneAgentMetadata(spiffeid string, reason string) error {
+	cmdUpdate := "UPDATE agents SET tombstoned_at=COALESCE(tombstoned_at, ?), tombstone_reason=? WHERE spiffeid=?"
+	statementUpdate, err := t.tx.PrepareContext(t.ctx, cmdUpdate)
+	if err != nil {
+		return SQLError{cmdUpdate, err}
+	}
+	defer statementUpdate.Close()
+	_, err = statementUpdate.ExecContext(t.ctx, time.Now().UnixNano(), reason, spiffeid)
+	if err != nil {
+		return SQLError{cmdUpdate, err}
+	}
+	return nil
+}
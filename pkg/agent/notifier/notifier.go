@@ -0,0 +1,48 @@
+package notifier
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Event describes a mutation made through Tornjak
+type Event struct {
+	ID       string          `json:"id"`
+	Time     time.Time       `json:"time"`
+	Actor    Actor           `json:"actor"`
+	Action   string          `json:"action"`
+	Resource Resource        `json:"resource"`
+	Before   json.RawMessage `json:"before,omitempty"`
+	After    json.RawMessage `json:"after,omitempty"`
+}
+
+// Actor describes who made a mutation
+type Actor struct {
+	User       string   `json:"user,omitempty"`
+	Roles      []string `json:"roles,omitempty"`
+	RemoteAddr string   `json:"remoteAddr,omitempty"`
+	UID        *uint32  `json:"uid,omitempty"`
+	GID        *uint32  `json:"gid,omitempty"`
+	Job        string   `json:"job,omitempty"`
+}
+
+// Resource identifies the object a mutation was made to
+type Resource struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Info describes a configured notifier
+type Info struct {
+	Name   string   `json:"name"`
+	Type   string   `json:"type"`
+	Target string   `json:"target"`
+	Events []string `json:"events"`
+}
+
+type Notifier interface {
+	// Notify queues event for delivery without blocking the caller
+	Notify(event Event)
+	// Info describes the notifier
+	Info() Info
+}
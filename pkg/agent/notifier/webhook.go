@@ -0,0 +1,289 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/spiffe/tornjak/pkg/agent/types"
+)
+
+const (
+	SignatureHeader = "X-Tornjak-Signature"
+	EventHeader     = "X-Tornjak-Event"
+	DeliveryHeader  = "X-Tornjak-Delivery"
+
+	defaultMaxAttempts = 5
+	defaultTimeout     = 10 * time.Second
+	defaultQueueSize   = 1000
+	defaultWorkers     = 4
+
+	// maxBackoff caps the wait between attempts
+	maxBackoff = time.Minute
+)
+
+// DeliveryStore records the status of webhook deliveries
+type DeliveryStore interface {
+	CreateWebhookDelivery(delivery types.WebhookDelivery) error
+	UpdateWebhookDelivery(delivery types.WebhookDelivery) error
+	DeadLetterWebhookDelivery(delivery types.WebhookDelivery) error
+}
+
+type WebhookConfig struct {
+	Name   string
+	URL    string
+	Secret string
+	// Events lists the actions to send, such as "entry.create" or "entry.*"; all if empty
+	Events      []string
+	MaxAttempts int
+	Timeout     time.Duration
+	QueueSize   int
+	// Workers is the number of deliveries sent at once
+	Workers int
+	// InitialBackoff is the wait before the first retry, doubled on each retry
+	InitialBackoff time.Duration
+	// Owner is the Tornjak instance recorded as sending the deliveries
+	Owner string
+}
+
+// WebhookNotifier posts events as JSON to an HTTP endpoint, signed with
+// HMAC-SHA256, from a pool of workers. Failed deliveries are queued again
+// after an exponential backoff, so that they do not hold up other events;
+// deliveries that fail all attempts are moved to the dead letter table.
+type WebhookNotifier struct {
+	config WebhookConfig
+	store  DeliveryStore
+	client *http.Client
+	queue  chan types.WebhookDelivery
+}
+
+// Validate checks the fields of config that have no default
+func (config WebhookConfig) Validate() error {
+	if config.Name == "" {
+		return errors.New("webhook name not defined")
+	}
+	u, err := url.Parse(config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("invalid webhook url %q: expected http(s)://<host>/<path>", config.URL)
+	}
+	if config.Secret == "" {
+		return errors.New("webhook secret not defined")
+	}
+	return nil
+}
+
+func NewWebhookNotifier(config WebhookConfig, store DeliveryStore) (*WebhookNotifier, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaultMaxAttempts
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = defaultQueueSize
+	}
+	if config.Workers <= 0 {
+		config.Workers = defaultWorkers
+	}
+	if config.InitialBackoff <= 0 {
+		config.InitialBackoff = time.Second
+	}
+
+	n := &WebhookNotifier{
+		config: config,
+		store:  store,
+		client: &http.Client{Timeout: config.Timeout},
+		queue:  make(chan types.WebhookDelivery, config.QueueSize),
+	}
+	for i := 0; i < config.Workers; i++ {
+		go n.work()
+	}
+	return n, nil
+}
+
+func (n *WebhookNotifier) Info() Info {
+	events := n.config.Events
+	if events == nil {
+		events = []string{}
+	}
+	return Info{
+		Name:   n.config.Name,
+		Type:   "webhook",
+		Target: n.config.URL,
+		Events: events,
+	}
+}
+
+// wants reports whether the webhook subscribes to action
+func (n *WebhookNotifier) wants(action string) bool {
+	if len(n.config.Events) == 0 {
+		return true
+	}
+	for _, pattern := range n.config.Events {
+		if pattern == action || (strings.HasSuffix(pattern, ".*") && strings.HasPrefix(action, strings.TrimSuffix(pattern, "*"))) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *WebhookNotifier) Notify(event Event) {
+	if !n.wants(event.Action) {
+		return
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Webhook %s: could not encode event %s: %v", n.config.Name, event.ID, err)
+		return
+	}
+	now := time.Now()
+	delivery := types.WebhookDelivery{
+		ID:        newID(),
+		Webhook:   n.config.Name,
+		EventID:   event.ID,
+		Action:    event.Action,
+		Status:    types.WebhookPending,
+		Payload:   payload,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Owner:     n.config.Owner,
+	}
+	if err := n.store.CreateWebhookDelivery(delivery); err != nil {
+		log.Printf("Webhook %s: could not record delivery of event %s: %v", n.config.Name, event.ID, err)
+		return
+	}
+	n.enqueue(delivery)
+}
+
+// Resume queues a pending delivery recorded by a previous run, keeping its
+// attempts so far
+func (n *WebhookNotifier) Resume(delivery types.WebhookDelivery) {
+	n.enqueue(delivery)
+}
+
+// enqueue queues the delivery, or dead-letters it if the queue is full
+func (n *WebhookNotifier) enqueue(delivery types.WebhookDelivery) {
+	select {
+	case n.queue <- delivery:
+	default:
+		delivery.LastError = "delivery queue is full"
+		n.deadLetter(delivery)
+	}
+}
+
+func (n *WebhookNotifier) work() {
+	for delivery := range n.queue {
+		n.deliver(delivery)
+	}
+}
+
+// deliver posts the delivery once. A failed delivery is queued again after
+// its backoff, or dead-lettered once all attempts have failed.
+func (n *WebhookNotifier) deliver(delivery types.WebhookDelivery) {
+	delivery.Attempts++
+	code, err := n.post(delivery)
+	delivery.ResponseCode = code
+	delivery.UpdatedAt = time.Now()
+	if err == nil {
+		delivery.Status = types.WebhookDelivered
+		delivery.LastError = ""
+	} else {
+		delivery.LastError = err.Error()
+	}
+	if uerr := n.store.UpdateWebhookDelivery(delivery); uerr != nil {
+		log.Printf("Webhook %s: could not update delivery %s: %v", n.config.Name, delivery.ID, uerr)
+	}
+	if err == nil {
+		return
+	}
+
+	// client errors other than timeouts and rate limiting will not succeed on retry
+	permanent := code >= 400 && code < 500 && code != http.StatusRequestTimeout && code != http.StatusTooManyRequests
+	if permanent || delivery.Attempts >= n.config.MaxAttempts {
+		n.deadLetter(delivery)
+		return
+	}
+	time.AfterFunc(n.retryDelay(delivery.Attempts), func() {
+		n.queue <- delivery
+	})
+}
+
+// retryDelay returns the wait before the next attempt of a delivery, doubled
+// after each attempt
+func (n *WebhookNotifier) retryDelay(attempts int) time.Duration {
+	delay := n.config.InitialBackoff
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+func (n *WebhookNotifier) deadLetter(delivery types.WebhookDelivery) {
+	delivery.UpdatedAt = time.Now()
+	log.Printf("Webhook %s: giving up on delivery %s of event %s after %d attempts: %s",
+		n.config.Name, delivery.ID, delivery.EventID, delivery.Attempts, delivery.LastError)
+	if err := n.store.DeadLetterWebhookDelivery(delivery); err != nil {
+		log.Printf("Webhook %s: could not dead-letter delivery %s: %v", n.config.Name, delivery.ID, err)
+	}
+}
+
+// Sign returns the signature header value for a payload sent at the given
+// time: "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<unix seconds>.<payload>">"
+func Sign(secret string, timestamp time.Time, payload []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts + "."))
+	mac.Write(payload)
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// post sends the delivery once and returns the response status code
+func (n *WebhookNotifier) post(delivery types.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), n.config.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.config.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, delivery.Action)
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(n.config.Secret, time.Now(), delivery.Payload))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand does not fail on supported platforms
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,221 @@
+package notifier
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spiffe/tornjak/pkg/agent/types"
+)
+
+// memoryStore records deliveries in memory
+type memoryStore struct {
+	mu          sync.Mutex
+	deliveries  map[string]types.WebhookDelivery
+	deadLetters map[string]types.WebhookDelivery
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{deliveries: map[string]types.WebhookDelivery{}, deadLetters: map[string]types.WebhookDelivery{}}
+}
+
+func (m *memoryStore) CreateWebhookDelivery(delivery types.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (m *memoryStore) UpdateWebhookDelivery(delivery types.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+func (m *memoryStore) DeadLetterWebhookDelivery(delivery types.WebhookDelivery) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delivery.Status = types.WebhookDead
+	m.deliveries[delivery.ID] = delivery
+	m.deadLetters[delivery.ID] = delivery
+	return nil
+}
+
+// waitDelivery waits until the only delivery of the event is no longer
+// pending and returns it
+func (m *memoryStore) waitDelivery(t *testing.T, eventID string) types.WebhookDelivery {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		m.mu.Lock()
+		for _, delivery := range m.deliveries {
+			if delivery.EventID == eventID && delivery.Status != types.WebhookPending {
+				m.mu.Unlock()
+				return delivery
+			}
+		}
+		m.mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Delivery of event %s did not finish", eventID)
+	return types.WebhookDelivery{}
+}
+
+// validSignature checks sig against the payload, signed with "whsec_test"
+// at the time given in sig
+func validSignature(sig string, payload []byte) bool {
+	ts, _, ok := strings.Cut(strings.TrimPrefix(sig, "t="), ",")
+	if !ok {
+		return false
+	}
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	return err == nil && Sign("whsec_test", time.Unix(sec, 0), payload) == sig
+}
+
+func TestSign(t *testing.T) {
+	// HMAC-SHA256 of "1700000000.{"id":"e1"}" with the key "whsec_test"
+	want := "t=1700000000,v1=6a85cb117c993612a34c72b4cfb5a16baed25a80d26e298ce0e6671320fd07c8"
+	if got := Sign("whsec_test", time.Unix(1700000000, 0), []byte(`{"id":"e1"}`)); got != want {
+		t.Fatalf("Expected signature %s, got %s", want, got)
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	tests := []struct {
+		name         string
+		responses    []int
+		wantStatus   string
+		wantAttempts int
+		wantCode     int
+	}{
+		{name: "delivered", responses: []int{http.StatusNoContent}, wantStatus: types.WebhookDelivered, wantAttempts: 1, wantCode: http.StatusNoContent},
+		{name: "retried then delivered", responses: []int{http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK}, wantStatus: types.WebhookDelivered, wantAttempts: 3, wantCode: http.StatusOK},
+		{name: "permanent client error", responses: []int{http.StatusBadRequest}, wantStatus: types.WebhookDead, wantAttempts: 1, wantCode: http.StatusBadRequest},
+		{name: "all attempts failed", responses: []int{http.StatusInternalServerError}, wantStatus: types.WebhookDead, wantAttempts: 3, wantCode: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				requests int
+			)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if sig := r.Header.Get(SignatureHeader); !validSignature(sig, body) {
+					t.Errorf("Invalid signature %q of %s", sig, body)
+				}
+				if r.Header.Get(EventHeader) != "entry.create" || r.Header.Get(DeliveryHeader) == "" {
+					t.Errorf("Unexpected headers %v", r.Header)
+				}
+
+				mu.Lock()
+				code := tt.responses[min(requests, len(tt.responses)-1)]
+				requests++
+				mu.Unlock()
+				w.WriteHeader(code)
+			}))
+			defer server.Close()
+
+			store := newMemoryStore()
+			n, err := NewWebhookNotifier(WebhookConfig{
+				Name:           "hook",
+				URL:            server.URL,
+				Secret:         "whsec_test",
+				MaxAttempts:    3,
+				InitialBackoff: time.Millisecond,
+			}, store)
+			if err != nil {
+				t.Fatal(err)
+			}
+			n.Notify(Event{ID: "e1", Action: "entry.create"})
+
+			delivery := store.waitDelivery(t, "e1")
+			if delivery.Status != tt.wantStatus || delivery.Attempts != tt.wantAttempts || delivery.ResponseCode != tt.wantCode {
+				t.Fatalf("Expected %s after %d attempts with %d, got %+v", tt.wantStatus, tt.wantAttempts, tt.wantCode, delivery)
+			}
+			store.mu.Lock()
+			_, dead := store.deadLetters[delivery.ID]
+			store.mu.Unlock()
+			if dead != (tt.wantStatus == types.WebhookDead) {
+				t.Fatalf("Expected dead letter %t, got %t", tt.wantStatus == types.WebhookDead, dead)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if requests != tt.wantAttempts {
+				t.Fatalf("Expected %d requests, got %d", tt.wantAttempts, requests)
+			}
+		})
+	}
+}
+
+func TestWebhookRetryDoesNotBlock(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(EventHeader) == "entry.delete" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	store := newMemoryStore()
+	n, err := NewWebhookNotifier(WebhookConfig{
+		Name:   "hook",
+		URL:    server.URL,
+		Secret: "whsec_test",
+		// the failed delivery waits an hour for its retry
+		InitialBackoff: time.Hour,
+		Workers:        1,
+	}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n.Notify(Event{ID: "e1", Action: "entry.delete"})
+	n.Notify(Event{ID: "e2", Action: "entry.create"})
+
+	if delivery := store.waitDelivery(t, "e2"); delivery.Status != types.WebhookDelivered {
+		t.Fatalf("Expected the second event delivered, got %+v", delivery)
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	for _, delivery := range store.deliveries {
+		if delivery.EventID == "e1" && (delivery.Status != types.WebhookPending || delivery.Attempts != 1) {
+			t.Fatalf("Expected the first event pending after one attempt, got %+v", delivery)
+		}
+	}
+}
+
+func TestWebhookResume(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	store := newMemoryStore()
+	n, err := NewWebhookNotifier(WebhookConfig{
+		Name:           "hook",
+		URL:            server.URL,
+		Secret:         "whsec_test",
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a delivery interrupted after two attempts has one attempt left
+	delivery := types.WebhookDelivery{ID: "d1", Webhook: "hook", EventID: "e1", Action: "entry.create",
+		Status: types.WebhookPending, Attempts: 2, Payload: []byte(`{"id":"e1"}`)}
+	if err := store.CreateWebhookDelivery(delivery); err != nil {
+		t.Fatal(err)
+	}
+	n.Resume(delivery)
+
+	if delivery := store.waitDelivery(t, "e1"); delivery.Status != types.WebhookDead || delivery.Attempts != 3 {
+		t.Fatalf("Expected the delivery dead after its last attempt, got %+v", delivery)
+	}
+}
@@ -1,10 +1,33 @@
 package types
 
+import "time"
+
+// Reasons of agent tombstones
+const (
+	AgentTombstoneDeleted = "deleted"
+	AgentTombstoneBanned  = "banned"
+	// AgentTombstoneOrphaned marks agents found missing from SPIRE
+	AgentTombstoneOrphaned = "orphaned"
+)
+
 // AgentInfo contains the information about agents workload attestor plugin
 type AgentInfo struct {
-	Spiffeid string `json:"spiffeid"`
-	Plugin   string `json:"plugin"`
-	Cluster  string `json:"cluster"`
+	Spiffeid    string      `json:"spiffeid"`
+	Plugin      string      `json:"plugin"`
+	Cluster     string      `json:"cluster"`
+	Labels      Labels      `json:"labels,omitempty"`
+	Annotations Annotations `json:"annotations,omitempty"`
+	// TombstonedAt is set once the agent is deleted or banned in SPIRE, if
+	// its metadata is kept as a tombstone
+	TombstonedAt    *time.Time `json:"tombstonedAt,omitempty"`
+	TombstoneReason string     `json:"tombstoneReason,omitempty"`
+}
+
+// AgentLabels contains the labels and annotations of an agent
+type AgentLabels struct {
+	Spiffeid    string      `json:"spiffeid"`
+	Labels      Labels      `json:"labels"`
+	Annotations Annotations `json:"annotations"`
 }
 
 // AgentInfoList contains the information about agents workload attestor plugin
@@ -23,7 +46,10 @@ type AllAgentEntries struct {
 	Agents []AgentEntries `json:"agents"`
 }
 
-// AgentMetadataRequest contains a list of spiffeids
+// AgentMetadataRequest contains a list of spiffeids, and a label selector
+// applied by the API; tombstoned agents are left out unless requested
 type AgentMetadataRequest struct {
-	Agents []string `json:"agents"`
+	Agents            []string `json:"agents"`
+	LabelSelector     string   `json:"labelSelector,omitempty"`
+	IncludeTombstones bool     `json:"includeTombstones,omitempty"`
 }
@@ -0,0 +1,96 @@
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Audit decisions of the authorization layer and outcomes of the request
+const (
+	AuditAllow   = "allow"
+	AuditDeny    = "deny"
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditRecord records one API request. Records form a hash chain: each
+// record's Hash covers its content and the Hash of the record before it.
+type AuditRecord struct {
+	Seq        int64     `json:"seq"`
+	Time       time.Time `json:"time"`
+	User       string    `json:"user,omitempty"`
+	Roles      []string  `json:"roles"`
+	RemoteAddr string    `json:"remoteAddr"`
+	Method     string    `json:"method"`
+	Route      string    `json:"route"`
+	Path       string    `json:"path"`
+	Decision   string    `json:"decision"`
+	Reason     string    `json:"reason,omitempty"`
+	Resources  []string  `json:"resources"`
+	Status     int       `json:"status"`
+	Outcome    string    `json:"outcome"`
+	PrevHash   string    `json:"prevHash"`
+	Hash       string    `json:"hash"`
+}
+
+// Action returns the action of the record, "<METHOD> <route>"
+func (r AuditRecord) Action() string {
+	return r.Method + " " + r.Route
+}
+
+// Normalize puts the record in the form it is stored in: times in UTC with
+// nanosecond precision and empty rather than nil lists
+func (r *AuditRecord) Normalize() {
+	r.Time = time.Unix(0, r.Time.UnixNano()).UTC()
+	if r.Roles == nil {
+		r.Roles = []string{}
+	}
+	if r.Resources == nil {
+		r.Resources = []string{}
+	}
+}
+
+// ComputeHash returns the hex SHA-256 of the JSON encoding of the
+// normalized record, with PrevHash set and Hash empty
+func (r AuditRecord) ComputeHash() (string, error) {
+	r.Normalize()
+	r.Hash = ""
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditFilter selects audit records; zero fields match all records
+type AuditFilter struct {
+	Since    time.Time
+	Until    time.Time
+	User     string
+	Action   string
+	Resource string
+	Decision string
+	Limit    int
+}
+
+// AuditRecordList contains a list of audit records
+type AuditRecordList struct {
+	Records []AuditRecord `json:"records"`
+}
+
+// AuditVerification is the result of checking the audit hash chain
+type AuditVerification struct {
+	Valid bool `json:"valid"`
+	// Checked is the number of records checked
+	Checked int64 `json:"checked"`
+	// FirstInvalidSeq is the first record whose hash or link does not match, 0 if valid
+	FirstInvalidSeq int64  `json:"firstInvalidSeq,omitempty"`
+	Error           string `json:"error,omitempty"`
+	// HeadSeq and HeadHash identify the latest record; keeping them outside
+	// Tornjak allows detecting a rewrite of the whole chain
+	HeadSeq  int64  `json:"headSeq"`
+	HeadHash string `json:"headHash"`
+}
@@ -1,7 +1,8 @@
 package types
 
+import "encoding/json"
+
 // ClusterInfo contains the meta-information about clusters
-// TODO include details field for extra info/tags in json format (probably a byte array)
 type ClusterInfo struct {
 	Name         string   `json:"name"`
 	EditedName   string   `json:"editedName"`
@@ -10,6 +11,10 @@ type ClusterInfo struct {
 	ManagedBy    string   `json:"managedBy"`
 	PlatformType string   `json:"platformType"`
 	AgentsList   []string `json:"agentsList"`
+	// Labels can be selected on when listing clusters
+	Labels Labels `json:"labels,omitempty"`
+	// Details is a free-form JSON object
+	Details json.RawMessage `json:"details,omitempty"`
 }
 
 type ClusterInput struct {
@@ -0,0 +1,20 @@
+package types
+
+import "time"
+
+// EntryMetadata is kept by Tornjak about a SPIRE registration entry, which
+// has no place for ownership or description
+type EntryMetadata struct {
+	EntryID     string    `json:"entryId"`
+	Owner       string    `json:"owner,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Ticket      string    `json:"ticket,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	CreatedBy   string    `json:"createdBy,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// EntryMetadataList contains the metadata of entries
+type EntryMetadataList struct {
+	Metadata []EntryMetadata `json:"metadata"`
+}
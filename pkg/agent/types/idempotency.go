@@ -0,0 +1,17 @@
+package types
+
+// IdempotencyRecord contains the response stored for an Idempotency-Key
+// StatusCode is 0 while the original request is still being processed
+type IdempotencyRecord struct {
+	Key         string
+	Path        string
+	RequestHash string
+	// Principal identifies the authenticated caller of the original request;
+	// keys are scoped to it, so the response is only replayed to that caller
+	Principal   string
+	StatusCode  int
+	ContentType string
+	Location    string
+	Response    []byte
+	CreatedAt   int64
+}
@@ -0,0 +1,45 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Job statuses; queued and running jobs are unfinished
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCanceled  = "canceled"
+)
+
+// JobInfo contains the state and progress of an asynchronous job
+type JobInfo struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Status    string          `json:"status"`
+	Params    json.RawMessage `json:"params"`
+	Total     int             `json:"total"`
+	Processed int             `json:"processed"`
+	Failed    int             `json:"failed"`
+	Failures  []JobFailure    `json:"failures"`
+	Error     string          `json:"error,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	UpdatedAt time.Time       `json:"updatedAt"`
+	// Owner is the Tornjak instance running the job; CancelRequested is set
+	// when another instance is asked to cancel it
+	Owner           string `json:"owner,omitempty"`
+	CancelRequested bool   `json:"cancelRequested,omitempty"`
+}
+
+// JobFailure records an item of a job that could not be processed
+type JobFailure struct {
+	Item  string `json:"item"`
+	Error string `json:"error"`
+}
+
+// JobInfoList contains a list of jobs
+type JobInfoList struct {
+	Jobs []JobInfo `json:"jobs"`
+}
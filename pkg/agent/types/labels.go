@@ -0,0 +1,221 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Labels are key/value pairs organising clusters and agents, following the
+// syntax of Kubernetes labels so that they can be selected by a LabelSelector
+type Labels map[string]string
+
+var (
+	labelKeyRegexp   = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+	labelValueRegexp = regexp.MustCompile(`^([A-Za-z0-9]([A-Za-z0-9._-]*[A-Za-z0-9])?)?$`)
+)
+
+const (
+	maxLabelKeyLength   = 253
+	maxLabelValueLength = 63
+)
+
+// Validate checks that keys and values are valid: keys of at most 253 and
+// values of at most 63 alphanumeric characters, '-', '_' or '.', starting and
+// ending with an alphanumeric character; keys may also contain '/'
+func (l Labels) Validate() error {
+	for key, value := range l {
+		if err := validateLabelKey(key); err != nil {
+			return err
+		}
+		if err := validateLabelValue(value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateLabelKey(key string) error {
+	if len(key) > maxLabelKeyLength || !labelKeyRegexp.MatchString(key) {
+		return fmt.Errorf("invalid label key %q", key)
+	}
+	return nil
+}
+
+func validateLabelValue(value string) error {
+	if len(value) > maxLabelValueLength || !labelValueRegexp.MatchString(value) {
+		return fmt.Errorf("invalid label value %q", value)
+	}
+	return nil
+}
+
+// Annotations are key/value pairs holding free-form notes, not selectable;
+// keys follow the syntax of label keys
+type Annotations map[string]string
+
+// Validate checks that keys are valid label keys
+func (a Annotations) Validate() error {
+	for key := range a {
+		if err := validateLabelKey(key); err != nil {
+			return fmt.Errorf("invalid annotation key %q", key)
+		}
+	}
+	return nil
+}
+
+// Label selector operators
+const (
+	SelectorEquals       = "="
+	SelectorNotEquals    = "!="
+	SelectorIn           = "in"
+	SelectorNotIn        = "notin"
+	SelectorExists       = "exists"
+	SelectorDoesNotExist = "!"
+)
+
+// LabelRequirement is a condition on the value of a label
+type LabelRequirement struct {
+	Key      string
+	Operator string
+	Values   []string
+}
+
+// Matches reports whether labels satisfy the requirement. A label that is not
+// set satisfies != and notin.
+func (r LabelRequirement) Matches(labels Labels) bool {
+	value, ok := labels[r.Key]
+	switch r.Operator {
+	case SelectorExists:
+		return ok
+	case SelectorDoesNotExist:
+		return !ok
+	case SelectorEquals, SelectorIn:
+		return ok && containsString(r.Values, value)
+	case SelectorNotEquals, SelectorNotIn:
+		return !ok || !containsString(r.Values, value)
+	}
+	return false
+}
+
+// LabelSelector selects the labels satisfying all of its requirements; the
+// empty selector selects everything
+type LabelSelector []LabelRequirement
+
+// Matches reports whether labels satisfy all requirements of the selector
+func (s LabelSelector) Matches(labels Labels) bool {
+	for _, r := range s {
+		if !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseLabelSelector parses a comma separated list of requirements, in the
+// syntax of Kubernetes label selectors:
+//
+//	key=value, key==value, key!=value, key in (v1,v2), key notin (v1,v2),
+//	key (the label is set), !key (the label is not set)
+func ParseLabelSelector(selector string) (LabelSelector, error) {
+	var parsed LabelSelector
+	for _, term := range splitSelector(selector) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			if strings.TrimSpace(selector) == "" {
+				break
+			}
+			return nil, fmt.Errorf("invalid label selector %q: empty requirement", selector)
+		}
+		r, err := parseLabelRequirement(term)
+		if err != nil {
+			return nil, fmt.Errorf("invalid label selector %q: %v", selector, err)
+		}
+		parsed = append(parsed, r)
+	}
+	return parsed, nil
+}
+
+// splitSelector splits selector on the commas outside of parentheses
+func splitSelector(selector string) []string {
+	var terms []string
+	depth, start := 0, 0
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, selector[start:])
+}
+
+func parseLabelRequirement(term string) (LabelRequirement, error) {
+	if strings.HasPrefix(term, "!") {
+		key := strings.TrimSpace(term[1:])
+		return LabelRequirement{Key: key, Operator: SelectorDoesNotExist}, validateLabelKey(key)
+	}
+	for _, op := range []string{"!=", "==", "="} {
+		if i := strings.Index(term, op); i >= 0 {
+			key := strings.TrimSpace(term[:i])
+			value := strings.TrimSpace(term[i+len(op):])
+			if err := validateLabelKey(key); err != nil {
+				return LabelRequirement{}, err
+			}
+			if err := validateLabelValue(value); err != nil {
+				return LabelRequirement{}, err
+			}
+			operator := SelectorEquals
+			if op == "!=" {
+				operator = SelectorNotEquals
+			}
+			return LabelRequirement{Key: key, Operator: operator, Values: []string{value}}, nil
+		}
+	}
+	fields := strings.Fields(term)
+	if len(fields) == 1 {
+		return LabelRequirement{Key: fields[0], Operator: SelectorExists}, validateLabelKey(fields[0])
+	}
+
+	// set based requirement: key in (values) or key notin (values)
+	key, rest := fields[0], strings.TrimSpace(strings.TrimPrefix(term, fields[0]))
+	var operator string
+	switch {
+	case strings.HasPrefix(rest, SelectorNotIn):
+		operator = SelectorNotIn
+	case strings.HasPrefix(rest, SelectorIn):
+		operator = SelectorIn
+	default:
+		return LabelRequirement{}, fmt.Errorf("unknown operator in %q", term)
+	}
+	list := strings.TrimSpace(strings.TrimPrefix(rest, operator))
+	if !strings.HasPrefix(list, "(") || !strings.HasSuffix(list, ")") {
+		return LabelRequirement{}, fmt.Errorf("expected a parenthesized list of values in %q", term)
+	}
+	if err := validateLabelKey(key); err != nil {
+		return LabelRequirement{}, err
+	}
+	values := []string{}
+	for _, value := range strings.Split(list[1:len(list)-1], ",") {
+		value = strings.TrimSpace(value)
+		if err := validateLabelValue(value); err != nil {
+			return LabelRequirement{}, err
+		}
+		values = append(values, value)
+	}
+	return LabelRequirement{Key: key, Operator: operator, Values: values}, nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, elem := range list {
+		if elem == s {
+			return true
+		}
+	}
+	return false
+}
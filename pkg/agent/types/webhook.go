@@ -0,0 +1,51 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook delivery statuses; pending deliveries are still being attempted
+const (
+	WebhookPending   = "pending"
+	WebhookDelivered = "delivered"
+	WebhookDead      = "dead"
+)
+
+// WebhookDelivery tracks the delivery of one event to one webhook
+type WebhookDelivery struct {
+	ID           string          `json:"id"`
+	Webhook      string          `json:"webhook"`
+	EventID      string          `json:"eventId"`
+	Action       string          `json:"action"`
+	Status       string          `json:"status"`
+	Attempts     int             `json:"attempts"`
+	ResponseCode int             `json:"responseCode,omitempty"`
+	LastError    string          `json:"lastError,omitempty"`
+	Payload      json.RawMessage `json:"-"`
+	CreatedAt    time.Time       `json:"createdAt"`
+	UpdatedAt    time.Time       `json:"updatedAt"`
+	// Owner is the Tornjak instance sending the delivery
+	Owner string `json:"owner,omitempty"`
+}
+
+// WebhookDeadLetter keeps the payload of a delivery that failed all attempts
+type WebhookDeadLetter struct {
+	DeliveryID string          `json:"deliveryId"`
+	Webhook    string          `json:"webhook"`
+	EventID    string          `json:"eventId"`
+	Action     string          `json:"action"`
+	Payload    json.RawMessage `json:"payload"`
+	Error      string          `json:"error"`
+	CreatedAt  time.Time       `json:"createdAt"`
+}
+
+// WebhookDeliveryList contains a list of webhook deliveries
+type WebhookDeliveryList struct {
+	Deliveries []WebhookDelivery `json:"deliveries"`
+}
+
+// WebhookDeadLetterList contains a list of dead letters
+type WebhookDeadLetterList struct {
+	DeadLetters []WebhookDeadLetter `json:"deadLetters"`
+}
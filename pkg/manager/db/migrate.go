@@ -0,0 +1,78 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// schema version table with one row per migration applied to the database
+const initSchemaVersionTable = "CREATE TABLE IF NOT EXISTS schema_version (version INTEGER PRIMARY KEY, description TEXT, applied_at INTEGER)"
+
+// migration is a change of the schema of the database, applied in order of
+// version in a transaction
+type migration struct {
+	version     int
+	description string
+	statements  []string
+}
+
+// migrations of the manager database; version 1 creates the tables if they
+// do not exist, adopting databases created before schema versions were
+// recorded
+var migrations = []migration{
+	{version: 1, description: "create tables", statements: []string{initServersTable}},
+}
+
+// SchemaVersionError is returned for a database migrated by a newer Tornjak
+// manager, whose schema this binary does not understand
+type SchemaVersionError struct {
+	Version int
+	Latest  int
+}
+
+func (e SchemaVersionError) Error() string {
+	return fmt.Sprintf("DB schema version %d is newer than version %d supported by this Tornjak manager; upgrade Tornjak", e.Version, e.Latest)
+}
+
+// migrate applies the pending migrations to database
+func migrate(database *sql.DB) error {
+	if _, err := database.Exec(initSchemaVersionTable); err != nil {
+		return errors.Errorf("Unable to execute SQL query :%v", initSchemaVersionTable)
+	}
+	var version sql.NullInt64
+	if err := database.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version); err != nil {
+		return errors.Errorf("Unable to read DB schema version: %v", err)
+	}
+	latest := migrations[len(migrations)-1].version
+	if int(version.Int64) > latest {
+		return SchemaVersionError{Version: int(version.Int64), Latest: latest}
+	}
+
+	for _, m := range migrations {
+		if m.version <= int(version.Int64) {
+			continue
+		}
+		tx, err := database.Begin()
+		if err != nil {
+			return errors.Errorf("Unable to begin migration %d: %v", m.version, err)
+		}
+		for _, cmd := range m.statements {
+			if _, err := tx.Exec(cmd); err != nil {
+				tx.Rollback()
+				return errors.Errorf("Migration %d (%s) failed: %v", m.version, m.description, err)
+			}
+		}
+		if _, err := tx.Exec("INSERT INTO schema_version (version, description, applied_at) VALUES (?,?,?)",
+			m.version, m.description, time.Now().UnixNano()); err != nil {
+			tx.Rollback()
+			return errors.Errorf("Migration %d (%s) failed: %v", m.version, m.description, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return errors.Errorf("Migration %d (%s) failed: %v", m.version, m.description, err)
+		}
+	}
+	return nil
+}
@@ -23,14 +23,10 @@ func NewLocalSqliteDB(dbpath string) (ManagerDB, error) {
 	if err != nil {
 		return nil, errors.New("Unable to open connection to DB")
 	}
-	// Table for servers
-	statement, err := database.Prepare(initServersTable)
-	if err != nil {
-		return nil, errors.Errorf("Unable to execute SQL query :%v", initServersTable)
-	}
-	_, err = statement.Exec()
-	if err != nil {
-		return nil, errors.Errorf("Unable to execute SQL query :%v", initServersTable)
+	// Tables, created or migrated to the schema of this binary
+	if err := migrate(database); err != nil {
+		database.Close()
+		return nil, err
 	}
 
 	return &LocalSqliteDb{
@@ -47,3 +47,36 @@ func TestServerCreate(t *testing.T) {
 		t.Fatal("Server list should initially be empty")
 	}
 }
+
+func TestSchemaVersion(t *testing.T) {
+	defer cleanup()
+	db, err := NewLocalSqliteDB("./local-test-db")
+	if err != nil {
+		t.Fatal(err)
+	}
+	database := db.(*LocalSqliteDb).database
+
+	var version int
+	if err := database.QueryRow("SELECT MAX(version) FROM schema_version").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	latest := migrations[len(migrations)-1].version
+	if version != latest {
+		t.Fatalf("Schema version should be %d, got %d", latest, version)
+	}
+
+	// reopening does not reapply migrations
+	if _, err := NewLocalSqliteDB("./local-test-db"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a schema newer than this binary is refused
+	if _, err := database.Exec("INSERT INTO schema_version (version, description, applied_at) VALUES (?,?,?)", latest+1, "from the future", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewLocalSqliteDB("./local-test-db"); err == nil {
+		t.Fatal("Newer schema should be refused")
+	} else if _, ok := err.(SchemaVersionError); !ok {
+		t.Fatalf("Newer schema should be refused with SchemaVersionError, got %v", err)
+	}
+}
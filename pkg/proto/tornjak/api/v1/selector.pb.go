@@ -0,0 +1,521 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: tornjak/api/v1/selector.proto
+
+package tornjakv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AgentInfo struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// The agent SPIFFE ID.
+	SpiffeId string `protobuf:"bytes,1,opt,name=spiffe_id,json=spiffeId,proto3" json:"spiffe_id,omitempty"`
+	// The agent workload attestor plugin.
+	Plugin string `protobuf:"bytes,2,opt,name=plugin,proto3" json:"plugin,omitempty"`
+	// The cluster the agent is assigned to, if any.
+	Cluster string `protobuf:"bytes,3,opt,name=cluster,proto3" json:"cluster,omitempty"`
+}
+
+func (x *AgentInfo) Reset() {
+	*x = AgentInfo{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tornjak_api_v1_selector_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *AgentInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentInfo) ProtoMessage() {}
+
+func (x *AgentInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_tornjak_api_v1_selector_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentInfo.ProtoReflect.Descriptor instead.
+func (*AgentInfo) Descriptor() ([]byte, []int) {
+	return file_tornjak_api_v1_selector_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AgentInfo) GetSpiffeId() string {
+	if x != nil {
+		return x.SpiffeId
+	}
+	return ""
+}
+
+func (x *AgentInfo) GetPlugin() string {
+	if x != nil {
+		return x.Plugin
+	}
+	return ""
+}
+
+func (x *AgentInfo) GetCluster() string {
+	if x != nil {
+		return x.Cluster
+	}
+	return ""
+}
+
+type ListSelectorsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListSelectorsRequest) Reset() {
+	*x = ListSelectorsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tornjak_api_v1_selector_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListSelectorsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSelectorsRequest) ProtoMessage() {}
+
+func (x *ListSelectorsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tornjak_api_v1_selector_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSelectorsRequest.ProtoReflect.Descriptor instead.
+func (*ListSelectorsRequest) Descriptor() ([]byte, []int) {
+	return file_tornjak_api_v1_selector_proto_rawDescGZIP(), []int{1}
+}
+
+type ListSelectorsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Agents []*AgentInfo `protobuf:"bytes,1,rep,name=agents,proto3" json:"agents,omitempty"`
+}
+
+func (x *ListSelectorsResponse) Reset() {
+	*x = ListSelectorsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tornjak_api_v1_selector_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListSelectorsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSelectorsResponse) ProtoMessage() {}
+
+func (x *ListSelectorsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tornjak_api_v1_selector_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSelectorsResponse.ProtoReflect.Descriptor instead.
+func (*ListSelectorsResponse) Descriptor() ([]byte, []int) {
+	return file_tornjak_api_v1_selector_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListSelectorsResponse) GetAgents() []*AgentInfo {
+	if x != nil {
+		return x.Agents
+	}
+	return nil
+}
+
+type DefineSelectorRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Required. The agent SPIFFE ID.
+	SpiffeId string `protobuf:"bytes,1,opt,name=spiffe_id,json=spiffeId,proto3" json:"spiffe_id,omitempty"`
+	// The agent workload attestor plugin.
+	Plugin string `protobuf:"bytes,2,opt,name=plugin,proto3" json:"plugin,omitempty"`
+}
+
+func (x *DefineSelectorRequest) Reset() {
+	*x = DefineSelectorRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tornjak_api_v1_selector_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DefineSelectorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DefineSelectorRequest) ProtoMessage() {}
+
+func (x *DefineSelectorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tornjak_api_v1_selector_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DefineSelectorRequest.ProtoReflect.Descriptor instead.
+func (*DefineSelectorRequest) Descriptor() ([]byte, []int) {
+	return file_tornjak_api_v1_selector_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DefineSelectorRequest) GetSpiffeId() string {
+	if x != nil {
+		return x.SpiffeId
+	}
+	return ""
+}
+
+func (x *DefineSelectorRequest) GetPlugin() string {
+	if x != nil {
+		return x.Plugin
+	}
+	return ""
+}
+
+type ListAgentMetadataRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// SPIFFE IDs of the agents to return. All agents are returned if empty.
+	SpiffeIds []string `protobuf:"bytes,1,rep,name=spiffe_ids,json=spiffeIds,proto3" json:"spiffe_ids,omitempty"`
+}
+
+func (x *ListAgentMetadataRequest) Reset() {
+	*x = ListAgentMetadataRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tornjak_api_v1_selector_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListAgentMetadataRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAgentMetadataRequest) ProtoMessage() {}
+
+func (x *ListAgentMetadataRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_tornjak_api_v1_selector_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAgentMetadataRequest.ProtoReflect.Descriptor instead.
+func (*ListAgentMetadataRequest) Descriptor() ([]byte, []int) {
+	return file_tornjak_api_v1_selector_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListAgentMetadataRequest) GetSpiffeIds() []string {
+	if x != nil {
+		return x.SpiffeIds
+	}
+	return nil
+}
+
+type ListAgentMetadataResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Agents []*AgentInfo `protobuf:"bytes,1,rep,name=agents,proto3" json:"agents,omitempty"`
+}
+
+func (x *ListAgentMetadataResponse) Reset() {
+	*x = ListAgentMetadataResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_tornjak_api_v1_selector_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListAgentMetadataResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAgentMetadataResponse) ProtoMessage() {}
+
+func (x *ListAgentMetadataResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_tornjak_api_v1_selector_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAgentMetadataResponse.ProtoReflect.Descriptor instead.
+func (*ListAgentMetadataResponse) Descriptor() ([]byte, []int) {
+	return file_tornjak_api_v1_selector_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListAgentMetadataResponse) GetAgents() []*AgentInfo {
+	if x != nil {
+		return x.Agents
+	}
+	return nil
+}
+
+var File_tornjak_api_v1_selector_proto protoreflect.FileDescriptor
+
+var file_tornjak_api_v1_selector_proto_rawDesc = []byte{
+	0x0a, 0x1d, 0x74, 0x6f, 0x72, 0x6e, 0x6a, 0x61, 0x6b, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31,
+	0x2f, 0x73, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12,
+	0x0e, 0x74, 0x6f, 0x72, 0x6e, 0x6a, 0x61, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x1a,
+	0x1b, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2f, 0x65, 0x6d, 0x70, 0x74, 0x79, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x5a, 0x0a, 0x09,
+	0x41, 0x67, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x70, 0x69,
+	0x66, 0x66, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x70,
+	0x69, 0x66, 0x66, 0x65, 0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e,
+	0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x12, 0x18,
+	0x0a, 0x07, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x07, 0x63, 0x6c, 0x75, 0x73, 0x74, 0x65, 0x72, 0x22, 0x16, 0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74,
+	0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x22, 0x4a, 0x0a, 0x15, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x31, 0x0a, 0x06, 0x61, 0x67, 0x65,
+	0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x74, 0x6f, 0x72, 0x6e,
+	0x6a, 0x61, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x41, 0x67, 0x65, 0x6e, 0x74,
+	0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x73, 0x22, 0x4c, 0x0a, 0x15,
+	0x44, 0x65, 0x66, 0x69, 0x6e, 0x65, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x73, 0x70, 0x69, 0x66, 0x66, 0x65, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x73, 0x70, 0x69, 0x66, 0x66, 0x65,
+	0x49, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x06, 0x70, 0x6c, 0x75, 0x67, 0x69, 0x6e, 0x22, 0x39, 0x0a, 0x18, 0x4c, 0x69,
+	0x73, 0x74, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x70, 0x69, 0x66, 0x66, 0x65,
+	0x5f, 0x69, 0x64, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x09, 0x73, 0x70, 0x69, 0x66,
+	0x66, 0x65, 0x49, 0x64, 0x73, 0x22, 0x4e, 0x0a, 0x19, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x67, 0x65,
+	0x6e, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x31, 0x0a, 0x06, 0x61, 0x67, 0x65, 0x6e, 0x74, 0x73, 0x18, 0x01, 0x20, 0x03,
+	0x28, 0x0b, 0x32, 0x19, 0x2e, 0x74, 0x6f, 0x72, 0x6e, 0x6a, 0x61, 0x6b, 0x2e, 0x61, 0x70, 0x69,
+	0x2e, 0x76, 0x31, 0x2e, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x06, 0x61,
+	0x67, 0x65, 0x6e, 0x74, 0x73, 0x32, 0xaa, 0x02, 0x0a, 0x0f, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74,
+	0x6f, 0x72, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x5c, 0x0a, 0x0d, 0x4c, 0x69, 0x73,
+	0x74, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x12, 0x24, 0x2e, 0x74, 0x6f, 0x72,
+	0x6e, 0x6a, 0x61, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74,
+	0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x25, 0x2e, 0x74, 0x6f, 0x72, 0x6e, 0x6a, 0x61, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76,
+	0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x73, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x4f, 0x0a, 0x0e, 0x44, 0x65, 0x66, 0x69, 0x6e,
+	0x65, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x12, 0x25, 0x2e, 0x74, 0x6f, 0x72, 0x6e,
+	0x6a, 0x61, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x66, 0x69, 0x6e,
+	0x65, 0x53, 0x65, 0x6c, 0x65, 0x63, 0x74, 0x6f, 0x72, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x16, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x45, 0x6d, 0x70, 0x74, 0x79, 0x12, 0x68, 0x0a, 0x11, 0x4c, 0x69, 0x73, 0x74,
+	0x41, 0x67, 0x65, 0x6e, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x12, 0x28, 0x2e,
+	0x74, 0x6f, 0x72, 0x6e, 0x6a, 0x61, 0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x4c,
+	0x69, 0x73, 0x74, 0x41, 0x67, 0x65, 0x6e, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x29, 0x2e, 0x74, 0x6f, 0x72, 0x6e, 0x6a, 0x61,
+	0x6b, 0x2e, 0x61, 0x70, 0x69, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x41, 0x67, 0x65,
+	0x6e, 0x74, 0x4d, 0x65, 0x74, 0x61, 0x64, 0x61, 0x74, 0x61, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x42, 0x3e, 0x5a, 0x3c, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x73, 0x70, 0x69, 0x66, 0x66, 0x65, 0x2f, 0x74, 0x6f, 0x72, 0x6e, 0x6a, 0x61, 0x6b, 0x2f,
+	0x70, 0x6b, 0x67, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x74, 0x6f, 0x72, 0x6e, 0x6a, 0x61,
+	0x6b, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x76, 0x31, 0x3b, 0x74, 0x6f, 0x72, 0x6e, 0x6a, 0x61, 0x6b,
+	0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_tornjak_api_v1_selector_proto_rawDescOnce sync.Once
+	file_tornjak_api_v1_selector_proto_rawDescData = file_tornjak_api_v1_selector_proto_rawDesc
+)
+
+func file_tornjak_api_v1_selector_proto_rawDescGZIP() []byte {
+	file_tornjak_api_v1_selector_proto_rawDescOnce.Do(func() {
+		file_tornjak_api_v1_selector_proto_rawDescData = protoimpl.X.CompressGZIP(file_tornjak_api_v1_selector_proto_rawDescData)
+	})
+	return file_tornjak_api_v1_selector_proto_rawDescData
+}
+
+var file_tornjak_api_v1_selector_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_tornjak_api_v1_selector_proto_goTypes = []any{
+	(*AgentInfo)(nil),                 // 0: tornjak.api.v1.AgentInfo
+	(*ListSelectorsRequest)(nil),      // 1: tornjak.api.v1.ListSelectorsRequest
+	(*ListSelectorsResponse)(nil),     // 2: tornjak.api.v1.ListSelectorsResponse
+	(*DefineSelectorRequest)(nil),     // 3: tornjak.api.v1.DefineSelectorRequest
+	(*ListAgentMetadataRequest)(nil),  // 4: tornjak.api.v1.ListAgentMetadataRequest
+	(*ListAgentMetadataResponse)(nil), // 5: tornjak.api.v1.ListAgentMetadataResponse
+	(*emptypb.Empty)(nil),             // 6: google.protobuf.Empty
+}
+var file_tornjak_api_v1_selector_proto_depIdxs = []int32{
+	0, // 0: tornjak.api.v1.ListSelectorsResponse.agents:type_name -> tornjak.api.v1.AgentInfo
+	0, // 1: tornjak.api.v1.ListAgentMetadataResponse.agents:type_name -> tornjak.api.v1.AgentInfo
+	1, // 2: tornjak.api.v1.SelectorService.ListSelectors:input_type -> tornjak.api.v1.ListSelectorsRequest
+	3, // 3: tornjak.api.v1.SelectorService.DefineSelector:input_type -> tornjak.api.v1.DefineSelectorRequest
+	4, // 4: tornjak.api.v1.SelectorService.ListAgentMetadata:input_type -> tornjak.api.v1.ListAgentMetadataRequest
+	2, // 5: tornjak.api.v1.SelectorService.ListSelectors:output_type -> tornjak.api.v1.ListSelectorsResponse
+	6, // 6: tornjak.api.v1.SelectorService.DefineSelector:output_type -> google.protobuf.Empty
+	5, // 7: tornjak.api.v1.SelectorService.ListAgentMetadata:output_type -> tornjak.api.v1.ListAgentMetadataResponse
+	5, // [5:8] is the sub-list for method output_type
+	2, // [2:5] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_tornjak_api_v1_selector_proto_init() }
+func file_tornjak_api_v1_selector_proto_init() {
+	if File_tornjak_api_v1_selector_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_tornjak_api_v1_selector_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*AgentInfo); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tornjak_api_v1_selector_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*ListSelectorsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tornjak_api_v1_selector_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*ListSelectorsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tornjak_api_v1_selector_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*DefineSelectorRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tornjak_api_v1_selector_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*ListAgentMetadataRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_tornjak_api_v1_selector_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*ListAgentMetadataResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_tornjak_api_v1_selector_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_tornjak_api_v1_selector_proto_goTypes,
+		DependencyIndexes: file_tornjak_api_v1_selector_proto_depIdxs,
+		MessageInfos:      file_tornjak_api_v1_selector_proto_msgTypes,
+	}.Build()
+	File_tornjak_api_v1_selector_proto = out.File
+	file_tornjak_api_v1_selector_proto_rawDesc = nil
+	file_tornjak_api_v1_selector_proto_goTypes = nil
+	file_tornjak_api_v1_selector_proto_depIdxs = nil
+}
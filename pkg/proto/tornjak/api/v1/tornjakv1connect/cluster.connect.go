@@ -0,0 +1,266 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: tornjak/api/v1/cluster.proto
+
+package tornjakv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	v1 "github.com/spiffe/tornjak/pkg/proto/tornjak/api/v1"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// ClusterServiceName is the fully-qualified name of the ClusterService service.
+	ClusterServiceName = "tornjak.api.v1.ClusterService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// ClusterServiceListClustersProcedure is the fully-qualified name of the ClusterService's
+	// ListClusters RPC.
+	ClusterServiceListClustersProcedure = "/tornjak.api.v1.ClusterService/ListClusters"
+	// ClusterServiceGetClusterProcedure is the fully-qualified name of the ClusterService's GetCluster
+	// RPC.
+	ClusterServiceGetClusterProcedure = "/tornjak.api.v1.ClusterService/GetCluster"
+	// ClusterServiceCreateClusterProcedure is the fully-qualified name of the ClusterService's
+	// CreateCluster RPC.
+	ClusterServiceCreateClusterProcedure = "/tornjak.api.v1.ClusterService/CreateCluster"
+	// ClusterServiceUpdateClusterProcedure is the fully-qualified name of the ClusterService's
+	// UpdateCluster RPC.
+	ClusterServiceUpdateClusterProcedure = "/tornjak.api.v1.ClusterService/UpdateCluster"
+	// ClusterServiceDeleteClusterProcedure is the fully-qualified name of the ClusterService's
+	// DeleteCluster RPC.
+	ClusterServiceDeleteClusterProcedure = "/tornjak.api.v1.ClusterService/DeleteCluster"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	clusterServiceServiceDescriptor             = v1.File_tornjak_api_v1_cluster_proto.Services().ByName("ClusterService")
+	clusterServiceListClustersMethodDescriptor  = clusterServiceServiceDescriptor.Methods().ByName("ListClusters")
+	clusterServiceGetClusterMethodDescriptor    = clusterServiceServiceDescriptor.Methods().ByName("GetCluster")
+	clusterServiceCreateClusterMethodDescriptor = clusterServiceServiceDescriptor.Methods().ByName("CreateCluster")
+	clusterServiceUpdateClusterMethodDescriptor = clusterServiceServiceDescriptor.Methods().ByName("UpdateCluster")
+	clusterServiceDeleteClusterMethodDescriptor = clusterServiceServiceDescriptor.Methods().ByName("DeleteCluster")
+)
+
+// ClusterServiceClient is a client for the tornjak.api.v1.ClusterService service.
+type ClusterServiceClient interface {
+	// Lists clusters.
+	//
+	// Authorized as GET /api/v1/tornjak/clusters.
+	ListClusters(context.Context, *connect.Request[v1.ListClustersRequest]) (*connect.Response[v1.ListClustersResponse], error)
+	// Gets a cluster by name.
+	//
+	// Authorized as GET /api/v1/tornjak/clusters.
+	GetCluster(context.Context, *connect.Request[v1.GetClusterRequest]) (*connect.Response[v1.Cluster], error)
+	// Creates a cluster.
+	//
+	// Authorized as POST /api/v1/tornjak/clusters.
+	CreateCluster(context.Context, *connect.Request[v1.CreateClusterRequest]) (*connect.Response[v1.Cluster], error)
+	// Replaces a cluster, renaming it if the new name differs. Labels and
+	// details not given are cleared.
+	//
+	// Authorized as PATCH /api/v1/tornjak/clusters.
+	UpdateCluster(context.Context, *connect.Request[v1.UpdateClusterRequest]) (*connect.Response[v1.Cluster], error)
+	// Deletes a cluster and its agent assignments.
+	//
+	// Authorized as DELETE /api/v1/tornjak/clusters.
+	DeleteCluster(context.Context, *connect.Request[v1.DeleteClusterRequest]) (*connect.Response[emptypb.Empty], error)
+}
+
+// NewClusterServiceClient constructs a client for the tornjak.api.v1.ClusterService service. By
+// default, it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses,
+// and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the
+// connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewClusterServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) ClusterServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &clusterServiceClient{
+		listClusters: connect.NewClient[v1.ListClustersRequest, v1.ListClustersResponse](
+			httpClient,
+			baseURL+ClusterServiceListClustersProcedure,
+			connect.WithSchema(clusterServiceListClustersMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getCluster: connect.NewClient[v1.GetClusterRequest, v1.Cluster](
+			httpClient,
+			baseURL+ClusterServiceGetClusterProcedure,
+			connect.WithSchema(clusterServiceGetClusterMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		createCluster: connect.NewClient[v1.CreateClusterRequest, v1.Cluster](
+			httpClient,
+			baseURL+ClusterServiceCreateClusterProcedure,
+			connect.WithSchema(clusterServiceCreateClusterMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		updateCluster: connect.NewClient[v1.UpdateClusterRequest, v1.Cluster](
+			httpClient,
+			baseURL+ClusterServiceUpdateClusterProcedure,
+			connect.WithSchema(clusterServiceUpdateClusterMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		deleteCluster: connect.NewClient[v1.DeleteClusterRequest, emptypb.Empty](
+			httpClient,
+			baseURL+ClusterServiceDeleteClusterProcedure,
+			connect.WithSchema(clusterServiceDeleteClusterMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// clusterServiceClient implements ClusterServiceClient.
+type clusterServiceClient struct {
+	listClusters  *connect.Client[v1.ListClustersRequest, v1.ListClustersResponse]
+	getCluster    *connect.Client[v1.GetClusterRequest, v1.Cluster]
+	createCluster *connect.Client[v1.CreateClusterRequest, v1.Cluster]
+	updateCluster *connect.Client[v1.UpdateClusterRequest, v1.Cluster]
+	deleteCluster *connect.Client[v1.DeleteClusterRequest, emptypb.Empty]
+}
+
+// ListClusters calls tornjak.api.v1.ClusterService.ListClusters.
+func (c *clusterServiceClient) ListClusters(ctx context.Context, req *connect.Request[v1.ListClustersRequest]) (*connect.Response[v1.ListClustersResponse], error) {
+	return c.listClusters.CallUnary(ctx, req)
+}
+
+// GetCluster calls tornjak.api.v1.ClusterService.GetCluster.
+func (c *clusterServiceClient) GetCluster(ctx context.Context, req *connect.Request[v1.GetClusterRequest]) (*connect.Response[v1.Cluster], error) {
+	return c.getCluster.CallUnary(ctx, req)
+}
+
+// CreateCluster calls tornjak.api.v1.ClusterService.CreateCluster.
+func (c *clusterServiceClient) CreateCluster(ctx context.Context, req *connect.Request[v1.CreateClusterRequest]) (*connect.Response[v1.Cluster], error) {
+	return c.createCluster.CallUnary(ctx, req)
+}
+
+// UpdateCluster calls tornjak.api.v1.ClusterService.UpdateCluster.
+func (c *clusterServiceClient) UpdateCluster(ctx context.Context, req *connect.Request[v1.UpdateClusterRequest]) (*connect.Response[v1.Cluster], error) {
+	return c.updateCluster.CallUnary(ctx, req)
+}
+
+// DeleteCluster calls tornjak.api.v1.ClusterService.DeleteCluster.
+func (c *clusterServiceClient) DeleteCluster(ctx context.Context, req *connect.Request[v1.DeleteClusterRequest]) (*connect.Response[emptypb.Empty], error) {
+	return c.deleteCluster.CallUnary(ctx, req)
+}
+
+// ClusterServiceHandler is an implementation of the tornjak.api.v1.ClusterService service.
+type ClusterServiceHandler interface {
+	// Lists clusters.
+	//
+	// Authorized as GET /api/v1/tornjak/clusters.
+	ListClusters(context.Context, *connect.Request[v1.ListClustersRequest]) (*connect.Response[v1.ListClustersResponse], error)
+	// Gets a cluster by name.
+	//
+	// Authorized as GET /api/v1/tornjak/clusters.
+	GetCluster(context.Context, *connect.Request[v1.GetClusterRequest]) (*connect.Response[v1.Cluster], error)
+	// Creates a cluster.
+	//
+	// Authorized as POST /api/v1/tornjak/clusters.
+	CreateCluster(context.Context, *connect.Request[v1.CreateClusterRequest]) (*connect.Response[v1.Cluster], error)
+	// Replaces a cluster, renaming it if the new name differs. Labels and
+	// details not given are cleared.
+	//
+	// Authorized as PATCH /api/v1/tornjak/clusters.
+	UpdateCluster(context.Context, *connect.Request[v1.UpdateClusterRequest]) (*connect.Response[v1.Cluster], error)
+	// Deletes a cluster and its agent assignments.
+	//
+	// Authorized as DELETE /api/v1/tornjak/clusters.
+	DeleteCluster(context.Context, *connect.Request[v1.DeleteClusterRequest]) (*connect.Response[emptypb.Empty], error)
+}
+
+// NewClusterServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewClusterServiceHandler(svc ClusterServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	clusterServiceListClustersHandler := connect.NewUnaryHandler(
+		ClusterServiceListClustersProcedure,
+		svc.ListClusters,
+		connect.WithSchema(clusterServiceListClustersMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	clusterServiceGetClusterHandler := connect.NewUnaryHandler(
+		ClusterServiceGetClusterProcedure,
+		svc.GetCluster,
+		connect.WithSchema(clusterServiceGetClusterMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	clusterServiceCreateClusterHandler := connect.NewUnaryHandler(
+		ClusterServiceCreateClusterProcedure,
+		svc.CreateCluster,
+		connect.WithSchema(clusterServiceCreateClusterMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	clusterServiceUpdateClusterHandler := connect.NewUnaryHandler(
+		ClusterServiceUpdateClusterProcedure,
+		svc.UpdateCluster,
+		connect.WithSchema(clusterServiceUpdateClusterMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	clusterServiceDeleteClusterHandler := connect.NewUnaryHandler(
+		ClusterServiceDeleteClusterProcedure,
+		svc.DeleteCluster,
+		connect.WithSchema(clusterServiceDeleteClusterMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/tornjak.api.v1.ClusterService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case ClusterServiceListClustersProcedure:
+			clusterServiceListClustersHandler.ServeHTTP(w, r)
+		case ClusterServiceGetClusterProcedure:
+			clusterServiceGetClusterHandler.ServeHTTP(w, r)
+		case ClusterServiceCreateClusterProcedure:
+			clusterServiceCreateClusterHandler.ServeHTTP(w, r)
+		case ClusterServiceUpdateClusterProcedure:
+			clusterServiceUpdateClusterHandler.ServeHTTP(w, r)
+		case ClusterServiceDeleteClusterProcedure:
+			clusterServiceDeleteClusterHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedClusterServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedClusterServiceHandler struct{}
+
+func (UnimplementedClusterServiceHandler) ListClusters(context.Context, *connect.Request[v1.ListClustersRequest]) (*connect.Response[v1.ListClustersResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.ClusterService.ListClusters is not implemented"))
+}
+
+func (UnimplementedClusterServiceHandler) GetCluster(context.Context, *connect.Request[v1.GetClusterRequest]) (*connect.Response[v1.Cluster], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.ClusterService.GetCluster is not implemented"))
+}
+
+func (UnimplementedClusterServiceHandler) CreateCluster(context.Context, *connect.Request[v1.CreateClusterRequest]) (*connect.Response[v1.Cluster], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.ClusterService.CreateCluster is not implemented"))
+}
+
+func (UnimplementedClusterServiceHandler) UpdateCluster(context.Context, *connect.Request[v1.UpdateClusterRequest]) (*connect.Response[v1.Cluster], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.ClusterService.UpdateCluster is not implemented"))
+}
+
+func (UnimplementedClusterServiceHandler) DeleteCluster(context.Context, *connect.Request[v1.DeleteClusterRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.ClusterService.DeleteCluster is not implemented"))
+}
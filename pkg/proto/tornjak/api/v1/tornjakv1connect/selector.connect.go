@@ -0,0 +1,192 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: tornjak/api/v1/selector.proto
+
+package tornjakv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	v1 "github.com/spiffe/tornjak/pkg/proto/tornjak/api/v1"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// SelectorServiceName is the fully-qualified name of the SelectorService service.
+	SelectorServiceName = "tornjak.api.v1.SelectorService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// SelectorServiceListSelectorsProcedure is the fully-qualified name of the SelectorService's
+	// ListSelectors RPC.
+	SelectorServiceListSelectorsProcedure = "/tornjak.api.v1.SelectorService/ListSelectors"
+	// SelectorServiceDefineSelectorProcedure is the fully-qualified name of the SelectorService's
+	// DefineSelector RPC.
+	SelectorServiceDefineSelectorProcedure = "/tornjak.api.v1.SelectorService/DefineSelector"
+	// SelectorServiceListAgentMetadataProcedure is the fully-qualified name of the SelectorService's
+	// ListAgentMetadata RPC.
+	SelectorServiceListAgentMetadataProcedure = "/tornjak.api.v1.SelectorService/ListAgentMetadata"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	selectorServiceServiceDescriptor                 = v1.File_tornjak_api_v1_selector_proto.Services().ByName("SelectorService")
+	selectorServiceListSelectorsMethodDescriptor     = selectorServiceServiceDescriptor.Methods().ByName("ListSelectors")
+	selectorServiceDefineSelectorMethodDescriptor    = selectorServiceServiceDescriptor.Methods().ByName("DefineSelector")
+	selectorServiceListAgentMetadataMethodDescriptor = selectorServiceServiceDescriptor.Methods().ByName("ListAgentMetadata")
+)
+
+// SelectorServiceClient is a client for the tornjak.api.v1.SelectorService service.
+type SelectorServiceClient interface {
+	// Lists agents with their workload attestor plugin.
+	//
+	// Authorized as GET /api/v1/tornjak/selectors.
+	ListSelectors(context.Context, *connect.Request[v1.ListSelectorsRequest]) (*connect.Response[v1.ListSelectorsResponse], error)
+	// Registers the workload attestor plugin of an agent.
+	//
+	// Authorized as POST /api/v1/tornjak/selectors.
+	DefineSelector(context.Context, *connect.Request[v1.DefineSelectorRequest]) (*connect.Response[emptypb.Empty], error)
+	// Lists agent metadata, including cluster assignment.
+	//
+	// Authorized as GET /api/v1/tornjak/agents.
+	ListAgentMetadata(context.Context, *connect.Request[v1.ListAgentMetadataRequest]) (*connect.Response[v1.ListAgentMetadataResponse], error)
+}
+
+// NewSelectorServiceClient constructs a client for the tornjak.api.v1.SelectorService service. By
+// default, it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses,
+// and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the
+// connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewSelectorServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) SelectorServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &selectorServiceClient{
+		listSelectors: connect.NewClient[v1.ListSelectorsRequest, v1.ListSelectorsResponse](
+			httpClient,
+			baseURL+SelectorServiceListSelectorsProcedure,
+			connect.WithSchema(selectorServiceListSelectorsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		defineSelector: connect.NewClient[v1.DefineSelectorRequest, emptypb.Empty](
+			httpClient,
+			baseURL+SelectorServiceDefineSelectorProcedure,
+			connect.WithSchema(selectorServiceDefineSelectorMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listAgentMetadata: connect.NewClient[v1.ListAgentMetadataRequest, v1.ListAgentMetadataResponse](
+			httpClient,
+			baseURL+SelectorServiceListAgentMetadataProcedure,
+			connect.WithSchema(selectorServiceListAgentMetadataMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// selectorServiceClient implements SelectorServiceClient.
+type selectorServiceClient struct {
+	listSelectors     *connect.Client[v1.ListSelectorsRequest, v1.ListSelectorsResponse]
+	defineSelector    *connect.Client[v1.DefineSelectorRequest, emptypb.Empty]
+	listAgentMetadata *connect.Client[v1.ListAgentMetadataRequest, v1.ListAgentMetadataResponse]
+}
+
+// ListSelectors calls tornjak.api.v1.SelectorService.ListSelectors.
+func (c *selectorServiceClient) ListSelectors(ctx context.Context, req *connect.Request[v1.ListSelectorsRequest]) (*connect.Response[v1.ListSelectorsResponse], error) {
+	return c.listSelectors.CallUnary(ctx, req)
+}
+
+// DefineSelector calls tornjak.api.v1.SelectorService.DefineSelector.
+func (c *selectorServiceClient) DefineSelector(ctx context.Context, req *connect.Request[v1.DefineSelectorRequest]) (*connect.Response[emptypb.Empty], error) {
+	return c.defineSelector.CallUnary(ctx, req)
+}
+
+// ListAgentMetadata calls tornjak.api.v1.SelectorService.ListAgentMetadata.
+func (c *selectorServiceClient) ListAgentMetadata(ctx context.Context, req *connect.Request[v1.ListAgentMetadataRequest]) (*connect.Response[v1.ListAgentMetadataResponse], error) {
+	return c.listAgentMetadata.CallUnary(ctx, req)
+}
+
+// SelectorServiceHandler is an implementation of the tornjak.api.v1.SelectorService service.
+type SelectorServiceHandler interface {
+	// Lists agents with their workload attestor plugin.
+	//
+	// Authorized as GET /api/v1/tornjak/selectors.
+	ListSelectors(context.Context, *connect.Request[v1.ListSelectorsRequest]) (*connect.Response[v1.ListSelectorsResponse], error)
+	// Registers the workload attestor plugin of an agent.
+	//
+	// Authorized as POST /api/v1/tornjak/selectors.
+	DefineSelector(context.Context, *connect.Request[v1.DefineSelectorRequest]) (*connect.Response[emptypb.Empty], error)
+	// Lists agent metadata, including cluster assignment.
+	//
+	// Authorized as GET /api/v1/tornjak/agents.
+	ListAgentMetadata(context.Context, *connect.Request[v1.ListAgentMetadataRequest]) (*connect.Response[v1.ListAgentMetadataResponse], error)
+}
+
+// NewSelectorServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewSelectorServiceHandler(svc SelectorServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	selectorServiceListSelectorsHandler := connect.NewUnaryHandler(
+		SelectorServiceListSelectorsProcedure,
+		svc.ListSelectors,
+		connect.WithSchema(selectorServiceListSelectorsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	selectorServiceDefineSelectorHandler := connect.NewUnaryHandler(
+		SelectorServiceDefineSelectorProcedure,
+		svc.DefineSelector,
+		connect.WithSchema(selectorServiceDefineSelectorMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	selectorServiceListAgentMetadataHandler := connect.NewUnaryHandler(
+		SelectorServiceListAgentMetadataProcedure,
+		svc.ListAgentMetadata,
+		connect.WithSchema(selectorServiceListAgentMetadataMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/tornjak.api.v1.SelectorService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case SelectorServiceListSelectorsProcedure:
+			selectorServiceListSelectorsHandler.ServeHTTP(w, r)
+		case SelectorServiceDefineSelectorProcedure:
+			selectorServiceDefineSelectorHandler.ServeHTTP(w, r)
+		case SelectorServiceListAgentMetadataProcedure:
+			selectorServiceListAgentMetadataHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedSelectorServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedSelectorServiceHandler struct{}
+
+func (UnimplementedSelectorServiceHandler) ListSelectors(context.Context, *connect.Request[v1.ListSelectorsRequest]) (*connect.Response[v1.ListSelectorsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SelectorService.ListSelectors is not implemented"))
+}
+
+func (UnimplementedSelectorServiceHandler) DefineSelector(context.Context, *connect.Request[v1.DefineSelectorRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SelectorService.DefineSelector is not implemented"))
+}
+
+func (UnimplementedSelectorServiceHandler) ListAgentMetadata(context.Context, *connect.Request[v1.ListAgentMetadataRequest]) (*connect.Response[v1.ListAgentMetadataResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SelectorService.ListAgentMetadata is not implemented"))
+}
@@ -0,0 +1,695 @@
+// Code generated by protoc-gen-connect-go. DO NOT EDIT.
+//
+// Source: tornjak/api/v1/spire.proto
+
+package tornjakv1connect
+
+import (
+	connect "connectrpc.com/connect"
+	context "context"
+	errors "errors"
+	v12 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/agent/v1"
+	v14 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/bundle/v1"
+	v11 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/debug/v1"
+	v13 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/entry/v1"
+	v15 "github.com/spiffe/spire-api-sdk/proto/spire/api/server/trustdomain/v1"
+	types "github.com/spiffe/spire-api-sdk/proto/spire/api/types"
+	v1 "github.com/spiffe/tornjak/pkg/proto/tornjak/api/v1"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	http "net/http"
+	strings "strings"
+)
+
+// This is a compile-time assertion to ensure that this generated file and the connect package are
+// compatible. If you get a compiler error that this constant is not defined, this code was
+// generated with a version of connect newer than the one compiled into your binary. You can fix the
+// problem by either regenerating this code with an older version of connect or updating the connect
+// version compiled into your binary.
+const _ = connect.IsAtLeastVersion1_13_0
+
+const (
+	// SPIREServiceName is the fully-qualified name of the SPIREService service.
+	SPIREServiceName = "tornjak.api.v1.SPIREService"
+)
+
+// These constants are the fully-qualified names of the RPCs defined in this package. They're
+// exposed at runtime as Spec.Procedure and as the final two segments of the HTTP route.
+//
+// Note that these are different from the fully-qualified method names used by
+// google.golang.org/protobuf/reflect/protoreflect. To convert from these constants to
+// reflection-formatted method names, remove the leading slash and convert the remaining slash to a
+// period.
+const (
+	// SPIREServiceGetInfoProcedure is the fully-qualified name of the SPIREService's GetInfo RPC.
+	SPIREServiceGetInfoProcedure = "/tornjak.api.v1.SPIREService/GetInfo"
+	// SPIREServiceListAgentsProcedure is the fully-qualified name of the SPIREService's ListAgents RPC.
+	SPIREServiceListAgentsProcedure = "/tornjak.api.v1.SPIREService/ListAgents"
+	// SPIREServiceGetAgentProcedure is the fully-qualified name of the SPIREService's GetAgent RPC.
+	SPIREServiceGetAgentProcedure = "/tornjak.api.v1.SPIREService/GetAgent"
+	// SPIREServiceDeleteAgentProcedure is the fully-qualified name of the SPIREService's DeleteAgent
+	// RPC.
+	SPIREServiceDeleteAgentProcedure = "/tornjak.api.v1.SPIREService/DeleteAgent"
+	// SPIREServiceBanAgentProcedure is the fully-qualified name of the SPIREService's BanAgent RPC.
+	SPIREServiceBanAgentProcedure = "/tornjak.api.v1.SPIREService/BanAgent"
+	// SPIREServiceCreateJoinTokenProcedure is the fully-qualified name of the SPIREService's
+	// CreateJoinToken RPC.
+	SPIREServiceCreateJoinTokenProcedure = "/tornjak.api.v1.SPIREService/CreateJoinToken"
+	// SPIREServiceListEntriesProcedure is the fully-qualified name of the SPIREService's ListEntries
+	// RPC.
+	SPIREServiceListEntriesProcedure = "/tornjak.api.v1.SPIREService/ListEntries"
+	// SPIREServiceGetEntryProcedure is the fully-qualified name of the SPIREService's GetEntry RPC.
+	SPIREServiceGetEntryProcedure = "/tornjak.api.v1.SPIREService/GetEntry"
+	// SPIREServiceBatchCreateEntryProcedure is the fully-qualified name of the SPIREService's
+	// BatchCreateEntry RPC.
+	SPIREServiceBatchCreateEntryProcedure = "/tornjak.api.v1.SPIREService/BatchCreateEntry"
+	// SPIREServiceBatchDeleteEntryProcedure is the fully-qualified name of the SPIREService's
+	// BatchDeleteEntry RPC.
+	SPIREServiceBatchDeleteEntryProcedure = "/tornjak.api.v1.SPIREService/BatchDeleteEntry"
+	// SPIREServiceGetBundleProcedure is the fully-qualified name of the SPIREService's GetBundle RPC.
+	SPIREServiceGetBundleProcedure = "/tornjak.api.v1.SPIREService/GetBundle"
+	// SPIREServiceListFederatedBundlesProcedure is the fully-qualified name of the SPIREService's
+	// ListFederatedBundles RPC.
+	SPIREServiceListFederatedBundlesProcedure = "/tornjak.api.v1.SPIREService/ListFederatedBundles"
+	// SPIREServiceBatchCreateFederatedBundleProcedure is the fully-qualified name of the SPIREService's
+	// BatchCreateFederatedBundle RPC.
+	SPIREServiceBatchCreateFederatedBundleProcedure = "/tornjak.api.v1.SPIREService/BatchCreateFederatedBundle"
+	// SPIREServiceBatchUpdateFederatedBundleProcedure is the fully-qualified name of the SPIREService's
+	// BatchUpdateFederatedBundle RPC.
+	SPIREServiceBatchUpdateFederatedBundleProcedure = "/tornjak.api.v1.SPIREService/BatchUpdateFederatedBundle"
+	// SPIREServiceBatchDeleteFederatedBundleProcedure is the fully-qualified name of the SPIREService's
+	// BatchDeleteFederatedBundle RPC.
+	SPIREServiceBatchDeleteFederatedBundleProcedure = "/tornjak.api.v1.SPIREService/BatchDeleteFederatedBundle"
+	// SPIREServiceListFederationRelationshipsProcedure is the fully-qualified name of the
+	// SPIREService's ListFederationRelationships RPC.
+	SPIREServiceListFederationRelationshipsProcedure = "/tornjak.api.v1.SPIREService/ListFederationRelationships"
+	// SPIREServiceBatchCreateFederationRelationshipProcedure is the fully-qualified name of the
+	// SPIREService's BatchCreateFederationRelationship RPC.
+	SPIREServiceBatchCreateFederationRelationshipProcedure = "/tornjak.api.v1.SPIREService/BatchCreateFederationRelationship"
+	// SPIREServiceBatchUpdateFederationRelationshipProcedure is the fully-qualified name of the
+	// SPIREService's BatchUpdateFederationRelationship RPC.
+	SPIREServiceBatchUpdateFederationRelationshipProcedure = "/tornjak.api.v1.SPIREService/BatchUpdateFederationRelationship"
+	// SPIREServiceBatchDeleteFederationRelationshipProcedure is the fully-qualified name of the
+	// SPIREService's BatchDeleteFederationRelationship RPC.
+	SPIREServiceBatchDeleteFederationRelationshipProcedure = "/tornjak.api.v1.SPIREService/BatchDeleteFederationRelationship"
+)
+
+// These variables are the protoreflect.Descriptor objects for the RPCs defined in this package.
+var (
+	sPIREServiceServiceDescriptor                                 = v1.File_tornjak_api_v1_spire_proto.Services().ByName("SPIREService")
+	sPIREServiceGetInfoMethodDescriptor                           = sPIREServiceServiceDescriptor.Methods().ByName("GetInfo")
+	sPIREServiceListAgentsMethodDescriptor                        = sPIREServiceServiceDescriptor.Methods().ByName("ListAgents")
+	sPIREServiceGetAgentMethodDescriptor                          = sPIREServiceServiceDescriptor.Methods().ByName("GetAgent")
+	sPIREServiceDeleteAgentMethodDescriptor                       = sPIREServiceServiceDescriptor.Methods().ByName("DeleteAgent")
+	sPIREServiceBanAgentMethodDescriptor                          = sPIREServiceServiceDescriptor.Methods().ByName("BanAgent")
+	sPIREServiceCreateJoinTokenMethodDescriptor                   = sPIREServiceServiceDescriptor.Methods().ByName("CreateJoinToken")
+	sPIREServiceListEntriesMethodDescriptor                       = sPIREServiceServiceDescriptor.Methods().ByName("ListEntries")
+	sPIREServiceGetEntryMethodDescriptor                          = sPIREServiceServiceDescriptor.Methods().ByName("GetEntry")
+	sPIREServiceBatchCreateEntryMethodDescriptor                  = sPIREServiceServiceDescriptor.Methods().ByName("BatchCreateEntry")
+	sPIREServiceBatchDeleteEntryMethodDescriptor                  = sPIREServiceServiceDescriptor.Methods().ByName("BatchDeleteEntry")
+	sPIREServiceGetBundleMethodDescriptor                         = sPIREServiceServiceDescriptor.Methods().ByName("GetBundle")
+	sPIREServiceListFederatedBundlesMethodDescriptor              = sPIREServiceServiceDescriptor.Methods().ByName("ListFederatedBundles")
+	sPIREServiceBatchCreateFederatedBundleMethodDescriptor        = sPIREServiceServiceDescriptor.Methods().ByName("BatchCreateFederatedBundle")
+	sPIREServiceBatchUpdateFederatedBundleMethodDescriptor        = sPIREServiceServiceDescriptor.Methods().ByName("BatchUpdateFederatedBundle")
+	sPIREServiceBatchDeleteFederatedBundleMethodDescriptor        = sPIREServiceServiceDescriptor.Methods().ByName("BatchDeleteFederatedBundle")
+	sPIREServiceListFederationRelationshipsMethodDescriptor       = sPIREServiceServiceDescriptor.Methods().ByName("ListFederationRelationships")
+	sPIREServiceBatchCreateFederationRelationshipMethodDescriptor = sPIREServiceServiceDescriptor.Methods().ByName("BatchCreateFederationRelationship")
+	sPIREServiceBatchUpdateFederationRelationshipMethodDescriptor = sPIREServiceServiceDescriptor.Methods().ByName("BatchUpdateFederationRelationship")
+	sPIREServiceBatchDeleteFederationRelationshipMethodDescriptor = sPIREServiceServiceDescriptor.Methods().ByName("BatchDeleteFederationRelationship")
+)
+
+// SPIREServiceClient is a client for the tornjak.api.v1.SPIREService service.
+type SPIREServiceClient interface {
+	// Authorized as GET /api/v1/spire/serverinfo.
+	GetInfo(context.Context, *connect.Request[v11.GetInfoRequest]) (*connect.Response[v11.GetInfoResponse], error)
+	// Authorized as GET /api/v1/spire/agents.
+	ListAgents(context.Context, *connect.Request[v12.ListAgentsRequest]) (*connect.Response[v12.ListAgentsResponse], error)
+	// Authorized as GET /api/v1/spire/agents.
+	GetAgent(context.Context, *connect.Request[v12.GetAgentRequest]) (*connect.Response[types.Agent], error)
+	// Authorized as DELETE /api/v1/spire/agents.
+	DeleteAgent(context.Context, *connect.Request[v12.DeleteAgentRequest]) (*connect.Response[emptypb.Empty], error)
+	// Authorized as POST /api/v1/spire/agents/ban.
+	BanAgent(context.Context, *connect.Request[v12.BanAgentRequest]) (*connect.Response[emptypb.Empty], error)
+	// Authorized as POST /api/v1/spire/agents/jointoken.
+	CreateJoinToken(context.Context, *connect.Request[v12.CreateJoinTokenRequest]) (*connect.Response[types.JoinToken], error)
+	// Authorized as GET /api/v1/spire/entries.
+	ListEntries(context.Context, *connect.Request[v13.ListEntriesRequest]) (*connect.Response[v13.ListEntriesResponse], error)
+	// Authorized as GET /api/v1/spire/entries.
+	GetEntry(context.Context, *connect.Request[v13.GetEntryRequest]) (*connect.Response[types.Entry], error)
+	// Authorized as POST /api/v1/spire/entries.
+	BatchCreateEntry(context.Context, *connect.Request[v13.BatchCreateEntryRequest]) (*connect.Response[v13.BatchCreateEntryResponse], error)
+	// Authorized as DELETE /api/v1/spire/entries.
+	BatchDeleteEntry(context.Context, *connect.Request[v13.BatchDeleteEntryRequest]) (*connect.Response[v13.BatchDeleteEntryResponse], error)
+	// Authorized as GET /api/v1/spire/bundle.
+	GetBundle(context.Context, *connect.Request[v14.GetBundleRequest]) (*connect.Response[types.Bundle], error)
+	// Authorized as GET /api/v1/spire/federations/bundles.
+	ListFederatedBundles(context.Context, *connect.Request[v14.ListFederatedBundlesRequest]) (*connect.Response[v14.ListFederatedBundlesResponse], error)
+	// Authorized as POST /api/v1/spire/federations/bundles.
+	BatchCreateFederatedBundle(context.Context, *connect.Request[v14.BatchCreateFederatedBundleRequest]) (*connect.Response[v14.BatchCreateFederatedBundleResponse], error)
+	// Authorized as PATCH /api/v1/spire/federations/bundles.
+	BatchUpdateFederatedBundle(context.Context, *connect.Request[v14.BatchUpdateFederatedBundleRequest]) (*connect.Response[v14.BatchUpdateFederatedBundleResponse], error)
+	// Authorized as DELETE /api/v1/spire/federations/bundles.
+	BatchDeleteFederatedBundle(context.Context, *connect.Request[v14.BatchDeleteFederatedBundleRequest]) (*connect.Response[v14.BatchDeleteFederatedBundleResponse], error)
+	// Authorized as GET /api/v1/spire/federations.
+	ListFederationRelationships(context.Context, *connect.Request[v15.ListFederationRelationshipsRequest]) (*connect.Response[v15.ListFederationRelationshipsResponse], error)
+	// Authorized as POST /api/v1/spire/federations.
+	BatchCreateFederationRelationship(context.Context, *connect.Request[v15.BatchCreateFederationRelationshipRequest]) (*connect.Response[v15.BatchCreateFederationRelationshipResponse], error)
+	// Authorized as PATCH /api/v1/spire/federations.
+	BatchUpdateFederationRelationship(context.Context, *connect.Request[v15.BatchUpdateFederationRelationshipRequest]) (*connect.Response[v15.BatchUpdateFederationRelationshipResponse], error)
+	// Authorized as DELETE /api/v1/spire/federations.
+	BatchDeleteFederationRelationship(context.Context, *connect.Request[v15.BatchDeleteFederationRelationshipRequest]) (*connect.Response[v15.BatchDeleteFederationRelationshipResponse], error)
+}
+
+// NewSPIREServiceClient constructs a client for the tornjak.api.v1.SPIREService service. By
+// default, it uses the Connect protocol with the binary Protobuf Codec, asks for gzipped responses,
+// and sends uncompressed requests. To use the gRPC or gRPC-Web protocols, supply the
+// connect.WithGRPC() or connect.WithGRPCWeb() options.
+//
+// The URL supplied here should be the base URL for the Connect or gRPC server (for example,
+// http://api.acme.com or https://acme.com/grpc).
+func NewSPIREServiceClient(httpClient connect.HTTPClient, baseURL string, opts ...connect.ClientOption) SPIREServiceClient {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return &sPIREServiceClient{
+		getInfo: connect.NewClient[v11.GetInfoRequest, v11.GetInfoResponse](
+			httpClient,
+			baseURL+SPIREServiceGetInfoProcedure,
+			connect.WithSchema(sPIREServiceGetInfoMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listAgents: connect.NewClient[v12.ListAgentsRequest, v12.ListAgentsResponse](
+			httpClient,
+			baseURL+SPIREServiceListAgentsProcedure,
+			connect.WithSchema(sPIREServiceListAgentsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getAgent: connect.NewClient[v12.GetAgentRequest, types.Agent](
+			httpClient,
+			baseURL+SPIREServiceGetAgentProcedure,
+			connect.WithSchema(sPIREServiceGetAgentMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		deleteAgent: connect.NewClient[v12.DeleteAgentRequest, emptypb.Empty](
+			httpClient,
+			baseURL+SPIREServiceDeleteAgentProcedure,
+			connect.WithSchema(sPIREServiceDeleteAgentMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		banAgent: connect.NewClient[v12.BanAgentRequest, emptypb.Empty](
+			httpClient,
+			baseURL+SPIREServiceBanAgentProcedure,
+			connect.WithSchema(sPIREServiceBanAgentMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		createJoinToken: connect.NewClient[v12.CreateJoinTokenRequest, types.JoinToken](
+			httpClient,
+			baseURL+SPIREServiceCreateJoinTokenProcedure,
+			connect.WithSchema(sPIREServiceCreateJoinTokenMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listEntries: connect.NewClient[v13.ListEntriesRequest, v13.ListEntriesResponse](
+			httpClient,
+			baseURL+SPIREServiceListEntriesProcedure,
+			connect.WithSchema(sPIREServiceListEntriesMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getEntry: connect.NewClient[v13.GetEntryRequest, types.Entry](
+			httpClient,
+			baseURL+SPIREServiceGetEntryProcedure,
+			connect.WithSchema(sPIREServiceGetEntryMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		batchCreateEntry: connect.NewClient[v13.BatchCreateEntryRequest, v13.BatchCreateEntryResponse](
+			httpClient,
+			baseURL+SPIREServiceBatchCreateEntryProcedure,
+			connect.WithSchema(sPIREServiceBatchCreateEntryMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		batchDeleteEntry: connect.NewClient[v13.BatchDeleteEntryRequest, v13.BatchDeleteEntryResponse](
+			httpClient,
+			baseURL+SPIREServiceBatchDeleteEntryProcedure,
+			connect.WithSchema(sPIREServiceBatchDeleteEntryMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		getBundle: connect.NewClient[v14.GetBundleRequest, types.Bundle](
+			httpClient,
+			baseURL+SPIREServiceGetBundleProcedure,
+			connect.WithSchema(sPIREServiceGetBundleMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listFederatedBundles: connect.NewClient[v14.ListFederatedBundlesRequest, v14.ListFederatedBundlesResponse](
+			httpClient,
+			baseURL+SPIREServiceListFederatedBundlesProcedure,
+			connect.WithSchema(sPIREServiceListFederatedBundlesMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		batchCreateFederatedBundle: connect.NewClient[v14.BatchCreateFederatedBundleRequest, v14.BatchCreateFederatedBundleResponse](
+			httpClient,
+			baseURL+SPIREServiceBatchCreateFederatedBundleProcedure,
+			connect.WithSchema(sPIREServiceBatchCreateFederatedBundleMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		batchUpdateFederatedBundle: connect.NewClient[v14.BatchUpdateFederatedBundleRequest, v14.BatchUpdateFederatedBundleResponse](
+			httpClient,
+			baseURL+SPIREServiceBatchUpdateFederatedBundleProcedure,
+			connect.WithSchema(sPIREServiceBatchUpdateFederatedBundleMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		batchDeleteFederatedBundle: connect.NewClient[v14.BatchDeleteFederatedBundleRequest, v14.BatchDeleteFederatedBundleResponse](
+			httpClient,
+			baseURL+SPIREServiceBatchDeleteFederatedBundleProcedure,
+			connect.WithSchema(sPIREServiceBatchDeleteFederatedBundleMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		listFederationRelationships: connect.NewClient[v15.ListFederationRelationshipsRequest, v15.ListFederationRelationshipsResponse](
+			httpClient,
+			baseURL+SPIREServiceListFederationRelationshipsProcedure,
+			connect.WithSchema(sPIREServiceListFederationRelationshipsMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		batchCreateFederationRelationship: connect.NewClient[v15.BatchCreateFederationRelationshipRequest, v15.BatchCreateFederationRelationshipResponse](
+			httpClient,
+			baseURL+SPIREServiceBatchCreateFederationRelationshipProcedure,
+			connect.WithSchema(sPIREServiceBatchCreateFederationRelationshipMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		batchUpdateFederationRelationship: connect.NewClient[v15.BatchUpdateFederationRelationshipRequest, v15.BatchUpdateFederationRelationshipResponse](
+			httpClient,
+			baseURL+SPIREServiceBatchUpdateFederationRelationshipProcedure,
+			connect.WithSchema(sPIREServiceBatchUpdateFederationRelationshipMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+		batchDeleteFederationRelationship: connect.NewClient[v15.BatchDeleteFederationRelationshipRequest, v15.BatchDeleteFederationRelationshipResponse](
+			httpClient,
+			baseURL+SPIREServiceBatchDeleteFederationRelationshipProcedure,
+			connect.WithSchema(sPIREServiceBatchDeleteFederationRelationshipMethodDescriptor),
+			connect.WithClientOptions(opts...),
+		),
+	}
+}
+
+// sPIREServiceClient implements SPIREServiceClient.
+type sPIREServiceClient struct {
+	getInfo                           *connect.Client[v11.GetInfoRequest, v11.GetInfoResponse]
+	listAgents                        *connect.Client[v12.ListAgentsRequest, v12.ListAgentsResponse]
+	getAgent                          *connect.Client[v12.GetAgentRequest, types.Agent]
+	deleteAgent                       *connect.Client[v12.DeleteAgentRequest, emptypb.Empty]
+	banAgent                          *connect.Client[v12.BanAgentRequest, emptypb.Empty]
+	createJoinToken                   *connect.Client[v12.CreateJoinTokenRequest, types.JoinToken]
+	listEntries                       *connect.Client[v13.ListEntriesRequest, v13.ListEntriesResponse]
+	getEntry                          *connect.Client[v13.GetEntryRequest, types.Entry]
+	batchCreateEntry                  *connect.Client[v13.BatchCreateEntryRequest, v13.BatchCreateEntryResponse]
+	batchDeleteEntry                  *connect.Client[v13.BatchDeleteEntryRequest, v13.BatchDeleteEntryResponse]
+	getBundle                         *connect.Client[v14.GetBundleRequest, types.Bundle]
+	listFederatedBundles              *connect.Client[v14.ListFederatedBundlesRequest, v14.ListFederatedBundlesResponse]
+	batchCreateFederatedBundle        *connect.Client[v14.BatchCreateFederatedBundleRequest, v14.BatchCreateFederatedBundleResponse]
+	batchUpdateFederatedBundle        *connect.Client[v14.BatchUpdateFederatedBundleRequest, v14.BatchUpdateFederatedBundleResponse]
+	batchDeleteFederatedBundle        *connect.Client[v14.BatchDeleteFederatedBundleRequest, v14.BatchDeleteFederatedBundleResponse]
+	listFederationRelationships       *connect.Client[v15.ListFederationRelationshipsRequest, v15.ListFederationRelationshipsResponse]
+	batchCreateFederationRelationship *connect.Client[v15.BatchCreateFederationRelationshipRequest, v15.BatchCreateFederationRelationshipResponse]
+	batchUpdateFederationRelationship *connect.Client[v15.BatchUpdateFederationRelationshipRequest, v15.BatchUpdateFederationRelationshipResponse]
+	batchDeleteFederationRelationship *connect.Client[v15.BatchDeleteFederationRelationshipRequest, v15.BatchDeleteFederationRelationshipResponse]
+}
+
+// GetInfo calls tornjak.api.v1.SPIREService.GetInfo.
+func (c *sPIREServiceClient) GetInfo(ctx context.Context, req *connect.Request[v11.GetInfoRequest]) (*connect.Response[v11.GetInfoResponse], error) {
+	return c.getInfo.CallUnary(ctx, req)
+}
+
+// ListAgents calls tornjak.api.v1.SPIREService.ListAgents.
+func (c *sPIREServiceClient) ListAgents(ctx context.Context, req *connect.Request[v12.ListAgentsRequest]) (*connect.Response[v12.ListAgentsResponse], error) {
+	return c.listAgents.CallUnary(ctx, req)
+}
+
+// GetAgent calls tornjak.api.v1.SPIREService.GetAgent.
+func (c *sPIREServiceClient) GetAgent(ctx context.Context, req *connect.Request[v12.GetAgentRequest]) (*connect.Response[types.Agent], error) {
+	return c.getAgent.CallUnary(ctx, req)
+}
+
+// DeleteAgent calls tornjak.api.v1.SPIREService.DeleteAgent.
+func (c *sPIREServiceClient) DeleteAgent(ctx context.Context, req *connect.Request[v12.DeleteAgentRequest]) (*connect.Response[emptypb.Empty], error) {
+	return c.deleteAgent.CallUnary(ctx, req)
+}
+
+// BanAgent calls tornjak.api.v1.SPIREService.BanAgent.
+func (c *sPIREServiceClient) BanAgent(ctx context.Context, req *connect.Request[v12.BanAgentRequest]) (*connect.Response[emptypb.Empty], error) {
+	return c.banAgent.CallUnary(ctx, req)
+}
+
+// CreateJoinToken calls tornjak.api.v1.SPIREService.CreateJoinToken.
+func (c *sPIREServiceClient) CreateJoinToken(ctx context.Context, req *connect.Request[v12.CreateJoinTokenRequest]) (*connect.Response[types.JoinToken], error) {
+	return c.createJoinToken.CallUnary(ctx, req)
+}
+
+// ListEntries calls tornjak.api.v1.SPIREService.ListEntries.
+func (c *sPIREServiceClient) ListEntries(ctx context.Context, req *connect.Request[v13.ListEntriesRequest]) (*connect.Response[v13.ListEntriesResponse], error) {
+	return c.listEntries.CallUnary(ctx, req)
+}
+
+// GetEntry calls tornjak.api.v1.SPIREService.GetEntry.
+func (c *sPIREServiceClient) GetEntry(ctx context.Context, req *connect.Request[v13.GetEntryRequest]) (*connect.Response[types.Entry], error) {
+	return c.getEntry.CallUnary(ctx, req)
+}
+
+// BatchCreateEntry calls tornjak.api.v1.SPIREService.BatchCreateEntry.
+func (c *sPIREServiceClient) BatchCreateEntry(ctx context.Context, req *connect.Request[v13.BatchCreateEntryRequest]) (*connect.Response[v13.BatchCreateEntryResponse], error) {
+	return c.batchCreateEntry.CallUnary(ctx, req)
+}
+
+// BatchDeleteEntry calls tornjak.api.v1.SPIREService.BatchDeleteEntry.
+func (c *sPIREServiceClient) BatchDeleteEntry(ctx context.Context, req *connect.Request[v13.BatchDeleteEntryRequest]) (*connect.Response[v13.BatchDeleteEntryResponse], error) {
+	return c.batchDeleteEntry.CallUnary(ctx, req)
+}
+
+// GetBundle calls tornjak.api.v1.SPIREService.GetBundle.
+func (c *sPIREServiceClient) GetBundle(ctx context.Context, req *connect.Request[v14.GetBundleRequest]) (*connect.Response[types.Bundle], error) {
+	return c.getBundle.CallUnary(ctx, req)
+}
+
+// ListFederatedBundles calls tornjak.api.v1.SPIREService.ListFederatedBundles.
+func (c *sPIREServiceClient) ListFederatedBundles(ctx context.Context, req *connect.Request[v14.ListFederatedBundlesRequest]) (*connect.Response[v14.ListFederatedBundlesResponse], error) {
+	return c.listFederatedBundles.CallUnary(ctx, req)
+}
+
+// BatchCreateFederatedBundle calls tornjak.api.v1.SPIREService.BatchCreateFederatedBundle.
+func (c *sPIREServiceClient) BatchCreateFederatedBundle(ctx context.Context, req *connect.Request[v14.BatchCreateFederatedBundleRequest]) (*connect.Response[v14.BatchCreateFederatedBundleResponse], error) {
+	return c.batchCreateFederatedBundle.CallUnary(ctx, req)
+}
+
+// BatchUpdateFederatedBundle calls tornjak.api.v1.SPIREService.BatchUpdateFederatedBundle.
+func (c *sPIREServiceClient) BatchUpdateFederatedBundle(ctx context.Context, req *connect.Request[v14.BatchUpdateFederatedBundleRequest]) (*connect.Response[v14.BatchUpdateFederatedBundleResponse], error) {
+	return c.batchUpdateFederatedBundle.CallUnary(ctx, req)
+}
+
+// BatchDeleteFederatedBundle calls tornjak.api.v1.SPIREService.BatchDeleteFederatedBundle.
+func (c *sPIREServiceClient) BatchDeleteFederatedBundle(ctx context.Context, req *connect.Request[v14.BatchDeleteFederatedBundleRequest]) (*connect.Response[v14.BatchDeleteFederatedBundleResponse], error) {
+	return c.batchDeleteFederatedBundle.CallUnary(ctx, req)
+}
+
+// ListFederationRelationships calls tornjak.api.v1.SPIREService.ListFederationRelationships.
+func (c *sPIREServiceClient) ListFederationRelationships(ctx context.Context, req *connect.Request[v15.ListFederationRelationshipsRequest]) (*connect.Response[v15.ListFederationRelationshipsResponse], error) {
+	return c.listFederationRelationships.CallUnary(ctx, req)
+}
+
+// BatchCreateFederationRelationship calls
+// tornjak.api.v1.SPIREService.BatchCreateFederationRelationship.
+func (c *sPIREServiceClient) BatchCreateFederationRelationship(ctx context.Context, req *connect.Request[v15.BatchCreateFederationRelationshipRequest]) (*connect.Response[v15.BatchCreateFederationRelationshipResponse], error) {
+	return c.batchCreateFederationRelationship.CallUnary(ctx, req)
+}
+
+// BatchUpdateFederationRelationship calls
+// tornjak.api.v1.SPIREService.BatchUpdateFederationRelationship.
+func (c *sPIREServiceClient) BatchUpdateFederationRelationship(ctx context.Context, req *connect.Request[v15.BatchUpdateFederationRelationshipRequest]) (*connect.Response[v15.BatchUpdateFederationRelationshipResponse], error) {
+	return c.batchUpdateFederationRelationship.CallUnary(ctx, req)
+}
+
+// BatchDeleteFederationRelationship calls
+// tornjak.api.v1.SPIREService.BatchDeleteFederationRelationship.
+func (c *sPIREServiceClient) BatchDeleteFederationRelationship(ctx context.Context, req *connect.Request[v15.BatchDeleteFederationRelationshipRequest]) (*connect.Response[v15.BatchDeleteFederationRelationshipResponse], error) {
+	return c.batchDeleteFederationRelationship.CallUnary(ctx, req)
+}
+
+// SPIREServiceHandler is an implementation of the tornjak.api.v1.SPIREService service.
+type SPIREServiceHandler interface {
+	// Authorized as GET /api/v1/spire/serverinfo.
+	GetInfo(context.Context, *connect.Request[v11.GetInfoRequest]) (*connect.Response[v11.GetInfoResponse], error)
+	// Authorized as GET /api/v1/spire/agents.
+	ListAgents(context.Context, *connect.Request[v12.ListAgentsRequest]) (*connect.Response[v12.ListAgentsResponse], error)
+	// Authorized as GET /api/v1/spire/agents.
+	GetAgent(context.Context, *connect.Request[v12.GetAgentRequest]) (*connect.Response[types.Agent], error)
+	// Authorized as DELETE /api/v1/spire/agents.
+	DeleteAgent(context.Context, *connect.Request[v12.DeleteAgentRequest]) (*connect.Response[emptypb.Empty], error)
+	// Authorized as POST /api/v1/spire/agents/ban.
+	BanAgent(context.Context, *connect.Request[v12.BanAgentRequest]) (*connect.Response[emptypb.Empty], error)
+	// Authorized as POST /api/v1/spire/agents/jointoken.
+	CreateJoinToken(context.Context, *connect.Request[v12.CreateJoinTokenRequest]) (*connect.Response[types.JoinToken], error)
+	// Authorized as GET /api/v1/spire/entries.
+	ListEntries(context.Context, *connect.Request[v13.ListEntriesRequest]) (*connect.Response[v13.ListEntriesResponse], error)
+	// Authorized as GET /api/v1/spire/entries.
+	GetEntry(context.Context, *connect.Request[v13.GetEntryRequest]) (*connect.Response[types.Entry], error)
+	// Authorized as POST /api/v1/spire/entries.
+	BatchCreateEntry(context.Context, *connect.Request[v13.BatchCreateEntryRequest]) (*connect.Response[v13.BatchCreateEntryResponse], error)
+	// Authorized as DELETE /api/v1/spire/entries.
+	BatchDeleteEntry(context.Context, *connect.Request[v13.BatchDeleteEntryRequest]) (*connect.Response[v13.BatchDeleteEntryResponse], error)
+	// Authorized as GET /api/v1/spire/bundle.
+	GetBundle(context.Context, *connect.Request[v14.GetBundleRequest]) (*connect.Response[types.Bundle], error)
+	// Authorized as GET /api/v1/spire/federations/bundles.
+	ListFederatedBundles(context.Context, *connect.Request[v14.ListFederatedBundlesRequest]) (*connect.Response[v14.ListFederatedBundlesResponse], error)
+	// Authorized as POST /api/v1/spire/federations/bundles.
+	BatchCreateFederatedBundle(context.Context, *connect.Request[v14.BatchCreateFederatedBundleRequest]) (*connect.Response[v14.BatchCreateFederatedBundleResponse], error)
+	// Authorized as PATCH /api/v1/spire/federations/bundles.
+	BatchUpdateFederatedBundle(context.Context, *connect.Request[v14.BatchUpdateFederatedBundleRequest]) (*connect.Response[v14.BatchUpdateFederatedBundleResponse], error)
+	// Authorized as DELETE /api/v1/spire/federations/bundles.
+	BatchDeleteFederatedBundle(context.Context, *connect.Request[v14.BatchDeleteFederatedBundleRequest]) (*connect.Response[v14.BatchDeleteFederatedBundleResponse], error)
+	// Authorized as GET /api/v1/spire/federations.
+	ListFederationRelationships(context.Context, *connect.Request[v15.ListFederationRelationshipsRequest]) (*connect.Response[v15.ListFederationRelationshipsResponse], error)
+	// Authorized as POST /api/v1/spire/federations.
+	BatchCreateFederationRelationship(context.Context, *connect.Request[v15.BatchCreateFederationRelationshipRequest]) (*connect.Response[v15.BatchCreateFederationRelationshipResponse], error)
+	// Authorized as PATCH /api/v1/spire/federations.
+	BatchUpdateFederationRelationship(context.Context, *connect.Request[v15.BatchUpdateFederationRelationshipRequest]) (*connect.Response[v15.BatchUpdateFederationRelationshipResponse], error)
+	// Authorized as DELETE /api/v1/spire/federations.
+	BatchDeleteFederationRelationship(context.Context, *connect.Request[v15.BatchDeleteFederationRelationshipRequest]) (*connect.Response[v15.BatchDeleteFederationRelationshipResponse], error)
+}
+
+// NewSPIREServiceHandler builds an HTTP handler from the service implementation. It returns the
+// path on which to mount the handler and the handler itself.
+//
+// By default, handlers support the Connect, gRPC, and gRPC-Web protocols with the binary Protobuf
+// and JSON codecs. They also support gzip compression.
+func NewSPIREServiceHandler(svc SPIREServiceHandler, opts ...connect.HandlerOption) (string, http.Handler) {
+	sPIREServiceGetInfoHandler := connect.NewUnaryHandler(
+		SPIREServiceGetInfoProcedure,
+		svc.GetInfo,
+		connect.WithSchema(sPIREServiceGetInfoMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceListAgentsHandler := connect.NewUnaryHandler(
+		SPIREServiceListAgentsProcedure,
+		svc.ListAgents,
+		connect.WithSchema(sPIREServiceListAgentsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceGetAgentHandler := connect.NewUnaryHandler(
+		SPIREServiceGetAgentProcedure,
+		svc.GetAgent,
+		connect.WithSchema(sPIREServiceGetAgentMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceDeleteAgentHandler := connect.NewUnaryHandler(
+		SPIREServiceDeleteAgentProcedure,
+		svc.DeleteAgent,
+		connect.WithSchema(sPIREServiceDeleteAgentMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceBanAgentHandler := connect.NewUnaryHandler(
+		SPIREServiceBanAgentProcedure,
+		svc.BanAgent,
+		connect.WithSchema(sPIREServiceBanAgentMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceCreateJoinTokenHandler := connect.NewUnaryHandler(
+		SPIREServiceCreateJoinTokenProcedure,
+		svc.CreateJoinToken,
+		connect.WithSchema(sPIREServiceCreateJoinTokenMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceListEntriesHandler := connect.NewUnaryHandler(
+		SPIREServiceListEntriesProcedure,
+		svc.ListEntries,
+		connect.WithSchema(sPIREServiceListEntriesMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceGetEntryHandler := connect.NewUnaryHandler(
+		SPIREServiceGetEntryProcedure,
+		svc.GetEntry,
+		connect.WithSchema(sPIREServiceGetEntryMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceBatchCreateEntryHandler := connect.NewUnaryHandler(
+		SPIREServiceBatchCreateEntryProcedure,
+		svc.BatchCreateEntry,
+		connect.WithSchema(sPIREServiceBatchCreateEntryMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceBatchDeleteEntryHandler := connect.NewUnaryHandler(
+		SPIREServiceBatchDeleteEntryProcedure,
+		svc.BatchDeleteEntry,
+		connect.WithSchema(sPIREServiceBatchDeleteEntryMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceGetBundleHandler := connect.NewUnaryHandler(
+		SPIREServiceGetBundleProcedure,
+		svc.GetBundle,
+		connect.WithSchema(sPIREServiceGetBundleMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceListFederatedBundlesHandler := connect.NewUnaryHandler(
+		SPIREServiceListFederatedBundlesProcedure,
+		svc.ListFederatedBundles,
+		connect.WithSchema(sPIREServiceListFederatedBundlesMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceBatchCreateFederatedBundleHandler := connect.NewUnaryHandler(
+		SPIREServiceBatchCreateFederatedBundleProcedure,
+		svc.BatchCreateFederatedBundle,
+		connect.WithSchema(sPIREServiceBatchCreateFederatedBundleMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceBatchUpdateFederatedBundleHandler := connect.NewUnaryHandler(
+		SPIREServiceBatchUpdateFederatedBundleProcedure,
+		svc.BatchUpdateFederatedBundle,
+		connect.WithSchema(sPIREServiceBatchUpdateFederatedBundleMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceBatchDeleteFederatedBundleHandler := connect.NewUnaryHandler(
+		SPIREServiceBatchDeleteFederatedBundleProcedure,
+		svc.BatchDeleteFederatedBundle,
+		connect.WithSchema(sPIREServiceBatchDeleteFederatedBundleMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceListFederationRelationshipsHandler := connect.NewUnaryHandler(
+		SPIREServiceListFederationRelationshipsProcedure,
+		svc.ListFederationRelationships,
+		connect.WithSchema(sPIREServiceListFederationRelationshipsMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceBatchCreateFederationRelationshipHandler := connect.NewUnaryHandler(
+		SPIREServiceBatchCreateFederationRelationshipProcedure,
+		svc.BatchCreateFederationRelationship,
+		connect.WithSchema(sPIREServiceBatchCreateFederationRelationshipMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceBatchUpdateFederationRelationshipHandler := connect.NewUnaryHandler(
+		SPIREServiceBatchUpdateFederationRelationshipProcedure,
+		svc.BatchUpdateFederationRelationship,
+		connect.WithSchema(sPIREServiceBatchUpdateFederationRelationshipMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	sPIREServiceBatchDeleteFederationRelationshipHandler := connect.NewUnaryHandler(
+		SPIREServiceBatchDeleteFederationRelationshipProcedure,
+		svc.BatchDeleteFederationRelationship,
+		connect.WithSchema(sPIREServiceBatchDeleteFederationRelationshipMethodDescriptor),
+		connect.WithHandlerOptions(opts...),
+	)
+	return "/tornjak.api.v1.SPIREService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case SPIREServiceGetInfoProcedure:
+			sPIREServiceGetInfoHandler.ServeHTTP(w, r)
+		case SPIREServiceListAgentsProcedure:
+			sPIREServiceListAgentsHandler.ServeHTTP(w, r)
+		case SPIREServiceGetAgentProcedure:
+			sPIREServiceGetAgentHandler.ServeHTTP(w, r)
+		case SPIREServiceDeleteAgentProcedure:
+			sPIREServiceDeleteAgentHandler.ServeHTTP(w, r)
+		case SPIREServiceBanAgentProcedure:
+			sPIREServiceBanAgentHandler.ServeHTTP(w, r)
+		case SPIREServiceCreateJoinTokenProcedure:
+			sPIREServiceCreateJoinTokenHandler.ServeHTTP(w, r)
+		case SPIREServiceListEntriesProcedure:
+			sPIREServiceListEntriesHandler.ServeHTTP(w, r)
+		case SPIREServiceGetEntryProcedure:
+			sPIREServiceGetEntryHandler.ServeHTTP(w, r)
+		case SPIREServiceBatchCreateEntryProcedure:
+			sPIREServiceBatchCreateEntryHandler.ServeHTTP(w, r)
+		case SPIREServiceBatchDeleteEntryProcedure:
+			sPIREServiceBatchDeleteEntryHandler.ServeHTTP(w, r)
+		case SPIREServiceGetBundleProcedure:
+			sPIREServiceGetBundleHandler.ServeHTTP(w, r)
+		case SPIREServiceListFederatedBundlesProcedure:
+			sPIREServiceListFederatedBundlesHandler.ServeHTTP(w, r)
+		case SPIREServiceBatchCreateFederatedBundleProcedure:
+			sPIREServiceBatchCreateFederatedBundleHandler.ServeHTTP(w, r)
+		case SPIREServiceBatchUpdateFederatedBundleProcedure:
+			sPIREServiceBatchUpdateFederatedBundleHandler.ServeHTTP(w, r)
+		case SPIREServiceBatchDeleteFederatedBundleProcedure:
+			sPIREServiceBatchDeleteFederatedBundleHandler.ServeHTTP(w, r)
+		case SPIREServiceListFederationRelationshipsProcedure:
+			sPIREServiceListFederationRelationshipsHandler.ServeHTTP(w, r)
+		case SPIREServiceBatchCreateFederationRelationshipProcedure:
+			sPIREServiceBatchCreateFederationRelationshipHandler.ServeHTTP(w, r)
+		case SPIREServiceBatchUpdateFederationRelationshipProcedure:
+			sPIREServiceBatchUpdateFederationRelationshipHandler.ServeHTTP(w, r)
+		case SPIREServiceBatchDeleteFederationRelationshipProcedure:
+			sPIREServiceBatchDeleteFederationRelationshipHandler.ServeHTTP(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// UnimplementedSPIREServiceHandler returns CodeUnimplemented from all methods.
+type UnimplementedSPIREServiceHandler struct{}
+
+func (UnimplementedSPIREServiceHandler) GetInfo(context.Context, *connect.Request[v11.GetInfoRequest]) (*connect.Response[v11.GetInfoResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.GetInfo is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) ListAgents(context.Context, *connect.Request[v12.ListAgentsRequest]) (*connect.Response[v12.ListAgentsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.ListAgents is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) GetAgent(context.Context, *connect.Request[v12.GetAgentRequest]) (*connect.Response[types.Agent], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.GetAgent is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) DeleteAgent(context.Context, *connect.Request[v12.DeleteAgentRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.DeleteAgent is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) BanAgent(context.Context, *connect.Request[v12.BanAgentRequest]) (*connect.Response[emptypb.Empty], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.BanAgent is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) CreateJoinToken(context.Context, *connect.Request[v12.CreateJoinTokenRequest]) (*connect.Response[types.JoinToken], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.CreateJoinToken is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) ListEntries(context.Context, *connect.Request[v13.ListEntriesRequest]) (*connect.Response[v13.ListEntriesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.ListEntries is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) GetEntry(context.Context, *connect.Request[v13.GetEntryRequest]) (*connect.Response[types.Entry], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.GetEntry is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) BatchCreateEntry(context.Context, *connect.Request[v13.BatchCreateEntryRequest]) (*connect.Response[v13.BatchCreateEntryResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.BatchCreateEntry is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) BatchDeleteEntry(context.Context, *connect.Request[v13.BatchDeleteEntryRequest]) (*connect.Response[v13.BatchDeleteEntryResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.BatchDeleteEntry is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) GetBundle(context.Context, *connect.Request[v14.GetBundleRequest]) (*connect.Response[types.Bundle], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.GetBundle is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) ListFederatedBundles(context.Context, *connect.Request[v14.ListFederatedBundlesRequest]) (*connect.Response[v14.ListFederatedBundlesResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.ListFederatedBundles is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) BatchCreateFederatedBundle(context.Context, *connect.Request[v14.BatchCreateFederatedBundleRequest]) (*connect.Response[v14.BatchCreateFederatedBundleResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.BatchCreateFederatedBundle is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) BatchUpdateFederatedBundle(context.Context, *connect.Request[v14.BatchUpdateFederatedBundleRequest]) (*connect.Response[v14.BatchUpdateFederatedBundleResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.BatchUpdateFederatedBundle is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) BatchDeleteFederatedBundle(context.Context, *connect.Request[v14.BatchDeleteFederatedBundleRequest]) (*connect.Response[v14.BatchDeleteFederatedBundleResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.BatchDeleteFederatedBundle is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) ListFederationRelationships(context.Context, *connect.Request[v15.ListFederationRelationshipsRequest]) (*connect.Response[v15.ListFederationRelationshipsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.ListFederationRelationships is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) BatchCreateFederationRelationship(context.Context, *connect.Request[v15.BatchCreateFederationRelationshipRequest]) (*connect.Response[v15.BatchCreateFederationRelationshipResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.BatchCreateFederationRelationship is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) BatchUpdateFederationRelationship(context.Context, *connect.Request[v15.BatchUpdateFederationRelationshipRequest]) (*connect.Response[v15.BatchUpdateFederationRelationshipResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.BatchUpdateFederationRelationship is not implemented"))
+}
+
+func (UnimplementedSPIREServiceHandler) BatchDeleteFederationRelationship(context.Context, *connect.Request[v15.BatchDeleteFederationRelationshipRequest]) (*connect.Response[v15.BatchDeleteFederationRelationshipResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("tornjak.api.v1.SPIREService.BatchDeleteFederationRelationship is not implemented"))
+}
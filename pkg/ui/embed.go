@@ -0,0 +1,37 @@
+//go:build embedui
+
+package ui
+
+import (
+	"embed"
+	"io/fs"
+)
+
+// The built UIs are copied into agent/ and manager/ before building with
+// -tags embedui; see 'make ui-embed'.
+
+//go:embed all:agent
+var agentFiles embed.FS
+
+//go:embed all:manager
+var managerFiles embed.FS
+
+// AgentAssets returns the embedded Tornjak backend UI, or nil if none was
+// embedded
+func AgentAssets() fs.FS {
+	return embedded(agentFiles, "agent")
+}
+
+// ManagerAssets returns the embedded Tornjak manager UI, or nil if none was
+// embedded
+func ManagerAssets() fs.FS {
+	return embedded(managerFiles, "manager")
+}
+
+func embedded(files embed.FS, dir string) fs.FS {
+	sub, err := fs.Sub(files, dir)
+	if err != nil || !hasIndex(sub) {
+		return nil
+	}
+	return sub
+}
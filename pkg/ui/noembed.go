@@ -0,0 +1,15 @@
+//go:build !embedui
+
+package ui
+
+import "io/fs"
+
+// AgentAssets returns nil: this binary was built without -tags embedui
+func AgentAssets() fs.FS {
+	return nil
+}
+
+// ManagerAssets returns nil: this binary was built without -tags embedui
+func ManagerAssets() fs.FS {
+	return nil
+}
@@ -0,0 +1,171 @@
+// Package ui serves the built Tornjak web UI, a single page application,
+// from an embedded or on-disk file system.
+package ui
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	indexPath = "index.html"
+
+	// cacheImmutable is sent for files with a content hash in their name,
+	// which change name whenever their content changes
+	cacheImmutable = "public, max-age=31536000, immutable"
+	// cacheRevalidate is sent for all other files, which clients must
+	// revalidate with their ETag before use
+	cacheRevalidate = "no-cache"
+)
+
+// hashedName matches file names carrying a content hash as generated by the
+// UI build, e.g. main.3f2a1b4c.js or 787.a1b2c3d4.chunk.css
+var hashedName = regexp.MustCompile(`\.[0-9a-f]{8,}\.`)
+
+// Handler serves a single page application: existing files are served as
+// they are, and any other path gets index.html so that the UI can route it.
+// Paths are resolved within the file system only, so they cannot escape it.
+type Handler struct {
+	fsys fs.FS
+
+	mu    sync.Mutex
+	etags map[string]etag
+}
+
+// etag caches the content hash of a file until it changes
+type etag struct {
+	modTime time.Time
+	size    int64
+	value   string
+}
+
+// NewHandler returns a Handler serving fsys
+func NewHandler(fsys fs.FS) *Handler {
+	return &Handler{fsys: fsys, etags: map[string]etag{}}
+}
+
+// New returns a Handler for the UI chosen by Select, logging where it is
+// served from
+func New(overridePath string, embedded fs.FS, defaultPath string) *Handler {
+	fsys, source := Select(overridePath, embedded, defaultPath)
+	if !hasIndex(fsys) {
+		log.Printf("WARNING: no UI found in %s", source)
+	} else {
+		log.Printf("Serving UI from %s", source)
+	}
+	return NewHandler(fsys)
+}
+
+// Select returns the file system to serve the UI from: the directory
+// overridePath if set, otherwise the embedded assets if the binary was built
+// with them, otherwise the directory defaultPath.
+func Select(overridePath string, embedded fs.FS, defaultPath string) (fs.FS, string) {
+	switch {
+	case overridePath != "":
+		return os.DirFS(overridePath), overridePath
+	case embedded != nil:
+		return embedded, "embedded assets"
+	default:
+		return os.DirFS(defaultPath), defaultPath
+	}
+}
+
+// hasIndex reports whether fsys contains a UI
+func hasIndex(fsys fs.FS) bool {
+	_, err := fs.Stat(fsys, indexPath)
+	return err == nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// path.Clean of a rooted path removes all ".." elements
+	name := strings.TrimPrefix(path.Clean("/"+r.URL.Path), "/")
+	if name == "" {
+		name = indexPath
+	}
+
+	f, info, err := h.open(name)
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		if f != nil {
+			f.Close()
+		}
+		name = indexPath
+		f, info, err = h.open(name)
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		http.Error(w, "UI not available", http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		http.Error(w, "UI file is not seekable", http.StatusInternalServerError)
+		return
+	}
+	tag, err := h.etag(name, info, content)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if name != indexPath && hashedName.MatchString(path.Base(name)) {
+		w.Header().Set("Cache-Control", cacheImmutable)
+	} else {
+		w.Header().Set("Cache-Control", cacheRevalidate)
+	}
+	w.Header().Set("ETag", tag)
+	// ServeContent answers conditional requests from the ETag
+	http.ServeContent(w, r, name, info.ModTime(), content)
+}
+
+func (h *Handler) open(name string) (fs.File, fs.FileInfo, error) {
+	f, err := h.fsys.Open(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+	return f, info, nil
+}
+
+// etag returns the quoted content hash of the file name, hashing it only
+// when it has changed since last served
+func (h *Handler) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	h.mu.Lock()
+	cached, ok := h.etags[name]
+	h.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.value, nil
+	}
+
+	sum := sha256.New()
+	if _, err := io.Copy(sum, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	value := `"` + hex.EncodeToString(sum.Sum(nil)[:16]) + `"`
+
+	h.mu.Lock()
+	h.etags[name] = etag{modTime: info.ModTime(), size: info.Size(), value: value}
+	h.mu.Unlock()
+	return value, nil
+}
@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+const (
+	testIndex  = "<html>tornjak</html>"
+	testScript = "console.log('tornjak')"
+)
+
+func testFS() fstest.MapFS {
+	modTime := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return fstest.MapFS{
+		"index.html":                 {Data: []byte(testIndex), ModTime: modTime},
+		"favicon.ico":                {Data: []byte("icon"), ModTime: modTime},
+		"static/js/main.3f2a1b4c.js": {Data: []byte(testScript), ModTime: modTime},
+	}
+}
+
+func serve(h http.Handler, target string, header http.Header) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	for key, values := range header {
+		r.Header[key] = values
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandler(t *testing.T) {
+	h := NewHandler(testFS())
+	tests := []struct {
+		name      string
+		target    string
+		wantBody  string
+		wantCache string
+	}{
+		{name: "root", target: "/", wantBody: testIndex, wantCache: cacheRevalidate},
+		{name: "index", target: "/index.html", wantBody: testIndex, wantCache: cacheRevalidate},
+		{name: "hashed file", target: "/static/js/main.3f2a1b4c.js", wantBody: testScript, wantCache: cacheImmutable},
+		{name: "unhashed file", target: "/favicon.ico", wantBody: "icon", wantCache: cacheRevalidate},
+		{name: "UI route", target: "/entries/create", wantBody: testIndex, wantCache: cacheRevalidate},
+		{name: "directory", target: "/static/js", wantBody: testIndex, wantCache: cacheRevalidate},
+		{name: "dot dot", target: "/../../etc/passwd", wantBody: testIndex, wantCache: cacheRevalidate},
+		{name: "encoded dot dot", target: "/%2e%2e/%2e%2e/etc/passwd", wantBody: testIndex, wantCache: cacheRevalidate},
+		{name: "encoded slash", target: "/static/..%2f..%2f..%2fetc/passwd", wantBody: testIndex, wantCache: cacheRevalidate},
+		{name: "dot dot to a file", target: "/static/js/../../favicon.ico", wantBody: "icon", wantCache: cacheRevalidate},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(h, tt.target, nil)
+			if w.Code != http.StatusOK || w.Body.String() != tt.wantBody {
+				t.Fatalf("Expected %d with %q, got %d with %q", http.StatusOK, tt.wantBody, w.Code, w.Body.String())
+			}
+			if cache := w.Header().Get("Cache-Control"); cache != tt.wantCache {
+				t.Fatalf("Expected Cache-Control %q, got %q", tt.wantCache, cache)
+			}
+			if w.Header().Get("ETag") == "" {
+				t.Fatalf("Expected an ETag")
+			}
+		})
+	}
+}
+
+func TestHandlerStaysInFS(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "ui"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ui", indexPath), []byte(testIndex), 0600); err != nil {
+		t.Fatal(err)
+	}
+	h := NewHandler(os.DirFS(filepath.Join(dir, "ui")))
+
+	for _, target := range []string{"/../secret.txt", "/%2e%2e/secret.txt", "/x/..%2f..%2fsecret.txt"} {
+		if w := serve(h, target, nil); w.Code != http.StatusOK || w.Body.String() != testIndex {
+			t.Fatalf("Expected index.html for %s, got %d with %q", target, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestHandlerConditional(t *testing.T) {
+	fsys := testFS()
+	h := NewHandler(fsys)
+	for _, target := range []string{"/", "/static/js/main.3f2a1b4c.js"} {
+		tag := serve(h, target, nil).Header().Get("ETag")
+
+		w := serve(h, target, http.Header{"If-None-Match": {tag}})
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Fatalf("Expected %d without body for %s, got %d with %q", http.StatusNotModified, target, w.Code, w.Body.String())
+		}
+		if w := serve(h, target, http.Header{"If-None-Match": {`"stale"`}}); w.Code != http.StatusOK {
+			t.Fatalf("Expected %d for a stale ETag of %s, got %d", http.StatusOK, target, w.Code)
+		}
+	}
+
+	// a changed file gets a new ETag
+	tag := serve(h, "/", nil).Header().Get("ETag")
+	fsys[indexPath] = &fstest.MapFile{Data: []byte("<html>new</html>"), ModTime: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)}
+	w := serve(h, "/", http.Header{"If-None-Match": {tag}})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") == tag {
+		t.Fatalf("Expected %d with a new ETag, got %d with %s", http.StatusOK, w.Code, w.Header().Get("ETag"))
+	}
+}
+
+func TestHandlerNoUI(t *testing.T) {
+	w := serve(NewHandler(fstest.MapFS{}), "/", nil)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("Expected %d, got %d", http.StatusNotFound, w.Code)
+	}
+}